@@ -0,0 +1,74 @@
+package live
+
+import (
+	"context"
+)
+
+// AsyncState the state of a value loaded with AssignAsync.
+type AsyncState string
+
+const (
+	// AsyncLoading the value is still being loaded.
+	AsyncLoading AsyncState = "loading"
+	// AsyncOK the value loaded.
+	AsyncOK AsyncState = "ok"
+	// AsyncError the value failed to load.
+	AsyncError AsyncState = "error"
+)
+
+// Async a value which is loaded in the background, for rendering a
+// placeholder while it loads and an error if it fails.
+//
+//	{{ if .Assigns.Profile.Loading }}Loading...{{ else if .Assigns.Profile.Failed }}{{ .Assigns.Profile.Err }}{{ else }}{{ .Assigns.Profile.Value.Name }}{{ end }}
+type Async[T any] struct {
+	State AsyncState
+	Value T
+	Err   error
+}
+
+// Loading is the value still loading.
+func (a Async[T]) Loading() bool {
+	return a.State == AsyncLoading
+}
+
+// OK has the value loaded.
+func (a Async[T]) OK() bool {
+	return a.State == AsyncOK
+}
+
+// Failed did the value fail to load.
+func (a Async[T]) Failed() bool {
+	return a.State == AsyncError
+}
+
+// AssignAsync loads a value in the background so that a slow fetch doesn't
+// block rendering. It returns a loading Async to assign straight away, and
+// when the fetch finishes the result is sent to the socket as a self event,
+// with the Async as its data.
+//
+// Call it from mount, the fetch only runs once the socket has connected so
+// the first render shows the loading state. The fetch is cancelled if the
+// socket closes.
+func AssignAsync[T any](s Socket, event string, fetch func(ctx context.Context) (T, error)) Async[T] {
+	loading := Async[T]{State: AsyncLoading}
+	if !s.Connected() {
+		return loading
+	}
+
+	ctx := context.Background()
+	if l, ok := s.(lifetimer); ok {
+		ctx = l.lifetime()
+	}
+	go func() {
+		v, err := fetch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		result := Async[T]{State: AsyncOK, Value: v}
+		if err != nil {
+			result = Async[T]{State: AsyncError, Err: err}
+		}
+		s.Self(ctx, event, result)
+	}()
+	return loading
+}
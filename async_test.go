@@ -0,0 +1,55 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAssignAsync(t *testing.T) {
+	results := make(chan Async[string], 1)
+	h := NewHandler()
+	h.HandleSelf("profile", func(ctx context.Context, s Socket, data interface{}) (interface{}, error) {
+		results <- data.(Async[string])
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+
+	// Unconnected sockets render the loading state without fetching.
+	fetched := false
+	a := AssignAsync(NewBaseSocket(NewSession(), e, false), "profile", func(ctx context.Context) (string, error) {
+		fetched = true
+		return "", nil
+	})
+	if !a.Loading() || fetched {
+		t.Fatalf("expected loading without a fetch, got %+v", a)
+	}
+
+	s := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(s)
+	AssignAsync(s, "profile", func(ctx context.Context) (string, error) {
+		return "Ada", nil
+	})
+	select {
+	case r := <-results:
+		if !r.OK() || r.Value != "Ada" {
+			t.Errorf("expected ok result, got %+v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a result")
+	}
+
+	failed := errors.New("upstream down")
+	AssignAsync(s, "profile", func(ctx context.Context) (string, error) {
+		return "", failed
+	})
+	select {
+	case r := <-results:
+		if !r.Failed() || !errors.Is(r.Err, failed) {
+			t.Errorf("expected error result, got %+v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a result")
+	}
+}
@@ -0,0 +1,101 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// ErrorCodeUnauthorized the code of errors sent to the client when an event
+// is not authorized.
+const ErrorCodeUnauthorized = "unauthorized"
+
+// authRedirect an auth failure which sends the client somewhere else.
+type authRedirect struct {
+	url *url.URL
+}
+
+func (a *authRedirect) Error() string {
+	return "unauthorized, redirecting to " + a.url.String()
+}
+
+func (a *authRedirect) Unwrap() error {
+	return ErrUnauthorized
+}
+
+// AuthRedirect returns an error for an AuthHandler to deny access and send
+// the client to the url, for example a login page.
+//
+//	h.HandleAuth(func(ctx context.Context, s live.Socket) error {
+//		if _, ok := s.Session()["user"]; !ok {
+//			return live.AuthRedirect(&url.URL{Path: "/login"})
+//		}
+//		return nil
+//	})
+func AuthRedirect(u *url.URL) error {
+	return &authRedirect{url: u}
+}
+
+// authRedirectURL returns where an auth failure should send the client.
+func authRedirectURL(err error) (*url.URL, bool) {
+	var a *authRedirect
+	if errors.As(err, &a) {
+		return a.url, true
+	}
+	return nil, false
+}
+
+// WithEventAuth runs the handler's AuthHandler before each event as well as
+// before mount.
+func WithEventAuth() EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.eventAuth = true
+		case *HttpEngine:
+			v.eventAuth = true
+		}
+		return nil
+	}
+}
+
+// authorizeEvent runs the AuthHandler before an event if WithEventAuth is
+// set. A redirect is sent to the socket as well as returned, so that the
+// event isn't handled.
+func (e *BaseEngine) authorizeEvent(ctx context.Context, sock Socket) error {
+	if !e.eventAuth {
+		return nil
+	}
+	err := e.Auth()(ctx, sock)
+	if err == nil {
+		return nil
+	}
+	if u, ok := authRedirectURL(err); ok {
+		sock.Redirect(u)
+		return NewError(ErrorCodeUnauthorized, err)
+	}
+	if ErrorCode(err) == ErrorCodeUnknown {
+		return NewError(ErrorCodeUnauthorized, err)
+	}
+	return err
+}
+
+// authorizeRequest runs the AuthHandler before mounting a page, returning
+// false if the request has been answered.
+func (h *HttpEngine) authorizeRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, sock Socket) bool {
+	err := h.Auth()(ctx, sock)
+	if err == nil {
+		return true
+	}
+	if u, ok := authRedirectURL(err); ok {
+		http.Redirect(w, r, u.String(), http.StatusSeeOther)
+		return false
+	}
+	if errors.Is(err, ErrUnauthorized) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return false
+	}
+	h.Error()(ctx, err)
+	return false
+}
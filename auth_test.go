@@ -0,0 +1,133 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAuthRequest(t *testing.T) {
+	mounted := false
+	h := testRenderHandler()
+	h.HandleMount(func(ctx context.Context, s Socket) (any, error) {
+		mounted = true
+		return nil, nil
+	})
+	h.HandleAuth(func(ctx context.Context, s Socket) error {
+		if s.Session()["user"] == nil {
+			return AuthRedirect(&url.URL{Path: "/login"})
+		}
+		return nil
+	})
+	store := NewTestStore("test")
+	e := NewHttpHandler(store, h)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/login" {
+		t.Fatalf("expected redirect to login, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	if mounted {
+		t.Fatal("expected mount not to be called")
+	}
+
+	store.s["user"] = "jo"
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || !mounted {
+		t.Fatalf("expected page to be mounted, got %d", w.Code)
+	}
+}
+
+func TestAuthRequestForbidden(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleAuth(func(ctx context.Context, s Socket) error {
+		return ErrUnauthorized
+	})
+	e := NewHttpHandler(NewTestStore("test"), h)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected forbidden, got %d", w.Code)
+	}
+}
+
+func TestAuthSocketRedirect(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleAuth(func(ctx context.Context, s Socket) error {
+		return AuthRedirect(&url.URL{Path: "/login"})
+	})
+	c := serveTestSocket(t, NewBaseEngine(h))
+
+	select {
+	case m := <-c.out:
+		var u string
+		json.Unmarshal(m.Data, &u)
+		if m.T != EventRedirect || u != "/login" {
+			t.Fatalf("expected redirect to login, got %v", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a redirect")
+	}
+}
+
+func TestAuthEvent(t *testing.T) {
+	allowed := true
+	redirect := false
+	called := false
+	h := NewHandler()
+	h.HandleAuth(func(ctx context.Context, s Socket) error {
+		if !allowed {
+			if redirect {
+				return AuthRedirect(&url.URL{Path: "/login"})
+			}
+			return errors.New("signed out")
+		}
+		return nil
+	})
+	h.HandleEvent("save", func(ctx context.Context, s Socket, p Params) (any, error) {
+		called = true
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	sock := NewBaseSocket(NewSession(), e, true)
+
+	allowed = false
+	if err := e.CallEvent(context.Background(), "save", sock, Event{T: "save"}); err != nil {
+		t.Fatalf("expected events not to be authorized by default, got %s", err)
+	}
+	called = false
+
+	WithEventAuth()(e)
+	err := e.CallEvent(context.Background(), "save", sock, Event{T: "save"})
+	if ErrorCode(err) != ErrorCodeUnauthorized {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+	if called {
+		t.Fatal("expected handler not to be called")
+	}
+
+	// A redirect is sent, and the handler still isn't called.
+	redirect = true
+	err = e.CallEvent(context.Background(), "save", sock, Event{T: "save"})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+	if called {
+		t.Fatal("expected handler not to be called on redirect")
+	}
+	select {
+	case m := <-sock.msgs:
+		if m.T != EventRedirect {
+			t.Errorf("expected redirect, got %v", m)
+		}
+	default:
+		t.Error("expected a redirect to be sent")
+	}
+}
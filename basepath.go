@@ -0,0 +1,59 @@
+package live
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// liveBasePath body attribute telling the client where the handler is mounted.
+const liveBasePath = "live-base-path"
+
+// WithBasePath set the path prefix the handler is mounted under, for example
+// when served behind a reverse proxy at "/app". The prefix is stripped from
+// requests which still carry it, and is given to the client so that redirects
+// and the protocol descriptor resolve correctly. It is also available to
+// templates as RenderContext.BasePath for asset references.
+func WithBasePath(path string) EngineConfig {
+	return func(e Engine) error {
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			path = "/" + strings.Trim(path, "/")
+			if path == "/" {
+				path = ""
+			}
+			httpEngine.basePath = path
+		}
+		return nil
+	}
+}
+
+// BasePath returns the path prefix the handler is mounted under.
+func (h *HttpEngine) BasePath() string {
+	return h.basePath
+}
+
+// stripBasePath removes the base path from a request, if it is there.
+func (h *HttpEngine) stripBasePath(r *http.Request) *http.Request {
+	if h.basePath == "" {
+		return r
+	}
+	p := strings.TrimPrefix(r.URL.Path, h.basePath)
+	if len(p) == len(r.URL.Path) || (p != "" && p[0] != '/') {
+		return r
+	}
+	if p == "" {
+		p = "/"
+	}
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = p
+	r2.URL.RawPath = ""
+	return r2
+}
+
+// basePather is implemented by engines which are mounted under a prefix.
+type basePather interface {
+	BasePath() string
+}
@@ -0,0 +1,56 @@
+package live
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBasePath(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body><script src="` + data.BasePath + `/live.js"></script></body></html>`), nil
+	})
+	e := NewHttpHandler(NewTestStore("test"), h, WithBasePath("/app/"), WithProtocolPath("/protocol"))
+
+	if e.BasePath() != "/app" {
+		t.Fatalf("expected /app got %q", e.BasePath())
+	}
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{path: "/app/protocol", body: `"version"`},
+		{path: "/protocol", body: `"version"`},
+		{path: "/app", body: `live-base-path="/app"`},
+		{path: "/app", body: `live-protocol="/app/protocol"`},
+		{path: "/app", body: `src="/app/live.js"`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: expected %s in %s", tt.path, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestStripBasePath(t *testing.T) {
+	e := &HttpEngine{basePath: "/app"}
+	for in, out := range map[string]string{
+		"/app":      "/",
+		"/app/":     "/",
+		"/app/room": "/room",
+		"/apple":    "/apple",
+		"/room":     "/room",
+	} {
+		r := e.stripBasePath(httptest.NewRequest(http.MethodGet, in, nil))
+		if r.URL.Path != out {
+			t.Errorf("%s: expected %s got %s", in, out, r.URL.Path)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package live
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// maxBatchSize the most events a client may send in one message.
+const maxBatchSize = 128
+
+// decodeEvents decodes a message from the client, which holds either a single
+// event or a batch of them. Clients send batches of the events they queued
+// while disconnected, so that they are handled together.
+func decodeEvents(text bool, data []byte) ([]Event, error) {
+	if !text {
+		var err error
+		if data, err = cborToJSON(data); err != nil {
+			return nil, err
+		}
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []Event
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) > maxBatchSize {
+			return nil, fmt.Errorf("%w: batch of %d events is larger than %d", ErrMessageMalformed, len(batch), maxBatchSize)
+		}
+		return batch, nil
+	}
+	var m Event
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return []Event{m}, nil
+}
@@ -0,0 +1,61 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecodeEvents(t *testing.T) {
+	ms, err := decodeEvents(true, []byte(`{"t":"inc","i":1}`))
+	if err != nil || len(ms) != 1 || ms[0].T != "inc" {
+		t.Fatalf("unexpected single event %v %v", ms, err)
+	}
+	ms, err = decodeEvents(true, []byte(` [{"t":"inc","i":1},{"t":"dec","i":2}]`))
+	if err != nil || len(ms) != 2 || ms[1].T != "dec" || ms[1].ID != 2 {
+		t.Fatalf("unexpected batch %v %v", ms, err)
+	}
+	big := []byte("[")
+	for i := 0; i <= maxBatchSize; i++ {
+		if i > 0 {
+			big = append(big, ',')
+		}
+		big = append(big, `{"t":"inc"}`...)
+	}
+	big = append(big, ']')
+	if _, err := decodeEvents(true, big); !errors.Is(err, ErrMessageMalformed) {
+		t.Errorf("expected oversized batch to be rejected, got %v", err)
+	}
+}
+
+func TestEventBatch(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		return 0, nil
+	})
+	h.HandleEvent("inc", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return s.Assigns().(int) + 1, nil
+	})
+	renders := 0
+	h.HandleRender(func(ctx context.Context, rc *RenderContext) (io.Reader, error) {
+		renders++
+		return strings.NewReader(fmt.Sprintf("<div>%d</div>", rc.Assigns)), nil
+	})
+	e := NewBaseEngine(h)
+	c := serveTestSocket(t, e)
+
+	c.in <- Event{T: "inc", ID: 1}
+	c.expectAck(t, 1)
+	before := renders
+
+	c.raw <- []byte(`[{"t":"inc","i":2},{"t":"inc","i":3},{"t":"inc","i":4}]`)
+	c.expectAck(t, 2)
+	c.expectAck(t, 3)
+	c.expectAck(t, 4)
+	if renders != before+1 {
+		t.Errorf("expected the batch to render once, rendered %d times", renders-before)
+	}
+}
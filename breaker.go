@@ -0,0 +1,153 @@
+package live
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrorCodeCircuitOpen the code of the error sent when an event is refused
+// because its handler has been failing.
+const ErrorCodeCircuitOpen = "circuit_open"
+
+// circuit the failures of the handler for one event.
+type circuit struct {
+	failures []time.Time
+	// openUntil calls are refused until this time.
+	openUntil time.Time
+	// trial a call has been let through after the cooldown to see if the
+	// handler has recovered, others are refused until it finishes.
+	trial bool
+}
+
+// circuitBreaker stops calling event handlers which keep failing, so that a
+// broken feature doesn't take the rest of the page down with it.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+	circuits  map[string]*circuit
+}
+
+// WithCircuitBreaker stops calling the handler for an event once it has
+// failed, returning an error, panicking or running past its timeout, threshold
+// times within the window. Which errors count can be narrowed with
+// WithCircuitBreakerClassifier. For the cooldown after, the event is refused with an error coded
+// ErrorCodeCircuitOpen without the handler being called. Then a single call is
+// let through as a trial, others are refused until it finishes, and if it
+// fails the circuit opens again straight away. Handlers are tracked by event
+// name across every socket on the engine.
+//
+//	live.WithCircuitBreaker(5, time.Minute, 30*time.Second)
+func WithCircuitBreaker(threshold int, window, cooldown time.Duration) EngineConfig {
+	return func(e Engine) error {
+		b := &circuitBreaker{
+			threshold: threshold,
+			window:    window,
+			cooldown:  cooldown,
+			circuits:  map[string]*circuit{},
+		}
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.breaker = b
+		case *HttpEngine:
+			v.breaker = b
+		}
+		return nil
+	}
+}
+
+// WithCircuitBreakerClassifier decides which errors returned by event
+// handlers count towards opening their circuit, by default they all do.
+// Panics and timeouts always count.
+//
+//	live.WithCircuitBreakerClassifier(func(err error) bool {
+//		return !errors.Is(err, errBadInput)
+//	})
+func WithCircuitBreakerClassifier(failure func(err error) bool) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.breakerClassifier = failure
+		case *HttpEngine:
+			v.breakerClassifier = failure
+		}
+		return nil
+	}
+}
+
+// breakerFailure returns true if an event handler's error counts towards
+// opening its circuit. Panics recovered by the connection are counted
+// separately.
+func (e *BaseEngine) breakerFailure(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrEventTimeout), errors.Is(err, ErrEventPanic):
+		return true
+	case e.breakerClassifier != nil:
+		return e.breakerClassifier(err)
+	}
+	return true
+}
+
+// allow returns an error if the handler for the event shouldn't be called,
+// and whether the call is the trial after a cooldown.
+func (b *circuitBreaker) allow(t string) (trial bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[t]
+	if !ok || c.openUntil.IsZero() {
+		return false, nil
+	}
+	if remaining := time.Until(c.openUntil); remaining > 0 {
+		return false, NewError(ErrorCodeCircuitOpen, fmt.Errorf("%w: %s, retry in %s", ErrCircuitOpen, t, remaining.Round(time.Second)))
+	}
+	if c.trial {
+		return false, NewError(ErrorCodeCircuitOpen, fmt.Errorf("%w: %s, being retried", ErrCircuitOpen, t))
+	}
+	c.trial = true
+	return true, nil
+}
+
+// result records the outcome of calling the handler for the event.
+func (b *circuitBreaker) result(t string, trial, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[t]
+	if !failed {
+		if ok && trial {
+			c.trial = false
+			c.openUntil = time.Time{}
+		}
+		return
+	}
+	if !ok {
+		c = &circuit{}
+		b.circuits[t] = c
+	}
+	now := time.Now()
+	if trial {
+		c.trial = false
+		c.openUntil = now.Add(b.cooldown)
+		c.failures = c.failures[:0]
+		return
+	}
+	// Calls let through before the circuit opened don't extend it.
+	if !c.openUntil.IsZero() {
+		return
+	}
+	failures := c.failures[:0]
+	for _, f := range c.failures {
+		if now.Sub(f) < b.window {
+			failures = append(failures, f)
+		}
+	}
+	c.failures = append(failures, now)
+	if len(c.failures) >= b.threshold {
+		c.openUntil = now.Add(b.cooldown)
+		c.failures = c.failures[:0]
+	}
+}
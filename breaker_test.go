@@ -0,0 +1,186 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	h := NewHandler()
+	calls := 0
+	fail := true
+	h.HandleEvent("flaky", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		calls++
+		if fail {
+			return nil, fmt.Errorf("%w: flaky", ErrEventTimeout)
+		}
+		return nil, nil
+	})
+	h.HandleEvent("other", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, nil
+	})
+	errInvalid := errors.New("invalid input")
+	h.HandleEvent("invalid", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, errInvalid
+	})
+	e := NewBaseEngine(h)
+	WithCircuitBreaker(2, time.Minute, 50*time.Millisecond)(e)
+	WithCircuitBreakerClassifier(func(err error) bool {
+		return !errors.Is(err, errInvalid)
+	})(e)
+	sock := NewBaseSocket(NewSession(), e, true)
+	ctx := context.Background()
+	call := func(t string) error {
+		return e.CallEvent(ctx, t, sock, Event{T: t})
+	}
+
+	call("flaky")
+	call("flaky")
+	err := call("flaky")
+	if !errors.Is(err, ErrCircuitOpen) || ErrorCode(err) != ErrorCodeCircuitOpen {
+		t.Fatalf("expected open circuit, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected handler to be called twice, got %d", calls)
+	}
+	if err := call("other"); err != nil {
+		t.Errorf("expected other events to be handled, got %v", err)
+	}
+
+	// Errors the classifier excludes don't count.
+	for i := 0; i < 3; i++ {
+		if err := call("invalid"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("expected excluded errors not to open the circuit")
+		}
+	}
+
+	// After the cooldown one failure opens it again.
+	time.Sleep(60 * time.Millisecond)
+	call("flaky")
+	if err := call("flaky"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected circuit to open after failed trial, got %v", err)
+	}
+
+	// A successful trial closes it.
+	fail = false
+	time.Sleep(60 * time.Millisecond)
+	if err := call("flaky"); err != nil {
+		t.Fatalf("expected trial to succeed, got %v", err)
+	}
+	fail = true
+	call("flaky")
+	if err := call("flaky"); errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected circuit to stay closed below the threshold")
+	}
+	if calls != 6 {
+		t.Errorf("expected 6 calls, got %d", calls)
+	}
+}
+
+func TestCircuitBreakerPanics(t *testing.T) {
+	h := NewHandler()
+	h.HandleEvent("boom", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		panic("boom")
+	})
+	e := NewBaseEngine(h)
+	WithCircuitBreaker(1, time.Minute, time.Minute)(e)
+	sock := NewBaseSocket(NewSession(), e, true)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to carry on to the connection")
+			}
+		}()
+		e.CallEvent(context.Background(), "boom", sock, Event{T: "boom"})
+	}()
+	if err := e.CallEvent(context.Background(), "boom", sock, Event{T: "boom"}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected open circuit after panic, got %v", err)
+	}
+}
+
+func TestCircuitBreakerSingleTrial(t *testing.T) {
+	h := NewHandler()
+	var fail atomic.Bool
+	fail.Store(true)
+	started, release := make(chan struct{}), make(chan struct{})
+	h.HandleEvent("flaky", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		if fail.Load() {
+			return nil, fmt.Errorf("%w: flaky", ErrEventTimeout)
+		}
+		close(started)
+		<-release
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	WithCircuitBreaker(1, time.Minute, 20*time.Millisecond)(e)
+	sock := NewBaseSocket(NewSession(), e, true)
+	call := func() error {
+		return e.CallEvent(context.Background(), "flaky", sock, Event{T: "flaky"})
+	}
+
+	call()
+	fail.Store(false)
+	time.Sleep(30 * time.Millisecond)
+
+	// While the trial runs other calls are refused.
+	trial := make(chan error, 1)
+	go func() { trial <- call() }()
+	<-started
+	if err := call(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected calls during the trial to be refused, got %v", err)
+	}
+	close(release)
+	if err := <-trial; err != nil {
+		t.Fatalf("expected the trial to succeed, got %v", err)
+	}
+	if _, err := e.breaker.allow("flaky"); err != nil {
+		t.Errorf("expected the circuit to close after the trial, got %v", err)
+	}
+}
+
+func TestCircuitBreakerErrors(t *testing.T) {
+	h := NewHandler()
+	calls := 0
+	h.HandleEvent("failing", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		calls++
+		return nil, errors.New("failed")
+	})
+	e := NewBaseEngine(h)
+	WithCircuitBreaker(2, time.Minute, time.Minute)(e)
+	sock := NewBaseSocket(NewSession(), e, true)
+
+	for i := 0; i < 2; i++ {
+		e.CallEvent(context.Background(), "failing", sock, Event{T: "failing"})
+	}
+	if err := e.CallEvent(context.Background(), "failing", sock, Event{T: "failing"}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected returned errors to open the circuit, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected handler to be called twice, got %d", calls)
+	}
+}
+
+func TestCircuitBreakerTimeoutPanics(t *testing.T) {
+	h := NewHandler()
+	h.HandleEvent("boom", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		panic("boom")
+	})
+	e := NewBaseEngine(h)
+	WithEventTimeout(time.Second)(e)
+	WithCircuitBreaker(1, time.Minute, time.Minute)(e)
+	WithCircuitBreakerClassifier(func(err error) bool { return false })(e)
+	sock := NewBaseSocket(NewSession(), e, true)
+
+	// With a timeout the panic comes back as an error, it still counts.
+	if err := e.CallEvent(context.Background(), "boom", sock, Event{T: "boom"}); !errors.Is(err, ErrEventPanic) {
+		t.Fatalf("expected the panic as an error, got %v", err)
+	}
+	if err := e.CallEvent(context.Background(), "boom", sock, Event{T: "boom"}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected open circuit after panic, got %v", err)
+	}
+}
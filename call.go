@@ -0,0 +1,150 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventReply sent by the client in response to a Call.
+const EventReply = "reply"
+
+// defaultCallTimeout how long a Call waits for the client when the context
+// has no deadline.
+const defaultCallTimeout = 10 * time.Second
+
+// callReply the data of a reply event.
+type callReply struct {
+	Result json.RawMessage `json:"r,omitempty"`
+	Err    string          `json:"e,omitempty"`
+}
+
+// replySlot holds the reply to a client event.
+type replySlot struct {
+	mu    sync.Mutex
+	reply json.RawMessage
+}
+
+func (r *replySlot) set(reply json.RawMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reply = reply
+}
+
+func (r *replySlot) get() json.RawMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reply
+}
+
+type replyContextKey struct{}
+
+// contextWithReply returns a context for handling a client event, and the
+// slot the reply its handler sets is kept in.
+func contextWithReply(ctx context.Context) (context.Context, *replySlot) {
+	slot := &replySlot{}
+	return context.WithValue(ctx, replyContextKey{}, slot), slot
+}
+
+// replyFromContext returns the reply slot of the client event being handled.
+func replyFromContext(ctx context.Context) *replySlot {
+	slot, _ := ctx.Value(replyContextKey{}).(*replySlot)
+	return slot
+}
+
+// pendingCalls tracks calls waiting for the client to reply.
+type pendingCalls struct {
+	mu      sync.Mutex
+	seq     int
+	pending map[int]chan callReply
+}
+
+// add registers a new call, returning its ID and the channel its reply is
+// delivered on.
+func (p *pendingCalls) add() (int, chan callReply) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending == nil {
+		p.pending = map[int]chan callReply{}
+	}
+	p.seq++
+	ch := make(chan callReply, 1)
+	p.pending[p.seq] = ch
+	return p.seq, ch
+}
+
+// remove forgets a call.
+func (p *pendingCalls) remove(id int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, id)
+}
+
+// resolve delivers a reply to its call.
+func (p *pendingCalls) resolve(id int, reply callReply) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ch, ok := p.pending[id]
+	if !ok {
+		return
+	}
+	delete(p.pending, id)
+	ch <- reply
+}
+
+// Call sends an event to the client and waits for its reply, for example to
+// read a value from local storage. The client handles the event with a hook
+// `handleEvent` callback, whose return value is the reply. If the context has
+// no deadline the call times out after 10 seconds.
+func (s *BaseSocket) Call(ctx context.Context, event string, data interface{}) (json.RawMessage, error) {
+	if !s.connected {
+		return nil, ErrNotConnected
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultCallTimeout)
+		defer cancel()
+	}
+
+	id, reply := s.calls.add()
+	defer s.calls.remove(id)
+	if err := s.Send(event, data, WithID(id), asCall()); err != nil {
+		return nil, err
+	}
+
+	select {
+	case r := <-reply:
+		if r.Err != "" {
+			return nil, fmt.Errorf("%w: %s", ErrCallFailed, r.Err)
+		}
+		return r.Result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("call %s: %w", event, ctx.Err())
+	case <-s.lifetime().Done():
+		return nil, fmt.Errorf("call %s: %w", event, ErrNoSocket)
+	}
+}
+
+// resolveCall delivers a reply from the client.
+func (s *BaseSocket) resolveCall(m Event) {
+	var r callReply
+	if err := json.Unmarshal(m.Data, &r); err != nil {
+		r = callReply{Err: ErrMessageMalformed.Error()}
+	}
+	s.calls.resolve(m.ID, r)
+}
+
+// caller is implemented by sockets which can make calls to the client.
+type caller interface {
+	resolveCall(m Event)
+}
+
+// asCall marks an event as a call the client should reply to.
+func asCall() EventConfig {
+	return func(e *Event) error {
+		e.Call = true
+		return nil
+	}
+}
@@ -0,0 +1,304 @@
+package live
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// binarySubprotocol the websocket subprotocol of connections which encode
+// events with CBOR, speaking the base protocol version.
+const binarySubprotocol = "live.cbor"
+
+// liveBinary body attribute telling the client it can ask for binary
+// encoding.
+const liveBinary = "live-binary"
+
+// WithBinaryEncoding allows clients to encode websocket events with CBOR
+// rather than JSON, which makes messages, patches in particular, smaller.
+// The encoding is negotiated when the websocket connects so clients which
+// don't support it keep using JSON. Not supported over WebTransport.
+func WithBinaryEncoding() EngineConfig {
+	return func(e Engine) error {
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			httpEngine.binary = true
+		}
+		return nil
+	}
+}
+
+// binaryConn is implemented by connections which can be binary encoded.
+type binaryConn interface {
+	binaryEncoded() bool
+}
+
+// isBinary checks if a connection has negotiated binary encoding.
+func isBinary(c socketConn) bool {
+	b, ok := c.(binaryConn)
+	return ok && b.binaryEncoded()
+}
+
+// encodeEvent encodes an event for a connection.
+func encodeEvent(c socketConn, msg Event) ([]byte, error) {
+	data, err := json.Marshal(&msg)
+	if err != nil || !isBinary(c) {
+		return data, err
+	}
+	// Events are transcoded from their JSON form so that payloads encode
+	// the same way in either encoding.
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := encodeCBOR(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeEvent decodes a binary encoded event.
+func decodeEvent(data []byte) (Event, error) {
+	j, err := cborToJSON(data)
+	if err != nil {
+		return Event{}, err
+	}
+	var m Event
+	if err := json.Unmarshal(j, &m); err != nil {
+		return Event{}, err
+	}
+	return m, nil
+}
+
+// cborToJSON transcodes a binary encoded message to JSON.
+func cborToJSON(data []byte) ([]byte, error) {
+	v, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrMessageMalformed)
+	}
+	return json.Marshal(v)
+}
+
+// CBOR major types.
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborSimple = 7
+)
+
+// cborHead writes the head of a data item.
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(major | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		buf.WriteByte(major | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+// encodeCBOR encodes a value decoded from JSON.
+func encodeCBOR(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(cborSimple<<5 | 22)
+	case bool:
+		if v {
+			buf.WriteByte(cborSimple<<5 | 21)
+		} else {
+			buf.WriteByte(cborSimple<<5 | 20)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			if i >= 0 {
+				cborHead(buf, cborUint, uint64(i))
+			} else {
+				cborHead(buf, cborNegInt, uint64(-1-i))
+			}
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(cborSimple<<5 | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	case string:
+		cborHead(buf, cborText, uint64(len(v)))
+		buf.WriteString(v)
+	case []interface{}:
+		cborHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			if err := encodeCBOR(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		cborHead(buf, cborMap, uint64(len(v)))
+		for _, k := range keys {
+			cborHead(buf, cborText, uint64(len(k)))
+			buf.WriteString(k)
+			if err := encodeCBOR(buf, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cbor: can't encode %T", v)
+	}
+	return nil
+}
+
+// decodeCBOR decodes a data item into the values JSON would decode to,
+// returning the remaining data.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: unexpected end of cbor", ErrMessageMalformed)
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	// Floats and simple values use the argument differently.
+	if major == cborSimple {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		case 25:
+			if len(data) < 2 {
+				break
+			}
+			return float16(binary.BigEndian.Uint16(data)), data[2:], nil
+		case 26:
+			if len(data) < 4 {
+				break
+			}
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), data[4:], nil
+		case 27:
+			if len(data) < 8 {
+				break
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(data)), data[8:], nil
+		}
+		return nil, nil, fmt.Errorf("%w: unsupported cbor simple value %d", ErrMessageMalformed, info)
+	}
+
+	var n uint64
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return nil, nil, fmt.Errorf("%w: unexpected end of cbor", ErrMessageMalformed)
+		}
+		for _, b := range data[:size] {
+			n = n<<8 | uint64(b)
+		}
+		data = data[size:]
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported cbor length %d", ErrMessageMalformed, info)
+	}
+
+	switch major {
+	case cborUint:
+		return float64(n), data, nil
+	case cborNegInt:
+		return -1 - float64(n), data, nil
+	case cborBytes, cborText:
+		if uint64(len(data)) < n {
+			return nil, nil, fmt.Errorf("%w: unexpected end of cbor", ErrMessageMalformed)
+		}
+		return string(data[:n]), data[n:], nil
+	case cborArray:
+		if n > uint64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end of cbor", ErrMessageMalformed)
+		}
+		out := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			var v interface{}
+			var err error
+			v, data, err = decodeCBOR(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			out = append(out, v)
+		}
+		return out, data, nil
+	case cborMap:
+		if n > uint64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end of cbor", ErrMessageMalformed)
+		}
+		out := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			k, rest, err := decodeCBOR(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: cbor map key must be a string", ErrMessageMalformed)
+			}
+			var v interface{}
+			v, data, err = decodeCBOR(rest)
+			if err != nil {
+				return nil, nil, err
+			}
+			out[key] = v
+		}
+		return out, data, nil
+	}
+	return nil, nil, fmt.Errorf("%w: unsupported cbor type %d", ErrMessageMalformed, major)
+}
+
+// float16 converts a half precision float.
+func float16(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
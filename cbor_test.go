@@ -0,0 +1,86 @@
+package live
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// binaryTestConn a test connection which has negotiated binary encoding.
+type binaryTestConn struct {
+	*testConn
+}
+
+func (c binaryTestConn) binaryEncoded() bool { return true }
+
+func TestEncodeCBOR(t *testing.T) {
+	tests := []struct {
+		in  string
+		out []byte
+	}{
+		{`{"a":1}`, []byte{0xa1, 0x61, 'a', 0x01}},
+		{`[-1,true,null]`, []byte{0x83, 0x20, 0xf5, 0xf6}},
+		{`500`, []byte{0x19, 0x01, 0xf4}},
+		{`1.5`, []byte{0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		d := json.NewDecoder(bytes.NewReader([]byte(tt.in)))
+		d.UseNumber()
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := encodeCBOR(&buf, v); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), tt.out) {
+			t.Errorf("%s: expected % x, got % x", tt.in, tt.out, buf.Bytes())
+		}
+	}
+}
+
+func TestDecodeCBORHalfFloat(t *testing.T) {
+	v, _, err := decodeCBOR([]byte{0xf9, 0x3e, 0x00})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 1.5 {
+		t.Errorf("expected 1.5, got %v", v)
+	}
+}
+
+func TestEncodeEventBinary(t *testing.T) {
+	msg := Event{T: EventPatch, ID: 3, Data: json.RawMessage(`[{"Anchor":"_l00","Action":1,"HTML":"<div>héllo</div>"}]`)}
+
+	c := binaryTestConn{newTestConn()}
+	data, err := encodeEvent(c, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := encodeEvent(newTestConn(), msg)
+	if len(data) >= len(plain) {
+		t.Errorf("expected binary encoding to be smaller, got %d >= %d", len(data), len(plain))
+	}
+
+	got, err := decodeEvent(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.T != msg.T || got.ID != msg.ID {
+		t.Errorf("unexpected event %+v", got)
+	}
+	var expected, actual interface{}
+	json.Unmarshal(msg.Data, &expected)
+	json.Unmarshal(got.Data, &actual)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected data %s, got %s", msg.Data, got.Data)
+	}
+}
+
+func TestDecodeEventMalformed(t *testing.T) {
+	if _, err := decodeEvent([]byte{0xa1, 0x61}); err == nil {
+		t.Error("expected truncated event to fail")
+	}
+}
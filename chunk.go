@@ -0,0 +1,88 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// defaultChunkSize the size of message above which events are chunked.
+const defaultChunkSize = 1 << 20
+
+// maxChunks the most chunks an event is split into, larger events have the
+// client reload the page instead.
+const maxChunks = 64
+
+// chunkOverhead room left in each chunk for the chunk event around it.
+const chunkOverhead = 128
+
+// chunk a piece of an event too large to send in one message. Data holds a
+// piece of the event's JSON encoding, the client joins the pieces back
+// together once it has all of them.
+type chunk struct {
+	ID   int    `json:"id"`
+	N    int    `json:"n"`
+	Of   int    `json:"of"`
+	Data []byte `json:"d"`
+}
+
+// WithChunkSize sets the size in bytes of the largest message sent to the
+// client, larger events such as big patches are split into chunks which the
+// client reassembles. Some proxies drop connections which send messages above
+// a limit. Events which would take too many chunks have the client reload the
+// page instead. Defaults to 1MB, zero turns chunking off.
+func WithChunkSize(size int) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.chunkSize = size
+		case *HttpEngine:
+			v.chunkSize = size
+		}
+		return nil
+	}
+}
+
+// chunker writes events to a connection, chunking those which are too large.
+type chunker struct {
+	size int
+	next int
+}
+
+// write an event to the connection.
+func (ch *chunker) write(ctx context.Context, c socketConn, msg Event) error {
+	data, err := encodeEvent(c, msg)
+	if err != nil {
+		return fmt.Errorf("failed writeTimeout: %w", err)
+	}
+	if ch.size <= 0 || len(data) <= ch.size {
+		return writeDataTimeout(ctx, time.Second*5, c, data)
+	}
+
+	encoded, err := json.Marshal(&msg)
+	if err != nil {
+		return fmt.Errorf("failed writeTimeout: %w", err)
+	}
+	// Chunk data is base64 encoded, which grows it by a third.
+	piece := (ch.size-chunkOverhead)*3/4 - 1
+	if piece <= 0 {
+		piece = 1
+	}
+	of := (len(encoded) + piece - 1) / piece
+	if of > maxChunks {
+		return writeTimeout(ctx, time.Second*5, c, Event{T: EventReload})
+	}
+	ch.next++
+	for n := 0; n < of; n++ {
+		end := min((n+1)*piece, len(encoded))
+		d, err := json.Marshal(chunk{ID: ch.next, N: n, Of: of, Data: encoded[n*piece : end]})
+		if err != nil {
+			return fmt.Errorf("failed writeTimeout: %w", err)
+		}
+		if err := writeTimeout(ctx, time.Second*5, c, Event{T: EventChunk, Data: d}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
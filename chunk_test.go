@@ -0,0 +1,71 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestChunkedWrite(t *testing.T) {
+	c := newTestConn()
+	ch := &chunker{size: 512}
+	msg := Event{T: EventPatch, ID: 7}
+	msg.Data, _ = json.Marshal(strings.Repeat("héllo ", 300))
+	if err := ch.write(context.Background(), c, msg); err != nil {
+		t.Fatal(err)
+	}
+	close(c.out)
+
+	var joined []byte
+	n := 0
+	for m := range c.out {
+		if m.T != EventChunk {
+			t.Fatalf("expected chunk, got %s", m.T)
+		}
+		raw, _ := json.Marshal(m)
+		if len(raw) > ch.size {
+			t.Errorf("chunk of %d bytes is larger than %d", len(raw), ch.size)
+		}
+		var part chunk
+		if err := json.Unmarshal(m.Data, &part); err != nil {
+			t.Fatal(err)
+		}
+		if part.ID != 1 || part.N != n {
+			t.Fatalf("unexpected chunk %d of %d", part.N, part.ID)
+		}
+		joined = append(joined, part.Data...)
+		n++
+	}
+	if n < 2 {
+		t.Fatalf("expected the event to be chunked, got %d chunks", n)
+	}
+	var got Event
+	if err := json.Unmarshal(joined, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.T != msg.T || got.ID != msg.ID || string(got.Data) != string(msg.Data) {
+		t.Errorf("reassembled event differs: %+v", got)
+	}
+}
+
+func TestChunkedWriteReload(t *testing.T) {
+	c := newTestConn()
+	ch := &chunker{size: 200}
+	msg := Event{T: EventPatch}
+	msg.Data, _ = json.Marshal(strings.Repeat("x", 200*maxChunks))
+	if err := ch.write(context.Background(), c, msg); err != nil {
+		t.Fatal(err)
+	}
+	if m := <-c.out; m.T != EventReload {
+		t.Errorf("expected reload, got %s", m.T)
+	}
+
+	small := Event{T: EventAck, ID: 1}
+	if err := ch.write(context.Background(), c, small); err != nil {
+		t.Fatal(err)
+	}
+	if m := <-c.out; m.T != EventAck {
+		t.Errorf("expected small event to be sent whole, got %s", m.T)
+	}
+}
@@ -0,0 +1,181 @@
+// Command liveevents generates event registration helpers, template funcs and
+// typescript constants from a block of Go event name constants.
+//
+// Given a package containing
+//
+//	//go:generate go run github.com/jfyne/live/cmd/liveevents -type=Event -ts=web/events.ts
+//	type Event string
+//
+//	const (
+//		EventInc Event = "inc"
+//		EventDec Event = "dec"
+//	)
+//
+// it writes event_live.go with a HandleEventInc / HandleEventDec helper for each
+// constant, an EventFuncs template.FuncMap exposing each constant by name so that
+// typos fail when a template is parsed, and optionally a typescript file of the
+// same constants for use in hooks.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	typeName = flag.String("type", "", "name of the event name type; must be set")
+	output   = flag.String("output", "", "output file name; default <type>_live.go")
+	tsOutput = flag.String("ts", "", "optional typescript constants output file")
+)
+
+// event a single event name constant.
+type event struct {
+	Const string
+	Value string
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("liveevents: ")
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if args := flag.Args(); len(args) > 0 {
+		dir = args[0]
+	}
+
+	pkg, events, err := parseEvents(dir, *typeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(events) == 0 {
+		log.Fatalf("no constants of type %s found", *typeName)
+	}
+
+	src, err := generateGo(pkg, *typeName, events)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, strings.ToLower(*typeName)+"_live.go")
+	}
+	if err := os.WriteFile(out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	if *tsOutput != "" {
+		if err := os.WriteFile(*tsOutput, generateTS(events), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// parseEvents finds all string constants of the given type in the package.
+func parseEvents(dir, typ string) (string, []event, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not parse %s: %w", dir, err)
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	var name string
+	events := []event{}
+	seen := map[string]string{}
+	for pkgName, pkg := range pkgs {
+		name = pkgName
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.CONST {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					vs := spec.(*ast.ValueSpec)
+					ident, ok := vs.Type.(*ast.Ident)
+					if !ok || ident.Name != typ {
+						continue
+					}
+					for idx, n := range vs.Names {
+						if idx >= len(vs.Values) {
+							return "", nil, fmt.Errorf("%s: constant has no value", fset.Position(n.Pos()))
+						}
+						lit, ok := vs.Values[idx].(*ast.BasicLit)
+						if !ok || lit.Kind != token.STRING {
+							return "", nil, fmt.Errorf("%s: constant must be a string literal", fset.Position(n.Pos()))
+						}
+						value := constant.StringVal(constant.MakeFromLiteral(lit.Value, lit.Kind, 0))
+						if other, ok := seen[value]; ok {
+							return "", nil, fmt.Errorf("%s: event %q already used by %s", fset.Position(n.Pos()), value, other)
+						}
+						seen[value] = n.Name
+						events = append(events, event{Const: n.Name, Value: value})
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Const < events[j].Const })
+	return name, events, nil
+}
+
+// generateGo writes the go helpers.
+func generateGo(pkg, typ string, events []event) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by liveevents -type=%s; DO NOT EDIT.\n\n", typ)
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import (\n\t\"html/template\"\n\n\t\"github.com/jfyne/live\"\n)\n\n")
+
+	for _, e := range events {
+		fmt.Fprintf(&buf, "// Handle%s registers an event handler for %q.\n", e.Const, e.Value)
+		fmt.Fprintf(&buf, "func Handle%s(h live.Handler, handler live.EventHandler[any]) {\n", e.Const)
+		fmt.Fprintf(&buf, "\th.HandleEvent(string(%s), handler)\n}\n\n", e.Const)
+		fmt.Fprintf(&buf, "// HandleSelf%s registers a self handler for %q.\n", e.Const, e.Value)
+		fmt.Fprintf(&buf, "func HandleSelf%s(h live.Handler, handler live.SelfHandler[any]) {\n", e.Const)
+		fmt.Fprintf(&buf, "\th.HandleSelf(string(%s), handler)\n}\n\n", e.Const)
+	}
+
+	fmt.Fprintf(&buf, "// %sFuncs template functions which return each event name, so that\n", typ)
+	fmt.Fprintf(&buf, "// a misspelt event fails when the template is parsed.\n")
+	fmt.Fprintf(&buf, "func %sFuncs() template.FuncMap {\n\treturn template.FuncMap{\n", typ)
+	for _, e := range events {
+		fmt.Fprintf(&buf, "\t\t%q: func() string { return string(%s) },\n", e.Const, e.Const)
+	}
+	fmt.Fprintf(&buf, "\t}\n}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid go: %w", err)
+	}
+	return src, nil
+}
+
+// generateTS writes the typescript constants.
+func generateTS(events []event) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by liveevents; DO NOT EDIT.\n\n")
+	for _, e := range events {
+		fmt.Fprintf(&buf, "export const %s = %q;\n", e.Const, e.Value)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// golden compares output with a golden file in testdata.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file, got:\n%s", name, got)
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	pkg, events, err := parseEvents(filepath.Join("testdata", "events"), "Event")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg != "events" {
+		t.Errorf("expected package events, got %q", pkg)
+	}
+	want := []event{{"EventDec", "dec"}, {"EventInc", "inc"}, {"EventReset", "reset"}}
+	if len(events) != len(want) {
+		t.Fatalf("expected %v, got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("expected %v, got %v", want[i], events[i])
+		}
+	}
+}
+
+func TestParseEventsDuplicate(t *testing.T) {
+	_, _, err := parseEvents(filepath.Join("testdata", "duplicate"), "Event")
+	if err == nil || !strings.Contains(err.Error(), `event "inc" already used by EventInc`) {
+		t.Errorf("expected a duplicate event error, got %v", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	pkg, events, err := parseEvents(filepath.Join("testdata", "events"), "Event")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generateGo(pkg, "Event", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "event_live.go.golden", src)
+	golden(t, "events.ts.golden", generateTS(events))
+}
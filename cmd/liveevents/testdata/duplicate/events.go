@@ -0,0 +1,8 @@
+package events
+
+type Event string
+
+const (
+	EventInc  Event = "inc"
+	EventMore Event = "inc"
+)
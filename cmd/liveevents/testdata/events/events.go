@@ -0,0 +1,14 @@
+package events
+
+// Event names an event.
+type Event string
+
+const (
+	EventInc Event = "inc"
+	EventDec Event = "dec"
+	// EventReset is raw to check literals are unquoted.
+	EventReset Event = `reset`
+)
+
+// Other constants are ignored.
+const Title = "counter"
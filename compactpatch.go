@@ -0,0 +1,43 @@
+package live
+
+// compactPatches patches in the format of protocol version 2. Strings are
+// sent once in a table which the ops refer to by index. Each op is the
+// anchor, the action and then the HTML, for SetAttrs the key value pairs of
+// the attributes, or for SetText the text.
+type compactPatches struct {
+	Strings []string `json:"s"`
+	Ops     [][]int  `json:"o"`
+}
+
+// encodePatches prepares patches to be sent to a client speaking the protocol
+// version.
+func encodePatches(patches []Patch, version int) interface{} {
+	if version < 2 {
+		return patches
+	}
+	out := compactPatches{Strings: []string{}, Ops: make([][]int, 0, len(patches))}
+	index := map[string]int{}
+	str := func(s string) int {
+		if i, ok := index[s]; ok {
+			return i
+		}
+		index[s] = len(out.Strings)
+		out.Strings = append(out.Strings, s)
+		return index[s]
+	}
+	for _, p := range patches {
+		op := []int{str(p.Anchor), int(p.Action)}
+		switch p.Action {
+		case SetAttrs:
+			for _, a := range p.Attrs {
+				op = append(op, str(a[0]), str(a[1]))
+			}
+		case SetText:
+			op = append(op, str(p.Text))
+		default:
+			op = append(op, str(p.HTML))
+		}
+		out.Ops = append(out.Ops, op)
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package live
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func parseShaped(t *testing.T, s string) *html.Node {
+	t.Helper()
+	n, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shapeTree(n)
+	return n
+}
+
+func TestDiffVersionSetAttrs(t *testing.T) {
+	current := parseShaped(t, `<div class="a"><p>Hello</p></div>`)
+	proposed := parseShaped(t, `<div class="b"><p>World</p></div>`)
+
+	patches, err := diffVersion(current, proposed, LatestProtocolVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("expected 2 patches got %v", patches)
+	}
+	if patches[0].Action != SetAttrs || patches[0].Anchor != "_l_0_1_0" {
+		t.Fatalf("expected attrs patch got %v", patches[0])
+	}
+	expected := [][2]string{{"class", "b"}, {"_l_0_1_0", ""}}
+	if !reflect.DeepEqual(patches[0].Attrs, expected) {
+		t.Fatalf("expected %v got %v", expected, patches[0].Attrs)
+	}
+	if patches[1].Action != SetText || patches[1].Anchor != "_l_0_1_0_0" || patches[1].Text != "World" {
+		t.Fatalf("expected child text patch got %v", patches[1])
+	}
+}
+
+func TestDiffVersionSetText(t *testing.T) {
+	for _, test := range []struct {
+		name, current, proposed string
+		version                 int
+		expected                Patch
+	}{
+		{
+			name:     "only child",
+			current:  `<table><tr><td>1</td><td>2</td></tr></table>`,
+			proposed: `<table><tr><td>1</td><td>3</td></tr></table>`,
+			version:  LatestProtocolVersion,
+			expected: Patch{Anchor: "_l_0_1_0_0_0_1", Action: SetText, Text: "3"},
+		},
+		{
+			name:     "mixed content",
+			current:  `<p>Hello <b>there</b></p>`,
+			proposed: `<p>Goodbye <b>there</b></p>`,
+			version:  LatestProtocolVersion,
+			expected: Patch{Anchor: "_l_0_1_0", Action: Replace, HTML: `<p _l_0_1_0="">Goodbye <b _l_0_1_0_1="">there</b></p>`},
+		},
+		{
+			name:     "version one",
+			current:  `<p>Hello</p>`,
+			proposed: `<p>World</p>`,
+			version:  ProtocolVersion,
+			expected: Patch{Anchor: "_l_0_1_0", Action: Replace, HTML: `<p _l_0_1_0="">World</p>`},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			patches, err := diffVersion(parseShaped(t, test.current), parseShaped(t, test.proposed), test.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(patches) != 1 || !reflect.DeepEqual(patches[0], test.expected) {
+				t.Fatalf("expected %v got %v", test.expected, patches)
+			}
+		})
+	}
+}
+
+func TestDiffVersionOneReplaces(t *testing.T) {
+	current := parseShaped(t, `<div class="a"><p>Hello</p></div>`)
+	proposed := parseShaped(t, `<div class="b"><p>Hello</p></div>`)
+
+	patches, err := diffVersion(current, proposed, ProtocolVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 || patches[0].Action != Replace {
+		t.Fatalf("expected a single replace got %v", patches)
+	}
+}
+
+func TestEncodePatches(t *testing.T) {
+	patches := []Patch{
+		{Anchor: "_l_0", Action: SetAttrs, Attrs: [][2]string{{"class", "b"}, {"_l_0", ""}}},
+		{Anchor: "_l_1", Action: Replace, HTML: ""},
+		{Anchor: "_l_0", Action: Append, HTML: "b"},
+		{Anchor: "_l_1", Action: SetText, Text: "class"},
+	}
+
+	if v1 := encodePatches(patches, ProtocolVersion); !reflect.DeepEqual(v1, patches) {
+		t.Fatalf("expected version 1 patches to be unchanged got %v", v1)
+	}
+
+	out, ok := encodePatches(patches, LatestProtocolVersion).(compactPatches)
+	if !ok {
+		t.Fatal("expected compact patches")
+	}
+	expectedStrings := []string{"_l_0", "class", "b", "", "_l_1"}
+	if !reflect.DeepEqual(out.Strings, expectedStrings) {
+		t.Fatalf("expected strings %v got %v", expectedStrings, out.Strings)
+	}
+	expectedOps := [][]int{{0, 4, 1, 2, 0, 3}, {4, 1, 3}, {0, 2, 2}, {4, 5, 1}}
+	if !reflect.DeepEqual(out.Ops, expectedOps) {
+		t.Fatalf("expected ops %v got %v", expectedOps, out.Ops)
+	}
+}
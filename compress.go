@@ -0,0 +1,39 @@
+package live
+
+import (
+	"nhooyr.io/websocket"
+)
+
+// defaultCompressionThreshold the size of the smallest message compressed if
+// no threshold is given.
+const defaultCompressionThreshold = 512
+
+// WithCompression compresses the messages sent to each client which supports
+// it with permessage-deflate. Patches for table heavy pages compress well.
+// Messages smaller than threshold bytes are sent as they are, as compressing
+// them costs more than it saves, zero uses a default of 512.
+func WithCompression(threshold int) EngineConfig {
+	return func(e Engine) error {
+		httpEngine, ok := e.(*HttpEngine)
+		if !ok {
+			return nil
+		}
+		if threshold <= 0 {
+			threshold = defaultCompressionThreshold
+		}
+		options := websocket.AcceptOptions{}
+		if httpEngine.acceptOptions != nil {
+			options = *httpEngine.acceptOptions
+		}
+		options.CompressionMode = websocket.CompressionNoContextTakeover
+		options.CompressionThreshold = threshold
+		httpEngine.acceptOptions = &options
+		httpEngine.compressThreshold = threshold
+		return nil
+	}
+}
+
+// compression whether websocket messages are compressed.
+func (h *HttpEngine) compression() bool {
+	return h.acceptOptions != nil && h.acceptOptions.CompressionMode != websocket.CompressionDisabled
+}
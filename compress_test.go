@@ -0,0 +1,58 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nhooyr.io/websocket"
+)
+
+// dialConnectData dials the handler and returns what it negotiated.
+func dialConnectData(t *testing.T, e *HttpEngine, userAgent string) ConnectData {
+	t.Helper()
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+	ctx := context.Background()
+	c, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), &websocket.DialOptions{
+		HTTPHeader:      http.Header{"User-Agent": {userAgent}},
+		CompressionMode: websocket.CompressionNoContextTakeover,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(websocket.StatusNormalClosure, "")
+	_, d, err := c.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Event
+	if err := json.Unmarshal(d, &m); err != nil {
+		t.Fatal(err)
+	}
+	var data ConnectData
+	if err := json.Unmarshal(m.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestCompression(t *testing.T) {
+	if dialConnectData(t, NewHttpHandler(NewTestStore("test"), testRenderHandler()), "Go").Compression {
+		t.Error("expected compression to be off by default")
+	}
+
+	e := NewHttpHandler(NewTestStore("test"), testRenderHandler(), WithCompression(0))
+	if e.acceptOptions.CompressionThreshold != defaultCompressionThreshold {
+		t.Errorf("expected default threshold, got %d", e.acceptOptions.CompressionThreshold)
+	}
+	if dialConnectData(t, e, "Safari").Compression {
+		t.Error("expected compression to be off for Safari")
+	}
+	if !dialConnectData(t, e, "Go").Compression {
+		t.Error("expected compression after a Safari client connected")
+	}
+}
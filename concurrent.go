@@ -0,0 +1,73 @@
+package live
+
+import (
+	"context"
+	"sync"
+)
+
+// maxConcurrentEvents the number of concurrent events a socket handles at
+// once.
+const maxConcurrentEvents = 16
+
+// WithConcurrentEvents marks events whose handlers are safe to run at the
+// same time as other events on the same socket, for example those which call
+// slow external services. Only the handler itself runs concurrently, component
+// handlers, assigning the state it returns, renders and acknowledgements are
+// still made one at a time. The handlers must only make changes to the socket
+// which are safe in any order, as the state each returns is assigned when it
+// finishes. Sockets
+// always handle their events independently of other sockets.
+//
+// Previews and params changes are always handled in order. Replies are kept
+// per event, and idempotency keys are checked, as for any other event.
+func WithConcurrentEvents(events ...string) EngineConfig {
+	return func(e Engine) error {
+		var base *BaseEngine
+		switch v := e.(type) {
+		case *BaseEngine:
+			base = v
+		case *HttpEngine:
+			base = v.BaseEngine
+		}
+		if base == nil {
+			return nil
+		}
+		if base.concurrentEvents == nil {
+			base.concurrentEvents = map[string]bool{}
+		}
+		for _, event := range events {
+			base.concurrentEvents[event] = true
+		}
+		return nil
+	}
+}
+
+// isConcurrentEvent checks if an event can be handled concurrently.
+func (e *BaseEngine) isConcurrentEvent(m Event) bool {
+	if m.Preview || m.T == EventParams {
+		return false
+	}
+	return e.concurrentEvents[m.T]
+}
+
+type eventLockContextKey struct{}
+
+// contextWithEventLock returns a context for handling a concurrent event with
+// the socket's event lock held.
+func contextWithEventLock(ctx context.Context, mu *sync.Mutex) context.Context {
+	return context.WithValue(ctx, eventLockContextKey{}, mu)
+}
+
+// runUnlocked runs an event handler, releasing the socket's event lock while
+// it runs if the event is concurrent.
+func runUnlocked(ctx context.Context, fn func(ctx context.Context)) {
+	mu, _ := ctx.Value(eventLockContextKey{}).(*sync.Mutex)
+	if mu == nil {
+		fn(ctx)
+		return
+	}
+	mu.Unlock()
+	defer mu.Lock()
+	// The lock isn't held by the handler, so it can't be released again.
+	fn(context.WithValue(ctx, eventLockContextKey{}, (*sync.Mutex)(nil)))
+}
@@ -0,0 +1,649 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// socketConn a websocket connection provided by a transport.
+type socketConn interface {
+	// read the next message, text is false for binary messages.
+	read(ctx context.Context) (text bool, data []byte, err error)
+	// write a text message.
+	write(ctx context.Context, data []byte) error
+}
+
+// serveSocket handles a connected socket until the connection closes. The
+// engine is the transport engine wrapping this base engine.
+func (e *BaseEngine) serveSocket(ctx context.Context, engine Engine, sock Socket, c socketConn, r *http.Request) error {
+	// Carry the request ID of the initial render through the connection.
+	id := socketRequestID(r)
+	ctx = contextWithRequestID(ctx, id)
+	if Connection(ctx) == nil {
+		ctx = contextWithConnInfo(ctx, newConnInfo(r))
+	}
+	log := slog.With("request_id", id)
+
+	// Patches are sent in the protocol version the client asked for.
+	if v, ok := c.(versionedConn); ok {
+		if s, ok := sock.(protocolVersioner); ok {
+			s.setProtocolVersion(v.protocolVersion())
+		}
+	}
+
+	// Patches carry the connection's mark for the client's Trusted Types
+	// policy.
+	if mark := trustedMarkFromContext(ctx); mark != "" {
+		if s, ok := sock.(trustedMarker); ok {
+			s.setTrustedMark(mark)
+		}
+	}
+
+	// The page load the client is connecting from, events it sends again are
+	// only handled once.
+	page := r.URL.Query().Get(pageParam)
+
+	// Show any flashes carried over from a redirect, they have now been seen.
+	consumeFlashes(ctx, sock)
+
+	// Internal errors.
+	internalErrors := make(chan error)
+
+	// Event errors.
+	eventErrors := make(chan ErrorEvent)
+
+	// stopped is closed once the connection is no longer served, so that
+	// handlers still running don't block reporting errors.
+	stopped := make(chan struct{})
+	defer close(stopped)
+	internalError := func(err error) {
+		select {
+		case internalErrors <- err:
+		case <-stopped:
+		}
+	}
+
+	// eventMu serialises handling of events from the websocket with
+	// scheduled events.
+	var eventMu sync.Mutex
+	eventsClosed := false
+
+	// sendEventError sends the client the error from an event, and passes it
+	// to the socket error handler.
+	sendEventError := func(m Event, err error) {
+		// Components show the errors they contain themselves.
+		if !errors.Is(err, ErrContained) {
+			select {
+			case eventErrors <- e.errorEvent(ctx, m, err):
+			case <-stopped:
+			}
+		}
+		if err := e.SocketError()(ctx, sock, m, err); err != nil {
+			internalError(fmt.Errorf("socket error handler: %w", err))
+		}
+	}
+
+	// sendLoading tells the client handling of an event has started or
+	// stopped.
+	sendLoading := func(m Event, loading bool) {
+		if err := e.sendLoading(sock, m, loading); err != nil {
+			internalError(fmt.Errorf("socket send error: %w", err))
+		}
+	}
+
+	// callEvent runs the handler for an event, returning the reply it set.
+	// eventMu must be held, concurrent events release it while their handler
+	// runs.
+	callEvent := func(m Event, concurrent bool) json.RawMessage {
+		ctx := ctx
+		if concurrent {
+			ctx = contextWithEventLock(ctx, &eventMu)
+		}
+		sendLoading(m, true)
+		// Retries waiting on this event are released even if it panics.
+		defer func() {
+			if r := recover(); r != nil {
+				e.storeIdempotentResult(sock, m, fmt.Errorf("event panic: %v", r), nil)
+				panic(r)
+			}
+		}()
+		ctx, slot := contextWithReply(ctx)
+		err := e.CallEvent(ctx, m.T, sock, m)
+		reply := slot.get()
+		e.storeIdempotentResult(sock, m, err, reply)
+		e.record(sock, m, err)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrNoEventHandler):
+				log.ErrorContext(ctx, "event error", "event", m, "error", err)
+			default:
+				sendEventError(m, err)
+			}
+		}
+		return reply
+	}
+
+	// renderSocket renders the socket after events have been handled,
+	// eventMu must be held.
+	renderSocket := func() {
+		render, err := RenderSocket(ctx, engine, sock)
+		if err != nil {
+			internalError(fmt.Errorf("socket handle error: %w", err))
+		} else {
+			sock.UpdateRender(render)
+		}
+	}
+
+	// ackEvent acknowledges an event, with the reply its handler set.
+	ackEvent := func(m Event, reply json.RawMessage) {
+		if err := sock.Send(EventAck, reply, WithID(m.ID)); err != nil {
+			internalError(fmt.Errorf("socket send error: %w", err))
+		}
+	}
+
+	// renderEvent renders the socket after an event has been handled and
+	// acknowledges it, eventMu must be held.
+	renderEvent := func(m Event, reply json.RawMessage, ack bool) {
+		renderSocket()
+		sendLoading(m, false)
+		if !ack {
+			return
+		}
+		ackEvent(m, reply)
+	}
+
+	// resolvedEvent acknowledges an event which doesn't need handling again,
+	// returning true if so. That is one the client has sent again after
+	// reconnecting, or one with an idempotency key which has already been
+	// handled, or is being handled on another connection.
+	resolvedEvent := func(m Event, ack bool) bool {
+		if e.replayed(page, m) {
+			if ack {
+				ackEvent(m, nil)
+			}
+			return true
+		}
+		if m.T == EventParams || m.Preview {
+			return false
+		}
+		if res, reply, ok := e.idempotentResult(ctx, sock, m); ok {
+			if res != nil {
+				sendEventError(m, res)
+			}
+			if ack {
+				ackEvent(m, reply)
+			}
+			return true
+		}
+		return false
+	}
+
+	// applyEvent runs the handlers for a single event from the client,
+	// returning the reply set, and true if the socket should be rendered and
+	// the event acknowledged. eventMu must be held.
+	applyEvent := func(m Event, ack bool) (json.RawMessage, bool) {
+		if resolvedEvent(m, ack) {
+			return nil, false
+		}
+		var reply json.RawMessage
+		switch m.T {
+		case EventParams:
+			ctx, slot := contextWithReply(ctx)
+			err := e.CallParams(ctx, sock, m)
+			reply = slot.get()
+			e.record(sock, m, err)
+			if err != nil {
+				switch {
+				case errors.Is(err, ErrNoEventHandler):
+					log.ErrorContext(ctx, "event error", "event", m, "error", err)
+				default:
+					sendEventError(m, err)
+				}
+			}
+		default:
+			// Preview events are handled without committing anything,
+			// including their reply.
+			if m.Preview {
+				ctx, _ := contextWithReply(ctx)
+				if err := e.previewEvent(ctx, engine, sock, m); err != nil {
+					switch {
+					case errors.Is(err, ErrNoEventHandler):
+						log.ErrorContext(ctx, "event error", "event", m, "error", err)
+					default:
+						sendEventError(m, err)
+					}
+				}
+				if ack {
+					ackEvent(m, nil)
+				}
+				return nil, false
+			}
+			reply = callEvent(m, false)
+		}
+		return reply, true
+	}
+
+	// handleEvent handles a single event from the client, eventMu must be held.
+	handleEvent := func(m Event, ack bool) {
+		if reply, ok := applyEvent(m, ack); ok {
+			renderEvent(m, reply, ack)
+		}
+	}
+
+	// handleBatch handles a batch of events from the client in order, then
+	// renders the socket once and acknowledges them. eventMu must be held.
+	handleBatch := func(batch []Event) {
+		applied := make([]Event, 0, len(batch))
+		replies := make([]json.RawMessage, 0, len(batch))
+		for _, m := range batch {
+			reply, ok := applyEvent(m, true)
+			if !ok {
+				continue
+			}
+			applied = append(applied, m)
+			replies = append(replies, reply)
+		}
+		if len(applied) == 0 {
+			return
+		}
+		renderSocket()
+		for idx, m := range applied {
+			sendLoading(m, false)
+			ackEvent(m, replies[idx])
+		}
+	}
+
+	// Events are handled in order off the read loop, so that the client's
+	// replies to calls made by a handler can still be read.
+	queue := make(chan []Event, maxMessageBufferSize)
+	handled := make(chan struct{})
+	// Events sent before the socket has been mounted again, such as those
+	// the client queued before connecting, wait until it has.
+	mounted := make(chan struct{})
+	abandoned := make(chan struct{})
+	defer func() {
+		select {
+		case <-mounted:
+		default:
+			close(abandoned)
+		}
+	}()
+	go func() {
+		defer close(handled)
+		select {
+		case <-mounted:
+		case <-abandoned:
+			for range queue {
+			}
+			return
+		}
+		// Events marked as concurrent have their handlers run alongside
+		// others, everything else they do is still serialised.
+		var concurrent sync.WaitGroup
+		defer concurrent.Wait()
+		limit := make(chan struct{}, maxConcurrentEvents)
+		for batch := range queue {
+			// Batches are handled together, one at a time.
+			if len(batch) > 1 {
+				func() {
+					eventMu.Lock()
+					defer eventMu.Unlock()
+					defer func() {
+						if err := recover(); err != nil {
+							internalError(fmt.Errorf("live: panic serving %v: %v\n%s", r.RemoteAddr, err, debug.Stack()))
+						}
+					}()
+					handleBatch(batch)
+				}()
+				continue
+			}
+			m := batch[0]
+			if e.isConcurrentEvent(m) {
+				limit <- struct{}{}
+				concurrent.Add(1)
+				go func() {
+					defer concurrent.Done()
+					defer func() { <-limit }()
+					defer func() {
+						if err := recover(); err != nil {
+							internalError(fmt.Errorf("live: panic serving %v: %v\n%s", r.RemoteAddr, err, debug.Stack()))
+						}
+					}()
+					// Retries wait for the result of the first without
+					// holding up other events.
+					if resolvedEvent(m, true) {
+						return
+					}
+					eventMu.Lock()
+					defer eventMu.Unlock()
+					reply := callEvent(m, true)
+					renderEvent(m, reply, true)
+				}()
+				continue
+			}
+			func() {
+				eventMu.Lock()
+				defer eventMu.Unlock()
+				defer func() {
+					if err := recover(); err != nil {
+						internalError(fmt.Errorf("live: panic serving %v: %v\n%s", r.RemoteAddr, err, debug.Stack()))
+					}
+				}()
+				handleEvent(m, true)
+			}()
+		}
+	}()
+
+	// When the client last sent anything, to spot missed heartbeats.
+	var lastRead atomic.Int64
+	lastRead.Store(time.Now().UnixNano())
+	// When the client last sent an event, to spot idle sockets.
+	var lastEvent atomic.Int64
+	lastEvent.Store(time.Now().UnixNano())
+
+	// Events the client has asked to be delivered later are kept for its
+	// page load, so they still arrive if it reconnects. Connections without
+	// one keep them for themselves.
+	scheduleKey, schedulePersist := page, page != ""
+	if !schedulePersist {
+		scheduleKey = "socket:" + string(sock.ID())
+	}
+	detachSchedule := e.schedules.attach(scheduleKey, schedulePersist, func(m Event) {
+		select {
+		case <-mounted:
+		case <-abandoned:
+			return
+		}
+		defer panicCatcher()
+		eventMu.Lock()
+		defer eventMu.Unlock()
+		if eventsClosed {
+			return
+		}
+		handleEvent(m, false)
+	})
+	defer detachSchedule()
+
+	// Handle events coming from the websocket connection.
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				internalError(fmt.Errorf("live: panic serving %v: %v\n%s", r.RemoteAddr, err, debug.Stack()))
+			}
+		}()
+
+		for {
+			text, d, err := c.read(ctx)
+			if err != nil {
+				internalError(err)
+				break
+			}
+			lastRead.Store(time.Now().UnixNano())
+			if !text && !isBinary(c) {
+				log.WarnContext(ctx, "binary messages unhandled")
+				continue
+			}
+			ms, err := decodeEvents(text, d)
+			if err != nil {
+				internalError(err)
+				continue
+			}
+			batch := make([]Event, 0, len(ms))
+			for _, m := range ms {
+				if m.T == EventHeartbeat {
+					continue
+				}
+				lastEvent.Store(time.Now().UnixNano())
+				if m.T == EventReply {
+					if cl, ok := sock.(caller); ok {
+						cl.resolveCall(m)
+					}
+					continue
+				}
+				if at, ok := m.deliverAt(); ok {
+					if err := e.schedules.schedule(scheduleKey, schedulePersist, at, m); err != nil {
+						sendEventError(m, err)
+					}
+					ackEvent(m, nil)
+					continue
+				}
+				batch = append(batch, m)
+			}
+			if len(batch) > 0 {
+				queue <- batch
+			}
+		}
+		close(queue)
+		<-handled
+		// Reading has stopped, so nothing can be waiting on the reader for
+		// the event lock to be released.
+		detachSchedule()
+		eventMu.Lock()
+		eventsClosed = true
+		eventMu.Unlock()
+		close(internalErrors)
+		close(eventErrors)
+	}()
+
+	// Check the socket is still allowed to see the page, a redirect has to
+	// be written directly as the connection is about to close.
+	if err := e.Auth()(ctx, sock); err != nil {
+		if u, ok := authRedirectURL(err); ok {
+			d, _ := json.Marshal(u.String())
+			if err := writeTimeout(ctx, time.Second*5, c, Event{T: EventRedirect, Data: d}); err != nil {
+				return fmt.Errorf("writing to socket error: %w", err)
+			}
+		}
+		return fmt.Errorf("socket auth error: %w", err)
+	}
+
+	// Run mount again now that eh socket is connected, passing true indicating
+	// a connection has been made.
+	data, err := e.Mount()(ctx, sock)
+	if err != nil {
+		return fmt.Errorf("socket mount error: %w", err)
+	}
+	sock.Assign(data)
+
+	// Run params again now that the socket is connected.
+	params := NewParamsFromRequest(r)
+	delete(params, pageParam)
+	delete(params, requestIDParam)
+	if k, ok := sock.(paramsKeeper); ok {
+		k.swapParams(params)
+	}
+	for _, ph := range e.Params() {
+		data, err := ph(ctx, sock, params)
+		if err != nil {
+			return fmt.Errorf("socket params error: %w", err)
+		}
+		sock.Assign(data)
+	}
+	if err := callChildParams(ctx, sock, params); err != nil {
+		return fmt.Errorf("socket params error: %w", err)
+	}
+
+	// Run connect, which is only called for a connected socket.
+	if err := e.Connect()(ctx, sock); err != nil {
+		return fmt.Errorf("socket connect error: %w", err)
+	}
+
+	// Run render now that we are connected for the first time and we have just
+	// mounted again. This will generate and send any patches if there have
+	// been changes.
+	render, err := RenderSocket(ctx, engine, sock)
+	if err != nil {
+		return fmt.Errorf("socket render error: %w", err)
+	}
+	sock.UpdateRender(render)
+	close(mounted)
+
+	// Join a read replica topic if the engine is configured for it.
+	if err := e.joinReplica(ctx, sock); err != nil {
+		return fmt.Errorf("socket replica error: %w", err)
+	}
+
+	// Make changes queued with Update between events.
+	if u, ok := sock.(updater); ok {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case update := <-u.updates():
+					eventMu.Lock()
+					if !eventsClosed {
+						func() {
+							defer panicCatcher()
+							update()
+						}()
+						render, err := RenderSocket(ctx, engine, sock)
+						if err != nil {
+							log.ErrorContext(ctx, "socket update error", "error", err, "socket", sock.ID())
+						} else {
+							sock.UpdateRender(render)
+						}
+					}
+					eventMu.Unlock()
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// Periodically re-render to keep relative times current.
+	if e.refreshInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(e.refreshInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					eventMu.Lock()
+					if !eventsClosed {
+						render, err := RenderSocket(ctx, engine, sock)
+						if err != nil {
+							log.ErrorContext(ctx, "socket refresh error", "error", err, "socket", sock.ID())
+						} else {
+							sock.UpdateRender(render)
+						}
+					}
+					eventMu.Unlock()
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// Close the connection if the client misses two heartbeats.
+	var heartbeat <-chan time.Time
+	if e.heartbeat > 0 {
+		ticker := time.NewTicker(e.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	// Close the connection if the client stops sending events.
+	var idle <-chan time.Time
+	var idleTimer *time.Timer
+	if e.idleTimeout > 0 {
+		idleTimer = time.NewTimer(e.idleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
+	// Events sent with priority go out ahead of the others waiting.
+	var priority chan Event
+	if p, ok := sock.(prioritySender); ok {
+		priority = p.priorityMessages()
+	}
+
+	// Send events to the websocket connection.
+	chunks := &chunker{size: e.chunkSize}
+	for {
+		select {
+		case msg := <-priority:
+			if err := chunks.write(ctx, c, msg); err != nil {
+				return fmt.Errorf("writing to socket error: %w", err)
+			}
+			continue
+		default:
+		}
+		select {
+		case msg := <-priority:
+			if err := chunks.write(ctx, c, msg); err != nil {
+				return fmt.Errorf("writing to socket error: %w", err)
+			}
+		case <-idle:
+			if remaining := e.idleTimeout - time.Since(time.Unix(0, lastEvent.Load())); remaining > 0 {
+				idleTimer.Reset(remaining)
+				continue
+			}
+			if err := writeTimeout(ctx, time.Second*5, c, Event{T: EventIdle}); err != nil {
+				return fmt.Errorf("writing to socket error: %w", err)
+			}
+			return fmt.Errorf("%w: %w", ErrSocketIdle, context.DeadlineExceeded)
+		case <-heartbeat:
+			if time.Since(time.Unix(0, lastRead.Load())) > 2*e.heartbeat {
+				return fmt.Errorf("client missed heartbeats: %w", context.DeadlineExceeded)
+			}
+		case msg := <-sock.Messages():
+			if err := chunks.write(ctx, c, msg); err != nil {
+				return fmt.Errorf("writing to socket error: %w", err)
+			}
+		case ee := <-eventErrors:
+			d, err := json.Marshal(ee)
+			if err != nil {
+				return fmt.Errorf("writing to socket error: %w", err)
+			}
+			if err := writeTimeout(ctx, time.Second*5, c, Event{T: EventError, Data: d}); err != nil {
+				return fmt.Errorf("writing to socket error: %w", err)
+			}
+		case err := <-internalErrors:
+			if err != nil {
+				d, err1 := json.Marshal(err.Error())
+				if err1 != nil {
+					return fmt.Errorf("writing to socket error: %w", err1)
+				}
+				if err := writeTimeout(ctx, time.Second*5, c, Event{T: EventError, Data: d}); err != nil {
+					return fmt.Errorf("writing to socket error: %w", err)
+				}
+				// Something catastrophic has happened.
+				return fmt.Errorf("internal error: %w", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func writeTimeout(ctx context.Context, timeout time.Duration, c socketConn, msg Event) error {
+	data, err := encodeEvent(c, msg)
+	if err != nil {
+		return fmt.Errorf("failed writeTimeout: %w", err)
+	}
+	return writeDataTimeout(ctx, timeout, c, data)
+}
+
+func writeDataTimeout(ctx context.Context, timeout time.Duration, c socketConn, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return c.write(ctx, data)
+}
@@ -0,0 +1,332 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testConn a socketConn driven by a test.
+type testConn struct {
+	in  chan Event
+	raw chan []byte
+	out chan Event
+	// stop ends serving the connection.
+	stop func()
+}
+
+func newTestConn() *testConn {
+	return &testConn{in: make(chan Event), raw: make(chan []byte), out: make(chan Event, 64)}
+}
+
+func (c *testConn) read(ctx context.Context) (bool, []byte, error) {
+	select {
+	case d := <-c.raw:
+		return true, d, nil
+	case m, ok := <-c.in:
+		if !ok {
+			return false, nil, io.EOF
+		}
+		d, err := json.Marshal(m)
+		return true, d, err
+	case <-ctx.Done():
+		return false, nil, ctx.Err()
+	}
+}
+
+func (c *testConn) write(ctx context.Context, data []byte) error {
+	var m Event
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	c.out <- m
+	return nil
+}
+
+// testSocket how serveTestSocket serves a socket.
+type testSocket struct {
+	// page the page load the socket connects from.
+	page string
+	// session the socket is given.
+	session Session
+	// sock the socket to serve, rather than a new one.
+	sock Socket
+}
+
+// testSocketOption configures how serveTestSocket serves a socket.
+type testSocketOption func(*testSocket)
+
+// withPage serves the socket as connecting from a page load.
+func withPage(page string) testSocketOption {
+	return func(o *testSocket) {
+		o.page = page
+	}
+}
+
+// withSession gives the served socket a session.
+func withSession(session Session) testSocketOption {
+	return func(o *testSocket) {
+		o.session = session
+	}
+}
+
+// withSocket serves a socket made by the test.
+func withSocket(sock Socket) testSocketOption {
+	return func(o *testSocket) {
+		o.sock = sock
+	}
+}
+
+// serveTestSocket serves a connected socket over a test connection, until
+// the test ends or the connection is stopped.
+func serveTestSocket(t *testing.T, e Engine, options ...testSocketOption) *testConn {
+	t.Helper()
+	var base *BaseEngine
+	switch v := e.(type) {
+	case *BaseEngine:
+		base = v
+	case *HttpEngine:
+		base = v.BaseEngine
+	default:
+		t.Fatalf("cannot serve a socket on %T", e)
+	}
+	o := testSocket{session: NewSession()}
+	for _, option := range options {
+		option(&o)
+	}
+	target := "/"
+	if o.page != "" {
+		target = "/?" + pageParam + "=" + o.page
+	}
+	sock := o.sock
+	if sock == nil {
+		sock = NewBaseSocket(o.session, base, true)
+	}
+	c := newTestConn()
+	e.AddSocket(sock)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		base.serveSocket(ctx, e, sock, c, httptest.NewRequest("GET", target, nil))
+	}()
+	c.stop = func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(c.stop)
+	return c
+}
+
+// syncSocket waits for the changes already queued to the sockets assigns to
+// be made.
+func syncSocket(t *testing.T, sock Socket) {
+	t.Helper()
+	synced := make(chan struct{})
+	if err := sock.Update(context.Background(), func(assigns interface{}) interface{} {
+		close(synced)
+		return assigns
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-synced
+}
+
+// expectAck waits for the acknowledgement of an event.
+func (c *testConn) expectAck(t *testing.T, id int) Event {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case m := <-c.out:
+			if m.T == EventAck && m.ID == id {
+				return m
+			}
+		case <-timeout:
+			t.Fatalf("no ack for event %d", id)
+		}
+	}
+}
+
+func testRenderHandler() *BaseHandler {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, rc *RenderContext) (io.Reader, error) {
+		return strings.NewReader("<div></div>"), nil
+	})
+	return h
+}
+
+func TestConcurrentEvents(t *testing.T) {
+	h := testRenderHandler()
+	release := make(chan struct{})
+	h.HandleEvent("slow", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+	h.HandleEvent("fast", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	WithConcurrentEvents("slow")(e)
+	c := serveTestSocket(t, e)
+
+	c.in <- Event{T: "slow", ID: 1}
+	c.in <- Event{T: "fast", ID: 2}
+	c.expectAck(t, 2)
+	close(release)
+	c.expectAck(t, 1)
+}
+
+// countingChild a child counting the events it is sent, without locking.
+type countingChild struct {
+	n *int
+}
+
+func (c countingChild) ID() string { return "counting" }
+func (c countingChild) CallEvent(ctx context.Context, t string, sock Socket, msg Params) error {
+	*c.n++
+	return ErrNoEventHandler
+}
+func (c countingChild) CallSelf(ctx context.Context, t string, sock Socket, msg Event) error {
+	return ErrNoEventHandler
+}
+func (c countingChild) GetState() any             { return nil }
+func (c countingChild) Event(event string) string { return event }
+
+func TestConcurrentEventsSerialiseChildren(t *testing.T) {
+	var n int
+	h := testRenderHandler()
+	h.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		s.AttachChild(countingChild{n: &n})
+		return nil, nil
+	})
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	h.HandleEvent("slow", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	WithConcurrentEvents("slow")(e)
+	c := serveTestSocket(t, e)
+
+	// Both handlers run at once, the components are still sent the events
+	// one at a time.
+	c.in <- Event{T: "slow", ID: 1}
+	c.in <- Event{T: "slow", ID: 2}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("expected the handlers to run concurrently")
+		}
+	}
+	close(release)
+	acked := map[int]bool{}
+	timeout := time.After(time.Second)
+	for len(acked) < 2 {
+		select {
+		case m := <-c.out:
+			if m.T == EventAck {
+				acked[m.ID] = true
+			}
+		case <-timeout:
+			t.Fatalf("expected acks for 1 and 2, got %v", acked)
+		}
+	}
+	if n != 2 {
+		t.Errorf("expected the component to be sent both events, got %d", n)
+	}
+}
+
+func TestConcurrentEventErrorAfterClose(t *testing.T) {
+	h := testRenderHandler()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h.HandleEvent("slow", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		close(started)
+		<-release
+		return nil, errors.New("failed")
+	})
+	reported := make(chan struct{})
+	h.HandleSocketError(func(ctx context.Context, s Socket, source Event, err error) error {
+		close(reported)
+		return nil
+	})
+	e := NewBaseEngine(h)
+	WithConcurrentEvents("slow")(e)
+	c := serveTestSocket(t, e)
+
+	// The connection closes while the handler is running, reporting its
+	// error mustn't block.
+	c.in <- Event{T: "slow", ID: 1}
+	<-started
+	c.stop()
+	close(release)
+	select {
+	case <-reported:
+	case <-time.After(time.Second):
+		t.Fatal("handler blocked reporting its error after the connection closed")
+	}
+}
+
+func TestConcurrentEventReplies(t *testing.T) {
+	var calls atomic.Int32
+	h := testRenderHandler()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h.HandleEvent("slow", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		calls.Add(1)
+		if err := s.Reply(ctx, "slow"); err != nil {
+			return nil, err
+		}
+		close(started)
+		<-release
+		return nil, nil
+	})
+	h.HandleEvent("fast", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, s.Reply(ctx, "fast")
+	})
+	e := NewBaseEngine(h)
+	WithConcurrentEvents("slow")(e)
+	WithIdempotency(time.Minute)(e)
+	c := serveTestSocket(t, e)
+
+	// Each event is acknowledged with its own reply, even though the slow
+	// one replied first.
+	c.in <- Event{T: "slow", ID: 1, Key: "once"}
+	<-started
+	c.in <- Event{T: "fast", ID: 2}
+	if ack := c.expectAck(t, 2); string(ack.Data) != `"fast"` {
+		t.Errorf("expected the fast reply, got %s", ack.Data)
+	}
+
+	// Concurrent events are still only handled once per idempotency key.
+	c.in <- Event{T: "slow", ID: 3, Key: "once"}
+	close(release)
+	acks := map[int]string{}
+	timeout := time.After(time.Second)
+	for len(acks) < 2 {
+		select {
+		case m := <-c.out:
+			if m.T == EventAck {
+				acks[m.ID] = string(m.Data)
+			}
+		case <-timeout:
+			t.Fatalf("expected acks for 1 and 3, got %v", acks)
+		}
+	}
+	if acks[1] != `"slow"` || acks[3] != `"slow"` {
+		t.Errorf("expected both to get the slow reply, got %v", acks)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected the slow handler to be called once, got %d", n)
+	}
+}
@@ -0,0 +1,53 @@
+package live
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+const connInfoKey contextKey = "context_conn_info"
+
+// ConnInfo describes the connection a socket was made over, captured from the
+// request which opened it. Unlike Request it is available to every event
+// handled on the socket, for example for audit logging.
+type ConnInfo struct {
+	// RemoteAddr the network address of the client, or of the proxy in front of it.
+	RemoteAddr string
+	// RemoteIP the IP of RemoteAddr, without the port.
+	RemoteIP string
+	// UserAgent the user agent the client connected with.
+	UserAgent string
+	// Header a copy of the headers of the request.
+	Header http.Header
+	// ConnectedAt when the connection was made.
+	ConnectedAt time.Time
+}
+
+// newConnInfo captures the connection info of a request.
+func newConnInfo(r *http.Request) *ConnInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return &ConnInfo{
+		RemoteAddr:  r.RemoteAddr,
+		RemoteIP:    ip,
+		UserAgent:   r.UserAgent(),
+		Header:      r.Header.Clone(),
+		ConnectedAt: time.Now(),
+	}
+}
+
+// contextWithConnInfo embed the connection info within the context.
+func contextWithConnInfo(ctx context.Context, info *ConnInfo) context.Context {
+	return context.WithValue(ctx, connInfoKey, info)
+}
+
+// Connection pulls the connection info out of a context, it is nil outside of
+// handlers.
+func Connection(ctx context.Context) *ConnInfo {
+	info, _ := ctx.Value(connInfoKey).(*ConnInfo)
+	return info
+}
@@ -0,0 +1,53 @@
+package live
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConnection(t *testing.T) {
+	h := testRenderHandler()
+	infos := make(chan *ConnInfo, 1)
+	h.HandleEvent("audit", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		infos <- Connection(ctx)
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	c := newTestConn()
+	sock := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(sock)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	defer func() {
+		cancel()
+		<-done
+	}()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("User-Agent", "test-agent")
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	go func() {
+		defer close(done)
+		e.serveSocket(ctx, e, sock, c, r)
+	}()
+
+	c.in <- Event{T: "audit", ID: 1}
+	c.expectAck(t, 1)
+	info := <-infos
+	if info == nil {
+		t.Fatal("expected connection info in event context")
+	}
+	if info.RemoteIP != "192.0.2.1" || info.RemoteAddr != "192.0.2.1:1234" {
+		t.Errorf("unexpected remote %q %q", info.RemoteIP, info.RemoteAddr)
+	}
+	if info.UserAgent != "test-agent" || info.Header.Get("X-Forwarded-For") != "203.0.113.7" {
+		t.Errorf("unexpected headers %+v", info)
+	}
+	if info.ConnectedAt.IsZero() {
+		t.Error("expected connected at to be set")
+	}
+	if Connection(context.Background()) != nil {
+		t.Error("expected no connection info outside of a handler")
+	}
+}
@@ -8,8 +8,10 @@ import (
 type contextKey string
 
 const (
-	requestKey contextKey = "context_request"
-	writerKey  contextKey = "context_writer"
+	requestKey    contextKey = "context_request"
+	writerKey     contextKey = "context_writer"
+	pathParamsKey contextKey = "context_path_params"
+	prevParamsKey contextKey = "context_previous_params"
 )
 
 // contextWithRequest embed the initiating request within the context.
@@ -41,3 +43,52 @@ func Writer(ctx context.Context) http.ResponseWriter {
 	}
 	return w
 }
+
+// RequestWithPathParams attaches route parameters extracted by a router to a
+// request. These are merged into the Params given to params handlers. Use this
+// to bridge routers which keep path variables outside of the request, for
+// example with gin
+//
+//	r.GET("/room/:id", func(c *gin.Context) {
+//		params := map[string]string{}
+//		for _, p := range c.Params {
+//			params[p.Key] = p.Value
+//		}
+//		h.ServeHTTP(c.Writer, live.RequestWithPathParams(c.Request, params))
+//	})
+func RequestWithPathParams(r *http.Request, params map[string]string) *http.Request {
+	merged := map[string]string{}
+	for k, v := range PathParams(r.Context()) {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey, merged))
+}
+
+// PathParams pulls out route parameters from a context.
+func PathParams(ctx context.Context) map[string]string {
+	data := ctx.Value(pathParamsKey)
+	p, ok := data.(map[string]string)
+	if !ok {
+		return nil
+	}
+	return p
+}
+
+// contextWithPreviousParams embed the params the socket had before a change.
+func contextWithPreviousParams(ctx context.Context, p Params) context.Context {
+	return context.WithValue(ctx, prevParamsKey, p)
+}
+
+// PreviousParams pulls out the params a socket had before the change being
+// handled by a params handler. It is empty when the params are first set, on
+// mount. Use with Params.Changed to only react to the keys that changed.
+func PreviousParams(ctx context.Context) Params {
+	p, ok := ctx.Value(prevParamsKey).(Params)
+	if !ok {
+		return Params{}
+	}
+	return p
+}
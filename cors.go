@@ -0,0 +1,146 @@
+package live
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"nhooyr.io/websocket"
+)
+
+// WithAllowedOrigins allow pages hosted on other origins to connect to this
+// handler. Origins are host patterns as understood by `path.Match`, for example
+// "app.example.com" or "*.example.com". The websocket upgrade is only accepted
+// from these origins and CORS headers are set for them on other requests.
+//
+// Cross origin requests only carry the session cookie if it is set with
+// `SameSite=None; Secure`, see WithCrossOrigin.
+func WithAllowedOrigins(origins ...string) EngineConfig {
+	return func(e Engine) error {
+		httpEngine, ok := e.(*HttpEngine)
+		if !ok {
+			return nil
+		}
+		for _, o := range origins {
+			if _, err := path.Match(o, ""); err != nil {
+				return fmt.Errorf("invalid origin pattern %q: %w", o, err)
+			}
+		}
+		httpEngine.allowedOrigins = slices.Clone(origins)
+		// Copy the options rather than change ones passed to
+		// WithWebsocketAcceptOptions.
+		var options websocket.AcceptOptions
+		if httpEngine.acceptOptions != nil {
+			options = *httpEngine.acceptOptions
+		}
+		options.OriginPatterns = slices.Clone(origins)
+		httpEngine.acceptOptions = &options
+		return nil
+	}
+}
+
+// WithCrossOrigin configure the session cookie so that it is sent on cross
+// origin requests, this sets `SameSite=None; Secure`.
+func WithCrossOrigin() CookieStoreConfig {
+	return func(c *CookieStore) error {
+		c.Store.Options.SameSite = http.SameSiteNoneMode
+		c.Store.Options.Secure = true
+		return nil
+	}
+}
+
+// checkCrossOrigin warns about configuration which will stop a split origin
+// setup from working.
+func (h *HttpEngine) checkCrossOrigin() {
+	if len(h.allowedOrigins) == 0 {
+		return
+	}
+	store, ok := h.sessionStore.(*CookieStore)
+	if !ok {
+		return
+	}
+	if store.Store.Options.SameSite != http.SameSiteNoneMode || !store.Store.Options.Secure {
+		slog.Warn("allowed origins are set but the session cookie is not SameSite=None; Secure, browsers will not send it cross origin. Use live.WithCrossOrigin on the cookie store")
+	}
+}
+
+// corsAllowHeaders the request headers the client sends cross origin.
+var corsAllowHeaders = strings.Join([]string{
+	"Content-Type",
+	sessionSaveHeader,
+	uploadIDHeader,
+	uploadInputHeader,
+	uploadFilenameHeader,
+	uploadSizeHeader,
+	uploadOffsetHeader,
+	RequestIDHeader,
+}, ", ")
+
+// corsExposeHeaders the response headers the client reads cross origin.
+var corsExposeHeaders = strings.Join([]string{
+	uploadOffsetHeader,
+	RequestIDHeader,
+}, ", ")
+
+// handleCORS sets CORS headers for allowed cross origin requests. Returns false
+// if the request should not be served.
+func (h *HttpEngine) handleCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(h.allowedOrigins) == 0 {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("live: malformed origin %q", origin), http.StatusForbidden)
+		return false
+	}
+	// Same origin requests don't need CORS.
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	if !h.originAllowed(u.Host) {
+		slog.WarnContext(r.Context(), "cross origin request rejected, add the origin with live.WithAllowedOrigins", "origin", origin, "host", r.Host)
+		http.Error(w, fmt.Sprintf("live: %s: %q", ErrOriginNotAllowed, origin), http.StatusForbidden)
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.Header().Add("Vary", "Origin")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+		w.WriteHeader(http.StatusNoContent)
+		return false
+	}
+	w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+	return true
+}
+
+// corsAllowed checks a request is same origin or from an allowed origin,
+// without answering it.
+func (h *HttpEngine) corsAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(h.allowedOrigins) == 0 {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host) || h.originAllowed(u.Host)
+}
+
+// originAllowed checks a host against the allowed origin patterns.
+func (h *HttpEngine) originAllowed(host string) bool {
+	for _, pattern := range h.allowedOrigins {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(host)); ok {
+			return true
+		}
+	}
+	return false
+}
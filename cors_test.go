@@ -0,0 +1,96 @@
+package live
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nhooyr.io/websocket"
+)
+
+func TestAllowedOrigins(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body>ok</body></html>`), nil
+	})
+	e := NewHttpHandler(NewTestStore("test"), h, WithAllowedOrigins("*.example.com"))
+
+	tests := []struct {
+		origin string
+		status int
+		allow  string
+	}{
+		{origin: "", status: http.StatusOK},
+		{origin: "https://app.example.com", status: http.StatusOK, allow: "https://app.example.com"},
+		{origin: "https://evil.com", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("origin %q: expected status %d got %d", tt.origin, tt.status, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allow {
+			t.Errorf("origin %q: expected allow origin %q got %q", tt.origin, tt.allow, got)
+		}
+	}
+}
+
+func TestAllowedOriginsInvalidPattern(t *testing.T) {
+	e := &HttpEngine{BaseEngine: &BaseEngine{}}
+	if err := WithAllowedOrigins("[")(e); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestAllowedOriginsPreflight(t *testing.T) {
+	e := NewHttpHandler(NewTestStore("test"), NewHandler(), WithAllowedOrigins("*.example.com"))
+
+	r := httptest.NewRequest(http.MethodOptions, "/", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected preflight to succeed, got %d", w.Code)
+	}
+	if methods := w.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(methods, http.MethodDelete) {
+		t.Errorf("expected DELETE to be allowed, got %q", methods)
+	}
+	headers := w.Header().Get("Access-Control-Allow-Headers")
+	for _, h := range []string{sessionSaveHeader, uploadIDHeader, uploadInputHeader, uploadFilenameHeader, uploadSizeHeader, uploadOffsetHeader, RequestIDHeader} {
+		if !strings.Contains(headers, h) {
+			t.Errorf("expected %s to be allowed, got %q", h, headers)
+		}
+	}
+
+	// The client reads the upload offset from responses.
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if exposed := w.Header().Get("Access-Control-Expose-Headers"); !strings.Contains(exposed, uploadOffsetHeader) {
+		t.Errorf("expected %s to be exposed, got %q", uploadOffsetHeader, exposed)
+	}
+}
+
+func TestAllowedOriginsCopiesAcceptOptions(t *testing.T) {
+	options := &websocket.AcceptOptions{CompressionMode: websocket.CompressionContextTakeover}
+	e := NewHttpHandler(NewTestStore("test"), NewHandler(), WithWebsocketAcceptOptions(options), WithAllowedOrigins("*.example.com"))
+	if options.OriginPatterns != nil {
+		t.Errorf("expected the caller's options to be left alone, got %v", options.OriginPatterns)
+	}
+	if e.acceptOptions.CompressionMode != websocket.CompressionContextTakeover {
+		t.Error("expected the other options to be kept")
+	}
+	if len(e.acceptOptions.OriginPatterns) != 1 || e.acceptOptions.OriginPatterns[0] != "*.example.com" {
+		t.Errorf("expected the origin patterns to be set, got %v", e.acceptOptions.OriginPatterns)
+	}
+}
@@ -0,0 +1,141 @@
+package live
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// devWatchInterval how often dev mode checks watched files for changes.
+const devWatchInterval = 500 * time.Millisecond
+
+// WithDevMode enables features which help while developing. Event errors
+// created with NewError are sent to the client along with their stack. Files
+// in the given directories are watched, when one changes the handlers
+// HandleReload funcs are called to re-parse templates and connected sockets
+// reload the page, until the engine is closed. It should not be used in
+// production.
+func WithDevMode(dirs ...string) EngineConfig {
+	return func(e Engine) error {
+		var base *BaseEngine
+		switch v := e.(type) {
+		case *BaseEngine:
+			base = v
+		case *HttpEngine:
+			base = v.BaseEngine
+		}
+		if base == nil {
+			return nil
+		}
+		base.devMode = true
+		if len(dirs) == 0 {
+			return nil
+		}
+		files, err := watchedFiles(dirs)
+		if err != nil {
+			return err
+		}
+		if base.stopWatch != nil {
+			base.stopWatch()
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		base.stopWatch = cancel
+		go base.watch(ctx, dirs, files)
+		return nil
+	}
+}
+
+// Close stops the engine's background work, such as watching files in dev
+// mode. Connected sockets are left alone.
+func (e *BaseEngine) Close() error {
+	if e.stopWatch != nil {
+		e.stopWatch()
+	}
+	return nil
+}
+
+// fileState is used to tell if a watched file has changed.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchedFiles gets the state of all the files in the directories.
+func watchedFiles(dirs []string) (map[string]fileState, error) {
+	files := map[string]fileState{}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// changed checks if any files have been added, removed or modified.
+func changed(prev, next map[string]fileState) bool {
+	if len(prev) != len(next) {
+		return true
+	}
+	for path, state := range next {
+		if prev[path] != state {
+			return true
+		}
+	}
+	return false
+}
+
+// watch polls the directories for changes, reloading when they do, until
+// the context is cancelled.
+func (e *BaseEngine) watch(ctx context.Context, dirs []string, files map[string]fileState) {
+	ticker := time.NewTicker(devWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		next, err := watchedFiles(dirs)
+		if err != nil {
+			slog.Error("dev mode watch error", "error", err)
+			continue
+		}
+		if !changed(files, next) {
+			continue
+		}
+		files = next
+		e.reload()
+	}
+}
+
+// reload runs the reload handlers and then has connected sockets reload.
+func (e *BaseEngine) reload() {
+	for _, r := range e.handler.getReload() {
+		if err := r(); err != nil {
+			// Leave the page as it is until the files are fixed.
+			slog.Error("dev mode reload error", "error", err)
+			return
+		}
+	}
+	for _, sock := range e.sockets() {
+		if err := sock.Send(EventReload, nil); err != nil {
+			slog.Error("dev mode reload error", "error", err, "socket", sock.ID())
+		}
+	}
+}
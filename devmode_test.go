@@ -0,0 +1,76 @@
+package live
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDevModeReload(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(tmpl, []byte("<div>one</div>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler()
+	reloaded := make(chan struct{}, 1)
+	h.HandleReload(func() error {
+		reloaded <- struct{}{}
+		return nil
+	})
+	e := NewBaseEngine(h)
+	s := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(s)
+	if err := WithDevMode(dir)(e); err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	if err := os.WriteFile(tmpl, []byte("<div>two!</div>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloaded:
+	case <-time.After(5 * devWatchInterval):
+		t.Fatal("expected templates to be reloaded")
+	}
+	select {
+	case msg := <-s.Messages():
+		if msg.T != EventReload {
+			t.Errorf("expected reload event, got %s", msg.T)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected socket to be told to reload")
+	}
+}
+
+func TestDevModeClose(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(tmpl, []byte("<div>one</div>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler()
+	reloaded := make(chan struct{}, 1)
+	h.HandleReload(func() error {
+		reloaded <- struct{}{}
+		return nil
+	})
+	e := NewBaseEngine(h)
+	if err := WithDevMode(dir)(e); err != nil {
+		t.Fatal(err)
+	}
+	e.Close()
+
+	if err := os.WriteFile(tmpl, []byte("<div>two!</div>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloaded:
+		t.Fatal("expected a closed engine to stop watching")
+	case <-time.After(3 * devWatchInterval):
+	}
+}
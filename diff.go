@@ -28,6 +28,12 @@ const (
 	Replace
 	Append
 	Prepend
+	// SetAttrs sets the attributes of an element leaving its children as
+	// they are, only sent to clients speaking protocol version 2.
+	SetAttrs
+	// SetText sets the text of an element whose only child is text, only
+	// sent to clients speaking protocol version 2.
+	SetText
 )
 
 // anchorGenerator generates an ID for a node in the tree.
@@ -72,6 +78,10 @@ type Patch struct {
 	Anchor string
 	Action PatchAction
 	HTML   string
+	// Attrs the attributes to set for a SetAttrs patch, as key value pairs.
+	Attrs [][2]string `json:",omitempty"`
+	// Text the text to set for a SetText patch.
+	Text string `json:",omitempty"`
 }
 
 func (p Patch) String() string {
@@ -85,6 +95,10 @@ func (p Patch) String() string {
 		action = "AP"
 	case Prepend:
 		action = "PR"
+	case SetAttrs:
+		return fmt.Sprintf("%s AT %v", p.Anchor, p.Attrs)
+	case SetText:
+		return fmt.Sprintf("%s TX %s", p.Anchor, p.Text)
 	}
 
 	return fmt.Sprintf("%s %s %s", p.Anchor, action, p.HTML)
@@ -92,10 +106,29 @@ func (p Patch) String() string {
 
 // Diff compare two node states and return patches.
 func Diff(current, proposed *html.Node) ([]Patch, error) {
-	patches := diffTrees(current, proposed)
+	return diffVersion(current, proposed, ProtocolVersion)
+}
+
+// diffVersion compare two node states and return patches a client speaking
+// the protocol version can apply.
+func diffVersion(current, proposed *html.Node, version int) ([]Patch, error) {
+	patches := diffTrees(current, proposed, version >= 2)
 	output := make([]Patch, len(patches))
 
 	for idx, p := range patches {
+		if p.Action == SetAttrs && p.Node != nil {
+			attrs := make([][2]string, 0, len(p.Node.Attr))
+			for _, a := range p.Node.Attr {
+				attrs = append(attrs, [2]string{a.Key, a.Val})
+			}
+			output[idx] = Patch{Anchor: p.Anchor, Action: SetAttrs, Attrs: attrs}
+			continue
+		}
+		if p.Action == SetText && p.Node != nil {
+			output[idx] = Patch{Anchor: p.Anchor, Action: SetText, Text: p.Node.Data}
+			continue
+		}
+
 		var buf bytes.Buffer
 		if p.Node != nil {
 			if err := html.Render(&buf, p.Node); err != nil {
@@ -130,11 +163,14 @@ type differ struct {
 	// `live-update` handler.
 	updateNode     *html.Node
 	updateModifier PatchAction
+	// inPlace patch attribute and text changes with SetAttrs and SetText
+	// rather than replacing the element.
+	inPlace bool
 }
 
 // diffTrees compares two html Nodes and outputs patches.
-func diffTrees(current, proposed *html.Node) []patch {
-	d := &differ{}
+func diffTrees(current, proposed *html.Node, inPlace bool) []patch {
+	d := &differ{inPlace: inPlace}
 	anchorTree(current, newAnchorGenerator())
 	anchorTree(proposed, newAnchorGenerator())
 	return d.compareNodes(current, proposed, "")
@@ -230,9 +266,18 @@ func (d *differ) compareNodes(oldNode, newNode *html.Node, parentAnchor string)
 	// Check for `live-update` modifiers.
 	d.liveUpdateCheck(newNode)
 
-	// If nodes at this position are not equal patch a replacement.
+	// If nodes at this position are not equal patch a replacement, or if
+	// only the attributes of an element have changed set them and carry on
+	// to its children. The text of an element with nothing else in it is
+	// set on its own.
 	if !nodeEqual(oldNode, newNode) {
-		return append(patches, d.generatePatch(newNode, parentAnchor, Replace))
+		if d.textChanged(oldNode, newNode, parentAnchor) {
+			return append(patches, patch{Anchor: parentAnchor, Action: SetText, Node: newNode})
+		}
+		if !d.attrsChanged(oldNode, newNode) {
+			return append(patches, d.generatePatch(newNode, parentAnchor, Replace))
+		}
+		patches = append(patches, patch{Anchor: findAnchor(oldNode), Action: SetAttrs, Node: newNode})
 	}
 
 	newChildren := generateNodeList(newNode.FirstChild)
@@ -282,6 +327,36 @@ func (d *differ) generatePatch(node *html.Node, target string, action PatchActio
 	}
 }
 
+// attrsChanged checks if an element can be patched by setting its
+// attributes.
+func (d *differ) attrsChanged(oldNode, newNode *html.Node) bool {
+	if !d.inPlace || d.updateNode != nil {
+		return false
+	}
+	if oldNode.Type != html.ElementNode || newNode.Type != html.ElementNode || oldNode.Data != newNode.Data {
+		return false
+	}
+	anchor := findAnchor(oldNode)
+	return anchor != "" && anchor == findAnchor(newNode)
+}
+
+// textChanged checks if the text of an element can be patched by setting it,
+// that is the text is the element's only child.
+func (d *differ) textChanged(oldNode, newNode *html.Node, parentAnchor string) bool {
+	if !d.inPlace || d.updateNode != nil || parentAnchor == "" {
+		return false
+	}
+	if oldNode.Type != html.TextNode || newNode.Type != html.TextNode {
+		return false
+	}
+	return onlyChild(oldNode) && onlyChild(newNode)
+}
+
+// onlyChild checks a node has no siblings.
+func onlyChild(node *html.Node) bool {
+	return node.Parent != nil && node.PrevSibling == nil && node.NextSibling == nil
+}
+
 func findAnchor(node *html.Node) string {
 	for _, a := range node.Attr {
 		if strings.HasPrefix(a.Key, liveAnchorPrefix) {
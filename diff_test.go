@@ -400,7 +400,7 @@ func BenchmarkDiff(b *testing.B) {
 	}
 
 	for n := 0; n < b.N; n++ {
-		diffTrees(root, root)
+		diffTrees(root, root, false)
 	}
 }
 
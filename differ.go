@@ -0,0 +1,74 @@
+package live
+
+import (
+	"golang.org/x/net/html"
+)
+
+// Differ compares the current render of a socket with a proposed one, returning
+// the patches which take the client from one to the other. Patches target
+// elements by the anchor returned by Anchor, the current tree is already
+// anchored and the proposed tree must be anchored with AnchorTree, so that
+// later patches can target its elements. The version is the protocol version
+// of the client the patches are for, SetAttrs and SetText patches need
+// version 2.
+type Differ interface {
+	Diff(current, proposed *html.Node, version int) ([]Patch, error)
+}
+
+// DifferFunc adapts a function to a Differ.
+type DifferFunc func(current, proposed *html.Node, version int) ([]Patch, error)
+
+// Diff calls the function.
+func (f DifferFunc) Diff(current, proposed *html.Node, version int) ([]Patch, error) {
+	return f(current, proposed, version)
+}
+
+// TreeDiffer the default Differ, it walks both trees together patching the
+// elements which changed.
+type TreeDiffer struct{}
+
+// Diff compares the trees.
+func (TreeDiffer) Diff(current, proposed *html.Node, version int) ([]Patch, error) {
+	return diffVersion(current, proposed, version)
+}
+
+// WithDiffer replaces the Differ used to patch sockets, for example with one
+// tuned to the shape of an apps pages.
+func WithDiffer(d Differ) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.differ = d
+		case *HttpEngine:
+			v.differ = d
+		}
+		return nil
+	}
+}
+
+// AnchorTree adds an anchor to every element in the tree which doesn't have one.
+func AnchorTree(root *html.Node) {
+	anchorTree(root, newAnchorGenerator())
+}
+
+// Anchor returns the anchor patches target an element with, empty if it has none.
+func Anchor(node *html.Node) string {
+	return findAnchor(node)
+}
+
+// renderDiffer is implemented by engines with their own Differ.
+type renderDiffer interface {
+	renderDiffer() Differ
+}
+
+func (e *BaseEngine) renderDiffer() Differ {
+	return e.differ
+}
+
+// diffRenders compares two renders with the engines Differ.
+func diffRenders(e interface{}, current, proposed *html.Node, version int) ([]Patch, error) {
+	if d, ok := e.(renderDiffer); ok && d.renderDiffer() != nil {
+		return d.renderDiffer().Diff(current, proposed, version)
+	}
+	return diffVersion(current, proposed, version)
+}
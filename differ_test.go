@@ -0,0 +1,61 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestWithDiffer(t *testing.T) {
+	h := NewHandler()
+	h.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		return 0, nil
+	})
+	h.HandleRender(func(ctx context.Context, rc *RenderContext) (io.Reader, error) {
+		return strings.NewReader(fmt.Sprintf("<div>%d</div>", rc.Assigns)), nil
+	})
+	h.HandleEvent("inc", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return s.Assigns().(int) + 1, nil
+	})
+
+	// Replace the whole body rather than the element which changed.
+	var calls int
+	e := NewBaseEngine(h)
+	WithDiffer(DifferFunc(func(current, proposed *html.Node, version int) ([]Patch, error) {
+		calls++
+		AnchorTree(proposed)
+		body := proposed.FirstChild.LastChild
+		var buf strings.Builder
+		for n := body.FirstChild; n != nil; n = n.NextSibling {
+			if err := html.Render(&buf, n); err != nil {
+				return nil, err
+			}
+		}
+		return []Patch{{Anchor: Anchor(body), Action: Replace, HTML: buf.String()}}, nil
+	}))(e)
+	c := serveTestSocket(t, e)
+
+	c.in <- Event{T: "inc", ID: 1}
+	var patches []Patch
+	for patches == nil {
+		m := <-c.out
+		if m.T != EventPatch {
+			continue
+		}
+		if err := json.Unmarshal(m.Data, &patches); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.expectAck(t, 1)
+	if calls == 0 {
+		t.Fatal("differ was not called")
+	}
+	if len(patches) != 1 || patches[0].Anchor != "_l_0_1" || patches[0].HTML != `<div _l_0_1_0="">1</div>` {
+		t.Errorf("unexpected patches %+v", patches)
+	}
+}
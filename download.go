@@ -0,0 +1,196 @@
+package live
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EventDownload sent to ask the client to download a file.
+const EventDownload = "download"
+
+// downloadParam the query param carrying the download token on the clients
+// request.
+const downloadParam = "live-download"
+
+// downloadTimeout how long the client has to make the download request.
+const downloadTimeout = time.Minute
+
+// Download describes a file sent to the client with SendFile.
+type Download struct {
+	// Name the file name the browser saves the download as.
+	Name string
+	// ContentType the type of the file, by default it is worked out
+	// from the name.
+	ContentType string
+	// Size the length of the file in bytes, if known.
+	Size int64
+}
+
+// DownloadConfig configures a download.
+type DownloadConfig func(d *Download) error
+
+// WithContentType sets the content type of a download.
+func WithContentType(contentType string) DownloadConfig {
+	return func(d *Download) error {
+		d.ContentType = contentType
+		return nil
+	}
+}
+
+// WithContentLength sets the size of a download, so that the browser can
+// show its progress.
+func WithContentLength(size int64) DownloadConfig {
+	return func(d *Download) error {
+		if size < 0 {
+			return fmt.Errorf("invalid download size %d", size)
+		}
+		d.Size = size
+		return nil
+	}
+}
+
+// downloader is implemented by engines which can serve files to a socket.
+type downloader interface {
+	addDownload(sock Socket, d Download, r io.Reader) (string, error)
+}
+
+// downloadEvent the data sent with EventDownload.
+type downloadEvent struct {
+	Token string `json:"token"`
+	Name  string `json:"name"`
+}
+
+// SendFile sends a file to the client, which the browser downloads. The
+// reader is streamed to the client when it makes the download request, and is
+// closed afterwards if it is an io.Closer. The download can be made once, by
+// the same session, within a minute.
+func (s *BaseSocket) SendFile(name string, r io.Reader, options ...DownloadConfig) error {
+	d := Download{Name: name}
+	for _, o := range options {
+		if err := o(&d); err != nil {
+			return fmt.Errorf("could not configure download: %w", err)
+		}
+	}
+	if d.ContentType == "" {
+		d.ContentType = mime.TypeByExtension(filepath.Ext(name))
+	}
+	if d.ContentType == "" {
+		d.ContentType = "application/octet-stream"
+	}
+
+	e, ok := s.engine.(downloader)
+	if !ok {
+		return fmt.Errorf("engine does not support downloads: %w", ErrNotImplemented)
+	}
+	token, err := e.addDownload(s, d, r)
+	if err != nil {
+		return err
+	}
+	return s.Send(EventDownload, downloadEvent{Token: token, Name: name})
+}
+
+// pendingDownloads tracks downloads that the client has yet to make.
+type pendingDownloads struct {
+	mu      sync.Mutex
+	pending map[string]pendingDownload
+}
+
+type pendingDownload struct {
+	session string
+	file    Download
+	r       io.Reader
+	expires time.Time
+}
+
+// close the downloads reader if it needs closing.
+func (p pendingDownload) close() {
+	if c, ok := p.r.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// add creates a one time token for a download.
+func (p *pendingDownloads) add(sock Socket, d Download, r io.Reader) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate download token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending == nil {
+		p.pending = map[string]pendingDownload{}
+	}
+	now := time.Now()
+	for t, d := range p.pending {
+		if now.After(d.expires) {
+			d.close()
+			delete(p.pending, t)
+		}
+	}
+	p.pending[token] = pendingDownload{
+		session: SessionID(sock.Session()),
+		file:    d,
+		r:       r,
+		expires: now.Add(downloadTimeout),
+	}
+	return token, nil
+}
+
+// take consumes a token, returning the download it was issued for.
+func (p *pendingDownloads) take(token string) (pendingDownload, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, ok := p.pending[token]
+	if !ok {
+		return pendingDownload{}, false
+	}
+	delete(p.pending, token)
+	if time.Now().After(d.expires) {
+		d.close()
+		return pendingDownload{}, false
+	}
+	return d, true
+}
+
+func (h *HttpEngine) addDownload(sock Socket, d Download, r io.Reader) (string, error) {
+	return h.downloads.add(sock, d, r)
+}
+
+// getDownload handles the clients request for a file sent with SendFile.
+func (h *HttpEngine) getDownload(ctx context.Context, w http.ResponseWriter, r *http.Request, token string) {
+	d, ok := h.downloads.take(token)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	defer d.close()
+
+	// The request must come from the same session as the socket.
+	session, err := h.sessionStore.Get(r)
+	if err != nil || SessionID(session) != d.session {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", d.file.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": d.file.Name}))
+	w.Header().Set("Cache-Control", "no-store")
+	if d.file.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(d.file.Size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, d.r); err != nil {
+		h.Error()(ctx, fmt.Errorf("could not send download: %w", err))
+	}
+}
@@ -0,0 +1,49 @@
+package live
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendFile(t *testing.T) {
+	store := NewTestStore("test")
+	e := NewHttpHandler(store, NewHandler())
+	sock := NewHttpSocket(store.s, e, true)
+
+	if err := sock.SendFile("export.csv", strings.NewReader("a,b\n1,2\n")); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-sock.Messages()
+	if msg.T != EventDownload {
+		t.Fatalf("expected download event got %s", msg.T)
+	}
+	var d downloadEvent
+	if err := json.Unmarshal(msg.Data, &d); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?"+downloadParam+"="+d.Token, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=export.csv` {
+		t.Errorf("unexpected content disposition %q", got)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != "a,b\n1,2\n" {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+
+	// The token can only be used once.
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?"+downloadParam+"="+d.Token, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a used token got %d", w.Code)
+	}
+}
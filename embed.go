@@ -0,0 +1,248 @@
+package live
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+var _ Child = &Embedded{}
+var _ ChildUnmounter = &Embedded{}
+var _ ChildPreviewer = &Embedded{}
+
+// Embedded a handler mounted as a region of another handler's page. It shares
+// the host's socket, but keeps its own assigns, and its events, self events
+// and params are handled by its own handler. This lets independently written
+// handlers, such as a notifications widget, be composed into a page without
+// turning them into components.
+//
+// Like a component, events are routed to the embedded handler by name, so
+// use Event to scope them if the host handles events with the same name.
+type Embedded struct {
+	id      string
+	handler Handler
+	socket  *embeddedSocket
+	assigns interface{}
+}
+
+// Embed mounts a handler as a region of the page the socket is on, and
+// attaches it to the socket so that it receives events. Call it from the
+// host's mount handler, and write it into the host's render with Render.
+//
+//	h.HandleMount(func(ctx context.Context, s live.Socket) (interface{}, error) {
+//		widget, err := live.Embed(ctx, "notifications", notifications.NewHandler(), s)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return &Page{Notifications: widget}, nil
+//	})
+func Embed(ctx context.Context, id string, h Handler, s Socket) (*Embedded, error) {
+	em := &Embedded{id: id, handler: h}
+	em.socket = &embeddedSocket{Socket: s, embedded: em}
+	if err := h.getAuth()(ctx, em.socket); err != nil {
+		return nil, fmt.Errorf("embedded handler %q auth: %w", id, err)
+	}
+	data, err := h.getMount()(ctx, em.socket)
+	if err != nil {
+		return nil, fmt.Errorf("embedded handler %q mount: %w", id, err)
+	}
+	em.assigns = data
+	if s.Connected() {
+		if err := h.getConnect()(ctx, em.socket); err != nil {
+			return nil, fmt.Errorf("embedded handler %q connect: %w", id, err)
+		}
+	}
+	s.AttachChild(em)
+	return em, nil
+}
+
+// ID returns the ID of the embedded handler.
+func (em *Embedded) ID() string {
+	return em.id
+}
+
+// Event scopes an event so that only this embedded handler handles it.
+func (em *Embedded) Event(event string) string {
+	return em.id + "--" + event
+}
+
+// GetState returns the assigns of the embedded handler.
+func (em *Embedded) GetState() any {
+	return em.assigns
+}
+
+// Socket returns the socket the embedded handler sees, its assigns are those
+// of the embedded handler rather than the host's.
+func (em *Embedded) Socket() Socket {
+	return em.socket
+}
+
+// CallEvent runs the embedded handler's handler for a client event.
+func (em *Embedded) CallEvent(ctx context.Context, t string, sock Socket, params Params) error {
+	t = strings.TrimPrefix(t, em.Event(""))
+	handler, err := em.handler.getEvent(t)
+	if err != nil {
+		return fmt.Errorf("embedded handler %q: %w", em.id, err)
+	}
+	if err := validateEvent(em.handler, t, params); err != nil {
+		return err
+	}
+	data, err := handler(ctx, em.socket, params)
+	if err != nil {
+		return err
+	}
+	em.assigns = data
+	return nil
+}
+
+// CallSelf runs the embedded handler's handler for a server event.
+func (em *Embedded) CallSelf(ctx context.Context, t string, sock Socket, msg Event) error {
+	t = strings.TrimPrefix(t, em.Event(""))
+	handler, err := em.handler.getSelf(t)
+	if err != nil {
+		return fmt.Errorf("embedded handler %q: %w", em.id, err)
+	}
+	data, err := handler(ctx, em.socket, msg.SelfData)
+	if err != nil {
+		return err
+	}
+	em.assigns = data
+	return nil
+}
+
+// callParams runs the embedded handler's params handlers.
+func (em *Embedded) callParams(ctx context.Context, params Params) error {
+	for _, ph := range em.handler.getParams() {
+		data, err := ph(ctx, em.socket, params)
+		if err != nil {
+			return fmt.Errorf("embedded handler %q params handler error: %w", em.id, err)
+		}
+		em.assigns = data
+	}
+	return nil
+}
+
+// UnmountChild calls the embedded handler's disconnect and unmount handlers
+// when the socket closes.
+func (em *Embedded) UnmountChild(ctx context.Context) error {
+	if err := em.handler.getDisconnect()(ctx, em.socket); err != nil {
+		return fmt.Errorf("embedded handler %q disconnect: %w", em.id, err)
+	}
+	if err := em.handler.getUnmount()(em.socket); err != nil {
+		return fmt.Errorf("embedded handler %q unmount: %w", em.id, err)
+	}
+	return nil
+}
+
+// PreviewState swaps the embedded handler's assigns for a copy while an event
+// is previewed.
+func (em *Embedded) PreviewState(copy func(state any) any) (restore func()) {
+	saved := em.assigns
+	em.assigns = copy(saved)
+	return func() {
+		em.assigns = saved
+	}
+}
+
+// Render renders the embedded handler inside a div with its ID, for the host
+// to write into its own render. The render context is the host's, with the
+// embedded handler's assigns.
+func (em *Embedded) Render(ctx context.Context, rc *RenderContext) (template.HTML, error) {
+	erc := *rc
+	erc.Socket = em.socket
+	erc.Assigns = em.assigns
+	r, err := em.handler.getRender()(ctx, &erc)
+	if err != nil {
+		return "", fmt.Errorf("embedded handler %q render: %w", em.id, err)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<div id="%s">`, html.EscapeString(em.id))
+	if _, err := io.Copy(&buf, r); err != nil {
+		return "", fmt.Errorf("embedded handler %q render: %w", em.id, err)
+	}
+	buf.WriteString("</div>")
+	return template.HTML(buf.String()), nil
+}
+
+// embeddedSocket the host's socket as seen by an embedded handler.
+type embeddedSocket struct {
+	Socket
+	embedded *Embedded
+}
+
+// Assigns returns the embedded handler's assigns.
+func (s *embeddedSocket) Assigns() interface{} {
+	return s.embedded.assigns
+}
+
+// Assign sets the embedded handler's assigns.
+func (s *embeddedSocket) Assign(data interface{}) {
+	s.embedded.assigns = data
+}
+
+// Self sends an event to the embedded handler only.
+func (s *embeddedSocket) Self(ctx context.Context, event string, data interface{}, options ...EventConfig) error {
+	return s.Socket.Self(ctx, event, data, append(options, WithTarget(s.embedded.id))...)
+}
+
+// Update changes the embedded handler's assigns with fn in turn with the
+// host's events, then renders the page.
+func (s *embeddedSocket) Update(ctx context.Context, fn func(assigns interface{}) interface{}) error {
+	return s.Socket.Update(ctx, func(host interface{}) interface{} {
+		s.embedded.assigns = fn(s.embedded.assigns)
+		return host
+	})
+}
+
+// Snapshot encodes the embedded handler's assigns.
+func (s *embeddedSocket) Snapshot() ([]byte, error) {
+	return snapshotAssigns(s.embedded.assigns)
+}
+
+// SendEvery sends a self event to the embedded handler every interval, until
+// stop is called or the socket is closed.
+func (s *embeddedSocket) SendEvery(event string, d time.Duration) (stop func()) {
+	if !s.Connected() || d <= 0 {
+		return func() {}
+	}
+	return sendEvery(s.lifetime(), s.Self, event, d)
+}
+
+// SelfAfter sends a self event to the embedded handler once the delay has
+// passed, unless cancel is called or the socket is closed first.
+func (s *embeddedSocket) SelfAfter(ctx context.Context, event string, data interface{}, d time.Duration) (cancel func()) {
+	if !s.Connected() {
+		return func() {}
+	}
+	return selfAfter(ctx, s.lifetime(), s.Self, event, data, d)
+}
+
+// lifetime returns the lifetime of the host's socket.
+func (s *embeddedSocket) lifetime() context.Context {
+	if l, ok := s.Socket.(lifetimer); ok {
+		return l.lifetime()
+	}
+	return context.Background()
+}
+
+// paramsChild is implemented by children with their own params handlers.
+type paramsChild interface {
+	callParams(ctx context.Context, params Params) error
+}
+
+// callChildParams runs the params handlers of the socket's children.
+func callChildParams(ctx context.Context, sock Socket, params Params) error {
+	for _, child := range sock.GetChildren() {
+		if p, ok := child.(paramsChild); ok {
+			if err := p.callParams(ctx, params); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
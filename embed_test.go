@@ -0,0 +1,161 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmbed(t *testing.T) {
+	widget := NewHandler()
+	widget.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		return 0, nil
+	})
+	widget.HandleEvent("bump", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return s.Assigns().(int) + 1, nil
+	})
+	widget.HandleSelf("reset", func(ctx context.Context, s Socket, data interface{}) (interface{}, error) {
+		return 0, nil
+	})
+	widget.HandleParams(func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		if n := p.Int("n"); n != 0 {
+			return n, nil
+		}
+		return s.Assigns(), nil
+	})
+	widget.HandleRender(func(ctx context.Context, rc *RenderContext) (io.Reader, error) {
+		return strings.NewReader(fmt.Sprintf("count %d", rc.Assigns)), nil
+	})
+
+	ctx := context.Background()
+	e := NewBaseEngine(testRenderHandler())
+	sock := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(sock)
+	sock.Assign("host")
+	em, err := Embed(ctx, "widget", widget, sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.CallEvent(ctx, em.Event("bump"), sock, Event{T: em.Event("bump")}); err != nil {
+		t.Fatal(err)
+	}
+	if em.GetState() != 1 {
+		t.Errorf("expected embedded assigns 1, got %v", em.GetState())
+	}
+	if sock.Assigns() != "host" {
+		t.Errorf("embedded event changed the host assigns to %v", sock.Assigns())
+	}
+
+	out, err := em.Render(ctx, &RenderContext{Socket: sock, Assigns: sock.Assigns()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != `<div id="widget">count 1</div>` {
+		t.Errorf("unexpected render %q", out)
+	}
+
+	if err := e.CallParams(ctx, sock, Event{T: EventParams, Data: []byte(`{"n":"5"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if em.GetState() != 5 {
+		t.Errorf("expected params to set embedded assigns to 5, got %v", em.GetState())
+	}
+
+	if err := em.Socket().Self(ctx, "reset", nil); err != nil {
+		t.Fatal(err)
+	}
+	if em.GetState() != 0 {
+		t.Errorf("expected self event to reset embedded assigns, got %v", em.GetState())
+	}
+	if sock.Assigns() != "host" {
+		t.Errorf("embedded self event changed the host assigns to %v", sock.Assigns())
+	}
+}
+
+type embeddedCounter struct {
+	N int
+}
+
+func TestEmbeddedSocket(t *testing.T) {
+	RegisterAssigns[embeddedCounter]("test-embedded-counter", nil)
+	ticks := make(chan int, 8)
+	widget := NewHandler()
+	widget.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		return embeddedCounter{}, nil
+	})
+	widget.HandleSelf("tick", func(ctx context.Context, s Socket, data interface{}) (interface{}, error) {
+		c := s.Assigns().(embeddedCounter)
+		c.N++
+		ticks <- c.N
+		return c, nil
+	})
+
+	ctx := context.Background()
+	e := NewBaseEngine(testRenderHandler())
+	sock := NewBaseSocket(NewSession(), e, false)
+	sock.Assign("host")
+	em, err := Embed(ctx, "widget", widget, sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := em.Socket()
+
+	// Update and Snapshot work on the embedded assigns.
+	if err := Update(ctx, s, func(c embeddedCounter) embeddedCounter {
+		c.N = 3
+		return c
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if em.GetState() != (embeddedCounter{N: 3}) {
+		t.Errorf("expected update to set embedded assigns, got %v", em.GetState())
+	}
+	if sock.Assigns() != "host" {
+		t.Errorf("embedded update changed the host assigns to %v", sock.Assigns())
+	}
+	snap, err := s.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Assign(embeddedCounter{})
+	if err := RestoreSocket(s, snap); err != nil {
+		t.Fatal(err)
+	}
+	if em.GetState() != (embeddedCounter{N: 3}) {
+		t.Errorf("expected the snapshot to restore embedded assigns, got %v", em.GetState())
+	}
+
+	// Timed self events are routed to the embedded handler.
+	connected := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(connected)
+	t.Cleanup(func() { e.DeleteSocket(connected) })
+	connected.Assign("host")
+	em, err = Embed(ctx, "widget", widget, connected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = em.Socket()
+	s.SelfAfter(ctx, "tick", nil, time.Millisecond)
+	select {
+	case n := <-ticks:
+		if n != 1 {
+			t.Errorf("expected the first tick, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SelfAfter was not handled by the embedded handler")
+	}
+	stop := s.SendEvery("tick", time.Millisecond)
+	defer stop()
+	select {
+	case n := <-ticks:
+		if n != 2 {
+			t.Errorf("expected the second tick, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SendEvery was not handled by the embedded handler")
+	}
+}
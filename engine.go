@@ -13,6 +13,9 @@ import (
 
 var _ Engine = &BaseEngine{}
 
+// unmountTimeout how long unmount handlers have to clean up.
+const unmountTimeout = 5 * time.Second
+
 // EngineConfig applies configuration to an engine.
 type EngineConfig func(e Engine) error
 
@@ -23,6 +26,8 @@ type BroadcastHandler func(ctx context.Context, e Engine, msg Event)
 type Engine interface {
 	// Handler takes a handler to configure the lifecycle.
 	Handler(h Handler)
+	// Auth the func that is called to authorize a socket before mount.
+	Auth() AuthHandler
 	// Mount a user should provide the mount function. This is what
 	// is called on initial GET request and later when the websocket connects.
 	// Data to render the handler should be fetched here and returned.
@@ -31,6 +36,13 @@ type Engine interface {
 	// is closed. This is called on websocket close. Can be used to track number of
 	// connected users.
 	Unmount() UnmountHandler
+	// UnmountContext the func that is called to report that a connection is
+	// closed and why.
+	UnmountContext() UnmountContextHandler
+	// Connect the func that is called once the websocket has connected.
+	Connect() ConnectHandler
+	// Disconnect the func that is called when the websocket closes.
+	Disconnect() DisconnectHandler
 	// Params called to handle any incoming paramters after mount.
 	Params() []EventHandler[any]
 	// Render is called to generate the HTML of a Socket. It is defined
@@ -39,11 +51,21 @@ type Engine interface {
 	// Error is called when an error occurs during the mount and render
 	// stages of the handler lifecycle.
 	Error() ErrorHandler
+	// EventError is called when an event handler returns an error, to build
+	// the error sent to the client.
+	EventError() EventErrorHandler
+	// SocketError is called when an event handler returns an error, with
+	// the socket and the event.
+	SocketError() SocketErrorHandler
 	// AddSocket add a socket to the engine.
 	AddSocket(sock Socket)
 	// GetSocket from a session get an already connected
 	// socket.
 	GetSocket(session Session) (Socket, error)
+	// GetSocketByID get an already connected socket by its ID.
+	GetSocketByID(id SocketID) (Socket, error)
+	// Sockets returns all the sockets connected to this engine.
+	Sockets() []Socket
 	// DeleteSocket remove a socket from the engine.
 	DeleteSocket(sock Socket)
 	// CallParams on params change run the handlers.
@@ -57,6 +79,8 @@ type Engine interface {
 
 	// self sends a message to the socket on this engine.
 	self(ctx context.Context, sock Socket, msg Event)
+	// renameSocket updates the engine when a socket changes its ID.
+	renameSocket(old, new SocketID) error
 }
 
 // BaseEngine handles live inner workings.
@@ -75,6 +99,96 @@ type BaseEngine struct {
 	// event lock.
 	eventMu sync.Mutex
 
+	// replicaTopic assigns sockets to read replica topics.
+	replicaTopic ReplicaTopicFunc
+	// Read replica groups, and which topic each socket is in.
+	replicasMu sync.Mutex
+	replicas   map[string]*replicaGroup
+	replicaOf  map[SocketID]string
+
+	// minify renders before diffing.
+	minify bool
+
+	// refreshInterval how often to re-render connected sockets.
+	refreshInterval time.Duration
+	// localeFunc picks the locale of new sockets.
+	localeFunc LocaleFunc
+
+	// trustedTypes the Trusted Types policy patches are marked with.
+	trustedTypes string
+
+	// idempotency stores the results of events with idempotency keys.
+	idempotency *idempotencyCache
+
+	// socketDeleted called once a socket has been removed, so that the
+	// transport can release what it holds for it.
+	socketDeleted func(sock Socket)
+
+	// breaker stops calling event handlers which keep failing.
+	breaker *circuitBreaker
+	// breakerClassifier which handler errors count towards the breaker, all
+	// of them if nil.
+	breakerClassifier func(err error) bool
+
+	// differ compares renders to patch sockets, the TreeDiffer if nil.
+	differ Differ
+
+	// replays the events handled for each page load.
+	replays *replayCache
+	// schedules the client events waiting for delivery, for each page load.
+	schedules *eventScheduler
+
+	// jobs running on the engine.
+	jobs *jobRegistry
+
+	// devMode sends details useful while developing to the client.
+	devMode bool
+	// stopWatch stops the dev mode file watcher, nil if files aren't watched.
+	stopWatch context.CancelFunc
+
+	// concurrentEvents events which can be handled alongside others.
+	concurrentEvents map[string]bool
+
+	// renders limits how many sockets render at once.
+	renders *renderPool
+
+	// eventAuth authorizes each event as well as mount.
+	eventAuth bool
+
+	// sessionSync links the sockets which share a session.
+	sessionSync bool
+
+	// registry records which node each socket is connected to, node is the
+	// name of this one.
+	registry SocketRegistry
+	node     string
+	// forward sends an event to another node.
+	forward func(ctx context.Context, node string, msg Event) error
+
+	// stats counts events and renders.
+	stats *engineStats
+
+	// recorder captures the events handled by sockets.
+	recorder Recorder
+
+	// heartbeat how often clients send a heartbeat, zero if they don't.
+	heartbeat time.Duration
+	// idleTimeout how long a socket can go without sending an event before
+	// it is disconnected, zero if it can forever.
+	idleTimeout time.Duration
+	// loadingEvents whether clients are sent EventLoading around the
+	// events they send.
+	loadingEvents bool
+
+	// eventTimeout how long event handlers may run, eventTimeouts overrides
+	// it for particular events.
+	eventTimeout  time.Duration
+	eventTimeouts map[string]time.Duration
+
+	// chunkSize the largest message sent to clients, larger events are
+	// chunked.
+	chunkSize int
+
 	// IgnoreFaviconRequest setting to ignore requests for /favicon.ico.
 	IgnoreFaviconRequest bool
 
@@ -85,6 +199,10 @@ type BaseEngine struct {
 	// UploadStagingLocation where uploads are stored before they are consumed. This defaults
 	// to the default OS temp directory.
 	UploadStagingLocation string
+
+	// UploadChunkSize the size of the chunks resumable uploads are sent in. When this
+	// is zero files are uploaded in a single request.
+	UploadChunkSize int64
 }
 
 // NewBaseEngine creates a new base engine.
@@ -96,6 +214,15 @@ func NewBaseEngine(h Handler) *BaseEngine {
 			h.self(ctx, nil, msg)
 		},
 		socketMap:            make(map[SocketID]Socket),
+		replicas:             make(map[string]*replicaGroup),
+		replicaOf:            make(map[SocketID]string),
+		jobs:                 &jobRegistry{jobs: map[string]*Job{}},
+		registry:             NewLocalRegistry(),
+		stats:                newEngineStats(),
+		replays:              newReplayCache(),
+		schedules:            newEventScheduler(maxScheduledEvents),
+		node:                 NewID(),
+		chunkSize:            defaultChunkSize,
 		IgnoreFaviconRequest: true,
 		MaxUploadSize:        100 * 1024 * 1024,
 		handler:              h,
@@ -109,6 +236,10 @@ func (e *BaseEngine) HandleBroadcast(f BroadcastHandler) {
 	e.broadcastHandler = f
 }
 
+func (e *BaseEngine) Auth() AuthHandler {
+	return e.handler.getAuth()
+}
+
 func (e *BaseEngine) Mount() MountHandler[any] {
 	return e.handler.getMount()
 }
@@ -117,6 +248,18 @@ func (e *BaseEngine) Unmount() UnmountHandler {
 	return e.handler.getUnmount()
 }
 
+func (e *BaseEngine) UnmountContext() UnmountContextHandler {
+	return e.handler.getUnmountContext()
+}
+
+func (e *BaseEngine) Connect() ConnectHandler {
+	return e.handler.getConnect()
+}
+
+func (e *BaseEngine) Disconnect() DisconnectHandler {
+	return e.handler.getDisconnect()
+}
+
 func (e *BaseEngine) Params() []EventHandler[any] {
 	return e.handler.getParams()
 }
@@ -129,6 +272,14 @@ func (e *BaseEngine) Error() ErrorHandler {
 	return e.handler.getError()
 }
 
+func (e *BaseEngine) EventError() EventErrorHandler {
+	return e.handler.getEventError()
+}
+
+func (e *BaseEngine) SocketError() SocketErrorHandler {
+	return e.handler.getSocketError()
+}
+
 // Broadcast send a message to all sockets connected to this engine.
 func (e *BaseEngine) Broadcast(event string, data interface{}) error {
 	ev := Event{T: event, SelfData: data}
@@ -140,16 +291,28 @@ func (e *BaseEngine) Broadcast(event string, data interface{}) error {
 
 // self sends a message to the socket on this engine.
 func (e *BaseEngine) self(ctx context.Context, sock Socket, msg Event) {
+	// Events forwarded from another node are for one of our sockets.
+	if sock == nil && msg.T == eventForward {
+		e.receiveForward(ctx, msg)
+		return
+	}
 	// If the socket is nil, this is broadcast message.
 	if sock == nil {
 		sockets := e.sockets()
 		for _, socket := range sockets {
+			// Mirrors get their state from the replica writer.
+			if e.isMirror(socket) {
+				continue
+			}
 			e.handleEmittedEvent(ctx, socket, msg)
 		}
 	} else {
 		if err := e.hasSocket(sock); err != nil {
 			return
 		}
+		if e.isMirror(sock) {
+			return
+		}
 		e.handleEmittedEvent(ctx, sock, msg)
 	}
 }
@@ -170,8 +333,9 @@ func (e *BaseEngine) handleEmittedEvent(ctx context.Context, s Socket, msg Event
 // AddSocket add a socket to the engine.
 func (e *BaseEngine) AddSocket(sock Socket) {
 	e.socketsMu.Lock()
-	defer e.socketsMu.Unlock()
 	e.socketMap[sock.ID()] = sock
+	e.socketsMu.Unlock()
+	e.register(sock.ID())
 }
 
 // GetSocket get a socket from a session.
@@ -186,11 +350,87 @@ func (e *BaseEngine) GetSocket(session Session) (Socket, error) {
 	return nil, ErrNoSocket
 }
 
+// GetSocketByID get a connected socket by its ID.
+func (e *BaseEngine) GetSocketByID(id SocketID) (Socket, error) {
+	e.socketsMu.Lock()
+	defer e.socketsMu.Unlock()
+	s, ok := e.socketMap[id]
+	if !ok {
+		return nil, ErrNoSocket
+	}
+	return s, nil
+}
+
+// Sockets returns all sockets connected to the engine.
+func (e *BaseEngine) Sockets() []Socket {
+	return e.sockets()
+}
+
+// renameSocket re-register a socket under a new ID.
+func (e *BaseEngine) renameSocket(old, new SocketID) error {
+	e.socketsMu.Lock()
+	s, ok := e.socketMap[old]
+	if !ok {
+		e.socketsMu.Unlock()
+		// Not connected yet, it will be registered under its new ID.
+		return nil
+	}
+	if _, ok := e.socketMap[new]; ok {
+		e.socketsMu.Unlock()
+		return ErrSocketIDTaken
+	}
+	delete(e.socketMap, old)
+	e.socketMap[new] = s
+
+	e.replicasMu.Lock()
+	if topic, ok := e.replicaOf[old]; ok {
+		delete(e.replicaOf, old)
+		e.replicaOf[new] = topic
+	}
+	e.replicasMu.Unlock()
+	e.socketsMu.Unlock()
+
+	// The registry may be on the network, so don't hold up other sockets.
+	e.unregister(old)
+	e.register(new)
+	return nil
+}
+
+// CloseSocket reports why a socket closed and then removes it from the engine.
+func (e *BaseEngine) CloseSocket(ctx context.Context, sock Socket, reason CloseReason) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), unmountTimeout)
+	defer cancel()
+	for _, child := range sock.GetChildren() {
+		if u, ok := child.(ChildUnmounter); ok {
+			if err := u.UnmountChild(ctx); err != nil {
+				slog.ErrorContext(ctx, "child unmount error", "error", err, "socket", sock.ID(), "child", child.ID())
+			}
+		}
+	}
+	if err := e.Disconnect()(ctx, sock); err != nil {
+		slog.ErrorContext(ctx, "socket disconnect error", "error", err, "socket", sock.ID(), "reason", reason)
+	}
+	if err := e.UnmountContext()(ctx, sock, reason); err != nil {
+		slog.ErrorContext(ctx, "socket unmount error", "error", err, "socket", sock.ID(), "reason", reason)
+	}
+	e.DeleteSocket(sock)
+}
+
 // DeleteSocket remove a socket from the engine.
 func (e *BaseEngine) DeleteSocket(sock Socket) {
+	// Deferred first so that they run once the lock is released, the
+	// registry may be on the network.
+	defer e.unregister(sock.ID())
+	if e.socketDeleted != nil {
+		defer e.socketDeleted(sock)
+	}
 	e.socketsMu.Lock()
 	defer e.socketsMu.Unlock()
 	delete(e.socketMap, sock.ID())
+	if l, ok := sock.(lifetimer); ok {
+		l.shutdown()
+	}
+	e.leaveReplica(sock)
 	err := e.Unmount()(sock)
 	if err != nil {
 		slog.Error("socket unmount error", "error", err, "socket", sock.ID())
@@ -198,17 +438,59 @@ func (e *BaseEngine) DeleteSocket(sock Socket) {
 }
 
 // CallEvent route an event to the correct handler.
-func (e *BaseEngine) CallEvent(ctx context.Context, t string, sock Socket, msg Event) error {
+func (e *BaseEngine) CallEvent(ctx context.Context, t string, sock Socket, msg Event) (err error) {
+	if e.isMirror(sock) {
+		return ErrReadOnly
+	}
+	// Previews change nothing, so they don't count towards the stats or the
+	// breaker.
+	defer func() {
+		if !msg.Preview {
+			e.stats.event(t, err)
+		}
+	}()
+
 	params, err := msg.Params()
 	if err != nil {
 		return fmt.Errorf("received message and could not extract params: %w", err)
 	}
 
+	if err := e.authorizeEvent(ctx, sock); err != nil {
+		return err
+	}
+
+	// Reject params which don't match the event's schema.
+	if err := validateEvent(e.handler, t, params); err != nil {
+		return err
+	}
+
+	// Refuse events whose handlers keep failing.
+	if e.breaker != nil && !msg.Preview {
+		var trial bool
+		if trial, err = e.breaker.allow(t); err != nil {
+			return err
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				e.breaker.result(t, trial, true)
+				panic(r)
+			}
+			e.breaker.result(t, trial, e.breakerFailure(err))
+		}()
+	}
+
+	// Errors contained by a component don't stop the event reaching the
+	// others, they are returned once it has been handled.
 	hasHandler := false
+	var contained error
 	if children := sock.GetChildren(); len(children) > 0 {
 		for _, child := range children {
 			if err := child.CallEvent(ctx, t, sock, params); err != nil {
-				if !errors.Is(err, ErrNoEventHandler) {
+				switch {
+				case errors.Is(err, ErrContained):
+					contained = errors.Join(contained, err)
+					hasHandler = true
+				case !errors.Is(err, ErrNoEventHandler):
 					return err
 				}
 				continue
@@ -220,18 +502,23 @@ func (e *BaseEngine) CallEvent(ctx context.Context, t string, sock Socket, msg E
 	handler, err := e.handler.getEvent(t)
 	if err != nil {
 		if hasHandler {
-			return nil
+			return contained
 		}
 		return err
 	}
 
-	data, err := handler(ctx, sock, params)
+	var data interface{}
+	runUnlocked(ctx, func(ctx context.Context) {
+		data, err = e.runEvent(ctx, t, func(ctx context.Context) (interface{}, error) {
+			return handler(ctx, sock, params)
+		})
+	})
 	if err != nil {
 		return err
 	}
 	sock.Assign(data)
 
-	return nil
+	return contained
 }
 
 // handleSelf route an event to the correct handler.
@@ -239,11 +526,26 @@ func (e *BaseEngine) handleSelf(ctx context.Context, t string, sock Socket, msg
 	e.eventMu.Lock()
 	defer e.eventMu.Unlock()
 
+	// Targeted events are only handled by their component.
+	if msg.Target != "" {
+		for _, child := range sock.GetChildren() {
+			if child.ID() == msg.Target {
+				return child.CallSelf(ctx, t, sock, msg)
+			}
+		}
+		return fmt.Errorf("no component %q: %w", msg.Target, ErrNoEventHandler)
+	}
+
 	hasHandler := false
+	var contained error
 	if children := sock.GetChildren(); len(children) > 0 {
 		for _, child := range children {
 			if err := child.CallSelf(ctx, t, sock, msg); err != nil {
-				if !errors.Is(err, ErrNoEventHandler) {
+				switch {
+				case errors.Is(err, ErrContained):
+					contained = errors.Join(contained, err)
+					hasHandler = true
+				case !errors.Is(err, ErrNoEventHandler):
 					return err
 				}
 				continue
@@ -255,7 +557,7 @@ func (e *BaseEngine) handleSelf(ctx context.Context, t string, sock Socket, msg
 	handler, err := e.handler.getSelf(t)
 	if err != nil {
 		if hasHandler {
-			return nil
+			return contained
 		}
 		return err
 	}
@@ -266,15 +568,26 @@ func (e *BaseEngine) handleSelf(ctx context.Context, t string, sock Socket, msg
 	}
 	sock.Assign(data)
 
-	return nil
+	return contained
 }
 
 // CallParams on params change run the handler.
 func (e *BaseEngine) CallParams(ctx context.Context, sock Socket, msg Event) error {
+	if e.isMirror(sock) {
+		return ErrReadOnly
+	}
+
 	params, err := msg.Params()
 	if err != nil {
 		return fmt.Errorf("received params message and could not extract params: %w", err)
 	}
+	// Route parameters don't change with the query string so keep them.
+	for k, v := range PathParams(ctx) {
+		params[k] = v
+	}
+	if k, ok := sock.(paramsKeeper); ok {
+		ctx = contextWithPreviousParams(ctx, k.swapParams(params))
+	}
 
 	for _, ph := range e.handler.getParams() {
 		data, err := ph(ctx, sock, params)
@@ -284,7 +597,8 @@ func (e *BaseEngine) CallParams(ctx context.Context, sock Socket, msg Event) err
 		sock.Assign(data)
 	}
 
-	return nil
+	// Embedded handlers have their own params handlers.
+	return callChildParams(ctx, sock, params)
 }
 
 // sockets returns all sockets connected to the engine.
@@ -18,6 +18,72 @@ var ErrMessageMalformed = errors.New("message malformed")
 // ErrNoSocket returned when a socket doesn't exist.
 var ErrNoSocket = errors.New("no socket")
 
+// ErrSocketIDTaken returned when a socket ID is already in use.
+var ErrSocketIDTaken = errors.New("socket id already in use")
+
+// ErrTooManyScheduled returned when a socket has too many events waiting for delivery.
+var ErrTooManyScheduled = errors.New("too many scheduled events")
+
+// ErrScheduledTooFar returned when an event asks to be delivered too far in the future.
+var ErrScheduledTooFar = errors.New("event scheduled too far ahead")
+
+// ErrSessionDecrypt returned when the values of a session can't be decrypted.
+var ErrSessionDecrypt = errors.New("could not decrypt session")
+
+// ErrNoSessionValue returned when a session value has not been set.
+var ErrNoSessionValue = errors.New("no session value")
+
+// ErrSessionValueType returned when a session value was not set with
+// SessionSet, so can't be got as the type asked for.
+var ErrSessionValueType = errors.New("session value has the wrong type")
+
+// ErrOriginNotAllowed returned when a cross origin request comes from an origin that is not allowed.
+var ErrOriginNotAllowed = errors.New("origin not allowed")
+
+// ErrNoSystemdListeners returned when the process was not started with systemd socket activation.
+var ErrNoSystemdListeners = errors.New("no systemd listeners")
+
+// ErrCSRF returned when a form is submitted without a valid CSRF token.
+var ErrCSRF = errors.New("invalid csrf token")
+
+// ErrJobNotFound returned when a job can't be found for a socket.
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrNotConnected returned when a socket needs to be connected to the client.
+var ErrNotConnected = errors.New("socket not connected")
+
+// ErrCallFailed returned when the client replies to a call with an error.
+var ErrCallFailed = errors.New("call failed")
+
+// ErrInvalidPayload returned when the params of an event don't match its schema.
+var ErrInvalidPayload = errors.New("invalid payload")
+
+// ErrUnauthorized returned by an AuthHandler to deny access.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrEventTimeout returned when an event handler runs for longer than its timeout.
+var ErrEventTimeout = errors.New("event timed out")
+
+// ErrEventPanic returned when an event handler run with a timeout panics.
+var ErrEventPanic = errors.New("event handler panicked")
+
+// ErrCircuitOpen returned when an event is refused because its handler keeps failing.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// ErrSocketIdle returned when a socket is disconnected for being idle.
+var ErrSocketIdle = errors.New("socket idle")
+
+// ErrNoEvent returned when replying outside of a client event's handler.
+var ErrNoEvent = errors.New("no client event is being handled")
+
+// ErrReadOnly returned when a read replica mirror is sent an event.
+var ErrReadOnly = errors.New("socket is a read only replica")
+
+// ErrContained returned by a component whose error boundary caught an error,
+// the component renders the error in its place so the client isn't sent it
+// but it is still passed to the socket error handler.
+var ErrContained = errors.New("error contained by component")
+
 // ErrNotImplemented returned when an interface has not been implemented correctly.
 var ErrNotImplemented = errors.New("not implemented")
 
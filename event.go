@@ -20,18 +20,58 @@ const (
 	// EventParams sent for a URL parameter update. Can be
 	// sent both directions.
 	EventParams = "params"
+	// EventURL sent to update the query params in the URL
+	// without notifying the server.
+	EventURL = "url"
 	// EventRedirect sent in order to trigger a browser
 	// redirect.
 	EventRedirect = "redirect"
+	// EventPreview sent with the patches for a previewed
+	// event, and those to revert it.
+	EventPreview = "preview"
+	// EventReload sent in dev mode to have the client
+	// reload the page.
+	EventReload = "reload"
+	// EventExec sent with js commands for the client to
+	// run.
+	EventExec = "exec"
+	// EventHeartbeat sent by the client to show the
+	// connection is alive, see WithHeartbeat.
+	EventHeartbeat = "hb"
+	// EventChunk sent with a piece of an event too large
+	// to send in one message, see WithChunkSize.
+	EventChunk = "chunk"
+	// EventIdle sent before a socket is disconnected for
+	// being idle, see WithIdleTimeout.
+	EventIdle = "idle"
 )
 
 // Event messages that are sent and received by the
 // socket.
 type Event struct {
-	T        string          `json:"t"`
-	ID       int             `json:"i,omitempty"`
-	Data     json.RawMessage `json:"d,omitempty"`
-	SelfData interface{}     `json:"s,omitempty"`
+	T       string `json:"t"`
+	ID      int    `json:"i,omitempty"`
+	Key     string `json:"k,omitempty"`
+	At      int64  `json:"a,omitempty"`
+	Preview bool   `json:"v,omitempty"`
+	// Call the client should reply to this event.
+	Call bool `json:"c,omitempty"`
+	// Transition the name of the view transition the client should apply
+	// this event in.
+	Transition string          `json:"vt,omitempty"`
+	Data       json.RawMessage `json:"d,omitempty"`
+	SelfData   interface{}     `json:"s,omitempty"`
+	// Target the ID of the component a self event is for, or of the
+	// element whose hooks an event sent to the client is for.
+	Target string `json:"tg,omitempty"`
+	// Mark the connection's Trusted Types mark, sent with patches so the
+	// client knows they came from the server.
+	Mark string `json:"m,omitempty"`
+
+	// priority send the event to the client ahead of those waiting.
+	priority bool
+	// noAck the sender doesn't wait for the event to be delivered.
+	noAck bool
 }
 
 // Params extract params from inbound message.
@@ -54,7 +94,54 @@ func WithID(ID int) EventConfig {
 	}
 }
 
+// WithTarget routes an event to one target. A self event is only handled by
+// the component with the ID, not by other components or the handler. An event
+// sent to the client is only passed to the hooks of the element with the ID.
+func WithTarget(ID string) EventConfig {
+	return func(e *Event) error {
+		e.Target = ID
+		return nil
+	}
+}
+
+// WithPriority sends an event to the client ahead of any others waiting to
+// be sent, for example a notification queued behind a burst of patches.
+func WithPriority() EventConfig {
+	return func(e *Event) error {
+		e.priority = true
+		return nil
+	}
+}
+
+// WithNoAck has the sender not wait for the event to be delivered. An event
+// sent to a client which isn't keeping up is dropped, rather than the client
+// being disconnected, and a self event is handled in the background rather
+// than before Self returns.
+func WithNoAck() EventConfig {
+	return func(e *Event) error {
+		e.noAck = true
+		return nil
+	}
+}
+
+// WithViewTransition has the client apply the event in a view transition,
+// animating the change with `document.startViewTransition`. While the
+// transition runs the document element has a `live-transition` attribute set
+// to the name, for use in css. Browsers without view transitions apply the
+// event immediately.
+func WithViewTransition(name string) EventConfig {
+	return func(e *Event) error {
+		e.Transition = name
+		return nil
+	}
+}
+
+// ErrorEvent the data of an "err" event, sent when an event handler returns
+// an error.
 type ErrorEvent struct {
-	Source Event  `json:"source"`
-	Err    string `json:"err"`
+	Source Event        `json:"source"`
+	Err    ErrorPayload `json:"err"`
+	// RequestID the ID of the request the socket was started by, for
+	// correlating errors reported by users with logs.
+	RequestID string `json:"request_id,omitempty"`
 }
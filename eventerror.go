@@ -0,0 +1,89 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// ErrorCodeUnknown the code of errors which don't have one.
+const ErrorCodeUnknown = "error"
+
+// ErrorPayload the error sent to the client when an event fails.
+type ErrorPayload struct {
+	// Code a machine readable code for the error.
+	Code string `json:"code"`
+	// Message a description of the error.
+	Message string `json:"message"`
+	// Stack where the error was created, only sent in dev mode.
+	Stack []string `json:"stack,omitempty"`
+}
+
+// Error an error with a code that the client can act on. It records where
+// it was created, which is sent to the client in dev mode.
+type Error struct {
+	Code   string
+	Err    error
+	frames []uintptr
+}
+
+// NewError creates an error with a code.
+//
+//	return nil, live.NewError("not_found", fmt.Errorf("no room %s", id))
+func NewError(code string, err error) *Error {
+	frames := make([]uintptr, 32)
+	n := runtime.Callers(2, frames)
+	return &Error{Code: code, Err: err, frames: frames[:n]}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Stack returns where the error was created, one frame per line.
+func (e *Error) Stack() []string {
+	stack := []string{}
+	frames := runtime.CallersFrames(e.frames)
+	for {
+		f, more := frames.Next()
+		stack = append(stack, fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line))
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// ErrorCode returns the code of an error, or ErrorCodeUnknown if it has none.
+func ErrorCode(err error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Code != "" {
+		return e.Code
+	}
+	return ErrorCodeUnknown
+}
+
+// NewErrorPayload the default EventErrorHandler, it sends the error message
+// and code to the client.
+func NewErrorPayload(ctx context.Context, err error) ErrorPayload {
+	return ErrorPayload{
+		Code:    ErrorCode(err),
+		Message: err.Error(),
+	}
+}
+
+// errorEvent builds the error event sent to the client when handling an
+// event fails.
+func (e *BaseEngine) errorEvent(ctx context.Context, source Event, err error) ErrorEvent {
+	payload := e.EventError()(ctx, err)
+	var le *Error
+	if e.devMode && payload.Stack == nil && errors.As(err, &le) {
+		payload.Stack = le.Stack()
+	}
+	return ErrorEvent{Source: source, Err: payload, RequestID: RequestID(ctx)}
+}
@@ -0,0 +1,156 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorCode(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", NewError("not_found", errors.New("no room")))
+	if ErrorCode(err) != "not_found" {
+		t.Errorf("expected not_found, got %s", ErrorCode(err))
+	}
+	if ErrorCode(errors.New("plain")) != ErrorCodeUnknown {
+		t.Errorf("expected unknown code, got %s", ErrorCode(errors.New("plain")))
+	}
+}
+
+func TestErrorEventDevMode(t *testing.T) {
+	err := NewError("not_found", errors.New("no room"))
+
+	e := NewBaseEngine(NewHandler())
+	ee := e.errorEvent(context.Background(), Event{T: "join"}, err)
+	if ee.Err.Code != "not_found" || ee.Err.Message != "no room" {
+		t.Errorf("unexpected payload %+v", ee.Err)
+	}
+	if ee.Err.Stack != nil {
+		t.Error("expected no stack outside of dev mode")
+	}
+
+	WithDevMode()(e)
+	ee = e.errorEvent(context.Background(), Event{T: "join"}, err)
+	if len(ee.Err.Stack) == 0 || !strings.Contains(ee.Err.Stack[0], "TestErrorEventDevMode") {
+		t.Errorf("expected stack from where the error was created, got %v", ee.Err.Stack)
+	}
+}
+
+func TestHandleEventError(t *testing.T) {
+	h := NewHandler()
+	h.HandleEventError(func(ctx context.Context, err error) ErrorPayload {
+		return ErrorPayload{Code: ErrorCode(err), Message: "something went wrong"}
+	})
+	e := NewBaseEngine(h)
+	ee := e.errorEvent(context.Background(), Event{T: "join"}, errors.New("db password is hunter2"))
+	if ee.Err.Message != "something went wrong" {
+		t.Errorf("expected the handler to replace the message, got %s", ee.Err.Message)
+	}
+}
+
+func TestHandleSocketError(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleEvent("fail", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	type call struct {
+		id    SocketID
+		event string
+	}
+	calls := make(chan call, 2)
+	h.HandleSocketError(func(ctx context.Context, s Socket, source Event, err error) error {
+		calls <- call{id: s.ID(), event: source.T}
+		if source.ID == 2 {
+			return errors.New("too many failures")
+		}
+		return nil
+	})
+	e := NewBaseEngine(h)
+	c := newTestConn()
+	sock := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(sock)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- e.serveSocket(ctx, e, sock, c, httptest.NewRequest("GET", "/", nil))
+	}()
+
+	c.in <- Event{T: "fail", ID: 1}
+	c.expectAck(t, 1)
+	if got := <-calls; got.id != sock.ID() || got.event != "fail" {
+		t.Errorf("unexpected call %+v", got)
+	}
+
+	c.in <- Event{T: "fail", ID: 2}
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "too many failures") {
+			t.Errorf("expected the handler's error to close the socket, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("socket was not closed")
+	}
+}
+
+// containedChild a child whose error boundary catches every error.
+type containedChild struct{}
+
+func (c containedChild) ID() string { return "contained" }
+func (c containedChild) CallEvent(ctx context.Context, t string, sock Socket, msg Params) error {
+	return fmt.Errorf("component %q: %w: %w", c.ID(), ErrContained, errors.New("boom"))
+}
+func (c containedChild) CallSelf(ctx context.Context, t string, sock Socket, msg Event) error {
+	return ErrNoEventHandler
+}
+func (c containedChild) GetState() any             { return nil }
+func (c containedChild) Event(event string) string { return event }
+
+func TestContainedError(t *testing.T) {
+	h := testRenderHandler()
+	handled := make(chan struct{}, 1)
+	h.HandleEvent("fail", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		handled <- struct{}{}
+		return nil, nil
+	})
+	h.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		s.AttachChild(containedChild{})
+		return nil, nil
+	})
+	reported := make(chan error, 1)
+	h.HandleSocketError(func(ctx context.Context, s Socket, source Event, err error) error {
+		reported <- err
+		return nil
+	})
+	c := serveTestSocket(t, NewBaseEngine(h))
+
+	c.in <- Event{T: "fail", ID: 1}
+	timeout := time.After(time.Second)
+	for acked := false; !acked; {
+		select {
+		case m := <-c.out:
+			if m.T == EventError {
+				t.Fatalf("expected the component to show the error, got %s", m.Data)
+			}
+			acked = m.T == EventAck && m.ID == 1
+		case <-timeout:
+			t.Fatal("event was not acknowledged")
+		}
+	}
+	select {
+	case <-handled:
+	default:
+		t.Error("expected the event to still reach the page's handler")
+	}
+	select {
+	case err := <-reported:
+		if !errors.Is(err, ErrContained) {
+			t.Errorf("expected a contained error, got %v", err)
+		}
+	default:
+		t.Error("expected the error to be passed to the socket error handler")
+	}
+}
@@ -0,0 +1,208 @@
+//go:build fasthttp
+
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+var _ Engine = &FastHTTPEngine{}
+var _ Socket = &FastHTTPSocket{}
+
+// FastHTTPEngine serves live for fasthttp. Page renders, uploads and session
+// saves are served by the net/http engine through an adaptor, websockets are
+// served natively using fasthttp/websocket.
+type FastHTTPEngine struct {
+	upgrader websocket.FastHTTPUpgrader
+	serve    fasthttp.RequestHandler
+	*HttpEngine
+}
+
+// NewFastHTTPHandler returns the fasthttp handler for live. Configure it with
+// the same configs as NewHttpHandler.
+func NewFastHTTPHandler(store HttpSessionStore, handler Handler, configs ...EngineConfig) *FastHTTPEngine {
+	e := &FastHTTPEngine{
+		HttpEngine: NewHttpHandler(store, handler, configs...),
+	}
+	e.serve = fasthttpadaptor.NewFastHTTPHandler(e.HttpEngine)
+	e.upgrader = websocket.FastHTTPUpgrader{
+		CheckOrigin:       e.checkOrigin,
+		Subprotocols:      subprotocols(e.binary),
+		EnableCompression: e.compression(),
+	}
+	return e
+}
+
+// ServeFastHTTP serves this handler, use it as a `fasthttp.RequestHandler`.
+func (h *FastHTTPEngine) ServeFastHTTP(ctx *fasthttp.RequestCtx) {
+	if !websocket.FastHTTPIsWebSocketUpgrade(ctx) {
+		h.serve(ctx)
+		return
+	}
+
+	// The request context can't be used once the connection is hijacked so
+	// convert the request first.
+	r := &http.Request{}
+	if err := fasthttpadaptor.ConvertRequest(ctx, r, true); err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+		return
+	}
+	// Requests which the net/http engine wouldn't upgrade, such as ignored
+	// paths, disallowed origins or static pages, are answered by it.
+	r, ok := h.upgradeRequest(r)
+	if !ok {
+		h.serve(ctx)
+		return
+	}
+
+	session, err := h.sessionStore.Get(r)
+	if err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	if err := h.upgrader.Upgrade(ctx, func(c *websocket.Conn) {
+		h.serveWS(session, r, c)
+	}); err != nil {
+		slog.Warn("websocket upgrade failed", "error", err)
+	}
+}
+
+// serveWS serve an upgraded websocket connection.
+func (h *FastHTTPEngine) serveWS(session Session, r *http.Request, c *websocket.Conn) {
+	defer c.Close()
+	c.SetReadLimit(h.maxMessageSize())
+
+	ctx, cancel := context.WithCancel(contextWithRequest(context.Background(), r))
+	defer cancel()
+
+	conn := fastHTTPConn{c: c, threshold: h.compressThreshold}
+	mark := h.trustedMark()
+	ctx = contextWithTrustedMark(ctx, mark)
+	writeTimeout(ctx, time.Second*5, conn, h.connectEvent(conn, h.upgrader.EnableCompression, mark))
+
+	err := h._serveWS(ctx, r, session, c)
+	switch {
+	case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway):
+	case errors.Is(err, context.Canceled):
+	default:
+		slog.DebugContext(ctx, fmt.Sprintf("ws closed: %s", err))
+	}
+}
+
+// _serveWS implement the logic for a web socket connection.
+func (h *FastHTTPEngine) _serveWS(ctx context.Context, r *http.Request, session Session, c *websocket.Conn) (err error) {
+	// Get the sessions socket and register it with the server.
+	sock := NewFastHTTPSocket(session, h, true)
+	sock.SetLocale(h.negotiateLocale(r, session))
+	sock.assignWS(c)
+	h.AddSocket(sock)
+	defer func() {
+		h.CloseSocket(ctx, sock, fastHTTPCloseReason(err))
+	}()
+
+	return h.serveSocket(ctx, h, sock, fastHTTPConn{c: c, threshold: h.compressThreshold}, r)
+}
+
+// checkOrigin allows same origin upgrades and those from allowed origins.
+func (h *FastHTTPEngine) checkOrigin(ctx *fasthttp.RequestCtx) bool {
+	origin := string(ctx.Request.Header.Peek("Origin"))
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, string(ctx.Host())) || h.originAllowed(u.Host) {
+		return true
+	}
+	slog.Warn("websocket rejected, if the page is on another origin allow it with live.WithAllowedOrigins", "origin", origin)
+	return false
+}
+
+// FastHTTPSocket a socket served by fasthttp.
+type FastHTTPSocket struct {
+	*BaseSocket
+}
+
+// NewFastHTTPSocket creates a new fasthttp socket.
+func NewFastHTTPSocket(s Session, e Engine, connected bool) *FastHTTPSocket {
+	return &FastHTTPSocket{
+		BaseSocket: NewBaseSocket(s, e, connected),
+	}
+}
+
+// assignWS connect a web socket to a socket.
+func (s *FastHTTPSocket) assignWS(ws *websocket.Conn) {
+	s.closeSlow = func() {
+		msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "socket too slow to keep up with messages")
+		ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		ws.Close()
+	}
+}
+
+// fastHTTPConn a fasthttp/websocket connection.
+type fastHTTPConn struct {
+	c *websocket.Conn
+	// threshold the smallest message compressed, if compression was
+	// negotiated.
+	threshold int
+}
+
+func (c fastHTTPConn) binaryEncoded() bool {
+	_, binary := parseSubprotocol(c.c.Subprotocol())
+	return binary
+}
+
+func (c fastHTTPConn) protocolVersion() int {
+	version, _ := parseSubprotocol(c.c.Subprotocol())
+	return version
+}
+
+func (c fastHTTPConn) read(ctx context.Context) (bool, []byte, error) {
+	// ReadMessage doesn't take a context, so close the connection to unblock
+	// it when ctx is done, the socket is finished with by then.
+	stop := context.AfterFunc(ctx, func() {
+		c.c.Close()
+	})
+	defer stop()
+	t, d, err := c.c.ReadMessage()
+	if err != nil && ctx.Err() != nil {
+		return false, nil, ctx.Err()
+	}
+	return t == websocket.TextMessage, d, err
+}
+
+func (c fastHTTPConn) write(ctx context.Context, data []byte) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.c.SetWriteDeadline(deadline)
+	}
+	if c.threshold > 0 {
+		c.c.EnableWriteCompression(len(data) >= c.threshold)
+	}
+	if c.binaryEncoded() {
+		return c.c.WriteMessage(websocket.BinaryMessage, data)
+	}
+	return c.c.WriteMessage(websocket.TextMessage, data)
+}
+
+// fastHTTPCloseReason works out why a websocket connection closed from the
+// error it closed with.
+func fastHTTPCloseReason(err error) CloseReason {
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		return CloseNormal
+	}
+	return closeReason(err)
+}
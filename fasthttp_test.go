@@ -0,0 +1,127 @@
+//go:build fasthttp
+
+package live
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestFastHTTPRender(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body><div>fast</div></body></html>`), nil
+	})
+	e := NewFastHTTPHandler(NewTestStore("test"), h)
+
+	var ctx fasthttp.RequestCtx
+	var req fasthttp.Request
+	req.SetRequestURI("http://example.com/")
+	ctx.Init(&req, nil, nil)
+	e.ServeFastHTTP(&ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected status 200 got %d", ctx.Response.StatusCode())
+	}
+	if !strings.Contains(string(ctx.Response.Body()), ">fast</div>") {
+		t.Errorf("unexpected body %q", ctx.Response.Body())
+	}
+}
+
+// dialFastHTTP serves the engine on an in memory listener and dials a
+// websocket to the path.
+func dialFastHTTP(t *testing.T, e *FastHTTPEngine, path string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	ln := fasthttputil.NewInmemoryListener()
+	s := &fasthttp.Server{Handler: e.ServeFastHTTP}
+	go s.Serve(ln)
+	t.Cleanup(func() {
+		s.Shutdown()
+	})
+	d := websocket.Dialer{
+		NetDial: func(network, addr string) (net.Conn, error) {
+			return ln.Dial()
+		},
+		HandshakeTimeout: time.Second,
+	}
+	c, res, err := d.Dial("ws://example.com"+path, nil)
+	if c != nil {
+		t.Cleanup(func() { c.Close() })
+	}
+	return c, res, err
+}
+
+func TestFastHTTPWebsocket(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body><div>fast</div></body></html>`), nil
+	})
+	e := NewFastHTTPHandler(NewTestStore("test"), h,
+		WithBasePath("/app"),
+		WithIgnorePaths("/assets/"),
+		WithStaticModeFunc(func(r *http.Request) bool {
+			return r.URL.Path == "/static"
+		}),
+	)
+
+	// The base path is stripped before the upgrade.
+	c, _, err := dialFastHTTP(t, e, "/app/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	var m Event
+	if err := c.ReadJSON(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m.T != EventConnect {
+		t.Errorf("expected the connect event, got %s", m.T)
+	}
+
+	// Requests the net/http engine wouldn't upgrade aren't.
+	for _, path := range []string{"/app/assets/x", "/app/static"} {
+		if _, res, err := dialFastHTTP(t, e, path); err == nil {
+			t.Errorf("%s: expected the upgrade to be refused", path)
+		} else if res == nil || res.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected not found, got %v", path, res)
+		}
+	}
+}
+
+func TestFastHTTPConnReadCancel(t *testing.T) {
+	e := NewFastHTTPHandler(NewTestStore("test"), testRenderHandler())
+	c, _, err := dialFastHTTP(t, e, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := fastHTTPConn{c: c}
+	if _, _, err := conn.read(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing more is sent, the read has to give up when ctx is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := conn.read(ctx)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Errorf("expected the context error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("read was not unblocked by the context")
+	}
+}
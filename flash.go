@@ -0,0 +1,76 @@
+package live
+
+import (
+	"context"
+)
+
+// sessionFlash the session key flashes are carried across page loads under.
+const sessionFlash = "_flash"
+
+// Flash a message to show the user after an action, such as "Saved!".
+type Flash struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// Flash adds a flash message to the socket. It is shown in renders until it
+// is cleared, and is carried across a redirect.
+func (s *BaseSocket) Flash(kind, message string) {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	s.flashes = append(s.flashes, Flash{Kind: kind, Message: message})
+}
+
+// Flashes returns the sockets flash messages.
+func (s *BaseSocket) Flashes() []Flash {
+	s.dataMu.RLock()
+	defer s.dataMu.RUnlock()
+	return append([]Flash{}, s.flashes...)
+}
+
+// ClearFlash removes the sockets flash messages.
+func (s *BaseSocket) ClearFlash() {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	s.flashes = nil
+}
+
+// persistFlashes stores the sockets flashes in its session so that they are
+// shown on the next page load. Returns true if there were any.
+func persistFlashes(s Socket) bool {
+	flashes := s.Flashes()
+	if len(flashes) == 0 {
+		return false
+	}
+	if err := SessionSet(s.Session(), sessionFlash, flashes); err != nil {
+		return false
+	}
+	return true
+}
+
+// sessionFlashes returns the flashes stored in a session.
+func sessionFlashes(session Session) []Flash {
+	flashes, err := SessionGet[[]Flash](session, sessionFlash)
+	if err != nil {
+		return nil
+	}
+	return flashes
+}
+
+// restoreFlashes adds the flashes stored in the session to the socket.
+func restoreFlashes(s Socket) {
+	for _, f := range sessionFlashes(s.Session()) {
+		s.Flash(f.Kind, f.Message)
+	}
+}
+
+// consumeFlashes adds the flashes stored in the session to the socket, then
+// removes them from the session so they are only shown once.
+func consumeFlashes(ctx context.Context, s Socket) {
+	if len(sessionFlashes(s.Session())) == 0 {
+		return
+	}
+	restoreFlashes(s)
+	SessionDelete(s.Session(), sessionFlash)
+	s.SaveSession(ctx)
+}
@@ -0,0 +1,36 @@
+package live
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestFlashRedirect(t *testing.T) {
+	e := NewHttpHandler(NewTestStore("test"), NewHandler())
+	session := NewSession()
+
+	// Flashes set before a redirect are stored in the session.
+	sock := NewHttpSocket(session, e, false)
+	sock.Flash("info", "Saved!")
+	sock.Redirect(&url.URL{Path: "/"})
+	if got := sessionFlashes(session); len(got) != 1 || got[0].Message != "Saved!" {
+		t.Fatalf("expected flash in session, got %v", got)
+	}
+
+	// The next page load shows them, and they are only shown once when the
+	// socket connects.
+	next := NewHttpSocket(session, e, true)
+	consumeFlashes(context.Background(), next)
+	if got := next.Flashes(); len(got) != 1 || got[0].Kind != "info" {
+		t.Fatalf("expected restored flash, got %v", got)
+	}
+	if got := sessionFlashes(session); len(got) != 0 {
+		t.Fatalf("expected flashes removed from session, got %v", got)
+	}
+
+	next.ClearFlash()
+	if got := next.Flashes(); len(got) != 0 {
+		t.Fatalf("expected no flashes, got %v", got)
+	}
+}
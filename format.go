@@ -0,0 +1,158 @@
+package live
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"net/http"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// dateLayouts short date layouts by base language.
+var dateLayouts = map[string]string{
+	"en": "Jan 2, 2006",
+	"de": "2.1.2006",
+	"fr": "02/01/2006",
+	"es": "2/1/2006",
+	"it": "2/1/2006",
+	"nl": "2-1-2006",
+	"ja": "2006/01/02",
+	"zh": "2006/1/2",
+}
+
+// FormatFuncs returns locale aware formatting functions for templates. Each takes
+// the locale as its first argument, which is available on the RenderContext.
+//
+//	{{ number .Locale 1234.5 }}            1,234.5
+//	{{ currency .Locale "EUR" 12.5 }}      €12.50
+//	{{ date .Locale .Assigns.Created }}    Jan 2, 2006
+//	{{ relativeTime .Locale .Assigns.At }} 3 minutes ago
+//	{{ relative .Locale .Assigns.At }}     <time live-relative ...>3 minutes ago</time>
+//
+// Times rendered with relative are translated and kept up to date by the
+// client without any further renders, relativeTime is only in English. Combine relativeTime with WithRelativeTimeRefresh to
+// have the server keep them up to date instead.
+func FormatFuncs() template.FuncMap {
+	return template.FuncMap{
+		"number":       FormatNumber,
+		"currency":     FormatCurrency,
+		"date":         FormatDate,
+		"relativeTime": FormatRelativeTime,
+		"relative":     RelativeTime,
+	}
+}
+
+// FormatNumber formats a number for the given locale.
+func FormatNumber(locale string, n interface{}) string {
+	return printer(locale).Sprint(number.Decimal(n))
+}
+
+// FormatCurrency formats an amount of the currency with the given ISO code for
+// the given locale.
+func FormatCurrency(locale string, code string, amount float64) (string, error) {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return "", fmt.Errorf("unknown currency %q: %w", code, err)
+	}
+	return printer(locale).Sprint(currency.Symbol(unit.Amount(amount))), nil
+}
+
+// FormatDate formats a date for the given locale.
+func FormatDate(locale string, t time.Time) string {
+	base, _ := parseLocale(locale).Base()
+	layout, ok := dateLayouts[base.String()]
+	if !ok {
+		layout = "2006-01-02"
+	}
+	return t.Format(layout)
+}
+
+// FormatRelativeTime describes a time relative to now, for example
+// "3 minutes ago" or "in 2 days". The number is formatted for the locale but
+// the words are always English, as golang.org/x/text has no relative time
+// data. Use RelativeTime to have the client replace it with the browser's
+// translation once the page loads.
+func FormatRelativeTime(locale string, t time.Time) string {
+	d := time.Since(t)
+	past := d >= 0
+	d = time.Duration(math.Abs(float64(d)))
+
+	var n int64
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int64(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int64(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int64(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int64(d/(365*24*time.Hour)), "year"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	count := FormatNumber(locale, n)
+	if past {
+		return fmt.Sprintf("%s %s ago", count, unit)
+	}
+	return fmt.Sprintf("in %s %s", count, unit)
+}
+
+// RelativeTime renders a time element containing the relative time, marked so
+// that the client refreshes just this element as time passes.
+func RelativeTime(locale string, t time.Time) template.HTML {
+	return template.HTML(fmt.Sprintf(
+		`<time datetime="%s" live-relative="%s">%s</time>`,
+		html.EscapeString(t.UTC().Format(time.RFC3339)),
+		html.EscapeString(locale),
+		html.EscapeString(FormatRelativeTime(locale, t)),
+	))
+}
+
+// printer returns a message printer for a locale.
+func printer(locale string) *message.Printer {
+	return message.NewPrinter(parseLocale(locale))
+}
+
+// parseLocale parses a locale, falling back to English.
+func parseLocale(locale string) language.Tag {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return language.English
+	}
+	return tag
+}
+
+// requestLocale picks the preferred locale from a requests Accept-Language header.
+func requestLocale(r *http.Request) string {
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	return tags[0].String()
+}
+
+// WithRelativeTimeRefresh re-render connected sockets at the given interval so
+// that relative times stay current.
+func WithRelativeTimeRefresh(interval time.Duration) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.refreshInterval = interval
+		case *HttpEngine:
+			v.refreshInterval = interval
+		}
+		return nil
+	}
+}
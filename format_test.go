@@ -0,0 +1,32 @@
+package live
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatFuncs(t *testing.T) {
+	if out := FormatNumber("en", 1234.5); out != "1,234.5" {
+		t.Errorf("en number got %q", out)
+	}
+	if out := FormatNumber("de", 1234.5); out != "1.234,5" {
+		t.Errorf("de number got %q", out)
+	}
+	if _, err := FormatCurrency("en", "NOTREAL", 1); err == nil {
+		t.Error("expected error for unknown currency")
+	}
+	if out := FormatRelativeTime("en", time.Now().Add(-3*time.Minute)); out != "3 minutes ago" {
+		t.Errorf("relative time got %q", out)
+	}
+	if out := FormatRelativeTime("en", time.Now().Add(49*time.Hour)); out != "in 2 days" {
+		t.Errorf("relative time got %q", out)
+	}
+	// Relative times are only in English, whatever the locale.
+	if out := FormatRelativeTime("de", time.Now().Add(-3*time.Minute)); out != "3 minutes ago" {
+		t.Errorf("de relative time got %q", out)
+	}
+	d := time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)
+	if out := FormatDate("de-DE", d); out != "7.3.2024" {
+		t.Errorf("de date got %q", out)
+	}
+}
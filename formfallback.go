@@ -0,0 +1,238 @@
+package live
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// formEventField the hidden form field carrying the event name.
+	formEventField = "_live_event"
+	// formCSRFField the hidden form field carrying the CSRF token.
+	formCSRFField = "_live_csrf"
+	// sessionCSRF the session key the CSRF token is kept under.
+	sessionCSRF = "_csrf"
+)
+
+// WithFormFallback make `live-submit` forms work without javascript. They are
+// rendered to POST to the handler with a CSRF token, the event handler is run
+// and the full page is rendered in response. File uploads are not supported
+// without javascript.
+func WithFormFallback() EngineConfig {
+	return func(e Engine) error {
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			httpEngine.formFallback = true
+		}
+		return nil
+	}
+}
+
+// formFallbacker is implemented by engines which render forms to work
+// without javascript.
+type formFallbacker interface {
+	formFallbackEnabled() bool
+}
+
+func (h *HttpEngine) formFallbackEnabled() bool {
+	return h.formFallback
+}
+
+// csrfToken returns the sessions CSRF token, creating one if needed.
+func csrfToken(session Session) string {
+	if token, ok := session[sessionCSRF].(string); ok && token != "" {
+		return token
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("live: could not generate csrf token: %s", err))
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	session[sessionCSRF] = token
+	return token
+}
+
+// setFormFallback points `live-submit` forms at the handler and adds the
+// hidden fields the handler needs to run the event.
+func setFormFallback(root *html.Node, token string) {
+	if root.NextSibling != nil {
+		setFormFallback(root.NextSibling, token)
+	}
+	if root.FirstChild != nil {
+		setFormFallback(root.FirstChild, token)
+	}
+
+	if root.Type != html.ElementNode || root.Data != "form" {
+		return
+	}
+	event := ""
+	for _, a := range root.Attr {
+		if a.Key == "live-submit" {
+			event = a.Val
+		}
+	}
+	if event == "" {
+		return
+	}
+	if !hasAttr(root, "method") {
+		root.Attr = append(root.Attr, html.Attribute{Key: "method", Val: "post"})
+	}
+	for _, field := range []html.Attribute{{Key: formEventField, Val: event}, {Key: formCSRFField, Val: token}} {
+		root.AppendChild(&html.Node{
+			Type: html.ElementNode,
+			Data: "input",
+			Attr: []html.Attribute{
+				{Key: "type", Val: "hidden"},
+				{Key: "name", Val: field.Key},
+				{Key: "value", Val: field.Val},
+			},
+		})
+	}
+}
+
+// isFormFallback checks if a POST is a form submitted without javascript.
+func isFormFallback(r *http.Request) bool {
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return ct == "application/x-www-form-urlencoded"
+}
+
+// postForm handles a form submitted without javascript, running its event
+// and rendering the full page.
+func (h *HttpEngine) postForm(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	session, err := h.sessionStore.Get(r)
+	if err != nil {
+		h.Error()(ctx, fmt.Errorf("no session found: %w", err))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.MaxUploadSize)
+	if err := r.ParseForm(); err != nil {
+		h.Error()(ctx, fmt.Errorf("could not parse form: %w", err))
+		return
+	}
+	expected, _ := session[sessionCSRF].(string)
+	token := r.PostForm.Get(formCSRFField)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(token)) != 1 {
+		http.Error(w, ErrCSRF.Error(), http.StatusForbidden)
+		return
+	}
+	event := r.PostForm.Get(formEventField)
+
+	// Flashes in the session were shown by the page that submitted the form.
+	SessionDelete(session, sessionFlash)
+
+	sock := NewHttpSocket(session, h, false)
+	sock.SetLocale(h.negotiateLocale(r, session))
+
+	if !h.authorizeRequest(ctx, w, r, sock) {
+		return
+	}
+
+	data, err := h.Mount()(ctx, sock)
+	if err != nil {
+		h.Error()(ctx, err)
+		return
+	}
+	sock.Assign(data)
+	for _, ph := range h.Params() {
+		data, err := ph(ctx, sock, NewParamsFromRequest(r))
+		if err != nil {
+			h.Error()(ctx, err)
+			return
+		}
+		sock.Assign(data)
+	}
+	if err := callChildParams(ctx, sock, NewParamsFromRequest(r)); err != nil {
+		h.Error()(ctx, err)
+		return
+	}
+
+	msg, err := formEvent(event, r.PostForm)
+	if err != nil {
+		h.Error()(ctx, err)
+		return
+	}
+	if err := h.CallEvent(ctx, event, sock, msg); err != nil {
+		// Components render the errors they contain, so the page is still
+		// shown.
+		if !errors.Is(err, ErrContained) {
+			h.Error()(ctx, err)
+			return
+		}
+		slog.ErrorContext(ctx, "form fallback event error", "event", event, "error", err)
+	}
+
+	if err := h.sessionStore.Save(w, r, session); err != nil {
+		h.Error()(ctx, err)
+		return
+	}
+
+	// The handler may have asked to redirect.
+	if u, ok := queuedRedirect(sock); ok {
+		http.Redirect(w, r, u, http.StatusSeeOther)
+		return
+	}
+
+	render, err := RenderSocket(ctx, h, sock)
+	if err != nil {
+		h.Error()(ctx, err)
+		return
+	}
+	var rendered bytes.Buffer
+	html.Render(&rendered, render)
+
+	w.WriteHeader(200)
+	io.Copy(w, &rendered)
+}
+
+// formEvent builds the event for a submitted form.
+func formEvent(event string, values url.Values) (Event, error) {
+	params := Params{}
+	for k, v := range values {
+		if strings.HasPrefix(k, "_live_") {
+			continue
+		}
+		if len(v) == 1 {
+			params[k] = v[0]
+		} else {
+			params[k] = v
+		}
+	}
+	d, err := json.Marshal(params)
+	if err != nil {
+		return Event{}, fmt.Errorf("could not encode form: %w", err)
+	}
+	return Event{T: event, Data: d}, nil
+}
+
+// queuedRedirect finds a redirect sent to an unconnected socket.
+func queuedRedirect(sock Socket) (string, bool) {
+	for {
+		select {
+		case msg := <-sock.Messages():
+			if msg.T != EventRedirect {
+				continue
+			}
+			var u string
+			if err := json.Unmarshal(msg.Data, &u); err != nil {
+				return "", false
+			}
+			return u, true
+		default:
+			return "", false
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFormFallback(t *testing.T) {
+	h := NewHandler()
+	h.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		return "", nil
+	})
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(fmt.Sprintf(`<html><body><p>%s</p><form live-submit="save"><input name="name"></form></body></html>`, data.Assigns)), nil
+	})
+	h.HandleEvent("save", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return "saved " + p.String("name"), nil
+	})
+	store := NewTestStore("test")
+	e := NewHttpHandler(store, h, WithFormFallback())
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, `method="post"`) || !strings.Contains(body, `name="_live_event" value="save"`) {
+		t.Fatalf("expected form fallback fields in %s", body)
+	}
+	token := csrfToken(store.s)
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+		return w
+	}
+
+	w = post(url.Values{formEventField: {"save"}, formCSRFField: {"wrong"}, "name": {"ada"}})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a bad token got %d", w.Code)
+	}
+
+	w = post(url.Values{formEventField: {"save"}, formCSRFField: {token}, "name": {"ada"}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "saved ada") {
+		t.Errorf("expected the event to run, got %d %s", w.Code, w.Body.String())
+	}
+}
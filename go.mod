@@ -3,12 +3,36 @@ module github.com/jfyne/live
 go 1.23
 
 require (
+	github.com/fasthttp/websocket v1.5.10
 	github.com/google/go-cmp v0.6.0
 	github.com/gorilla/sessions v1.3.0
+	github.com/quic-go/webtransport-go v0.8.0
+	github.com/redis/go-redis/v9 v9.7.0
 	github.com/rs/xid v1.5.0
+	github.com/valyala/fasthttp v1.55.0
+	golang.org/x/crypto v0.26.0
 	golang.org/x/net v0.28.0
+	golang.org/x/text v0.17.0
 	golang.org/x/time v0.6.0
 	nhooyr.io/websocket v1.8.17
 )
 
-require github.com/gorilla/securecookie v1.1.2 // indirect
+require (
+	github.com/andybalholm/brotli v1.1.0 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-task/slim-sprig v0.0.0-20230315185526-52ccab3ef572 // indirect
+	github.com/google/pprof v0.0.0-20230821062121-407c9e7a662f // indirect
+	github.com/gorilla/securecookie v1.1.2 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/onsi/ginkgo/v2 v2.12.0 // indirect
+	github.com/quic-go/qpack v0.4.0 // indirect
+	github.com/quic-go/quic-go v0.43.0 // indirect
+	github.com/savsgio/gotils v0.0.0-20240704082632-aef3928b8a38 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	go.uber.org/mock v0.4.0 // indirect
+	golang.org/x/exp v0.0.0-20230817173708-d852ddb80c63 // indirect
+	golang.org/x/mod v0.17.0 // indirect
+	golang.org/x/sys v0.23.0 // indirect
+	golang.org/x/tools v0.21.1-0.20240508182429-e35e4ccd0d2d // indirect
+)
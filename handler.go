@@ -12,6 +12,11 @@ var _ Handler = &BaseHandler{}
 // HandlerConfig applies config to a handler.
 type HandlerConfig func(h Handler) error
 
+// AuthHandler the func that is called to authorize a socket before it is
+// mounted, and before each event with WithEventAuth. Return AuthRedirect to
+// send the client to a login page.
+type AuthHandler func(ctx context.Context, c Socket) error
+
 // MountHandler the func that is called by a handler to gather data to
 // be rendered in a template. This is called on first GET and then later when
 // the web socket first connects. It should return the state to be maintained
@@ -23,6 +28,50 @@ type MountHandler[T any] func(ctx context.Context, c Socket) (T, error)
 // connected users.
 type UnmountHandler func(c Socket) error
 
+// CloseReason describes why a websocket connection was closed.
+type CloseReason int
+
+const (
+	// CloseNormal the client closed the connection, for example by
+	// navigating away or refreshing the page.
+	CloseNormal CloseReason = iota
+	// CloseTimeout the connection timed out.
+	CloseTimeout
+	// CloseError the connection failed.
+	CloseError
+	// CloseShutdown the server closed the connection.
+	CloseShutdown
+)
+
+// String returns a readable close reason.
+func (r CloseReason) String() string {
+	switch r {
+	case CloseNormal:
+		return "normal"
+	case CloseTimeout:
+		return "timeout"
+	case CloseError:
+		return "error"
+	case CloseShutdown:
+		return "shutdown"
+	}
+	return "unknown"
+}
+
+// UnmountContextHandler the func that is called by a handler to report that a
+// connection is closed along with the reason for closing. The context has a
+// deadline so that cleanup is bounded.
+type UnmountContextHandler func(ctx context.Context, c Socket, reason CloseReason) error
+
+// ConnectHandler the func that is called once the websocket has connected,
+// after mount. Unlike mount it is not called for the initial GET, so it is the
+// place to subscribe to things only a connected socket needs.
+type ConnectHandler func(ctx context.Context, c Socket) error
+
+// DisconnectHandler the func that is called when the websocket of a socket
+// that connected closes.
+type DisconnectHandler func(ctx context.Context, c Socket) error
+
 // RenderHandler the func that is called to render the current state of the
 // data for the socket.
 type RenderHandler func(ctx context.Context, rc *RenderContext) (io.Reader, error)
@@ -31,6 +80,21 @@ type RenderHandler func(ctx context.Context, rc *RenderContext) (io.Reader, erro
 // a handler of this type will be called.
 type ErrorHandler func(ctx context.Context, err error)
 
+// ReloadHandler the func that is called in dev mode when watched files
+// change, for example to re-parse templates.
+type ReloadHandler func() error
+
+// EventErrorHandler if an event handler returns an error a handler of this
+// type builds what is sent to the client in the "err" event. Use it to log the
+// error and decide how much of it the client should see.
+type EventErrorHandler func(ctx context.Context, err error) ErrorPayload
+
+// SocketErrorHandler if an event handler returns an error a handler of this
+// type is called with the socket and the event which caused it, after the
+// error has been sent to the client. Use it to tell the user, or log against
+// the socket. Returning an error closes the connection.
+type SocketErrorHandler func(ctx context.Context, s Socket, source Event, err error) error
+
 // EventHandler a function to handle events, returns the data that should
 // be set to the socket after handling.
 type EventHandler[T any] func(context.Context, Socket, Params) (T, error)
@@ -41,71 +105,134 @@ type SelfHandler[T any] func(context.Context, Socket, interface{}) (T, error)
 
 // Handler methods.
 type Handler interface {
+	// HandleAuth authorizes a socket before it is mounted on both the initial
+	// request and when the websocket connects.
+	HandleAuth(handler AuthHandler)
 	// HandleMount handles initial setup on first request, and then later when
 	// the socket first connets.
 	HandleMount(handler MountHandler[any])
 	// HandleUnmount used to track webcocket disconnections.
 	HandleUnmount(handler UnmountHandler)
+	// HandleUnmountContext used to track websocket disconnections and why
+	// they happened.
+	HandleUnmountContext(handler UnmountContextHandler)
+	// HandleConnect called only when the websocket connects.
+	HandleConnect(handler ConnectHandler)
+	// HandleDisconnect called only when the websocket disconnects.
+	HandleDisconnect(handler DisconnectHandler)
 	// HandleRender used to set the render method for the handler.
 	HandleRender(handler RenderHandler)
 	// HandleError for when an error occurs.
 	HandleError(handler ErrorHandler)
+	// HandleEventError for when an event handler returns an error.
+	HandleEventError(handler EventErrorHandler)
+	// HandleSocketError for when an event handler returns an error, with the
+	// socket and event it happened on.
+	HandleSocketError(handler SocketErrorHandler)
 	// HandleEvent handles an event that comes from the client. For example a click
 	// from `live-click="myevent"`.
 	HandleEvent(t string, handler EventHandler[any])
+	// HandleEventSchema validates the params of an event before its handler
+	// is called.
+	HandleEventSchema(t string, validator EventValidator)
 	// HandleSelf handles an event that comes from the server side socket. For example calling
 	// h.Self(socket, msg) will be handled here.
 	HandleSelf(t string, handler SelfHandler[any])
 	// HandleParams handles a URL query parameter change. This is useful for handling
 	// things like pagincation, or some filtering.
 	HandleParams(handler EventHandler[any])
+	// HandleReload called in dev mode when watched files change.
+	HandleReload(handler ReloadHandler)
 
+	getAuth() AuthHandler
 	getMount() MountHandler[any]
 	getUnmount() UnmountHandler
+	getUnmountContext() UnmountContextHandler
+	getConnect() ConnectHandler
+	getDisconnect() DisconnectHandler
 	getRender() RenderHandler
 	getError() ErrorHandler
+	getEventError() EventErrorHandler
+	getSocketError() SocketErrorHandler
 	getEvent(t string) (EventHandler[any], error)
+	getEventSchema(t string) (EventValidator, bool)
 	getSelf(t string) (SelfHandler[any], error)
 	getParams() []EventHandler[any]
+	getReload() []ReloadHandler
 }
 
 // BaseHandler.
 type BaseHandler struct {
+	// authHandler authorizes a socket before it is mounted.
+	authHandler AuthHandler
 	// mountHandler a user should provide the mount function. This is what
 	// is called on initial GET request and later when the websocket connects.
 	// Data to render the handler should be fetched here and returned.
 	mountHandler MountHandler[any]
 	// unmountHandler used to track webcocket disconnections.
 	unmountHandler UnmountHandler
+	// unmountContextHandler used to track websocket disconnections with a reason.
+	unmountContextHandler UnmountContextHandler
+	// connectHandler called when the websocket connects.
+	connectHandler ConnectHandler
+	// disconnectHandler called when the websocket disconnects.
+	disconnectHandler DisconnectHandler
 	// Render is called to generate the HTML of a Socket. It is defined
 	// by default and will render any template provided.
 	renderHandler RenderHandler
 	// Error is called when an error occurs during the mount and render
 	// stages of the handler lifecycle.
 	errorHandler ErrorHandler
+	// eventErrorHandler builds the error sent to the client when an event
+	// handler fails.
+	eventErrorHandler EventErrorHandler
+	// socketErrorHandler is called with the socket when an event handler
+	// fails.
+	socketErrorHandler SocketErrorHandler
 	// eventHandlers the map of client event handlers.
 	eventHandlers map[string]EventHandler[any]
+	// eventSchemas the map of client event validators.
+	eventSchemas map[string]EventValidator
 	// selfHandlers the map of handler event handlers.
 	selfHandlers map[string]SelfHandler[any]
 	// paramsHandlers a slice of handlers which respond to a change in URL parameters.
 	paramsHandlers []EventHandler[any]
+	// reloadHandlers called in dev mode when watched files change.
+	reloadHandlers []ReloadHandler
 }
 
 // NewHandler sets up a base handler for live.
 func NewHandler(configs ...HandlerConfig) *BaseHandler {
 	h := &BaseHandler{
 		eventHandlers:  make(map[string]EventHandler[any]),
+		eventSchemas:   make(map[string]EventValidator),
 		selfHandlers:   make(map[string]SelfHandler[any]),
 		paramsHandlers: []EventHandler[any]{},
+		authHandler: func(ctx context.Context, s Socket) error {
+			return nil
+		},
 		mountHandler: func(ctx context.Context, s Socket) (interface{}, error) {
 			return nil, nil
 		},
 		unmountHandler: func(s Socket) error {
 			return nil
 		},
+		unmountContextHandler: func(ctx context.Context, s Socket, reason CloseReason) error {
+			return nil
+		},
+		connectHandler: func(ctx context.Context, s Socket) error {
+			return nil
+		},
+		disconnectHandler: func(ctx context.Context, s Socket) error {
+			return nil
+		},
 		renderHandler: func(ctx context.Context, rc *RenderContext) (io.Reader, error) {
 			return nil, ErrNoRenderer
 		},
+		eventErrorHandler: NewErrorPayload,
+		socketErrorHandler: func(ctx context.Context, s Socket, source Event, err error) error {
+			return nil
+		},
 		errorHandler: func(ctx context.Context, err error) {
 			w := Writer(ctx)
 			if w != nil {
@@ -122,18 +249,41 @@ func NewHandler(configs ...HandlerConfig) *BaseHandler {
 	return h
 }
 
+// HandleAuth authorizes a socket before it is mounted, on both the initial
+// request and when the websocket connects, so that checks don't need to be
+// repeated in mount. With the WithEventAuth engine config it is also called
+// before each event.
+func (h *BaseHandler) HandleAuth(f AuthHandler) {
+	h.authHandler = f
+}
+
 func (h *BaseHandler) HandleMount(f MountHandler[any]) {
 	h.mountHandler = f
 }
 func (h *BaseHandler) HandleUnmount(f UnmountHandler) {
 	h.unmountHandler = f
 }
+func (h *BaseHandler) HandleUnmountContext(f UnmountContextHandler) {
+	h.unmountContextHandler = f
+}
+func (h *BaseHandler) HandleConnect(f ConnectHandler) {
+	h.connectHandler = f
+}
+func (h *BaseHandler) HandleDisconnect(f DisconnectHandler) {
+	h.disconnectHandler = f
+}
 func (h *BaseHandler) HandleRender(f RenderHandler) {
 	h.renderHandler = f
 }
 func (h *BaseHandler) HandleError(f ErrorHandler) {
 	h.errorHandler = f
 }
+func (h *BaseHandler) HandleEventError(f EventErrorHandler) {
+	h.eventErrorHandler = f
+}
+func (h *BaseHandler) HandleSocketError(f SocketErrorHandler) {
+	h.socketErrorHandler = f
+}
 
 // HandleEvent handles an event that comes from the client. For example a click
 // from `live-click="myevent"`.
@@ -141,6 +291,13 @@ func (h *BaseHandler) HandleEvent(t string, handler EventHandler[any]) {
 	h.eventHandlers[t] = handler
 }
 
+// HandleEventSchema validates the params of an event before its handler is
+// called, events which fail are rejected with an ErrorCodeInvalidPayload
+// error. Use Schema to validate against a struct.
+func (h *BaseHandler) HandleEventSchema(t string, validator EventValidator) {
+	h.eventSchemas[t] = validator
+}
+
 // HandleSelf handles an event that comes from the server side socket. For example calling
 // h.Self(socket, msg) will be handled here.
 func (h *BaseHandler) HandleSelf(t string, handler SelfHandler[any]) {
@@ -153,18 +310,42 @@ func (h *BaseHandler) HandleParams(handler EventHandler[any]) {
 	h.paramsHandlers = append(h.paramsHandlers, handler)
 }
 
+// HandleReload handles watched files changing in dev mode, see WithDevMode.
+// Re-parse templates here so the reloaded page uses them.
+func (h *BaseHandler) HandleReload(handler ReloadHandler) {
+	h.reloadHandlers = append(h.reloadHandlers, handler)
+}
+
+func (h *BaseHandler) getAuth() AuthHandler {
+	return h.authHandler
+}
 func (h *BaseHandler) getMount() MountHandler[any] {
 	return h.mountHandler
 }
 func (h *BaseHandler) getUnmount() UnmountHandler {
 	return h.unmountHandler
 }
+func (h *BaseHandler) getUnmountContext() UnmountContextHandler {
+	return h.unmountContextHandler
+}
+func (h *BaseHandler) getConnect() ConnectHandler {
+	return h.connectHandler
+}
+func (h *BaseHandler) getDisconnect() DisconnectHandler {
+	return h.disconnectHandler
+}
 func (h *BaseHandler) getRender() RenderHandler {
 	return h.renderHandler
 }
 func (h *BaseHandler) getError() ErrorHandler {
 	return h.errorHandler
 }
+func (h *BaseHandler) getEventError() EventErrorHandler {
+	return h.eventErrorHandler
+}
+func (h *BaseHandler) getSocketError() SocketErrorHandler {
+	return h.socketErrorHandler
+}
 func (h *BaseHandler) getEvent(t string) (EventHandler[any], error) {
 	handler, ok := h.eventHandlers[t]
 	if !ok {
@@ -172,6 +353,10 @@ func (h *BaseHandler) getEvent(t string) (EventHandler[any], error) {
 	}
 	return handler, nil
 }
+func (h *BaseHandler) getEventSchema(t string) (EventValidator, bool) {
+	validator, ok := h.eventSchemas[t]
+	return validator, ok
+}
 func (h *BaseHandler) getSelf(t string) (SelfHandler[any], error) {
 	handler, ok := h.selfHandlers[t]
 	if !ok {
@@ -182,3 +367,6 @@ func (h *BaseHandler) getSelf(t string) (SelfHandler[any], error) {
 func (h *BaseHandler) getParams() []EventHandler[any] {
 	return h.paramsHandlers
 }
+func (h *BaseHandler) getReload() []ReloadHandler {
+	return h.reloadHandlers
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 type Tester struct {
@@ -60,3 +61,49 @@ func TestHandlerErrorNoRenderer(t *testing.T) {
 		return
 	}
 }
+
+func TestWithTemplateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":      {Data: []byte(`{{ define "layout" }}<main>{{ template "title" . }}</main>{{ end }}`)},
+		"partials/t.html": {Data: []byte(`{{ define "title" }}<h1>{{ .Assigns }}</h1>{{ end }}`)},
+	}
+	h := NewHandler(WithTemplateFS(fsys, "index.html", "partials/*.html"))
+	out, err := h.getRender()(context.Background(), &RenderContext{Assigns: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(out)
+	if string(b) != "<main><h1>hello</h1></main>" {
+		t.Errorf("unexpected render %s", b)
+	}
+
+	fsys["partials/t.html"] = &fstest.MapFile{Data: []byte(`{{ define "title" }}<h2>{{ .Assigns }}</h2>{{ end }}`)}
+	for _, r := range h.getReload() {
+		if err := r(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, err = h.getRender()(context.Background(), &RenderContext{Assigns: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = io.ReadAll(out)
+	if string(b) != "<main><h2>hello</h2></main>" {
+		t.Errorf("expected reloaded templates, got %s", b)
+	}
+}
+
+func TestWithTemplateFSFirstFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte(`<p>{{ number .Locale 1234.5 }}</p>`)},
+	}
+	h := NewHandler(WithTemplateFS(fsys, "*.html"))
+	out, err := h.getRender()(context.Background(), &RenderContext{Locale: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(out)
+	if string(b) != "<p>1,234.5</p>" {
+		t.Errorf("unexpected render %s", b)
+	}
+}
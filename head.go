@@ -0,0 +1,131 @@
+package live
+
+import (
+	"golang.org/x/net/html"
+)
+
+const (
+	// EventTitle sent to update the document title.
+	EventTitle = "title"
+	// EventMeta sent to update a meta tag.
+	EventMeta = "meta"
+)
+
+// metaEvent the data sent with EventMeta.
+type metaEvent struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// SetTitle sets the document title. Patches only update the body, so use this
+// when the title depends on the sockets state. The title is also set on
+// full page renders.
+func (s *BaseSocket) SetTitle(title string) {
+	s.dataMu.Lock()
+	s.title = title
+	s.dataMu.Unlock()
+	if s.connected {
+		s.Send(EventTitle, title)
+	}
+}
+
+// SetMeta sets the content of a named meta tag, adding it if needed.
+func (s *BaseSocket) SetMeta(name, content string) {
+	s.dataMu.Lock()
+	if s.meta == nil {
+		s.meta = map[string]string{}
+	}
+	s.meta[name] = content
+	s.dataMu.Unlock()
+	if s.connected {
+		s.Send(EventMeta, metaEvent{Name: name, Content: content})
+	}
+}
+
+// head returns the title and meta tags set on the socket.
+func (s *BaseSocket) head() (string, map[string]string) {
+	s.dataMu.RLock()
+	defer s.dataMu.RUnlock()
+	meta := make(map[string]string, len(s.meta))
+	for k, v := range s.meta {
+		meta[k] = v
+	}
+	return s.title, meta
+}
+
+// headSetter is implemented by sockets which set the document head.
+type headSetter interface {
+	head() (string, map[string]string)
+}
+
+// setHead sets the title and meta tags in the head of a render.
+func setHead(root *html.Node, title string, meta map[string]string) {
+	if title == "" && len(meta) == 0 {
+		return
+	}
+	head := findElement(root, "head")
+	if head == nil {
+		return
+	}
+
+	if title != "" {
+		t := findElement(head, "title")
+		if t == nil {
+			t = &html.Node{Type: html.ElementNode, Data: "title"}
+			head.AppendChild(t)
+		}
+		for t.FirstChild != nil {
+			t.RemoveChild(t.FirstChild)
+		}
+		t.AppendChild(&html.Node{Type: html.TextNode, Data: title})
+	}
+
+	for name, content := range meta {
+		setMeta(head, name, content)
+	}
+}
+
+// setMeta sets the content of the named meta tag in the head.
+func setMeta(head *html.Node, name, content string) {
+	for c := head.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "meta" || getAttr(c, "name") != name {
+			continue
+		}
+		for i, a := range c.Attr {
+			if a.Key == "content" {
+				c.Attr[i].Val = content
+				return
+			}
+		}
+		c.Attr = append(c.Attr, html.Attribute{Key: "content", Val: content})
+		return
+	}
+	head.AppendChild(&html.Node{
+		Type: html.ElementNode,
+		Data: "meta",
+		Attr: []html.Attribute{{Key: "name", Val: name}, {Key: "content", Val: content}},
+	})
+}
+
+// findElement finds the first element with the tag in the tree.
+func findElement(root *html.Node, tag string) *html.Node {
+	if root.Type == html.ElementNode && root.Data == tag {
+		return root
+	}
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if n := findElement(c, tag); n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+// getAttr returns the value of an attribute on a node.
+func getAttr(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package live
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestSetHead(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<html><head><title>Old</title><meta name="description" content="old"></head><body></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setHead(root, "New", map[string]string{"description": "new", "robots": "noindex"})
+
+	var buf bytes.Buffer
+	html.Render(&buf, root)
+	out := buf.String()
+	for _, want := range []string{
+		`<title>New</title>`,
+		`<meta name="description" content="new"/>`,
+		`<meta name="robots" content="noindex"/>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+}
+
+func TestSocketSetTitle(t *testing.T) {
+	s := NewBaseSocket(NewSession(), NewBaseEngine(NewHandler()), true)
+	s.SetTitle("Inbox (3)")
+	msg := <-s.Messages()
+	if msg.T != EventTitle || string(msg.Data) != `"Inbox (3)"` {
+		t.Errorf("unexpected title event %s %s", msg.T, msg.Data)
+	}
+	if title, _ := s.head(); title != "Inbox (3)" {
+		t.Errorf("expected title kept on socket got %q", title)
+	}
+}
@@ -3,7 +3,6 @@ package live
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -12,7 +11,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,10 +23,14 @@ import (
 var _ Engine = &HttpEngine{}
 var _ Socket = &HttpSocket{}
 var _ HttpSessionStore = &CookieStore{}
+var _ SessionRegenerator = &CookieStore{}
 
 // sessionCookie the name of the session cookie.
 const sessionCookie string = "_ls"
 
+// liveWebTransport body attribute telling the client to try WebTransport.
+const liveWebTransport = "live-webtransport"
+
 // HttpSessionStore handles storing and retrieving sessions.
 type HttpSessionStore interface {
 	Get(*http.Request) (Session, error)
@@ -39,6 +42,35 @@ type HttpSessionStore interface {
 type HttpEngine struct {
 	acceptOptions *websocket.AcceptOptions
 	sessionStore  HttpSessionStore
+	protocolPath  string
+	pathValues    []string
+	basePath      string
+	formFallback  bool
+	// allowedOrigins cross origin hosts allowed to use this handler.
+	allowedOrigins []string
+	sessionSaves   pendingSessionSaves
+	// chunkedUploads the chunked uploads being received.
+	chunkedUploads chunkedUploads
+	// tempStaging where uploads are staged without a staging location.
+	tempStaging tempStaging
+	downloads   pendingDownloads
+	// webTransport advertise the experimental WebTransport transport to clients.
+	webTransport bool
+	// binary allow clients to binary encode events.
+	binary bool
+	// ignorePaths paths which aren't served by live, they are passed to the
+	// fallback handler.
+	ignorePaths []string
+	fallback    http.Handler
+	// compressThreshold the smallest message compressed, when compression
+	// is on.
+	compressThreshold int
+	// staticMode matches requests which are rendered without connecting.
+	staticMode func(r *http.Request) bool
+	// endpoint the path clients dial, empty for the current page.
+	endpoint string
+	// readLimit the largest websocket message read, zero for the default.
+	readLimit int64
 	*BaseEngine
 }
 
@@ -52,27 +84,50 @@ func WithWebsocketAcceptOptions(options *websocket.AcceptOptions) EngineConfig {
 	}
 }
 
+// WithPathValues include the named `http.ServeMux` pattern wildcards in
+// Params. For example when mounted with `mux.Handle("/room/{id}", h)` use
+// WithPathValues("id").
+func WithPathValues(names ...string) EngineConfig {
+	return func(e Engine) error {
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			httpEngine.pathValues = names
+		}
+		return nil
+	}
+}
+
 // NewHttpHandler returns the net/http handler for live.
 func NewHttpHandler(store HttpSessionStore, handler Handler, configs ...EngineConfig) *HttpEngine {
 	e := &HttpEngine{
 		sessionStore: store,
 		BaseEngine:   NewBaseEngine(handler),
 	}
+	e.socketDeleted = e.dropUploads
 	for _, conf := range configs {
 		if err := conf(e); err != nil {
 			slog.Warn("could not apply config to engine", "error", err)
 		}
 	}
+	e.checkCrossOrigin()
 	return e
 }
 
 // ServeHTTP serves this handler.
 func (h *HttpEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path == "/favicon.ico" {
-		if h.IgnoreFaviconRequest {
-			w.WriteHeader(404)
-			return
-		}
+	r = h.stripBasePath(r)
+
+	if h.ignored(r) {
+		h.serveIgnored(w, r)
+		return
+	}
+
+	if !h.handleCORS(w, r) {
+		return
+	}
+
+	if h.protocolPath != "" && r.URL.Path == h.protocolPath {
+		h.serveProtocol(w, r)
+		return
 	}
 
 	// Check if we are going to upgrade to a websocket.
@@ -84,24 +139,85 @@ func (h *HttpEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	r = h.withPathValues(r)
+
 	ctx := httpContext(w, r)
 
 	if !upgrade {
+		id := requestID(r)
+		w.Header().Set(RequestIDHeader, id)
+		ctx = contextWithRequestID(ctx, id)
 		switch r.Method {
 		case http.MethodPost:
 			h.post(ctx, w, r)
+		case http.MethodDelete:
+			h.deleteUpload(ctx, w, r)
 		default:
 			h.get(ctx, w, r)
 		}
 		return
 	}
 
+	// Static pages never connect.
+	if h.isStatic(r) {
+		http.Error(w, "websocket disabled", http.StatusNotFound)
+		return
+	}
+
 	// Upgrade to the websocket version.
 	h.serveWS(ctx, w, r)
 }
 
+// withPathValues adds the path values configured with WithPathValues to the
+// request's params.
+func (h *HttpEngine) withPathValues(r *http.Request) *http.Request {
+	if len(h.pathValues) == 0 {
+		return r
+	}
+	params := map[string]string{}
+	for _, name := range h.pathValues {
+		params[name] = r.PathValue(name)
+	}
+	return RequestWithPathParams(r, params)
+}
+
+// upgradeRequest prepares a websocket request in the same order as
+// ServeHTTP, returning false if ServeHTTP would answer it without upgrading.
+func (h *HttpEngine) upgradeRequest(r *http.Request) (*http.Request, bool) {
+	r = h.stripBasePath(r)
+	if h.ignored(r) || !h.corsAllowed(r) {
+		return r, false
+	}
+	if h.protocolPath != "" && r.URL.Path == h.protocolPath {
+		return r, false
+	}
+	r = h.withPathValues(r)
+	if h.isStatic(r) {
+		return r, false
+	}
+	return r, true
+}
+
 // post handler.
 func (h *HttpEngine) post(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// The client is saving its session.
+	if token := r.Header.Get(sessionSaveHeader); token != "" {
+		h.postSession(ctx, w, r, token)
+		return
+	}
+
+	// A chunk of a resumable upload.
+	if r.Header.Get(uploadIDHeader) != "" {
+		h.postUploadChunk(ctx, w, r)
+		return
+	}
+
+	// A form submitted without javascript.
+	if h.formFallback && isFormFallback(r) {
+		h.postForm(ctx, w, r)
+		return
+	}
+
 	// Get session.
 	session, err := h.sessionStore.Get(r)
 	if err != nil {
@@ -122,35 +238,25 @@ func (h *HttpEngine) post(ctx context.Context, w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	uploadDir := filepath.Join(h.UploadStagingLocation, string(sock.ID()))
-	if h.UploadStagingLocation == "" {
-		uploadDir, err = os.MkdirTemp("", string(sock.ID()))
-		if err != nil {
-			h.Error()(ctx, fmt.Errorf("%s upload dir creation failed: %w", sock.ID(), err))
-			return
-		}
+	uploadDir, err := h.stagingDir(sock)
+	if err != nil {
+		h.Error()(ctx, fmt.Errorf("%s upload dir creation failed: %w", sock.ID(), err))
+		return
 	}
 
 	for _, config := range sock.UploadConfigs() {
 		for _, fileHeader := range r.MultipartForm.File[config.Name] {
-			u := uploadFromFileHeader(fileHeader)
+			u := uploadFromFileHeader(config.Name, fileHeader)
 			sock.AssignUpload(config.Name, u)
 			handleFileUpload(h, sock, config, u, uploadDir, fileHeader)
-
-			sock.Lock()
-			defer sock.Unlock()
-			render, err := RenderSocket(ctx, h, sock)
-			if err != nil {
-				h.Error()(ctx, err)
-				return
-			}
-			sock.UpdateRender(render)
+			h.updateUploads(ctx, sock, func() {})
 		}
 	}
 }
 
-func uploadFromFileHeader(fh *multipart.FileHeader) *Upload {
+func uploadFromFileHeader(input string, fh *multipart.FileHeader) *Upload {
 	return &Upload{
+		ID:   uploadID(input, fh.Filename, fh.Size),
 		Name: fh.Filename,
 		Size: fh.Size,
 	}
@@ -220,6 +326,11 @@ func handleFileUpload(h *HttpEngine, sock Socket, config *UploadConfig, u *Uploa
 
 // get renderer.
 func (h *HttpEngine) get(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// The client is fetching a file sent with SendFile.
+	if token := r.URL.Query().Get(downloadParam); token != "" {
+		h.getDownload(ctx, w, r, token)
+		return
+	}
 
 	// Get session.
 	session, err := h.sessionStore.Get(r)
@@ -240,6 +351,15 @@ func (h *HttpEngine) get(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	// Get socket.
 	sock := NewHttpSocket(session, h, false)
+	sock.SetLocale(h.negotiateLocale(r, session))
+
+	// Show any flashes carried over from a redirect.
+	restoreFlashes(sock)
+
+	// Check the socket is allowed to see the page.
+	if !h.authorizeRequest(ctx, w, r, sock) {
+		return
+	}
 
 	// Run mount, this generates the state for the page we are on.
 	data, err := h.Mount()(ctx, sock)
@@ -258,6 +378,10 @@ func (h *HttpEngine) get(ctx context.Context, w http.ResponseWriter, r *http.Req
 		}
 		sock.Assign(data)
 	}
+	if err := callChildParams(ctx, sock, NewParamsFromRequest(r)); err != nil {
+		h.Error()(ctx, err)
+		return
+	}
 
 	// Render the HTML to display the page.
 	render, err := RenderSocket(ctx, h, sock)
@@ -266,6 +390,11 @@ func (h *HttpEngine) get(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return
 	}
 	sock.UpdateRender(render)
+	if h.isStatic(r) {
+		stripLive(render)
+	} else if id := RequestID(ctx); id != "" {
+		setBodyAttrs(render, []html.Attribute{{Key: liveRequestID, Val: id}})
+	}
 
 	var rendered bytes.Buffer
 	html.Render(&rendered, render)
@@ -288,22 +417,32 @@ func (h *HttpEngine) serveWS(ctx context.Context, w http.ResponseWriter, r *http
 		return
 	}
 
+	options := websocket.AcceptOptions{}
+	if h.acceptOptions != nil {
+		options = *h.acceptOptions
+	}
+
 	// https://github.com/nhooyr/websocket/issues/218
 	// https://github.com/gorilla/websocket/issues/731
 	if strings.Contains(r.UserAgent(), "Safari") {
-		if h.acceptOptions == nil {
-			h.acceptOptions = &websocket.AcceptOptions{}
-		}
-		h.acceptOptions.CompressionMode = websocket.CompressionDisabled
+		options.CompressionMode = websocket.CompressionDisabled
 	}
+	options.Subprotocols = append(options.Subprotocols[:len(options.Subprotocols):len(options.Subprotocols)], subprotocols(h.binary)...)
 
-	c, err := websocket.Accept(w, r, h.acceptOptions)
+	c, err := websocket.Accept(w, r, &options)
 	if err != nil {
+		if origin := r.Header.Get("Origin"); origin != "" && len(h.allowedOrigins) == 0 {
+			slog.WarnContext(ctx, "websocket rejected, if the page is on another origin allow it with live.WithAllowedOrigins", "origin", origin, "error", err)
+		}
 		h.Error()(ctx, err)
 		return
 	}
 	defer c.Close(websocket.StatusInternalError, "")
-	writeTimeout(ctx, time.Second*5, c, Event{T: EventConnect})
+	c.SetReadLimit(h.maxMessageSize())
+	conn := newHTTPConn(c)
+	mark := h.trustedMark()
+	ctx = contextWithTrustedMark(ctx, mark)
+	writeTimeout(ctx, time.Second*5, conn, h.connectEvent(conn, options.CompressionMode != websocket.CompressionDisabled, mark))
 	{
 		err := h._serveWS(ctx, r, session, c)
 		if errors.Is(err, context.Canceled) {
@@ -322,134 +461,17 @@ func (h *HttpEngine) serveWS(ctx context.Context, w http.ResponseWriter, r *http
 }
 
 // _serveWS implement the logic for a web socket connection.
-func (h *HttpEngine) _serveWS(ctx context.Context, r *http.Request, session Session, c *websocket.Conn) error {
+func (h *HttpEngine) _serveWS(ctx context.Context, r *http.Request, session Session, c *websocket.Conn) (err error) {
 	// Get the sessions socket and register it with the server.
 	sock := NewHttpSocket(session, h, true)
+	sock.SetLocale(h.negotiateLocale(r, session))
 	sock.assignWS(c)
 	h.AddSocket(sock)
-	defer h.DeleteSocket(sock)
-
-	// Internal errors.
-	internalErrors := make(chan error)
-
-	// Event errors.
-	eventErrors := make(chan ErrorEvent)
-
-	// Handle events coming from the websocket connection.
-	go func() {
-		defer func() {
-			if err := recover(); err != nil {
-				internalErrors <- fmt.Errorf("live: panic serving %v: %v\n%s", r.RemoteAddr, err, debug.Stack())
-			}
-		}()
-
-		for {
-			t, d, err := c.Read(ctx)
-			if err != nil {
-				internalErrors <- err
-				break
-			}
-			switch t {
-			case websocket.MessageText:
-				var m Event
-				if err := json.Unmarshal(d, &m); err != nil {
-					internalErrors <- err
-					break
-				}
-				switch m.T {
-				case EventParams:
-					if err := h.CallParams(ctx, sock, m); err != nil {
-						switch {
-						case errors.Is(err, ErrNoEventHandler):
-							slog.ErrorContext(ctx, "event error", "event", m, "error", err)
-						default:
-							eventErrors <- ErrorEvent{Source: m, Err: err.Error()}
-						}
-					}
-				default:
-					if err := h.CallEvent(ctx, m.T, sock, m); err != nil {
-						switch {
-						case errors.Is(err, ErrNoEventHandler):
-							slog.ErrorContext(ctx, "event error", "event", m, "error", err)
-						default:
-							eventErrors <- ErrorEvent{Source: m, Err: err.Error()}
-						}
-					}
-				}
-				render, err := RenderSocket(ctx, h, sock)
-				if err != nil {
-					internalErrors <- fmt.Errorf("socket handle error: %w", err)
-				} else {
-					sock.UpdateRender(render)
-				}
-				if err := sock.Send(EventAck, nil, WithID(m.ID)); err != nil {
-					internalErrors <- fmt.Errorf("socket send error: %w", err)
-				}
-			case websocket.MessageBinary:
-				slog.WarnContext(ctx, "binary messages unhandled")
-			}
-		}
-		close(internalErrors)
-		close(eventErrors)
+	defer func() {
+		h.CloseSocket(ctx, sock, closeReason(err))
 	}()
 
-	// Run mount again now that eh socket is connected, passing true indicating
-	// a connection has been made.
-	data, err := h.Mount()(ctx, sock)
-	if err != nil {
-		return fmt.Errorf("socket mount error: %w", err)
-	}
-	sock.Assign(data)
-
-	// Run params again now that the socket is connected.
-	for _, ph := range h.Params() {
-		data, err := ph(ctx, sock, NewParamsFromRequest(r))
-		if err != nil {
-			return fmt.Errorf("socket params error: %w", err)
-		}
-		sock.Assign(data)
-	}
-
-	// Run render now that we are connected for the first time and we have just
-	// mounted again. This will generate and send any patches if there have
-	// been changes.
-	render, err := RenderSocket(ctx, h, sock)
-	if err != nil {
-		return fmt.Errorf("socket render error: %w", err)
-	}
-	sock.UpdateRender(render)
-
-	// Send events to the websocket connection.
-	for {
-		select {
-		case msg := <-sock.msgs:
-			if err := writeTimeout(ctx, time.Second*5, c, msg); err != nil {
-				return fmt.Errorf("writing to socket error: %w", err)
-			}
-		case ee := <-eventErrors:
-			d, err := json.Marshal(ee)
-			if err != nil {
-				return fmt.Errorf("writing to socket error: %w", err)
-			}
-			if err := writeTimeout(ctx, time.Second*5, c, Event{T: EventError, Data: d}); err != nil {
-				return fmt.Errorf("writing to socket error: %w", err)
-			}
-		case err := <-internalErrors:
-			if err != nil {
-				d, err1 := json.Marshal(err.Error())
-				if err1 != nil {
-					return fmt.Errorf("writing to socket error: %w", err1)
-				}
-				if err := writeTimeout(ctx, time.Second*5, c, Event{T: EventError, Data: d}); err != nil {
-					return fmt.Errorf("writing to socket error: %w", err)
-				}
-				// Something catastrophic has happened.
-				return fmt.Errorf("internal error: %w", err)
-			}
-		case <-ctx.Done():
-			return nil
-		}
-	}
+	return h.serveSocket(ctx, h, sock, newHTTPConn(c), r)
 }
 
 type HttpSocket struct {
@@ -463,6 +485,27 @@ func NewHttpSocket(s Session, e Engine, connected bool) *HttpSocket {
 	}
 }
 
+// bodyAttributes annotate the rendered body for the client.
+func (h *HttpEngine) bodyAttributes() []html.Attribute {
+	attrs := []html.Attribute{}
+	if h.protocolPath != "" {
+		attrs = append(attrs, html.Attribute{Key: liveProtocol, Val: h.basePath + h.protocolPath})
+	}
+	if h.basePath != "" {
+		attrs = append(attrs, html.Attribute{Key: liveBasePath, Val: h.basePath})
+	}
+	if h.webTransport {
+		attrs = append(attrs, html.Attribute{Key: liveWebTransport})
+	}
+	if h.UploadChunkSize > 0 {
+		attrs = append(attrs, html.Attribute{Key: liveUploadChunk, Val: strconv.FormatInt(h.UploadChunkSize, 10)})
+	}
+	if h.binary {
+		attrs = append(attrs, html.Attribute{Key: liveBinary})
+	}
+	return attrs
+}
+
 // assignWS connect a web socket to a socket.
 func (s *HttpSocket) assignWS(ws *websocket.Conn) {
 	s.closeSlow = func() {
@@ -470,42 +513,144 @@ func (s *HttpSocket) assignWS(ws *websocket.Conn) {
 	}
 }
 
+// httpConn a nhooyr.io/websocket connection.
+type httpConn struct {
+	c *websocket.Conn
+	// version the protocol version the client speaks.
+	version int
+	// cbor events are binary encoded.
+	cbor bool
+}
+
+func newHTTPConn(c *websocket.Conn) httpConn {
+	version, cbor := parseSubprotocol(c.Subprotocol())
+	return httpConn{c: c, version: version, cbor: cbor}
+}
+
+func (c httpConn) protocolVersion() int {
+	return c.version
+}
+
+func (c httpConn) read(ctx context.Context) (bool, []byte, error) {
+	t, d, err := c.c.Read(ctx)
+	return t == websocket.MessageText, d, err
+}
+
+func (c httpConn) write(ctx context.Context, data []byte) error {
+	if c.cbor {
+		return c.c.Write(ctx, websocket.MessageBinary, data)
+	}
+	return c.c.Write(ctx, websocket.MessageText, data)
+}
+
+func (c httpConn) binaryEncoded() bool {
+	return c.cbor
+}
+
+// closeReason works out why a websocket connection closed from the error it
+// closed with.
+func closeReason(err error) CloseReason {
+	switch websocket.CloseStatus(err) {
+	case websocket.StatusNormalClosure, websocket.StatusGoingAway:
+		return CloseNormal
+	}
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return CloseShutdown
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return CloseTimeout
+	}
+	return CloseError
+}
+
 func httpContext(w http.ResponseWriter, r *http.Request) context.Context {
 	ctx := r.Context()
 	ctx = contextWithRequest(ctx, r)
 	ctx = contextWithWriter(ctx, w)
+	ctx = contextWithConnInfo(ctx, newConnInfo(r))
 	return ctx
 }
 
-func writeTimeout(ctx context.Context, timeout time.Duration, c *websocket.Conn, msg Event) error {
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+// CookieStore a `gorilla/sessions` based cookie store.
+type CookieStore struct {
+	Store       *sessions.CookieStore
+	sessionName string // session name.
+	// keys encrypt the values of the session, if set.
+	keys *Keyring
+	// secureSet whether WithSecure was used, otherwise the cookie is secure
+	// when the request came over TLS.
+	secureSet bool
+}
 
-	data, err := json.Marshal(&msg)
-	if err != nil {
-		return fmt.Errorf("failed writeTimeout: %w", err)
+// CookieStoreConfig applies config to a cookie store.
+type CookieStoreConfig func(c *CookieStore) error
+
+// WithSecure set whether the session cookie is only sent over HTTPS. By
+// default it is when the request was made over TLS.
+func WithSecure(secure bool) CookieStoreConfig {
+	return func(c *CookieStore) error {
+		c.Store.Options.Secure = secure
+		c.secureSet = true
+		return nil
 	}
+}
 
-	return c.Write(ctx, websocket.MessageText, data)
+// WithSameSite set the SameSite mode of the session cookie.
+func WithSameSite(mode http.SameSite) CookieStoreConfig {
+	return func(c *CookieStore) error {
+		c.Store.Options.SameSite = mode
+		return nil
+	}
 }
 
-// CookieStore a `gorilla/sessions` based cookie store.
-type CookieStore struct {
-	Store       *sessions.CookieStore
-	sessionName string // session name.
+// WithDomain set the domain of the session cookie.
+func WithDomain(domain string) CookieStoreConfig {
+	return func(c *CookieStore) error {
+		c.Store.Options.Domain = domain
+		return nil
+	}
+}
+
+// WithMaxAge set the max age of the session cookie in seconds.
+func WithMaxAge(maxAge int) CookieStoreConfig {
+	return func(c *CookieStore) error {
+		c.Store.MaxAge(maxAge)
+		return nil
+	}
+}
+
+// WithPath set the path of the session cookie, use this when the handler is
+// mounted under a sub path.
+func WithPath(path string) CookieStoreConfig {
+	return func(c *CookieStore) error {
+		c.Store.Options.Path = path
+		return nil
+	}
 }
 
 // NewCookieStore create a new `gorilla/sessions` based cookie store.
 func NewCookieStore(sessionName string, keyPairs ...[]byte) *CookieStore {
+	return NewCookieStoreWithConfig(sessionName, keyPairs)
+}
+
+// NewCookieStoreWithConfig create a new `gorilla/sessions` based cookie store
+// and apply config to it.
+func NewCookieStoreWithConfig(sessionName string, keyPairs [][]byte, configs ...CookieStoreConfig) *CookieStore {
 	s := sessions.NewCookieStore(keyPairs...)
 	s.Options.HttpOnly = true
 	s.Options.Secure = false
 	s.Options.SameSite = http.SameSiteStrictMode
 
-	return &CookieStore{
+	c := &CookieStore{
 		Store:       s,
 		sessionName: sessionName,
 	}
+	for _, conf := range configs {
+		if err := conf(c); err != nil {
+			slog.Warn("could not apply config to cookie store", "error", err)
+		}
+	}
+	return c
 }
 
 // Get get a session.
@@ -528,6 +673,13 @@ func (c CookieStore) Get(r *http.Request) (Session, error) {
 			sess = ns
 		}
 	}
+	if c.keys != nil {
+		open, err := openSession(c.keys, sess)
+		if err != nil {
+			return NewSession(), err
+		}
+		sess = open
+	}
 	return sess, nil
 }
 
@@ -537,18 +689,47 @@ func (c CookieStore) Save(w http.ResponseWriter, r *http.Request, session Sessio
 	if err != nil {
 		return err
 	}
+	if c.keys != nil {
+		session, err = sealSession(c.keys, session)
+		if err != nil {
+			return err
+		}
+	}
 	s.Values[sessionCookie] = session
+	s.Options.Secure = c.secure(r)
 	return s.Save(r, w)
 }
 
+// secure whether the session cookie should only be sent over HTTPS.
+func (c CookieStore) secure(r *http.Request) bool {
+	if c.secureSet {
+		return c.Store.Options.Secure
+	}
+	return r.TLS != nil
+}
+
+// Regenerate a session. Cookie sessions keep nothing on the server, so the
+// new cookie replacing the old one is all there is to do.
+func (c CookieStore) Regenerate(w http.ResponseWriter, r *http.Request, old string, session Session) error {
+	return c.Save(w, r, session)
+}
+
 // Clear a session.
 func (c CookieStore) Clear(w http.ResponseWriter, r *http.Request) error {
+	path := c.Store.Options.Path
+	if path == "" {
+		path = "/"
+	}
 	http.SetCookie(w, &http.Cookie{
 		Name:     c.sessionName,
 		Value:    "",
-		Path:     "/",
+		Path:     path,
+		Domain:   c.Store.Options.Domain,
 		Expires:  time.Unix(0, 0),
-		HttpOnly: true,
+		MaxAge:   -1,
+		Secure:   c.secure(r),
+		HttpOnly: c.Store.Options.HttpOnly,
+		SameSite: c.Store.Options.SameSite,
 	})
 	return nil
 }
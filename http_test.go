@@ -1,6 +1,15 @@
 package live
 
-import "net/http"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nhooyr.io/websocket"
+)
 
 var _ HttpSessionStore = &TestStore{}
 
@@ -34,3 +43,75 @@ func (t *TestStore) Clear(w http.ResponseWriter, r *http.Request) error {
 	t.s = map[string]interface{}{}
 	return nil
 }
+
+func TestCloseReason(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason CloseReason
+	}{
+		{err: websocket.CloseError{Code: websocket.StatusGoingAway}, reason: CloseNormal},
+		{err: websocket.CloseError{Code: websocket.StatusNormalClosure}, reason: CloseNormal},
+		{err: nil, reason: CloseShutdown},
+		{err: fmt.Errorf("read: %w", context.Canceled), reason: CloseShutdown},
+		{err: fmt.Errorf("read: %w", context.DeadlineExceeded), reason: CloseTimeout},
+		{err: errors.New("broken pipe"), reason: CloseError},
+	}
+	for _, test := range tests {
+		if r := closeReason(test.err); r != test.reason {
+			t.Errorf("closeReason(%v) got %s want %s", test.err, r, test.reason)
+		}
+	}
+}
+
+func TestCookieStoreClear(t *testing.T) {
+	s := NewCookieStoreWithConfig("test", [][]byte{[]byte("secret")},
+		WithPath("/app"),
+		WithDomain("example.com"),
+		WithSecure(true),
+		WithSameSite(http.SameSiteLaxMode),
+	)
+
+	rr := httptest.NewRecorder()
+	if err := s.Clear(rr, httptest.NewRequest("GET", "/app", nil)); err != nil {
+		t.Fatal(err)
+	}
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected one cookie, got %d", len(cookies))
+	}
+	c := cookies[0]
+	if c.Path != "/app" || c.Domain != "example.com" || !c.Secure || c.SameSite != http.SameSiteLaxMode {
+		t.Errorf("clear cookie did not respect options: %+v", c)
+	}
+	if c.MaxAge >= 0 {
+		t.Errorf("expected cookie to be expired, got max age %d", c.MaxAge)
+	}
+}
+
+func TestCookieStoreSecure(t *testing.T) {
+	save := func(s *CookieStore, r *http.Request) *http.Cookie {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		if err := s.Save(rr, r, NewSession()); err != nil {
+			t.Fatal(err)
+		}
+		cookies := rr.Result().Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("expected one cookie, got %d", len(cookies))
+		}
+		return cookies[0]
+	}
+
+	s := NewCookieStore("test", []byte("secret"))
+	if c := save(s, httptest.NewRequest("GET", "https://example.com/", nil)); !c.Secure {
+		t.Error("expected the cookie to be secure over TLS")
+	}
+	if c := save(s, httptest.NewRequest("GET", "http://example.com/", nil)); c.Secure {
+		t.Error("expected the cookie not to be secure over plain HTTP")
+	}
+
+	s = NewCookieStoreWithConfig("test", [][]byte{[]byte("secret")}, WithSecure(false))
+	if c := save(s, httptest.NewRequest("GET", "https://example.com/", nil)); c.Secure {
+		t.Error("expected WithSecure to override the default")
+	}
+}
@@ -0,0 +1,178 @@
+package live
+
+import (
+	"html/template"
+	"net/http"
+	"sync"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// sessionLocale the session key a users chosen locale is kept under.
+const sessionLocale = "_locale"
+
+// LocaleFunc picks the locale for a socket from the request which started it
+// and its session.
+type LocaleFunc func(r *http.Request, session Session) string
+
+// WithLocaleNegotiation use a function to pick the locale of each socket. By
+// default a locale stored with SetSessionLocale is used, then the
+// Accept-Language header.
+func WithLocaleNegotiation(f LocaleFunc) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.localeFunc = f
+		case *HttpEngine:
+			v.localeFunc = f
+		}
+		return nil
+	}
+}
+
+// NegotiateLocale returns a LocaleFunc which matches the session locale, or
+// the Accept-Language header, against the supported locales. The first
+// supported locale is used when nothing matches.
+func NegotiateLocale(supported ...string) LocaleFunc {
+	tags := make([]language.Tag, 0, len(supported))
+	for _, s := range supported {
+		tags = append(tags, parseLocale(s))
+	}
+	matcher := language.NewMatcher(tags)
+	return func(r *http.Request, session Session) string {
+		_, idx := language.MatchStrings(matcher, SessionLocale(session), r.Header.Get("Accept-Language"))
+		return supported[idx]
+	}
+}
+
+// SetSessionLocale store a users chosen locale in their session, it is used
+// when negotiating the locale of their sockets.
+func SetSessionLocale(session Session, locale string) {
+	session[sessionLocale] = locale
+}
+
+// SessionLocale returns the locale stored in a session with SetSessionLocale.
+func SessionLocale(session Session) string {
+	locale, _ := session[sessionLocale].(string)
+	return locale
+}
+
+// negotiateLocale picks the locale for a socket.
+func (e *BaseEngine) negotiateLocale(r *http.Request, session Session) string {
+	if e.localeFunc != nil {
+		return e.localeFunc(r, session)
+	}
+	if locale := SessionLocale(session); locale != "" {
+		return locale
+	}
+	return requestLocale(r)
+}
+
+// Catalog provides translated messages. Plural messages are stored under the
+// key suffixed with the CLDR plural form, for example "items.one" and
+// "items.other".
+type Catalog interface {
+	// Message returns the message for a key in a locale, ok is false if
+	// there isn't one.
+	Message(locale, key string) (msg string, ok bool)
+}
+
+// MapCatalog a Catalog of messages keyed by locale then message key.
+type MapCatalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+// NewMapCatalog creates a catalog from messages keyed by locale then message
+// key.
+func NewMapCatalog(messages map[string]map[string]string) *MapCatalog {
+	if messages == nil {
+		messages = map[string]map[string]string{}
+	}
+	return &MapCatalog{messages: messages}
+}
+
+// Set a message in the catalog.
+func (c *MapCatalog) Set(locale, key, msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.messages[locale]; !ok {
+		c.messages[locale] = map[string]string{}
+	}
+	c.messages[locale][key] = msg
+}
+
+// Message returns the message for a key in a locale.
+func (c *MapCatalog) Message(locale, key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	msg, ok := c.messages[locale][key]
+	return msg, ok
+}
+
+// I18nFuncs returns translation functions for templates backed by a catalog.
+// Like FormatFuncs each takes the locale as its first argument.
+//
+//	{{ t .Locale "greeting" .Assigns.Name }}  Hello, Ada
+//	{{ plural .Locale "items" 3 }}            3 items
+//
+// Messages are formatted with the locale's printer, so may contain verbs
+// such as %s and %d. A message is looked up in the locale, then its base
+// language, and the key is returned if there is no message.
+func I18nFuncs(c Catalog) template.FuncMap {
+	return template.FuncMap{
+		"t": func(locale, key string, args ...interface{}) string {
+			return Translate(c, locale, key, args...)
+		},
+		"plural": func(locale, key string, n int, args ...interface{}) string {
+			return TranslatePlural(c, locale, key, n, args...)
+		},
+	}
+}
+
+// Translate looks up a message and formats it with the args.
+func Translate(c Catalog, locale, key string, args ...interface{}) string {
+	msg, ok := lookupMessage(c, locale, key)
+	if !ok {
+		return key
+	}
+	return printer(locale).Sprintf(msg, args...)
+}
+
+// TranslatePlural looks up the plural form of a message for n and formats it
+// with n followed by the args.
+func TranslatePlural(c Catalog, locale, key string, n int, args ...interface{}) string {
+	form := pluralForms[plural.Cardinal.MatchPlural(parseLocale(locale), n, 0, 0, 0, 0)]
+	msg, ok := lookupMessage(c, locale, key+"."+form)
+	if !ok {
+		msg, ok = lookupMessage(c, locale, key+".other")
+	}
+	if !ok {
+		return key
+	}
+	return printer(locale).Sprintf(msg, append([]interface{}{n}, args...)...)
+}
+
+// pluralForms the catalog suffix for each plural form.
+var pluralForms = map[plural.Form]string{
+	plural.Other: "other",
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+}
+
+// lookupMessage finds a message in the locale, falling back to its base
+// language.
+func lookupMessage(c Catalog, locale, key string) (string, bool) {
+	if msg, ok := c.Message(locale, key); ok {
+		return msg, true
+	}
+	base, _ := parseLocale(locale).Base()
+	if base.String() != locale {
+		return c.Message(base.String(), key)
+	}
+	return "", false
+}
@@ -0,0 +1,61 @@
+package live
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateLocale(t *testing.T) {
+	f := NegotiateLocale("en", "fr", "de")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "de-CH, fr;q=0.8")
+	if got := f(r, NewSession()); got != "de" {
+		t.Errorf("expected de from header got %s", got)
+	}
+
+	session := NewSession()
+	SetSessionLocale(session, "fr")
+	if got := f(r, session); got != "fr" {
+		t.Errorf("expected fr from session got %s", got)
+	}
+
+	r.Header.Set("Accept-Language", "ja")
+	if got := f(r, NewSession()); got != "en" {
+		t.Errorf("expected default en got %s", got)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	c := NewMapCatalog(map[string]map[string]string{
+		"en": {
+			"greeting":    "Hello, %s",
+			"items.one":   "%d item",
+			"items.other": "%d items",
+		},
+		"fr": {
+			"greeting":    "Bonjour, %s",
+			"items.one":   "%d article",
+			"items.other": "%d articles",
+		},
+	})
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{Translate(c, "en", "greeting", "Ada"), "Hello, Ada"},
+		{Translate(c, "fr-CA", "greeting", "Ada"), "Bonjour, Ada"},
+		{Translate(c, "en", "missing"), "missing"},
+		{TranslatePlural(c, "en", "items", 1), "1 item"},
+		{TranslatePlural(c, "en", "items", 3), "3 items"},
+		{TranslatePlural(c, "fr", "items", 0), "0 article"},
+		{TranslatePlural(c, "en", "items", 1000), "1,000 items"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q got %q", tt.want, tt.got)
+		}
+	}
+}
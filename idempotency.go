@@ -0,0 +1,120 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// idempotencyEntry the result of an event, done is closed once the event
+// has been handled.
+type idempotencyEntry struct {
+	done    chan struct{}
+	err     error
+	reply   json.RawMessage
+	expires time.Time
+}
+
+// idempotencyCache stores event results by idempotency key so that retried
+// events are not handled twice.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*idempotencyEntry
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		ttl:     ttl,
+		entries: map[string]*idempotencyEntry{},
+	}
+}
+
+// WithIdempotency enables idempotency keys on events. Results of events carrying a
+// key are stored for the given ttl, retries of the event within that time are
+// acknowledged with the stored result rather than being handled again. A
+// retry of an event which is still being handled, for example one sent again
+// after reconnecting, waits for its result.
+func WithIdempotency(ttl time.Duration) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.idempotency = newIdempotencyCache(ttl)
+		case *HttpEngine:
+			v.idempotency = newIdempotencyCache(ttl)
+		}
+		return nil
+	}
+}
+
+// idempotencyKey scopes an event key to the sockets session.
+func idempotencyKey(sock Socket, msg Event) string {
+	return SessionID(sock.Session()) + ":" + msg.Key
+}
+
+// reserve returns the entry for an event, and true if this is the first time
+// the event has been seen and so it should be handled. Entries are only
+// expired once their event has been handled.
+func (c *idempotencyCache) reserve(sock Socket, msg Event) (*idempotencyEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, entry := range c.entries {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	key := idempotencyKey(sock, msg)
+	if entry, ok := c.entries[key]; ok {
+		return entry, false
+	}
+	entry := &idempotencyEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	return entry, true
+}
+
+// set stores the result for an event, releasing any retries waiting for it.
+func (c *idempotencyCache) set(sock Socket, msg Event, err error, reply json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := idempotencyKey(sock, msg)
+	entry, ok := c.entries[key]
+	if !ok || !entry.expires.IsZero() {
+		entry = &idempotencyEntry{done: make(chan struct{})}
+		c.entries[key] = entry
+	}
+	entry.err = err
+	entry.reply = reply
+	entry.expires = time.Now().Add(c.ttl)
+	close(entry.done)
+}
+
+// idempotentResult returns the result and reply of an event if it has
+// already been handled, waiting for it if it is still being handled.
+// Otherwise the event's key is reserved, and its result must be stored with
+// storeIdempotentResult.
+func (e *BaseEngine) idempotentResult(ctx context.Context, sock Socket, msg Event) (error, json.RawMessage, bool) {
+	if e.idempotency == nil || msg.Key == "" {
+		return nil, nil, false
+	}
+	entry, first := e.idempotency.reserve(sock, msg)
+	if first {
+		return nil, nil, false
+	}
+	select {
+	case <-entry.done:
+		return entry.err, entry.reply, true
+	case <-ctx.Done():
+		return ctx.Err(), nil, true
+	}
+}
+
+// storeIdempotentResult stores the result of handling an event, and the
+// reply its handler set.
+func (e *BaseEngine) storeIdempotentResult(sock Socket, msg Event, err error, reply json.RawMessage) {
+	if e.idempotency == nil || msg.Key == "" {
+		return
+	}
+	e.idempotency.set(sock, msg, err, reply)
+}
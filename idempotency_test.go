@@ -0,0 +1,149 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotentResult(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+	WithIdempotency(time.Minute)(e)
+	sock := NewBaseSocket(NewSession(), e, true)
+	ctx := context.Background()
+	buy := Event{T: "buy", Key: "order-1"}
+
+	// Miss, the event is handled and its result stored.
+	if _, _, ok := e.idempotentResult(ctx, sock, buy); ok {
+		t.Fatal("expected a miss for a new key")
+	}
+	failed := errors.New("out of stock")
+	e.storeIdempotentResult(sock, buy, failed, json.RawMessage(`"order-1"`))
+
+	// Hit.
+	res, reply, ok := e.idempotentResult(ctx, sock, buy)
+	if !ok || res != failed {
+		t.Fatalf("expected the stored result, got %v %v", res, ok)
+	}
+	if string(reply) != `"order-1"` {
+		t.Errorf("expected the stored reply, got %s", reply)
+	}
+
+	// Other keys, and other sessions, miss.
+	if _, _, ok := e.idempotentResult(ctx, sock, Event{T: "buy", Key: "order-2"}); ok {
+		t.Error("expected a miss for another key")
+	}
+	other := NewBaseSocket(NewSession(), e, true)
+	if _, _, ok := e.idempotentResult(ctx, other, buy); ok {
+		t.Error("expected a miss for another session")
+	}
+
+	// Events without keys are always handled.
+	if _, _, ok := e.idempotentResult(ctx, sock, Event{T: "buy"}); ok {
+		t.Error("expected events without keys to be handled")
+	}
+}
+
+func TestIdempotentResultExpires(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+	WithIdempotency(10 * time.Millisecond)(e)
+	sock := NewBaseSocket(NewSession(), e, true)
+	ctx := context.Background()
+	buy := Event{T: "buy", Key: "order-1"}
+
+	if _, _, ok := e.idempotentResult(ctx, sock, buy); ok {
+		t.Fatal("expected a miss for a new key")
+	}
+	e.storeIdempotentResult(sock, buy, nil, nil)
+	time.Sleep(20 * time.Millisecond)
+	if _, _, ok := e.idempotentResult(ctx, sock, buy); ok {
+		t.Error("expected the result to have expired")
+	}
+}
+
+func TestIdempotentRetryInFlight(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := testRenderHandler()
+	h.HandleEvent("buy", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	WithIdempotency(time.Minute)(e)
+
+	// The retry comes in on a new connection for the same session while the
+	// first is still being handled.
+	session := NewSession()
+	serve := func() *testConn {
+		c := newTestConn()
+		sock := NewBaseSocket(session, e, true)
+		e.AddSocket(sock)
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go e.serveSocket(ctx, e, sock, c, httptest.NewRequest("GET", "/", nil))
+		return c
+	}
+	first := serve()
+	first.in <- Event{T: "buy", ID: 1, Key: "order-1"}
+	<-started
+
+	retry := serve()
+	retry.in <- Event{T: "buy", ID: 1, Key: "order-1"}
+	select {
+	case m := <-retry.out:
+		if m.T == EventAck {
+			t.Fatal("retry was acknowledged before the first event was handled")
+		}
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	first.expectAck(t, 1)
+	retry.expectAck(t, 1)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected the handler to be called once, got %d", n)
+	}
+}
+
+func TestIdempotentRetryReply(t *testing.T) {
+	var orders atomic.Int32
+	h := testRenderHandler()
+	h.HandleEvent("buy", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, s.Reply(ctx, orders.Add(1))
+	})
+	h.HandleEvent("look", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, s.Reply(ctx, "looked")
+	})
+	h.HandleEvent("noop", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	WithIdempotency(time.Minute)(e)
+	c := serveTestSocket(t, e)
+
+	// A retry gets the reply of the first attempt.
+	c.in <- Event{T: "buy", ID: 1, Key: "order-1"}
+	first := c.expectAck(t, 1)
+	c.in <- Event{T: "buy", ID: 2, Key: "order-1"}
+	retry := c.expectAck(t, 2)
+	if string(first.Data) != "1" || string(retry.Data) != "1" {
+		t.Errorf("expected both acks to reply 1, got %s and %s", first.Data, retry.Data)
+	}
+
+	// A reply set by a preview isn't sent with the next event.
+	c.in <- Event{T: "look", ID: 3, Preview: true}
+	c.expectAck(t, 3)
+	c.in <- Event{T: "noop", ID: 4}
+	if ack := c.expectAck(t, 4); len(ack.Data) != 0 && string(ack.Data) != "null" {
+		t.Errorf("expected no reply, got %s", ack.Data)
+	}
+}
@@ -0,0 +1,22 @@
+package live
+
+import (
+	"time"
+)
+
+// WithIdleTimeout disconnects sockets which haven't sent an event for the
+// duration, so that abandoned tabs don't keep their state and subscriptions
+// on the server. Heartbeats keep a connection alive but are not activity. The
+// client is sent EventIdle first, and reconnects when the user comes back to
+// the page.
+func WithIdleTimeout(d time.Duration) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.idleTimeout = d
+		case *HttpEngine:
+			v.idleTimeout = d
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdleTimeout(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleEvent("poke", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	WithIdleTimeout(60 * time.Millisecond)(e)
+	c := newTestConn()
+	sock := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(sock)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- e.serveSocket(ctx, e, sock, c, httptest.NewRequest("GET", "/", nil))
+	}()
+
+	// Events keep the socket open, heartbeats don't.
+	for i := 1; i <= 3; i++ {
+		c.in <- Event{T: "poke", ID: i}
+		c.expectAck(t, i)
+		time.Sleep(30 * time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("socket closed while sending events: %v", err)
+	default:
+	}
+	c.in <- Event{T: EventHeartbeat}
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case m := <-c.out:
+			if m.T != EventIdle {
+				continue
+			}
+			err := <-done
+			if !errors.Is(err, ErrSocketIdle) {
+				t.Errorf("expected idle error, got %v", err)
+			}
+			if closeReason(err) != CloseTimeout {
+				t.Errorf("expected timeout close reason, got %s", closeReason(err))
+			}
+			return
+		case <-timeout:
+			t.Fatal("idle socket was not disconnected")
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package live
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// WithIgnorePaths stops the handler serving requests whose path matches any of
+// the patterns, so that it can be mounted at "/" alongside things like
+// robots.txt and /.well-known. Patterns are matched with path.Match, and a
+// pattern ending in "/" matches everything under it. Ignored requests are
+// passed to the handler set with WithFallback, or are not found.
+//
+//	live.WithIgnorePaths("/robots.txt", "/.well-known/", "/static/")
+func WithIgnorePaths(patterns ...string) EngineConfig {
+	return func(e Engine) error {
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			httpEngine.ignorePaths = append(httpEngine.ignorePaths, patterns...)
+		}
+		return nil
+	}
+}
+
+// WithFallback serves requests the handler ignores, those matching
+// WithIgnorePaths and /favicon.ico, with another handler.
+//
+//	live.WithFallback(http.FileServerFS(static))
+func WithFallback(fallback http.Handler) EngineConfig {
+	return func(e Engine) error {
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			httpEngine.fallback = fallback
+		}
+		return nil
+	}
+}
+
+// ignored checks if a request should not be served by live.
+func (h *HttpEngine) ignored(r *http.Request) bool {
+	p := r.URL.Path
+	if p == "/favicon.ico" && h.IgnoreFaviconRequest {
+		return true
+	}
+	for _, pattern := range h.ignorePaths {
+		if strings.HasSuffix(pattern, "/") {
+			if strings.HasPrefix(p, pattern) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// serveIgnored serves a request which live ignores.
+func (h *HttpEngine) serveIgnored(w http.ResponseWriter, r *http.Request) {
+	if h.fallback != nil {
+		h.fallback.ServeHTTP(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
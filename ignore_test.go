@@ -0,0 +1,53 @@
+package live
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIgnorePaths(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body>live</body></html>`), nil
+	})
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fallback"))
+	})
+	e := NewHttpHandler(NewTestStore("test"), h,
+		WithIgnorePaths("/robots.txt", "/.well-known/", "/*.png"),
+		WithFallback(fallback),
+	)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{path: "/", body: "live"},
+		{path: "/room", body: "live"},
+		{path: "/robots.txt", body: "fallback"},
+		{path: "/.well-known/security.txt", body: "fallback"},
+		{path: "/logo.png", body: "fallback"},
+		{path: "/img/logo.png", body: "live"},
+		{path: "/favicon.ico", body: "fallback"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: expected %s in %s", tt.path, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestIgnorePathsNotFound(t *testing.T) {
+	e := NewHttpHandler(NewTestStore("test"), NewHandler(), WithIgnorePaths("/robots.txt"))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected not found, got %d", w.Code)
+	}
+}
@@ -1,8 +1,12 @@
 package live
 
 import (
+	"bytes"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"net/http"
+	"time"
 )
 
 var (
@@ -13,17 +17,35 @@ var (
 	// JSMap is the contents of auto.js.map
 	//go:embed web/browser/auto.js.map
 	JSMap []byte
+
+	// jsDebug is the contents of auto.dev.js, the unminified build of the
+	// client generated alongside auto.js.
+	//go:embed web/browser/auto.dev.js
+	jsDebug []byte
+)
+
+// The ETags of the embedded assets, computed once as they don't change.
+var (
+	jsETag      = assetETag(JS)
+	jsMapETag   = assetETag(JSMap)
+	jsDebugETag = assetETag(jsDebug)
 )
 
 // Javascript handles serving the client side
-// portion of live.
+// portion of live. Responses carry an ETag so
+// browsers only download the script when it changes.
 type Javascript struct {
+	// Debug serve the unminified build of the client.
+	Debug bool
 }
 
 // ServeHTTP.
 func (j Javascript) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "text/javascript")
-	w.Write(JS)
+	content, etag := JS, jsETag
+	if j.Debug {
+		content, etag = jsDebug, jsDebugETag
+	}
+	serveAsset(w, r, "text/javascript", content, etag)
 }
 
 // JavascriptMap handles serving source map.
@@ -32,6 +54,19 @@ type JavascriptMap struct {
 
 // ServeHTTP.
 func (j JavascriptMap) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(JSMap)
+	serveAsset(w, r, "application/json", JSMap, jsMapETag)
+}
+
+// assetETag the ETag of embedded content.
+func assetETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// serveAsset serve embedded content with caching headers.
+func serveAsset(w http.ResponseWriter, r *http.Request, contentType string, content []byte, etag string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=0, must-revalidate")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
 }
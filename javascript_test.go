@@ -0,0 +1,47 @@
+package live
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJavascriptETag(t *testing.T) {
+	w := httptest.NewRecorder()
+	Javascript{}.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/live.js", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", w.Code)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an etag")
+	}
+	if w.Body.Len() != len(JS) {
+		t.Errorf("expected %d bytes got %d", len(JS), w.Body.Len())
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/live.js", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	Javascript{}.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 got %d", w.Code)
+	}
+}
+
+func TestJavascriptDebug(t *testing.T) {
+	w := httptest.NewRecorder()
+	Javascript{Debug: true}.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/live.js", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", w.Code)
+	}
+	if len(jsDebug) == 0 || w.Body.Len() != len(jsDebug) {
+		t.Errorf("expected the debug build, got %d bytes", w.Body.Len())
+	}
+	if w.Body.Len() == len(JS) {
+		t.Error("expected the debug build to differ from the minified build")
+	}
+	if etag := w.Header().Get("ETag"); etag != jsDebugETag || etag == jsETag {
+		t.Errorf("expected the debug build's own etag, got %s", etag)
+	}
+}
@@ -0,0 +1,182 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// jobRetention how long a finished job is kept so a reconnecting socket can
+// find out how it finished.
+const jobRetention = 5 * time.Minute
+
+// JobFunc the work a job does, it should report progress on the job and
+// stop when the context is cancelled.
+type JobFunc func(ctx context.Context, job *Job) error
+
+// JobProgress the progress of a job, sent to the socket as the data of the
+// jobs self event.
+type JobProgress struct {
+	// JobID the ID of the job.
+	JobID string
+	// Percent how complete the job is, from 0 to 100.
+	Percent float64
+	// Message describing what the job is doing.
+	Message string
+	// Done the job has finished.
+	Done bool
+	// Err the error the job failed with.
+	Err error
+}
+
+// Job a long running task started from a socket, which reports its progress
+// to the socket as self events. Only the session which started a job can find
+// it again, and a socket which reconnects takes over the job's progress by
+// finding it with FindJob.
+type Job struct {
+	// ID identifies the job.
+	ID string
+
+	event   string
+	session string
+	engine  Engine
+	cancel  context.CancelFunc
+
+	mu sync.RWMutex
+	// socket the socket progress is reported to.
+	socket SocketID
+	last   JobProgress
+}
+
+// Progress reports the progress of the job to its socket.
+func (j *Job) Progress(percent float64, message string) {
+	j.report(JobProgress{JobID: j.ID, Percent: percent, Message: message})
+}
+
+// Status returns the last progress the job reported.
+func (j *Job) Status() JobProgress {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.last
+}
+
+// Cancel cancels the jobs context.
+func (j *Job) Cancel() {
+	j.cancel()
+}
+
+// report records the progress and sends it to the job's socket, if it is
+// connected.
+func (j *Job) report(p JobProgress) {
+	j.mu.Lock()
+	j.last = p
+	id := j.socket
+	j.mu.Unlock()
+
+	sock, err := j.engine.GetSocketByID(id)
+	if err != nil {
+		return
+	}
+	sock.Self(context.Background(), j.event, p)
+}
+
+// jobRegistry the jobs running on an engine.
+type jobRegistry struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// jobRunner is implemented by engines which run jobs.
+type jobRunner interface {
+	jobRegistry() *jobRegistry
+}
+
+func (e *BaseEngine) jobRegistry() *jobRegistry {
+	return e.jobs
+}
+
+// engineHolder is implemented by sockets which know their engine.
+type engineHolder interface {
+	socketEngine() Engine
+}
+
+// socketEngine returns the engine a socket belongs to.
+func socketEngine(s Socket) Engine {
+	if b, ok := s.(engineHolder); ok {
+		return b.socketEngine()
+	}
+	return nil
+}
+
+// StartJob runs a job in the background, its progress is sent to the socket
+// as the data of the event.
+func StartJob(s Socket, event string, fn JobFunc) (*Job, error) {
+	e, ok := socketEngine(s).(jobRunner)
+	if !ok {
+		return nil, fmt.Errorf("engine does not support jobs: %w", ErrNotImplemented)
+	}
+	registry := e.jobRegistry()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &Job{
+		ID:      NewID(),
+		event:   event,
+		session: SessionID(s.Session()),
+		engine:  socketEngine(s),
+		cancel:  cancel,
+		socket:  s.ID(),
+	}
+	j.last = JobProgress{JobID: j.ID}
+
+	registry.mu.Lock()
+	registry.jobs[j.ID] = j
+	registry.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		err := func() (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("job panic: %v", r)
+				}
+			}()
+			return fn(ctx, j)
+		}()
+		last := j.Status()
+		last.Done = true
+		last.Err = err
+		if err == nil {
+			last.Percent = 100
+		}
+		j.report(last)
+
+		time.AfterFunc(jobRetention, func() {
+			registry.mu.Lock()
+			delete(registry.jobs, j.ID)
+			registry.mu.Unlock()
+		})
+	}()
+	return j, nil
+}
+
+// FindJob finds a job started by the sockets session, for example to show its
+// progress after the socket reconnects. The job's progress is sent to the
+// socket from then on.
+func FindJob(s Socket, id string) (*Job, error) {
+	e, ok := socketEngine(s).(jobRunner)
+	if !ok {
+		return nil, fmt.Errorf("engine does not support jobs: %w", ErrNotImplemented)
+	}
+	registry := e.jobRegistry()
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	j, ok := registry.jobs[id]
+	if !ok || j.session != SessionID(s.Session()) {
+		return nil, ErrJobNotFound
+	}
+	j.mu.Lock()
+	j.socket = s.ID()
+	j.mu.Unlock()
+	return j, nil
+}
@@ -0,0 +1,112 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJob(t *testing.T) {
+	progress := make(chan JobProgress, 8)
+	h := NewHandler()
+	h.HandleSelf("export", func(ctx context.Context, s Socket, data interface{}) (interface{}, error) {
+		progress <- data.(JobProgress)
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	session := NewSession()
+	s := NewBaseSocket(session, e, true)
+	e.AddSocket(s)
+
+	step := make(chan struct{})
+	j, err := StartJob(s, "export", func(ctx context.Context, job *Job) error {
+		job.Progress(50, "halfway")
+		<-step
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := <-progress; p.Percent != 50 || p.Message != "halfway" {
+		t.Errorf("unexpected progress %+v", p)
+	}
+
+	// The socket reconnects, the job carries on reporting to the new socket.
+	e.DeleteSocket(s)
+	reconnected := NewBaseSocket(session, e, true)
+	e.AddSocket(reconnected)
+	found, err := FindJob(reconnected, j.ID)
+	if err != nil || found != j {
+		t.Fatalf("expected to find the job, got %v", err)
+	}
+	close(step)
+	select {
+	case p := <-progress:
+		if !p.Done || p.Percent != 100 || p.Err != nil {
+			t.Errorf("unexpected final progress %+v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected final progress")
+	}
+
+	// Other sessions can't find the job.
+	if _, err := FindJob(NewBaseSocket(NewSession(), e, true), j.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound got %v", err)
+	}
+}
+
+func TestJobReportsToItsSocket(t *testing.T) {
+	reported := make(chan SocketID, 8)
+	h := NewHandler()
+	h.HandleSelf("export", func(ctx context.Context, s Socket, data interface{}) (interface{}, error) {
+		reported <- s.ID()
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	session := NewSession()
+	s := NewBaseSocket(session, e, true)
+	other := NewBaseSocket(session, e, true)
+	e.AddSocket(other)
+	e.AddSocket(s)
+
+	step := make(chan struct{})
+	j, err := StartJob(s, "export", func(ctx context.Context, job *Job) error {
+		job.Progress(50, "halfway")
+		<-step
+		job.Progress(75, "nearly")
+		<-step
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := <-reported; id != s.ID() {
+		t.Errorf("expected progress on the starting socket, got %s", id)
+	}
+
+	// The session's other tab doesn't get the job's progress.
+	e.DeleteSocket(s)
+	step <- struct{}{}
+	select {
+	case id := <-reported:
+		t.Fatalf("expected no progress while the socket is gone, got it on %s", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Until the reconnected socket finds the job.
+	reconnected := NewBaseSocket(session, e, true)
+	e.AddSocket(reconnected)
+	if _, err := FindJob(reconnected, j.ID); err != nil {
+		t.Fatal(err)
+	}
+	close(step)
+	select {
+	case id := <-reported:
+		if id != reconnected.ID() {
+			t.Errorf("expected progress on the reconnected socket, got %s", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected final progress")
+	}
+}
@@ -0,0 +1,14 @@
+package js
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func Example() {
+	cmds := Focus("#search").AddClass("#panel", "open")
+	b, _ := json.Marshal(cmds)
+	fmt.Println(string(b))
+	// Output:
+	// [{"op":"focus","to":"#search"},{"op":"add_class","to":"#panel","name":"open"}]
+}
@@ -0,0 +1,134 @@
+// Package js builds commands which the live client runs in the browser, for
+// small DOM behaviours that don't need a hook or another event.
+//
+//	s.Exec(js.Focus("#search").AddClass("#panel", "open"))
+//
+// Targets are css selectors, a command applies to every element matching its
+// selector.
+package js
+
+// Command a single command for the client to run.
+type Command struct {
+	// Op the operation to run.
+	Op string `json:"op"`
+	// To the selector of the elements the command applies to.
+	To string `json:"to"`
+	// Name the class, attribute or event name the command uses.
+	Name string `json:"name,omitempty"`
+	// Value the attribute value to set.
+	Value string `json:"value,omitempty"`
+	// Detail the detail of a dispatched event.
+	Detail interface{} `json:"detail,omitempty"`
+}
+
+const (
+	// OpFocus focus the first matching element.
+	OpFocus = "focus"
+	// OpBlur blur the matching elements.
+	OpBlur = "blur"
+	// OpAddClass add a class to the matching elements.
+	OpAddClass = "add_class"
+	// OpRemoveClass remove a class from the matching elements.
+	OpRemoveClass = "remove_class"
+	// OpToggleClass toggle a class on the matching elements.
+	OpToggleClass = "toggle_class"
+	// OpSetAttr set an attribute on the matching elements.
+	OpSetAttr = "set_attr"
+	// OpRemoveAttr remove an attribute from the matching elements.
+	OpRemoveAttr = "remove_attr"
+	// OpShow show the matching elements by removing their hidden attribute.
+	OpShow = "show"
+	// OpHide hide the matching elements with the hidden attribute.
+	OpHide = "hide"
+	// OpDispatch dispatch a CustomEvent on the matching elements.
+	OpDispatch = "dispatch"
+)
+
+// Commands a list of commands which are run in order.
+type Commands []Command
+
+// Focus focus the first element matching the selector.
+func Focus(selector string) Commands { return Commands{}.Focus(selector) }
+
+// Blur blur the elements matching the selector.
+func Blur(selector string) Commands { return Commands{}.Blur(selector) }
+
+// AddClass add a class to the elements matching the selector.
+func AddClass(selector, class string) Commands { return Commands{}.AddClass(selector, class) }
+
+// RemoveClass remove a class from the elements matching the selector.
+func RemoveClass(selector, class string) Commands { return Commands{}.RemoveClass(selector, class) }
+
+// ToggleClass toggle a class on the elements matching the selector.
+func ToggleClass(selector, class string) Commands { return Commands{}.ToggleClass(selector, class) }
+
+// SetAttr set an attribute on the elements matching the selector.
+func SetAttr(selector, name, value string) Commands {
+	return Commands{}.SetAttr(selector, name, value)
+}
+
+// RemoveAttr remove an attribute from the elements matching the selector.
+func RemoveAttr(selector, name string) Commands { return Commands{}.RemoveAttr(selector, name) }
+
+// Show show the elements matching the selector.
+func Show(selector string) Commands { return Commands{}.Show(selector) }
+
+// Hide hide the elements matching the selector.
+func Hide(selector string) Commands { return Commands{}.Hide(selector) }
+
+// Dispatch dispatch a bubbling CustomEvent with the detail on the elements
+// matching the selector.
+func Dispatch(selector, event string, detail interface{}) Commands {
+	return Commands{}.Dispatch(selector, event, detail)
+}
+
+// Focus focus the first element matching the selector.
+func (c Commands) Focus(selector string) Commands {
+	return append(c, Command{Op: OpFocus, To: selector})
+}
+
+// Blur blur the elements matching the selector.
+func (c Commands) Blur(selector string) Commands {
+	return append(c, Command{Op: OpBlur, To: selector})
+}
+
+// AddClass add a class to the elements matching the selector.
+func (c Commands) AddClass(selector, class string) Commands {
+	return append(c, Command{Op: OpAddClass, To: selector, Name: class})
+}
+
+// RemoveClass remove a class from the elements matching the selector.
+func (c Commands) RemoveClass(selector, class string) Commands {
+	return append(c, Command{Op: OpRemoveClass, To: selector, Name: class})
+}
+
+// ToggleClass toggle a class on the elements matching the selector.
+func (c Commands) ToggleClass(selector, class string) Commands {
+	return append(c, Command{Op: OpToggleClass, To: selector, Name: class})
+}
+
+// SetAttr set an attribute on the elements matching the selector.
+func (c Commands) SetAttr(selector, name, value string) Commands {
+	return append(c, Command{Op: OpSetAttr, To: selector, Name: name, Value: value})
+}
+
+// RemoveAttr remove an attribute from the elements matching the selector.
+func (c Commands) RemoveAttr(selector, name string) Commands {
+	return append(c, Command{Op: OpRemoveAttr, To: selector, Name: name})
+}
+
+// Show show the elements matching the selector.
+func (c Commands) Show(selector string) Commands {
+	return append(c, Command{Op: OpShow, To: selector})
+}
+
+// Hide hide the elements matching the selector.
+func (c Commands) Hide(selector string) Commands {
+	return append(c, Command{Op: OpHide, To: selector})
+}
+
+// Dispatch dispatch a bubbling CustomEvent with the detail on the elements
+// matching the selector.
+func (c Commands) Dispatch(selector, event string, detail interface{}) Commands {
+	return append(c, Command{Op: OpDispatch, To: selector, Name: event, Detail: detail})
+}
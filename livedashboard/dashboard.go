@@ -0,0 +1,157 @@
+// Package livedashboard provides a live handler showing the stats of a live
+// engine, its sockets, event throughput, render latency and recent errors.
+//
+//	e := live.NewHttpHandler(store, h)
+//	http.Handle("/", e)
+//	http.Handle("/dashboard", live.NewHttpHandler(store, livedashboard.NewHandler(e)))
+//	http.Handle("/live.js", live.Javascript{})
+package livedashboard
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/jfyne/live"
+)
+
+// tick the self event which refreshes the dashboard.
+const tick = "tick"
+
+// Source is where the dashboard gets its stats, usually a live engine.
+type Source interface {
+	Stats() live.Stats
+}
+
+// Config configures the dashboard.
+type Config func(o *options) error
+
+type options struct {
+	interval time.Duration
+	script   string
+}
+
+// WithInterval sets how often the dashboard refreshes, defaults to a second.
+func WithInterval(d time.Duration) Config {
+	return func(o *options) error {
+		o.interval = d
+		return nil
+	}
+}
+
+// WithScript sets the path live.js is served at, defaults to "/live.js".
+func WithScript(path string) Config {
+	return func(o *options) error {
+		o.script = path
+		return nil
+	}
+}
+
+// EventCount the number of times an event has been handled.
+type EventCount struct {
+	Event string
+	Count uint64
+}
+
+// model the state of the dashboard.
+type model struct {
+	Stats live.Stats
+	// Throughput events handled per second since the last refresh.
+	Throughput float64
+	// Events the event counts, most handled first.
+	Events []EventCount
+	Script string
+	At     time.Time
+}
+
+// NewHandler creates a live handler showing the stats of the source.
+func NewHandler(source Source, configs ...Config) *live.BaseHandler {
+	o := &options{interval: time.Second, script: "/live.js"}
+	for _, conf := range configs {
+		if err := conf(o); err != nil {
+			slog.Warn("could not apply config to dashboard", "error", err)
+		}
+	}
+
+	h := live.NewHandler(live.WithTemplateRenderer(dashboardTemplate))
+	h.HandleMount(func(ctx context.Context, s live.Socket) (any, error) {
+		return newModel(source.Stats(), nil, o.script), nil
+	})
+	h.HandleConnect(func(ctx context.Context, s live.Socket) error {
+		s.SendEvery(tick, o.interval)
+		return nil
+	})
+	h.HandleSelf(tick, func(ctx context.Context, s live.Socket, _ any) (any, error) {
+		prev, _ := s.Assigns().(*model)
+		return newModel(source.Stats(), prev, o.script), nil
+	})
+	return h
+}
+
+// newModel builds the dashboard from stats, working out throughput since the
+// previous model.
+func newModel(stats live.Stats, prev *model, script string) *model {
+	m := &model{Stats: stats, Script: script, At: time.Now()}
+	for event, count := range stats.EventCounts {
+		m.Events = append(m.Events, EventCount{Event: event, Count: count})
+	}
+	sort.Slice(m.Events, func(i, j int) bool {
+		if m.Events[i].Count != m.Events[j].Count {
+			return m.Events[i].Count > m.Events[j].Count
+		}
+		return m.Events[i].Event < m.Events[j].Event
+	})
+	if prev != nil && stats.Events >= prev.Stats.Events {
+		if elapsed := m.At.Sub(prev.At).Seconds(); elapsed > 0 {
+			m.Throughput = float64(stats.Events-prev.Stats.Events) / elapsed
+		}
+	}
+	// Newest errors first.
+	errs := make([]live.StatsError, len(stats.Errors))
+	for i, e := range stats.Errors {
+		errs[len(errs)-1-i] = e
+	}
+	m.Stats.Errors = errs
+	return m
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!doctype html>
+<html>
+<head>
+<title>Live dashboard</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+.cards { display: flex; gap: 1em; flex-wrap: wrap; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 1em; min-width: 10em; }
+.card strong { display: block; font-size: 2em; }
+table { border-collapse: collapse; margin-top: 1em; }
+td, th { text-align: left; padding: 0.25em 1em 0.25em 0; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Live dashboard</h1>
+{{ with .Assigns }}
+<div class="cards">
+<div class="card"><strong>{{ .Stats.Sockets }}</strong>sockets</div>
+<div class="card"><strong>{{ printf "%.1f" .Throughput }}</strong>events / second</div>
+<div class="card"><strong>{{ .Stats.Events }}</strong>events, {{ .Stats.EventErrors }} failed</div>
+<div class="card"><strong>{{ .Stats.AverageRender }}</strong>average render over {{ .Stats.Renders }}</div>
+{{ if .Stats.Render.Limit }}<div class="card"><strong>{{ .Stats.Render.Active }} / {{ .Stats.Render.Limit }}</strong>renders running, {{ .Stats.Render.Queued }} queued</div>{{ end }}
+</div>
+<h2>Events</h2>
+<table>
+<tr><th>Event</th><th>Handled</th></tr>
+{{ range .Events }}<tr><td>{{ .Event }}</td><td>{{ .Count }}</td></tr>{{ else }}<tr><td colspan="2">No events yet</td></tr>{{ end }}
+</table>
+<h2>Recent errors</h2>
+<table>
+<tr><th>Time</th><th>Event</th><th>Error</th></tr>
+{{ range .Stats.Errors }}<tr class="error"><td>{{ .Time.Format "15:04:05" }}</td><td>{{ .Event }}</td><td>{{ .Message }}</td></tr>{{ else }}<tr><td colspan="3">No errors</td></tr>{{ end }}
+</table>
+<script src="{{ .Script }}"></script>
+{{ end }}
+</body>
+</html>`))
@@ -0,0 +1,46 @@
+package livedashboard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/jfyne/live"
+)
+
+type fixedStats live.Stats
+
+func (f fixedStats) Stats() live.Stats {
+	return live.Stats(f)
+}
+
+func Example() {
+	e := live.NewHttpHandler(live.NewCookieStore("session-name", []byte("weak-secret")), live.NewHandler())
+	http.Handle("/", e)
+	http.Handle("/dashboard", live.NewHttpHandler(live.NewCookieStore("session-name", []byte("weak-secret")), NewHandler(e)))
+	http.Handle("/live.js", live.Javascript{})
+}
+
+func ExampleNewHandler() {
+	source := fixedStats{
+		Sockets:     3,
+		Events:      12,
+		EventCounts: map[string]uint64{"save": 2, "click": 10},
+		Errors:      []live.StatsError{{Time: time.Now(), Event: "save", Message: errors.New("disk full").Error()}},
+	}
+	h := live.NewHttpHandler(live.NewCookieStore("session-name", []byte("weak-secret")), NewHandler(source))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := w.Body.String()
+	fmt.Println(strings.Contains(body, ">3</strong>sockets"))
+	fmt.Println(strings.Index(body, "click") < strings.Index(body, "save"))
+	fmt.Println(strings.Contains(body, "disk full"))
+	// Output:
+	// true
+	// true
+	// true
+}
@@ -0,0 +1,65 @@
+package live
+
+import (
+	"strings"
+)
+
+// EventLoading sent when the server starts and stops handling a client
+// event, see WithLoadingEvents.
+const EventLoading = "loading"
+
+// LoadingData the data of an EventLoading.
+type LoadingData struct {
+	// Event the name of the event being handled, without any component
+	// scope.
+	Event string `json:"e"`
+	// Target the ID of the component the event is for, empty if it is for
+	// the page.
+	Target string `json:"tg,omitempty"`
+	// Loading true when handling starts, false once the socket has been
+	// rendered after it.
+	Loading bool `json:"l"`
+}
+
+// WithLoadingEvents sends the client EventLoading when the server starts
+// handling an event, and again once the socket has been rendered after it.
+// The client uses them to mark elements with a live-loading attribute naming
+// the event, or live-loading-target naming the component, as loading, and
+// to disable those with live-loading-disable, so apps don't need to keep busy
+// flags in their assigns.
+func WithLoadingEvents() EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.loadingEvents = true
+		case *HttpEngine:
+			v.loadingEvents = true
+		}
+		return nil
+	}
+}
+
+// loadingData describes an event for EventLoading, finding the component it
+// is scoped to.
+func loadingData(sock Socket, t string, loading bool) LoadingData {
+	data := LoadingData{Event: t, Loading: loading}
+	for _, child := range sock.GetChildren() {
+		prefix := child.Event("")
+		if prefix == "" || !strings.HasPrefix(t, prefix) {
+			continue
+		}
+		data.Event = t[len(prefix):]
+		data.Target = child.ID()
+		break
+	}
+	return data
+}
+
+// sendLoading tells the client that handling of an event has started or
+// stopped, if the engine sends loading events.
+func (e *BaseEngine) sendLoading(sock Socket, m Event, loading bool) error {
+	if !e.loadingEvents || m.T == EventParams || m.Preview {
+		return nil
+	}
+	return sock.Send(EventLoading, loadingData(sock, m.T, loading))
+}
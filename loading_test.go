@@ -0,0 +1,87 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadingEvents(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, rc *RenderContext) (io.Reader, error) {
+		return strings.NewReader(fmt.Sprintf("<div>%v</div>", rc.Assigns)), nil
+	})
+	h.HandleEvent("save", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return "saved", nil
+	})
+	e := NewBaseEngine(h)
+	WithLoadingEvents()(e)
+	c := newTestConn()
+	sock := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(sock)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.serveSocket(ctx, e, sock, c, httptest.NewRequest("GET", "/", nil))
+
+	c.in <- Event{T: "save", ID: 1}
+
+	// Handling starts, the socket is patched, handling stops, then the
+	// event is acknowledged.
+	var seen []string
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case m := <-c.out:
+			switch m.T {
+			case EventLoading:
+				var data LoadingData
+				if err := json.Unmarshal(m.Data, &data); err != nil {
+					t.Fatal(err)
+				}
+				if data.Event != "save" || data.Target != "" {
+					t.Errorf("unexpected loading data %+v", data)
+				}
+				if data.Loading {
+					seen = append(seen, "start")
+				} else {
+					seen = append(seen, "stop")
+				}
+			case EventPatch:
+				seen = append(seen, "patch")
+			case EventAck:
+				if len(seen) != 3 || seen[0] != "start" || seen[1] != "patch" || seen[2] != "stop" {
+					t.Errorf("expected start, patch, stop before the ack, got %v", seen)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("event was not acknowledged")
+		}
+	}
+}
+
+type loadingChild struct {
+	*unmountChild
+	id string
+}
+
+func (c loadingChild) ID() string                { return c.id }
+func (c loadingChild) Event(event string) string { return c.id + "--" + event }
+
+func TestLoadingData(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+	sock := NewBaseSocket(NewSession(), e, true)
+	sock.AttachChild(loadingChild{unmountChild: &unmountChild{}, id: "cart"})
+
+	if d := loadingData(sock, "cart--add", true); d.Event != "add" || d.Target != "cart" || !d.Loading {
+		t.Errorf("unexpected component loading data %+v", d)
+	}
+	if d := loadingData(sock, "save", false); d.Event != "save" || d.Target != "" || d.Loading {
+		t.Errorf("unexpected page loading data %+v", d)
+	}
+}
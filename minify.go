@@ -0,0 +1,91 @@
+package live
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// WithMinify minify renders before they are diffed. Comments are stripped and
+// runs of whitespace are collapsed, except within elements where whitespace is
+// significant. This reduces the size of both the initial page and patches.
+func WithMinify() EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.minify = true
+		case *HttpEngine:
+			v.minify = true
+		}
+		return nil
+	}
+}
+
+// minifier is implemented by engines which can minify renders.
+type minifier interface {
+	minifyEnabled() bool
+}
+
+func (e *BaseEngine) minifyEnabled() bool {
+	return e.minify
+}
+
+// minifyTree minifies a rendered tree in place. The output only depends on
+// the input tree so it is stable between renders.
+func minifyTree(root *html.Node) {
+	var next *html.Node
+	for c := root.FirstChild; c != nil; c = next {
+		next = c.NextSibling
+		switch c.Type {
+		case html.CommentNode:
+			root.RemoveChild(c)
+		case html.TextNode:
+			if !preservesWhitespace(root) {
+				c.Data = collapseWhitespace(c.Data)
+			}
+		case html.ElementNode:
+			for idx, a := range c.Attr {
+				if a.Namespace == "" && a.Key == "class" {
+					c.Attr[idx].Val = strings.Join(strings.Fields(a.Val), " ")
+				}
+			}
+			minifyTree(c)
+		default:
+			minifyTree(c)
+		}
+	}
+}
+
+// preservesWhitespace returns true if the whitespace within an element is significant.
+func preservesWhitespace(node *html.Node) bool {
+	for n := node; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.Data {
+		case "pre", "textarea", "script", "style":
+			return true
+		}
+	}
+	return false
+}
+
+// collapseWhitespace replaces runs of whitespace with a single space.
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+		default:
+			b.WriteRune(r)
+			space = false
+		}
+	}
+	return b.String()
+}
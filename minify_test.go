@@ -0,0 +1,48 @@
+package live
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestMinifyTree(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{
+			in:  "<div>\n    Hello   <b>World</b>\n</div>",
+			out: "<div> Hello <b>World</b> </div>",
+		},
+		{
+			in:  "<div><!-- comment -->Hello</div>",
+			out: "<div>Hello</div>",
+		},
+		{
+			in:  "<pre>  keep\n  this</pre>",
+			out: "<pre>  keep\n  this</pre>",
+		},
+		{
+			in:  `<div class="  a   b "></div>`,
+			out: `<div class="a b"></div>`,
+		},
+	}
+	for _, test := range tests {
+		root, err := html.Parse(strings.NewReader(test.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		minifyTree(root)
+		var buf bytes.Buffer
+		body := root.FirstChild.LastChild
+		for c := body.FirstChild; c != nil; c = c.NextSibling {
+			html.Render(&buf, c)
+		}
+		if buf.String() != test.out {
+			t.Errorf("minify %q got %q want %q", test.in, buf.String(), test.out)
+		}
+	}
+}
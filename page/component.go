@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 
 	"github.com/jfyne/live"
 )
@@ -16,13 +17,35 @@ type RegisterHandler[T any] func(c *Component[T]) error
 // MountHandler the components mount function called on first GET request and again when the socket connects.
 type MountHandler[T any] func(ctx context.Context, c *Component[T]) error
 
+// PreloadHandler loads data for every instance of a component created together with InitMany, before
+// they are mounted, so that it can be fetched in one query rather than one per component.
+type PreloadHandler[T any] func(ctx context.Context, cs []*Component[T]) error
+
+// UpdateHandler called after one of the components event handlers has updated its state, before
+// the component is rendered. Use this to keep derived state in sync.
+type UpdateHandler[T any] func(ctx context.Context, c *Component[T]) error
+
+// UnmountHandler called when the components socket closes or the component is detached. Use this
+// to stop subscriptions the component started.
+type UnmountHandler[T any] func(ctx context.Context, c *Component[T]) error
+
 // RenderHandler ths component.
 type RenderHandler[T any] func(w io.Writer, c *Component[T]) error
 
+// RenderContextHandler renders the component with the context of the render, so that it can respect
+// deadlines and read request scoped values.
+type RenderContextHandler[T any] func(ctx context.Context, w io.Writer, c *Component[T]) error
+
+// ErrorBoundaryHandler renders a fallback for the component when one of its event
+// handlers or its render fails.
+type ErrorBoundaryHandler[T any] func(w io.Writer, c *Component[T], err error) error
+
 // ComponentConstructor a func for creating a new component.
 type ComponentConstructor[T any] func(ctx context.Context, h live.Handler, s live.Socket) (*Component[T], error)
 
 var _ live.Child = &Component[any]{}
+var _ live.ChildUnmounter = &Component[any]{}
+var _ live.ChildPreviewer = &Component[any]{}
 
 // Component is a self-contained component on the page. Components can be reused across the application
 // or used to compose complex interfaces by splitting events handlers and render logic into
@@ -46,12 +69,29 @@ type Component[T any] struct {
 	// Register the component. This should be used to setup event handling.
 	Register RegisterHandler[T]
 
+	// Preload the state of many instances of the component at once, see InitMany.
+	Preload PreloadHandler[T]
+
 	// Mount the component, this should be used to setup the components initial state.
 	Mount MountHandler[T]
 
+	// Update the component after an event has changed its state.
+	Update UpdateHandler[T]
+
+	// Unmount the component, this should be used to clean up anything started in mount.
+	Unmount UnmountHandler[T]
+
 	// Render the component, this should be used to describe how to render the component.
 	Render RenderHandler[T]
 
+	// RenderContext if set, is used to render the component instead of Render.
+	RenderContext RenderContextHandler[T]
+
+	// ErrorBoundary if set, errors from this components handlers and render are
+	// contained to the component and rendered using this instead of propagating to the page.
+	// Handler errors are still passed to the socket error handler, render errors are logged.
+	ErrorBoundary ErrorBoundaryHandler[T]
+
 	// State the components state.
 	State T
 
@@ -62,6 +102,12 @@ type Component[T any] struct {
 	eventHandlers map[string]live.EventHandler[T]
 	// selfHandlers the map of handler event handlers for this component.
 	selfHandlers map[string]live.SelfHandler[T]
+
+	// err the error caught by the error boundary.
+	err error
+
+	// scope the css scope class names are rewritten with, if set.
+	scope string
 }
 
 // NewComponent creates a new component and returns it. It does not register it or mount it.
@@ -71,7 +117,10 @@ func NewComponent[T any](ID string, h live.Handler, s live.Socket, configuration
 		Handler:  h,
 		Socket:   s,
 		Register: defaultRegister[T],
+		Preload:  defaultPreload[T],
 		Mount:    defaultMount[T],
+		Update:   defaultUpdate[T],
+		Unmount:  defaultUnmount[T],
 		Render:   defaultRender[T],
 
 		eventHandlers: make(map[string]live.EventHandler[T]),
@@ -103,19 +152,75 @@ func Init[T any](ctx context.Context, construct func() (*Component[T], error)) (
 	return comp, nil
 }
 
+// InitMany constructs n components and registers them, then preloads them in a single batch with the
+// Preload handler of the first before mounting each one. Use this when the same component is shown
+// many times on a page, for example a row per record.
+func InitMany[T any](ctx context.Context, n int, construct func(i int) (*Component[T], error)) ([]*Component[T], error) {
+	comps := make([]*Component[T], 0, n)
+	for i := 0; i < n; i++ {
+		comp, err := construct(i)
+		if err != nil {
+			return nil, fmt.Errorf("could not install component on construct: %w", err)
+		}
+		if err := comp.Register(comp); err != nil {
+			return nil, fmt.Errorf("could not install component on register: %w", err)
+		}
+		comps = append(comps, comp)
+	}
+	if len(comps) == 0 {
+		return comps, nil
+	}
+	if err := comps[0].Preload(ctx, comps); err != nil {
+		return nil, fmt.Errorf("could not install components on preload: %w", err)
+	}
+	for _, comp := range comps {
+		if err := comp.Mount(ctx, comp); err != nil {
+			return nil, fmt.Errorf("could not install component on mount: %w", err)
+		}
+	}
+	return comps, nil
+}
+
 func (c *Component[T]) GetState() any {
 	return c.State
 }
 
+// PreviewState swaps the components state for a copy while an event is
+// previewed, the error caught by its boundary is put back too.
+func (c *Component[T]) PreviewState(copy func(state any) any) (restore func()) {
+	state, err := c.State, c.err
+	if s, ok := copy(state).(T); ok {
+		c.State = s
+	}
+	return func() {
+		c.State, c.err = state, err
+	}
+}
+
 // ID returns the components ID.
 func (c *Component[T]) ID() string {
 	return c.id
 }
 
-// Self sends an event to this component.
-func (c *Component) Self(ctx context.Context, s live.Socket, event string, data interface{}) error {
-	// return s.Self(ctx, event, data)
-	return nil
+// Err returns the error currently caught by the components error boundary.
+func (c *Component[T]) Err() error {
+	return c.err
+}
+
+// Scope returns the css scope of the component, empty if it isn't scoped.
+func (c *Component[T]) Scope() string {
+	return c.scope
+}
+
+// Self sends an event to this component. The event is scoped with the components ID so that
+// it is only handled by this components self handler, and not by other components handling the
+// same event. Use it to push events to the component from background goroutines. If s is nil
+// the components own socket is used.
+func (c *Component[T]) Self(ctx context.Context, s live.Socket, event string, data interface{}) error {
+	if s == nil {
+		s = c.Socket
+	}
+	return s.Self(ctx, c.Event(event), data)
 }
 
 // HandleSelf handles scoped incoming events from the server.
@@ -127,6 +232,7 @@ func (c *Component[T]) HandleSelf(event string, handler live.SelfHandler[T]) {
 // HandleEvent handles a component event sent from the client.
 func (c *Component[T]) HandleEvent(event string, handler live.EventHandler[T]) {
 	c.eventHandlers[event] = handler
+	c.eventHandlers[c.Event(event)] = handler
 }
 
 // HandleParams handles parameter changes. Caution these handlers are not scoped to a specific Component.
@@ -151,12 +257,14 @@ func (c *Component[T]) CallSelf(ctx context.Context, event string, s live.Socket
 	if handler == nil {
 		return fmt.Errorf("no self handler on component %q for %q: %w", c.id, event, live.ErrNoEventHandler)
 	}
-	state, err := handler(ctx, c.Socket, data.SelfData)
-	if err != nil {
-		return err
-	}
-	c.State = state
-	return nil
+	return c.boundary(func() error {
+		state, err := handler(ctx, c.Socket, data.SelfData)
+		if err != nil {
+			return err
+		}
+		c.State = state
+		return c.Update(ctx, c)
+	})
 }
 
 func (c *Component[T]) CallEvent(ctx context.Context, event string, s live.Socket, data live.Params) error {
@@ -164,18 +272,95 @@ func (c *Component[T]) CallEvent(ctx context.Context, event string, s live.Socke
 	if handler == nil {
 		return fmt.Errorf("no event handler on component %q for %q: %w", c.id, event, live.ErrNoEventHandler)
 	}
-	state, err := handler(ctx, c.Socket, data)
-	if err != nil {
-		return err
+	return c.boundary(func() error {
+		state, err := handler(ctx, c.Socket, data)
+		if err != nil {
+			return err
+		}
+		c.State = state
+		return c.Update(ctx, c)
+	})
+}
+
+// UnmountChild unmounts the component when its socket closes.
+func (c *Component[T]) UnmountChild(ctx context.Context) error {
+	return c.Unmount(ctx, c)
+}
+
+// Detach removes the component from its socket and unmounts it.
+func (c *Component[T]) Detach(ctx context.Context) error {
+	c.Socket.DetachChild(c)
+	return c.Unmount(ctx, c)
+}
+
+// boundary runs a handler, if the component has an error boundary any error or panic
+// is caught and held on the component. It is returned wrapped in live.ErrContained so the
+// engine reports it without sending it to the client.
+func (c *Component[T]) boundary(fn func() error) (err error) {
+	if c.ErrorBoundary == nil {
+		return fn()
 	}
-	c.State = state
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("component %q panic: %v", c.id, r)
+		}
+		if c.err != nil {
+			err = fmt.Errorf("component %q: %w: %w", c.id, live.ErrContained, c.err)
+		}
+	}()
+	c.err = fn()
 	return nil
 }
 
+// render writes the component, scoping its class names if required.
+func (c *Component[T]) render(ctx context.Context, w io.Writer) error {
+	if c.scope != "" {
+		return scoped(w, c.scope, func(w io.Writer) error {
+			return c.renderBoundary(ctx, w)
+		})
+	}
+	return c.renderBoundary(ctx, w)
+}
+
+// renderBoundary writes the component, falling back to the error boundary if required.
+func (c *Component[T]) renderBoundary(ctx context.Context, w io.Writer) error {
+	if c.ErrorBoundary == nil {
+		return c.renderHandler(ctx, w)
+	}
+	if c.err != nil {
+		return c.ErrorBoundary(w, c, c.err)
+	}
+	var buf bytes.Buffer
+	if err := c.renderRecover(ctx, &buf); err != nil {
+		slog.ErrorContext(ctx, "component render error", "component", c.id, "error", err)
+		return c.ErrorBoundary(w, c, err)
+	}
+	_, err := io.Copy(w, &buf)
+	return err
+}
+
+// renderRecover calls the render handler, returning a panic as an error.
+func (c *Component[T]) renderRecover(ctx context.Context, w io.Writer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("component %q render panic: %v", c.id, r)
+		}
+	}()
+	return c.renderHandler(ctx, w)
+}
+
+// renderHandler calls the render handler the component has.
+func (c *Component[T]) renderHandler(ctx context.Context, w io.Writer) error {
+	if c.RenderContext != nil {
+		return c.RenderContext(ctx, w, c)
+	}
+	return c.Render(w, c)
+}
+
 // String renders the component to a string.
 func (c *Component[T]) String() string {
 	var buf bytes.Buffer
-	if err := c.Render(&buf, c); err != nil {
+	if err := c.render(context.Background(), &buf); err != nil {
 		return fmt.Sprintf("template rendering failed: %s", err)
 	}
 	return buf.String()
@@ -186,11 +371,26 @@ func defaultRegister[T any](c *Component[T]) error {
 	return nil
 }
 
+// defaultPreload is the default preload handler which does nothing.
+func defaultPreload[T any](ctx context.Context, cs []*Component[T]) error {
+	return nil
+}
+
 // defaultMount is the default mount handler which does nothing.
 func defaultMount[T any](ctx context.Context, c *Component[T]) error {
 	return nil
 }
 
+// defaultUpdate is the default update handler which does nothing.
+func defaultUpdate[T any](ctx context.Context, c *Component[T]) error {
+	return nil
+}
+
+// defaultUnmount is the default unmount handler which does nothing.
+func defaultUnmount[T any](ctx context.Context, c *Component[T]) error {
+	return nil
+}
+
 // defaultRender is the default render handler which does nothing.
 func defaultRender[T any](w io.Writer, c *Component[T]) error {
 	_, err := w.Write([]byte(fmt.Sprintf("%+v", c.State)))
@@ -198,5 +398,8 @@ func defaultRender[T any](w io.Writer, c *Component[T]) error {
 }
 
 var _ RegisterHandler[any] = defaultRegister[any]
+var _ PreloadHandler[any] = defaultPreload[any]
 var _ MountHandler[any] = defaultMount[any]
+var _ UpdateHandler[any] = defaultUpdate[any]
+var _ UnmountHandler[any] = defaultUnmount[any]
 var _ RenderHandler[any] = defaultRender[any]
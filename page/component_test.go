@@ -0,0 +1,119 @@
+package page
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/jfyne/live"
+)
+
+// newBoundaryComponent creates a component with an error boundary which
+// renders the error it caught.
+func newBoundaryComponent(t *testing.T, configurations ...ComponentConfig[int]) *Component[int] {
+	t.Helper()
+	h := live.NewHandler()
+	s := live.NewBaseSocket(live.NewSession(), live.NewBaseEngine(h), false)
+	configurations = append(configurations, WithErrorBoundary(func(w io.Writer, c *Component[int], err error) error {
+		_, werr := fmt.Fprintf(w, "failed: %s", err)
+		return werr
+	}))
+	c, err := Init(context.Background(), func() (*Component[int], error) {
+		return NewComponent("counter", h, s, configurations...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// renderComponent renders the component to a string.
+func renderComponent(t *testing.T, c *Component[int]) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := c.render(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestErrorBoundaryEvent(t *testing.T) {
+	boom := errors.New("boom")
+	c := newBoundaryComponent(t,
+		WithRegister(func(c *Component[int]) error {
+			c.HandleEvent("fail", func(ctx context.Context, s live.Socket, p live.Params) (int, error) {
+				return 0, boom
+			})
+			c.HandleEvent("inc", func(ctx context.Context, s live.Socket, p live.Params) (int, error) {
+				return c.State + 1, nil
+			})
+			return nil
+		}),
+		WithRender(func(w io.Writer, c *Component[int]) error {
+			_, err := fmt.Fprintf(w, "count: %d", c.State)
+			return err
+		}),
+	)
+
+	err := c.CallEvent(context.Background(), "fail", c.Socket, live.Params{})
+	if !errors.Is(err, live.ErrContained) || !errors.Is(err, boom) {
+		t.Fatalf("expected the error to be contained, got %v", err)
+	}
+	if !errors.Is(c.Err(), boom) {
+		t.Errorf("expected the component to hold the error, got %v", c.Err())
+	}
+	if out := renderComponent(t, c); out != "failed: boom" {
+		t.Errorf("expected the boundary to render, got %q", out)
+	}
+
+	// A successful event clears the error.
+	if err := c.CallEvent(context.Background(), "inc", c.Socket, live.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	if out := renderComponent(t, c); out != "count: 1" {
+		t.Errorf("expected the component to render, got %q", out)
+	}
+}
+
+func TestErrorBoundaryPanic(t *testing.T) {
+	c := newBoundaryComponent(t,
+		WithRegister(func(c *Component[int]) error {
+			c.HandleSelf("tick", func(ctx context.Context, s live.Socket, data interface{}) (int, error) {
+				panic("tick failed")
+			})
+			return nil
+		}),
+	)
+
+	err := c.CallSelf(context.Background(), "tick", c.Socket, live.Event{})
+	if !errors.Is(err, live.ErrContained) {
+		t.Fatalf("expected the panic to be contained, got %v", err)
+	}
+	if out := renderComponent(t, c); out != `failed: component "counter" panic: tick failed` {
+		t.Errorf("expected the boundary to render, got %q", out)
+	}
+}
+
+func TestErrorBoundaryRender(t *testing.T) {
+	for name, render := range map[string]RenderHandler[int]{
+		"error": func(w io.Writer, c *Component[int]) error {
+			io.WriteString(w, "partial")
+			return errors.New("bad template")
+		},
+		"panic": func(w io.Writer, c *Component[int]) error {
+			io.WriteString(w, "partial")
+			panic("bad template")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := newBoundaryComponent(t, WithRender(render))
+			out := renderComponent(t, c)
+			if out != "failed: bad template" && out != `failed: component "counter" render panic: bad template` {
+				t.Errorf("expected only the boundary to render, got %q", out)
+			}
+		})
+	}
+}
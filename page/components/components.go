@@ -0,0 +1,32 @@
+// Package components is a starter library of common live components built on page.Component.
+// Use Register to add them all to a page.Registry, or construct them directly.
+package components
+
+import (
+	"github.com/jfyne/live"
+	"github.com/jfyne/live/page"
+)
+
+// Names the components are registered under by Register.
+const (
+	ModalName     = "modal"
+	FlashName     = "flash"
+	PaginatorName = "paginator"
+	TableName     = "table"
+)
+
+// Register adds every component in the library to the registry.
+func Register(r *page.Registry) {
+	page.Register(r, ModalName, func(ID string, h live.Handler, s live.Socket) (*page.Component[Modal], error) {
+		return NewModal(ID, h, s)
+	})
+	page.Register(r, FlashName, func(ID string, h live.Handler, s live.Socket) (*page.Component[[]live.Flash], error) {
+		return NewFlash(ID, h, s)
+	})
+	page.Register(r, PaginatorName, func(ID string, h live.Handler, s live.Socket) (*page.Component[Pagination], error) {
+		return NewPaginator(ID, h, s)
+	})
+	page.Register(r, TableName, func(ID string, h live.Handler, s live.Socket) (*page.Component[Table], error) {
+		return NewTable(ID, h, s)
+	})
+}
@@ -0,0 +1,50 @@
+package components
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jfyne/live"
+	"github.com/jfyne/live/page"
+)
+
+func ExampleRegister() {
+	r := page.NewRegistry()
+	Register(r)
+	fmt.Println(r.Names())
+
+	ctx := context.Background()
+	h := live.NewHandler()
+	s := live.NewBaseSocket(live.NewSession(), live.NewBaseEngine(h), false)
+	pager, _ := page.NewFrom[Pagination](ctx, r, PaginatorName, "pager", h, s)
+	pager.State = Pagination{Page: 1, PerPage: 10, Total: 25}
+
+	pager.CallEvent(ctx, pager.Event("next"), s, live.Params{})
+	pager.CallEvent(ctx, pager.Event("next"), s, live.Params{})
+	pager.CallEvent(ctx, pager.Event("next"), s, live.Params{})
+	fmt.Println(pager.State.Page, pager.State.Pages(), pager.State.Offset())
+	// Output:
+	// [flash modal paginator table]
+	// 3 3 20
+}
+
+func ExampleNewTable() {
+	ctx := context.Background()
+	h := live.NewHandler()
+	s := live.NewBaseSocket(live.NewSession(), live.NewBaseEngine(h), false)
+	table, _ := page.Init(ctx, func() (*page.Component[Table], error) {
+		return NewTable("people", h, s)
+	})
+	table.State = Table{
+		Columns: []string{"Name", "Age"},
+		Rows:    [][]string{{"Ada", "36"}, {"Grace", "85"}, {"Alan", "9"}},
+	}
+
+	table.CallEvent(ctx, table.Event("sort"), s, live.Params{"column": "1"})
+	fmt.Println(table.State.Rows)
+	table.CallEvent(ctx, table.Event("sort"), s, live.Params{"column": "1"})
+	fmt.Println(table.State.Rows)
+	// Output:
+	// [[Alan 9] [Ada 36] [Grace 85]]
+	// [[Grace 85] [Ada 36] [Alan 9]]
+}
@@ -0,0 +1,33 @@
+package components
+
+import (
+	"context"
+	"io"
+
+	"github.com/jfyne/live"
+	"github.com/jfyne/live/page"
+)
+
+// NewFlash creates a component showing the socket's flash messages, which handles the
+// "dismiss" event by clearing them.
+func NewFlash(ID string, h live.Handler, s live.Socket, configurations ...page.ComponentConfig[[]live.Flash]) (*page.Component[[]live.Flash], error) {
+	return page.NewComponent(ID, h, s, append([]page.ComponentConfig[[]live.Flash]{
+		page.WithRegister(func(c *page.Component[[]live.Flash]) error {
+			c.HandleEvent("dismiss", func(ctx context.Context, s live.Socket, p live.Params) ([]live.Flash, error) {
+				s.ClearFlash()
+				return nil, nil
+			})
+			return nil
+		}),
+		page.WithRender(func(w io.Writer, c *page.Component[[]live.Flash]) error {
+			// Flashes are added to the socket, not the component, so read them on each render.
+			c.State = c.Socket.Flashes()
+			return page.HTML(flashTemplate, c).Render(w)
+		}),
+	}, configurations...)...)
+}
+
+const flashTemplate = `{{ if . }}<div class="flash">
+	{{ range . }}<p class="flash-{{ .Kind }}" role="alert">{{ .Message }}</p>{{ end }}
+	<button class="flash-dismiss" live-click="{{ Event "dismiss" }}" aria-label="Dismiss">&times;</button>
+</div>{{ end }}`
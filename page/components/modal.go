@@ -0,0 +1,47 @@
+package components
+
+import (
+	"context"
+	"html/template"
+	"io"
+
+	"github.com/jfyne/live"
+	"github.com/jfyne/live/page"
+)
+
+// Modal the state of a modal dialog.
+type Modal struct {
+	Open  bool
+	Title string
+	Body  template.HTML
+}
+
+// NewModal creates a modal dialog, which handles the "open" and "close" events.
+func NewModal(ID string, h live.Handler, s live.Socket, configurations ...page.ComponentConfig[Modal]) (*page.Component[Modal], error) {
+	return page.NewComponent(ID, h, s, append([]page.ComponentConfig[Modal]{
+		page.WithRegister(func(c *page.Component[Modal]) error {
+			c.HandleEvent("open", func(ctx context.Context, s live.Socket, p live.Params) (Modal, error) {
+				c.State.Open = true
+				return c.State, nil
+			})
+			c.HandleEvent("close", func(ctx context.Context, s live.Socket, p live.Params) (Modal, error) {
+				c.State.Open = false
+				return c.State, nil
+			})
+			return nil
+		}),
+		page.WithRender(func(w io.Writer, c *page.Component[Modal]) error {
+			return page.HTML(modalTemplate, c).Render(w)
+		}),
+	}, configurations...)...)
+}
+
+const modalTemplate = `{{ if .Open }}<div class="modal" role="dialog" aria-modal="true" live-window-keyup="{{ Event "close" }}" live-key="Escape">
+	<div class="modal-content">
+		<header class="modal-header">
+			<h2>{{ .Title }}</h2>
+			<button class="modal-close" live-click="{{ Event "close" }}" aria-label="Close">&times;</button>
+		</header>
+		<div class="modal-body">{{ .Body }}</div>
+	</div>
+</div>{{ end }}`
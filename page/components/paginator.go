@@ -0,0 +1,96 @@
+package components
+
+import (
+	"context"
+	"io"
+
+	"github.com/jfyne/live"
+	"github.com/jfyne/live/page"
+)
+
+// Pagination the state of a paginator. Page counts from 1.
+type Pagination struct {
+	Page    int
+	PerPage int
+	Total   int
+}
+
+// Pages returns the number of pages, at least one.
+func (p Pagination) Pages() int {
+	if p.PerPage <= 0 || p.Total <= 0 {
+		return 1
+	}
+	return (p.Total + p.PerPage - 1) / p.PerPage
+}
+
+// Offset returns the index of the first item on the current page.
+func (p Pagination) Offset() int {
+	if p.Page < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.PerPage
+}
+
+// HasPrev reports whether there is a page before the current one.
+func (p Pagination) HasPrev() bool {
+	return p.Page > 1
+}
+
+// HasNext reports whether there is a page after the current one.
+func (p Pagination) HasNext() bool {
+	return p.Page < p.Pages()
+}
+
+// PageNumbers returns the number of every page, for rendering links to them.
+func (p Pagination) PageNumbers() []int {
+	numbers := make([]int, p.Pages())
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+	return numbers
+}
+
+// clamp keeps the page within range.
+func (p Pagination) clamp(page int) Pagination {
+	if page > p.Pages() {
+		page = p.Pages()
+	}
+	if page < 1 {
+		page = 1
+	}
+	p.Page = page
+	return p
+}
+
+// NewPaginator creates a paginator, which handles the "prev", "next" and "goto" events, the last
+// taking the page from its "page" param. Pass page.WithUpdate to load the new page of items
+// after it changes.
+func NewPaginator(ID string, h live.Handler, s live.Socket, configurations ...page.ComponentConfig[Pagination]) (*page.Component[Pagination], error) {
+	return page.NewComponent(ID, h, s, append([]page.ComponentConfig[Pagination]{
+		page.WithMount(func(ctx context.Context, c *page.Component[Pagination]) error {
+			c.State = c.State.clamp(c.State.Page)
+			return nil
+		}),
+		page.WithRegister(func(c *page.Component[Pagination]) error {
+			c.HandleEvent("prev", func(ctx context.Context, s live.Socket, p live.Params) (Pagination, error) {
+				return c.State.clamp(c.State.Page - 1), nil
+			})
+			c.HandleEvent("next", func(ctx context.Context, s live.Socket, p live.Params) (Pagination, error) {
+				return c.State.clamp(c.State.Page + 1), nil
+			})
+			c.HandleEvent("goto", func(ctx context.Context, s live.Socket, p live.Params) (Pagination, error) {
+				return c.State.clamp(p.Int("page")), nil
+			})
+			return nil
+		}),
+		page.WithRender(func(w io.Writer, c *page.Component[Pagination]) error {
+			return page.HTML(paginatorTemplate, c).Render(w)
+		}),
+	}, configurations...)...)
+}
+
+const paginatorTemplate = `{{ $page := .Page }}<nav class="paginator" aria-label="Pagination">
+	<button live-click="{{ Event "prev" }}"{{ if not .HasPrev }} disabled{{ end }}>Previous</button>
+	{{ range .PageNumbers }}<button live-click="{{ Event "goto" }}" live-value-page="{{ . }}"{{ if eq . $page }} aria-current="page"{{ end }}>{{ . }}</button>{{ end }}
+	<button live-click="{{ Event "next" }}"{{ if not .HasNext }} disabled{{ end }}>Next</button>
+</nav>`
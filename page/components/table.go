@@ -0,0 +1,79 @@
+package components
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/jfyne/live"
+	"github.com/jfyne/live/page"
+)
+
+// Table the state of a sortable table.
+type Table struct {
+	Columns []string
+	Rows    [][]string
+	// Sorted whether the rows have been sorted by SortBy.
+	Sorted bool
+	// SortBy the index of the column the rows are sorted by.
+	SortBy int
+	// Desc sorts the rows in descending order.
+	Desc bool
+}
+
+// Sort orders the rows by a column, values which are both numbers are compared as numbers.
+func (t Table) Sort(column int, desc bool) Table {
+	if column < 0 || column >= len(t.Columns) {
+		return t
+	}
+	rows := make([][]string, len(t.Rows))
+	copy(rows, t.Rows)
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := cell(rows[i], column), cell(rows[j], column)
+		if desc {
+			a, b = b, a
+		}
+		af, aerr := strconv.ParseFloat(a, 64)
+		bf, berr := strconv.ParseFloat(b, 64)
+		if aerr == nil && berr == nil {
+			return af < bf
+		}
+		return a < b
+	})
+	t.Rows = rows
+	t.Sorted = true
+	t.SortBy = column
+	t.Desc = desc
+	return t
+}
+
+// cell returns a column of a row, or nothing if the row is short.
+func cell(row []string, column int) string {
+	if column >= len(row) {
+		return ""
+	}
+	return row[column]
+}
+
+// NewTable creates a sortable table, which handles the "sort" event taking the index of the
+// column from its "column" param. Sorting by the same column again reverses the order.
+func NewTable(ID string, h live.Handler, s live.Socket, configurations ...page.ComponentConfig[Table]) (*page.Component[Table], error) {
+	return page.NewComponent(ID, h, s, append([]page.ComponentConfig[Table]{
+		page.WithRegister(func(c *page.Component[Table]) error {
+			c.HandleEvent("sort", func(ctx context.Context, s live.Socket, p live.Params) (Table, error) {
+				column := p.Int("column")
+				return c.State.Sort(column, c.State.Sorted && column == c.State.SortBy && !c.State.Desc), nil
+			})
+			return nil
+		}),
+		page.WithRender(func(w io.Writer, c *page.Component[Table]) error {
+			return page.HTML(tableTemplate, c).Render(w)
+		}),
+	}, configurations...)...)
+}
+
+const tableTemplate = `{{ $sorted := .Sorted }}{{ $sort := .SortBy }}{{ $desc := .Desc }}<table class="table">
+	<thead><tr>{{ range $i, $col := .Columns }}<th live-click="{{ Event "sort" }}" live-value-column="{{ $i }}"{{ if and $sorted (eq $i $sort) }} aria-sort="{{ if $desc }}descending{{ else }}ascending{{ end }}"{{ end }}>{{ $col }}</th>{{ end }}</tr></thead>
+	<tbody>{{ range .Rows }}<tr>{{ range . }}<td>{{ . }}</td>{{ end }}</tr>{{ end }}</tbody>
+</table>`
@@ -20,6 +20,14 @@ func WithRegister[T any](fn RegisterHandler[T]) ComponentConfig[T] {
 	}
 }
 
+// WithPreload set a preload handler on the component.
+func WithPreload[T any](fn PreloadHandler[T]) ComponentConfig[T] {
+	return func(c *Component[T]) error {
+		c.Preload = fn
+		return nil
+	}
+}
+
 // WithMount set a mount handler on the component.
 func WithMount[T any](fn MountHandler[T]) ComponentConfig[T] {
 	return func(c *Component[T]) error {
@@ -28,6 +36,22 @@ func WithMount[T any](fn MountHandler[T]) ComponentConfig[T] {
 	}
 }
 
+// WithUpdate set an update handler on the component.
+func WithUpdate[T any](fn UpdateHandler[T]) ComponentConfig[T] {
+	return func(c *Component[T]) error {
+		c.Update = fn
+		return nil
+	}
+}
+
+// WithUnmount set an unmount handler on the component.
+func WithUnmount[T any](fn UnmountHandler[T]) ComponentConfig[T] {
+	return func(c *Component[T]) error {
+		c.Unmount = fn
+		return nil
+	}
+}
+
 // WithRender set a render handler on the component.
 func WithRender[T any](fn RenderHandler[T]) ComponentConfig[T] {
 	return func(c *Component[T]) error {
@@ -36,6 +60,33 @@ func WithRender[T any](fn RenderHandler[T]) ComponentConfig[T] {
 	}
 }
 
+// WithRenderContext set a render handler on the component which is passed the context of the render.
+func WithRenderContext[T any](fn RenderContextHandler[T]) ComponentConfig[T] {
+	return func(c *Component[T]) error {
+		c.RenderContext = fn
+		return nil
+	}
+}
+
+// WithErrorBoundary set an error boundary on the component.
+func WithErrorBoundary[T any](fn ErrorBoundaryHandler[T]) ComponentConfig[T] {
+	return func(c *Component[T]) error {
+		c.ErrorBoundary = fn
+		return nil
+	}
+}
+
+// WithScopedCSS rewrite the class names the component renders with a scope
+// derived from the name, so that its styles don't collide with other
+// components. Scope the components stylesheet to match with
+// ScopeCSS(css, CSSScope(name)).
+func WithScopedCSS[T any](name string) ComponentConfig[T] {
+	return func(c *Component[T]) error {
+		c.scope = CSSScope(name)
+		return nil
+	}
+}
+
 // WithComponentMount set the live.Handler to mount the root component.
 func WithComponentMount[T any](construct ComponentConstructor[T]) live.HandlerConfig {
 	return func(h live.Handler) error {
@@ -61,14 +112,14 @@ func WithComponentMount[T any](construct ComponentConstructor[T]) live.HandlerCo
 // WithComponentRenderer set the live.Handler to use a root component to render.
 func WithComponentRenderer[T any]() live.HandlerConfig {
 	return func(h live.Handler) error {
-		h.HandleRender(func(_ context.Context, data *live.RenderContext) (io.Reader, error) {
+		h.HandleRender(func(ctx context.Context, data *live.RenderContext) (io.Reader, error) {
 			c, ok := data.Assigns.(*Component[T])
 			if !ok {
 				return nil, fmt.Errorf("root render data is not a component")
 			}
 			c.Uploads = data.Uploads
 			var buf bytes.Buffer
-			if err := c.Render(&buf, c); err != nil {
+			if err := c.render(ctx, &buf); err != nil {
 				return nil, err
 			}
 			return &buf, nil
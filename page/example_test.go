@@ -1,24 +1,30 @@
 package page
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
 
 	"github.com/jfyne/live"
 )
 
 // NewGreeter creates a new greeter component.
-func NewGreeter(ID string, h live.Handler, s live.Socket, name string) (*Component, error) {
+func NewGreeter(ID string, h live.Handler, s live.Socket, name string) (*Component[string], error) {
 	return NewComponent(
 		ID,
 		h,
 		s,
-		WithMount(func(ctx context.Context, c *Component) error {
+		WithMount(func(ctx context.Context, c *Component[string]) error {
 			c.State = name
 			return nil
 		}),
-		WithRender(func(w io.Writer, c *Component) error {
+		WithRender(func(w io.Writer, c *Component[string]) error {
 			// Render the greeter, here we are including the script just to make this toy example work.
 			return HTML(`
                 <div class="greeter">Hello {{.}}</div>
@@ -30,13 +36,160 @@ func NewGreeter(ID string, h live.Handler, s live.Socket, name string) (*Compone
 
 func Example() {
 	h := live.NewHandler(
-		WithComponentMount(func(ctx context.Context, h live.Handler, s live.Socket) (*Component, error) {
+		WithComponentMount(func(ctx context.Context, h live.Handler, s live.Socket) (*Component[string], error) {
 			return NewGreeter("hello-id", h, s, "World!")
 		}),
-		WithComponentRenderer(),
+		WithComponentRenderer[string](),
 	)
 
 	http.Handle("/", live.NewHttpHandler(live.NewCookieStore("session-name", []byte("weak-secret")), h))
 	http.Handle("/live.js", live.Javascript{})
 	http.ListenAndServe(":8080", nil)
 }
+
+func ExampleScopeCSS() {
+	scope := CSSScope("greeter")
+	fmt.Println(ScopeCSS(`.greeter { color: red; } @media (min-width: 40em) { .greeter .name { font-size: 2em; } }`, scope))
+
+	var buf bytes.Buffer
+	ScopeHTML(&buf, strings.NewReader(`<div class="greeter"><span class="name live-click-loading">World!</span></div>`), scope)
+	fmt.Println(buf.String())
+	// Output:
+	// .greeter-s4b249b51 { color: red; } @media (min-width: 40em) { .greeter-s4b249b51 .name-s4b249b51 { font-size: 2em; } }
+	// <div class="greeter-s4b249b51"><span class="name-s4b249b51 live-click-loading">World!</span></div>
+}
+
+func ExampleInitMany() {
+	h := live.NewHandler()
+	s := live.NewBaseSocket(live.NewSession(), live.NewBaseEngine(h), false)
+	ids := []string{"1", "2", "3"}
+
+	rows, _ := InitMany(context.Background(), len(ids), func(i int) (*Component[string], error) {
+		return NewComponent(
+			"row-"+ids[i],
+			h,
+			s,
+			WithPreload(func(ctx context.Context, cs []*Component[string]) error {
+				// Load every row in one query.
+				fmt.Println("preloading", len(cs), "rows")
+				for _, c := range cs {
+					c.State = "record " + c.ID()
+				}
+				return nil
+			}),
+		)
+	})
+	for _, r := range rows {
+		fmt.Println(r.State)
+	}
+	// Output:
+	// preloading 3 rows
+	// record row-1
+	// record row-2
+	// record row-3
+}
+
+func ExampleFragments() {
+	f := NewFragments(nil)
+	RegisterFragment(f, "counter", func(ctx context.Context, h live.Handler, s live.Socket) (*Component[int], error) {
+		return NewComponent(
+			"counter",
+			h,
+			s,
+			WithRegister(func(c *Component[int]) error {
+				c.HandleEvent("inc", func(ctx context.Context, s live.Socket, p live.Params) (int, error) {
+					return p.Int("count") + 1, nil
+				})
+				return nil
+			}),
+			WithRender(func(w io.Writer, c *Component[int]) error {
+				return HTML(`<span>{{.}}</span><button name="event" value="{{Event "inc"}}">+</button>`, c).Render(w)
+			}),
+		)
+	})
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fragments/counter", nil))
+	fmt.Println(w.Body.String())
+
+	form := url.Values{"event": {"counter--inc"}, "count": {"41"}}
+	r := httptest.NewRequest(http.MethodPost, "/fragments/counter", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	f.ServeHTTP(w, r)
+	fmt.Println(w.Body.String())
+	// Output:
+	// <span>0</span><button name="event" value="counter--inc">+</button>
+	// <span>42</span><button name="event" value="counter--inc">+</button>
+}
+
+func ExampleComponent_Self() {
+	h := live.NewHandler()
+	h.HandleRender(func(ctx context.Context, rc *live.RenderContext) (io.Reader, error) {
+		return strings.NewReader("<div></div>"), nil
+	})
+	e := live.NewBaseEngine(h)
+	s := live.NewBaseSocket(live.NewSession(), e, true)
+	e.AddSocket(s)
+
+	newCounter := func(id string) *Component[int] {
+		c, _ := Init(context.Background(), func() (*Component[int], error) {
+			return NewComponent(id, h, s, WithRegister(func(c *Component[int]) error {
+				c.HandleSelf("inc", func(ctx context.Context, s live.Socket, data interface{}) (int, error) {
+					return c.State + data.(int), nil
+				})
+				return nil
+			}))
+		})
+		return c
+	}
+	a := newCounter("a")
+	b := newCounter("b")
+
+	a.Self(context.Background(), nil, "inc", 2)
+	fmt.Println(a.State, b.State)
+	// Output:
+	// 2 0
+}
+
+func ExampleRegistry() {
+	r := NewRegistry()
+	Register(r, "greeter", func(ID string, h live.Handler, s live.Socket) (*Component[string], error) {
+		return NewComponent(ID, h, s, WithMount(func(ctx context.Context, c *Component[string]) error {
+			c.State = "Hello " + c.ID()
+			return nil
+		}))
+	})
+
+	h := live.NewHandler()
+	s := live.NewBaseSocket(live.NewSession(), live.NewBaseEngine(h), false)
+	greeter, _ := r.New(context.Background(), "greeter", "world", h, s)
+	fmt.Println(r.Names(), greeter.GetState())
+
+	_, err := NewFrom[int](context.Background(), r, "greeter", "world", h, s)
+	fmt.Println(err)
+	// Output:
+	// [greeter] Hello world
+	// component "greeter" is a *page.Component[string], not a *page.Component[int]
+}
+
+func ExampleWithRenderContext() {
+	type localeKey struct{}
+
+	h := live.NewHandler()
+	s := live.NewBaseSocket(live.NewSession(), live.NewBaseEngine(h), false)
+	c, _ := NewComponent("greeter", h, s, WithRenderContext(func(ctx context.Context, w io.Writer, c *Component[string]) error {
+		greeting := "Hello"
+		if ctx.Value(localeKey{}) == "fr" {
+			greeting = "Bonjour"
+		}
+		_, err := fmt.Fprintf(w, "%s %s", greeting, c.State)
+		return err
+	}))
+	c.State = "World!"
+
+	ctx := context.WithValue(context.Background(), localeKey{}, "fr")
+	RenderContext(ctx, c).Render(os.Stdout)
+	// Output:
+	// Bonjour World!
+}
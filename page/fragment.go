@@ -0,0 +1,138 @@
+package page
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/jfyne/live"
+)
+
+// fragmentEvent the form value naming the event a fragment POST calls.
+const fragmentEvent = "event"
+
+// Fragments serves components as plain HTML fragments, so that they can be
+// used from pages which aren't connected with a websocket, for example with
+// htmx. The component is picked by the last element of the request path, and
+// is constructed and mounted afresh for every request. A GET renders it with
+// the query as its params, a POST also calls the event named by the "event"
+// form value with the rest of the form as params, then renders it.
+//
+//	f := page.NewFragments(store)
+//	page.RegisterFragment(f, "counter", newCounter)
+//	http.Handle("/fragments/", f)
+type Fragments struct {
+	store live.HttpSessionStore
+
+	mu         sync.RWMutex
+	components map[string]func(ctx context.Context, h live.Handler, s live.Socket) (Instance, error)
+}
+
+// NewFragments creates a new fragment handler, sessions are read from the
+// store if it is not nil.
+func NewFragments(store live.HttpSessionStore) *Fragments {
+	return &Fragments{
+		store:      store,
+		components: map[string]func(ctx context.Context, h live.Handler, s live.Socket) (Instance, error){},
+	}
+}
+
+// RegisterFragment serves a component from the fragment handler under the ID.
+func RegisterFragment[T any](f *Fragments, id string, construct ComponentConstructor[T]) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.components[id] = func(ctx context.Context, h live.Handler, s live.Socket) (Instance, error) {
+		c, err := construct(ctx, h, s)
+		if err != nil {
+			return nil, fmt.Errorf("could not install component on construct: %w", err)
+		}
+		if err := c.Register(c); err != nil {
+			return nil, fmt.Errorf("could not install component on register: %w", err)
+		}
+		if err := c.Mount(ctx, c); err != nil {
+			return nil, fmt.Errorf("could not install component on mount: %w", err)
+		}
+		return c, nil
+	}
+}
+
+// ServeHTTP renders a registered component.
+func (f *Fragments) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.RLock()
+	construct, ok := f.components[path.Base(r.URL.Path)]
+	f.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	session := live.NewSession()
+	if f.store != nil {
+		if s, err := f.store.Get(r); err == nil {
+			session = s
+		}
+	}
+	h := live.NewHandler()
+	engine := live.NewBaseEngine(h)
+	sock := live.NewBaseSocket(session, engine, false)
+	ctx := r.Context()
+
+	c, err := construct(ctx, h, sock)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	params := live.NewParamsFromRequest(r)
+	for _, ph := range engine.Params() {
+		if _, err := ph(ctx, sock, params); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		event := strings.TrimPrefix(r.PostForm.Get(fragmentEvent), c.ID()+"--")
+		params := live.Params{}
+		for k, v := range r.PostForm {
+			if k == fragmentEvent {
+				continue
+			}
+			if len(v) == 1 {
+				params[k] = v[0]
+			} else {
+				params[k] = v
+			}
+		}
+		if err := c.CallEvent(ctx, event, sock, params); err != nil {
+			switch {
+			case errors.Is(err, live.ErrContained):
+				// The component renders the error in its place.
+				slog.ErrorContext(ctx, "fragment event error", "component", c.ID(), "error", err)
+			case errors.Is(err, live.ErrNoEventHandler):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.render(ctx, &buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
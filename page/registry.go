@@ -0,0 +1,102 @@
+package page
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/jfyne/live"
+)
+
+// Constructor creates a component with the given ID. It does not register it or mount it.
+type Constructor[T any] func(ID string, h live.Handler, s live.Socket) (*Component[T], error)
+
+// Instance a registered and mounted component, whatever the type of its state.
+type Instance interface {
+	live.Child
+	String() string
+	render(ctx context.Context, w io.Writer) error
+}
+
+var _ Instance = &Component[any]{}
+
+// Registry holds component constructors by name, so that components can be shared between handlers
+// and projects without importing each of them where they are used.
+//
+//	r := page.NewRegistry()
+//	page.Register(r, "greeter", NewGreeter)
+//	greeter, err := r.New(ctx, "greeter", "hello-id", h, s)
+type Registry struct {
+	mu           sync.RWMutex
+	constructors map[string]func(ctx context.Context, ID string, h live.Handler, s live.Socket) (Instance, error)
+}
+
+// NewRegistry creates a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		constructors: map[string]func(ctx context.Context, ID string, h live.Handler, s live.Socket) (Instance, error){},
+	}
+}
+
+// Register adds a component constructor to the registry under the name, replacing any
+// already registered with it.
+func Register[T any](r *Registry, name string, construct Constructor[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.constructors[name] = func(ctx context.Context, ID string, h live.Handler, s live.Socket) (Instance, error) {
+		return Init(ctx, func() (*Component[T], error) {
+			return construct(ID, h, s)
+		})
+	}
+}
+
+// New creates, registers and mounts the component registered under the name.
+func (r *Registry) New(ctx context.Context, name, ID string, h live.Handler, s live.Socket) (Instance, error) {
+	r.mu.RLock()
+	construct, ok := r.constructors[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no component registered as %q", name)
+	}
+	return construct(ctx, ID, h, s)
+}
+
+// Names returns the names of the registered components, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.constructors))
+	for name := range r.constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFrom creates a component from the registry, checking that its state is a T so
+// that it can be used directly.
+func NewFrom[T any](ctx context.Context, r *Registry, name, ID string, h live.Handler, s live.Socket) (*Component[T], error) {
+	i, err := r.New(ctx, name, ID, h, s)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := i.(*Component[T])
+	if !ok {
+		return nil, fmt.Errorf("component %q is a %T, not a %T", name, i, c)
+	}
+	return c, nil
+}
+
+// RenderInstance wraps a component created by a registry into a RenderFunc.
+func RenderInstance(i Instance) RenderFunc {
+	return RenderInstanceContext(context.Background(), i)
+}
+
+// RenderInstanceContext wraps a component created by a registry into a RenderFunc, rendering it with the context.
+func RenderInstanceContext(ctx context.Context, i Instance) RenderFunc {
+	return RenderFunc(func(w io.Writer) error {
+		return i.render(ctx, w)
+	})
+}
@@ -1,6 +1,7 @@
 package page
 
 import (
+	"context"
 	"html/template"
 	"io"
 
@@ -12,6 +13,7 @@ import (
 //
 // Template functions
 // - "Event" takes an event string and scopes it for the component.
+// - "Scope" returns the components css scope.
 func HTML(layout string, c live.Child) RenderFunc {
 	t := template.Must(template.New("").Funcs(templateFuncs(c)).Parse(layout))
 	return RenderFunc(func(w io.Writer) error {
@@ -23,8 +25,13 @@ func HTML(layout string, c live.Child) RenderFunc {
 }
 
 func templateFuncs(c live.Child) template.FuncMap {
+	scope := ""
+	if s, ok := c.(interface{ Scope() string }); ok {
+		scope = s.Scope()
+	}
 	return template.FuncMap{
 		"Event": c.Event,
+		"Scope": func() string { return scope },
 	}
 }
 
@@ -38,7 +45,13 @@ func (r RenderFunc) Render(w io.Writer) error {
 
 // Render wrap a component and provide a RenderFunc.
 func Render[T any](c *Component[T]) RenderFunc {
+	return RenderContext(context.Background(), c)
+}
+
+// RenderContext wrap a component and provide a RenderFunc which renders it with the context. Use it
+// to pass the context of a parent's render on to its children.
+func RenderContext[T any](ctx context.Context, c *Component[T]) RenderFunc {
 	return RenderFunc(func(w io.Writer) error {
-		return c.Render(w, c)
+		return c.render(ctx, w)
 	})
 }
@@ -0,0 +1,115 @@
+package page
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// scopedClass matches a class which has already been scoped, so that the
+// output of nested components isn't scoped again by their parent.
+var scopedClass = regexp.MustCompile(`-s[0-9a-f]{8}$`)
+
+// cssClassSelector matches class selectors in css.
+var cssClassSelector = regexp.MustCompile(`\.(-?[_a-zA-Z][_a-zA-Z0-9-]*)`)
+
+// CSSScope returns the scope for components with the given name. Use this in
+// a css build step to scope a components stylesheet with ScopeCSS.
+func CSSScope(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return "s" + hex.EncodeToString(sum[:4])
+}
+
+// scopeClass scopes a single class name.
+func scopeClass(class, scope string) string {
+	if strings.HasPrefix(class, "live-") || scopedClass.MatchString(class) {
+		return class
+	}
+	return class + "-" + scope
+}
+
+// ScopeHTML rewrites the class names in rendered html with the scope. Classes
+// starting with "live-" and those already scoped are left alone.
+func ScopeHTML(w io.Writer, r io.Reader, scope string) error {
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return nil
+			}
+			return z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := append([]byte{}, z.Raw()...)
+			t := z.Token()
+			changed := false
+			for i, a := range t.Attr {
+				if a.Key != "class" {
+					continue
+				}
+				classes := strings.Fields(a.Val)
+				for j, c := range classes {
+					classes[j] = scopeClass(c, scope)
+				}
+				t.Attr[i].Val = strings.Join(classes, " ")
+				changed = true
+			}
+			if !changed {
+				if _, err := w.Write(raw); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := io.WriteString(w, t.String()); err != nil {
+				return err
+			}
+		default:
+			if _, err := w.Write(z.Raw()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ScopeCSS rewrites the class selectors in a stylesheet with the scope, so
+// that it only applies to components rendered with that scope.
+func ScopeCSS(css, scope string) string {
+	var out, buf strings.Builder
+	for _, r := range css {
+		switch r {
+		case '{':
+			selector := buf.String()
+			if !strings.HasPrefix(strings.TrimSpace(selector), "@") {
+				selector = cssClassSelector.ReplaceAllStringFunc(selector, func(m string) string {
+					return "." + scopeClass(m[1:], scope)
+				})
+			}
+			out.WriteString(selector)
+			out.WriteRune(r)
+			buf.Reset()
+		case '}', ';':
+			out.WriteString(buf.String())
+			out.WriteRune(r)
+			buf.Reset()
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	out.WriteString(buf.String())
+	return out.String()
+}
+
+// scoped renders into a buffer and scopes the output.
+func scoped(w io.Writer, scope string, render func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return err
+	}
+	return ScopeHTML(w, &buf, scope)
+}
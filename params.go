@@ -1,7 +1,10 @@
 package live
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 )
 
@@ -94,7 +97,56 @@ func mapFloat32(p map[string]interface{}, key string) float32 {
 	return 0.0
 }
 
-// NewParamsFromRequest helper to generate Params from an http request.
+// Changed returns the keys whose values differ from the previous params,
+// including those that have been added or removed, sorted.
+//
+//	h.HandleParams(func(ctx context.Context, s live.Socket, p live.Params) (any, error) {
+//		if slices.Contains(p.Changed(live.PreviousParams(ctx)), "filter") {
+//			...
+//		}
+//	})
+func (p Params) Changed(prev Params) []string {
+	changed := []string{}
+	for k, v := range p {
+		old, ok := prev[k]
+		if !ok || fmt.Sprint(old) != fmt.Sprint(v) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range prev {
+		if _, ok := p[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// values encodes params as a query string.
+func (p Params) values() url.Values {
+	values := url.Values{}
+	for k, v := range p {
+		switch vs := v.(type) {
+		case []string:
+			values[k] = vs
+		case []interface{}:
+			for _, v := range vs {
+				values.Add(k, fmt.Sprint(v))
+			}
+		default:
+			values.Set(k, fmt.Sprint(v))
+		}
+	}
+	return values
+}
+
+// paramsKeeper is implemented by sockets which remember their current params.
+type paramsKeeper interface {
+	swapParams(p Params) Params
+}
+
+// NewParamsFromRequest helper to generate Params from an http request. Any
+// route parameters attached to the request are included.
 func NewParamsFromRequest(r *http.Request) Params {
 	out := Params{}
 	values := r.URL.Query()
@@ -105,5 +157,8 @@ func NewParamsFromRequest(r *http.Request) Params {
 			out[k] = v
 		}
 	}
+	for k, v := range PathParams(r.Context()) {
+		out[k] = v
+	}
 	return out
 }
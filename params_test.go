@@ -1,8 +1,11 @@
 package live
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"testing"
 )
 
@@ -112,3 +115,49 @@ func TestParamsFromRequest(t *testing.T) {
 		t.Error("did not get expected params", params)
 	}
 }
+
+func TestParamsFromRequestPathParams(t *testing.T) {
+	r := httptest.NewRequest("GET", "/room/42?page=2", nil)
+	r = RequestWithPathParams(r, map[string]string{"id": "42"})
+	p := NewParamsFromRequest(r)
+	if p.Int("id") != 42 {
+		t.Errorf("expected path param id, got %v", p)
+	}
+	if p.Int("page") != 2 {
+		t.Errorf("expected query param page, got %v", p)
+	}
+}
+
+func TestParamsChanged(t *testing.T) {
+	prev := Params{"page": "1", "filter": "open", "sort": []string{"a", "b"}}
+	p := Params{"page": "2", "sort": []interface{}{"a", "b"}, "q": "x"}
+	got := p.Changed(prev)
+	expected := []string{"filter", "page", "q"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestCallParamsPreviousParams(t *testing.T) {
+	h := NewHandler()
+	var changed []string
+	h.HandleParams(func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		changed = p.Changed(PreviousParams(ctx))
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	s := NewBaseSocket(NewSession(), e, true)
+
+	if err := e.CallParams(context.Background(), s, Event{T: EventParams, Data: []byte(`{"page":"1","filter":"open"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changed, []string{"filter", "page"}) {
+		t.Errorf("expected all params to change, got %v", changed)
+	}
+	if err := e.CallParams(context.Background(), s, Event{T: EventParams, Data: []byte(`{"page":"2","filter":"open"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changed, []string{"page"}) {
+		t.Errorf("expected only page to change, got %v", changed)
+	}
+}
@@ -0,0 +1,128 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Preview the patches which show the result of a previewed event, and the
+// patches which revert them.
+type Preview struct {
+	Patches []Patch `json:"patches"`
+	Revert  []Patch `json:"revert"`
+}
+
+// previewEvent runs an event handler against a copy of the sockets assigns,
+// and the state of its children, and sends the patches it would cause
+// flagged as a preview. The assigns and latest render are left as they were,
+// so nothing is committed. Side effects of the handler itself, such as
+// sending or broadcasting, still happen.
+func (e *BaseEngine) previewEvent(ctx context.Context, engine Engine, sock Socket, msg Event) error {
+	current := sock.LatestRender()
+	if current == nil {
+		return nil
+	}
+
+	saved := sock.Assigns()
+	sock.Assign(copyAssigns(saved))
+	defer sock.Assign(saved)
+	for _, child := range sock.GetChildren() {
+		if p, ok := child.(ChildPreviewer); ok {
+			defer p.PreviewState(copyAssigns)()
+		}
+	}
+
+	// A component containing an error previews its fallback.
+	if err := e.CallEvent(ctx, msg.T, sock, msg); err != nil && !errors.Is(err, ErrContained) {
+		return err
+	}
+	proposed, err := renderTree(ctx, engine, sock)
+	if err != nil {
+		return fmt.Errorf("preview render error: %w", err)
+	}
+
+	version := socketProtocolVersion(sock)
+	patches, err := diffRenders(engine, current, proposed, version)
+	if err != nil {
+		return fmt.Errorf("preview diff error: %w", err)
+	}
+	revert, err := diffRenders(engine, proposed, current, version)
+	if err != nil {
+		return fmt.Errorf("preview diff error: %w", err)
+	}
+
+	return sock.Send(EventPreview, Preview{Patches: patches, Revert: revert})
+}
+
+// copyAssigns makes a deep copy of assigns, so that a handler which modifies
+// them in place, through pointers, maps or slices, doesn't change the
+// original. Unexported fields, channels and funcs are shared with the
+// original.
+func copyAssigns(assigns interface{}) interface{} {
+	if assigns == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(assigns), map[uintptr]reflect.Value{}).Interface()
+}
+
+// deepCopy copies a value, seen holds the copies of pointers already made so
+// that cycles are kept.
+func deepCopy(v reflect.Value, seen map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		if c, ok := seen[v.Pointer()]; ok {
+			return c
+		}
+		c := reflect.New(v.Elem().Type())
+		seen[v.Pointer()] = c
+		c.Elem().Set(deepCopy(v.Elem(), seen))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem(), seen))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := c.Field(i); f.CanSet() {
+				f.Set(deepCopy(v.Field(i), seen))
+			}
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value(), seen))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+		return c
+	}
+	return v
+}
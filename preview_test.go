@@ -0,0 +1,154 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type previewState struct {
+	Count int
+}
+
+func TestPreviewEvent(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		s := data.Assigns.(*previewState)
+		return strings.NewReader(fmt.Sprintf(`<html><body><div>%d</div></body></html>`, s.Count)), nil
+	})
+	h.HandleEvent("inc", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		state := s.Assigns().(*previewState)
+		state.Count++
+		return state, nil
+	})
+	e := NewBaseEngine(h)
+
+	ctx := context.Background()
+	sock := NewBaseSocket(NewSession(), e, true)
+	state := &previewState{Count: 1}
+	sock.Assign(state)
+	render, err := RenderSocket(ctx, e, sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock.UpdateRender(render)
+
+	if err := e.previewEvent(ctx, e, sock, Event{T: "inc", Preview: true}); err != nil {
+		t.Fatal(err)
+	}
+	if state.Count != 1 || sock.Assigns() != state {
+		t.Errorf("expected assigns to be restored, got %+v", sock.Assigns())
+	}
+	if sock.LatestRender() != render {
+		t.Error("expected the latest render to be unchanged")
+	}
+
+	msg := <-sock.Messages()
+	if msg.T != EventPreview {
+		t.Fatalf("expected a preview event got %s", msg.T)
+	}
+	var preview Preview
+	if err := json.Unmarshal(msg.Data, &preview); err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Patches) != 1 || !strings.Contains(preview.Patches[0].HTML, ">2<") {
+		t.Errorf("expected a patch to 2, got %v", preview.Patches)
+	}
+	if len(preview.Revert) != 1 || !strings.Contains(preview.Revert[0].HTML, ">1<") {
+		t.Errorf("expected a revert to 1, got %v", preview.Revert)
+	}
+}
+
+type previewCart struct {
+	Items []string
+	Total *previewState
+	Tags  map[string][]string
+}
+
+func TestPreviewEventDeepCopy(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		c := data.Assigns.(*previewCart)
+		return strings.NewReader(fmt.Sprintf(`<html><body><div>%v %d</div></body></html>`, c.Items, c.Total.Count)), nil
+	})
+	h.HandleEvent("add", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		c := s.Assigns().(*previewCart)
+		c.Items[0] = "changed"
+		c.Total.Count++
+		c.Tags["a"][0] = "changed"
+		return c, nil
+	})
+	h.HandleEvent("fail", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, fmt.Errorf("failed")
+	})
+	e := NewBaseEngine(h)
+	WithCircuitBreaker(1, time.Minute, time.Minute)(e)
+
+	ctx := context.Background()
+	sock := NewBaseSocket(NewSession(), e, true)
+	cart := &previewCart{Items: []string{"a"}, Total: &previewState{Count: 1}, Tags: map[string][]string{"a": {"a"}}}
+	sock.Assign(cart)
+	render, err := RenderSocket(ctx, e, sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock.UpdateRender(render)
+
+	if err := e.previewEvent(ctx, e, sock, Event{T: "add", Preview: true}); err != nil {
+		t.Fatal(err)
+	}
+	if cart.Items[0] != "a" || cart.Total.Count != 1 || cart.Tags["a"][0] != "a" {
+		t.Errorf("preview changed the assigns %+v", cart)
+	}
+
+	// Previews don't count towards the stats or trip the breaker.
+	e.previewEvent(ctx, e, sock, Event{T: "fail", Preview: true})
+	e.previewEvent(ctx, e, sock, Event{T: "fail", Preview: true})
+	if n := e.Stats().Events; n != 0 {
+		t.Errorf("expected previews not to be counted, got %d", n)
+	}
+	if err := e.CallEvent(ctx, "fail", sock, Event{T: "fail"}); errors.Is(err, ErrCircuitOpen) {
+		t.Error("expected previews not to open the circuit")
+	}
+}
+
+func TestPreviewEventChildState(t *testing.T) {
+	widget := NewHandler()
+	widget.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		return &previewState{Count: 1}, nil
+	})
+	widget.HandleEvent("inc", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		state := s.Assigns().(*previewState)
+		state.Count++
+		return state, nil
+	})
+	widget.HandleRender(func(ctx context.Context, rc *RenderContext) (io.Reader, error) {
+		return strings.NewReader(fmt.Sprintf("%d", rc.Assigns.(*previewState).Count)), nil
+	})
+
+	ctx := context.Background()
+	e := NewBaseEngine(testRenderHandler())
+	sock := NewBaseSocket(NewSession(), e, true)
+	em, err := Embed(ctx, "widget", widget, sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := em.GetState().(*previewState)
+	render, err := RenderSocket(ctx, e, sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock.UpdateRender(render)
+
+	if err := e.previewEvent(ctx, e, sock, Event{T: em.Event("inc"), Preview: true}); err != nil {
+		t.Fatal(err)
+	}
+	if em.GetState() != state || state.Count != 1 {
+		t.Errorf("expected the embedded state to be restored, got %+v", em.GetState())
+	}
+}
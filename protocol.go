@@ -0,0 +1,268 @@
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ProtocolVersion the base version of the wire protocol, spoken by every
+// client.
+const ProtocolVersion = 1
+
+// LatestProtocolVersion the newest version of the wire protocol the server
+// speaks. Version 2 sends compact patches, which can set the attributes of an
+// element without replacing it. Clients ask for it when they connect.
+const LatestProtocolVersion = 2
+
+// liveProtocol body attribute pointing the client at the protocol descriptor.
+const liveProtocol = "live-protocol"
+
+// defaultReadLimit the websocket read limit if none is configured.
+const defaultReadLimit = 32768
+
+// Encodings of websocket messages.
+const (
+	EncodingJSON = "json"
+	EncodingCBOR = "cbor"
+)
+
+// ConnectData sent to the client with the connect event, it tells the client
+// what has been negotiated for the connection.
+type ConnectData struct {
+	// Version the protocol version spoken on this connection.
+	Version int `json:"v,omitempty"`
+	// Encoding how messages on this connection are encoded.
+	Encoding string `json:"enc,omitempty"`
+	// Compression whether the server compresses websocket messages.
+	Compression bool `json:"z,omitempty"`
+	// Heartbeat how often in milliseconds the client should send a heartbeat,
+	// zero if it shouldn't.
+	Heartbeat int64 `json:"hb,omitempty"`
+	// Batch whether the client may send a batch of events in one message.
+	Batch bool `json:"b,omitempty"`
+	// TrustedTypes the Trusted Types policy name the client should apply
+	// patches through.
+	TrustedTypes string `json:"tt,omitempty"`
+	// TrustedMark the mark patches sent on this connection carry, the
+	// client refuses patches without it.
+	TrustedMark string `json:"tm,omitempty"`
+	// ReadLimit the largest message in bytes the server reads, the client
+	// splits batches to stay under it.
+	ReadLimit int64 `json:"rl,omitempty"`
+}
+
+// ProtocolDescriptor describes how a client should talk to a handler.
+type ProtocolDescriptor struct {
+	// Version the base protocol version spoken by the server.
+	Version int `json:"version"`
+	// Versions all the protocol versions spoken by the server.
+	Versions []int `json:"versions"`
+	// Endpoint the path to dial the websocket on, empty means the current page.
+	Endpoint string `json:"endpoint"`
+	// Features which are enabled on the handler.
+	Features ProtocolFeatures `json:"features"`
+	// Limits the handler enforces.
+	Limits ProtocolLimits `json:"limits"`
+}
+
+// ProtocolFeatures features that are enabled on a handler.
+type ProtocolFeatures struct {
+	Uploads     bool `json:"uploads"`
+	Compression bool `json:"compression"`
+	Binary      bool `json:"binary"`
+	// TrustedTypes the policy patches are applied through, if any.
+	TrustedTypes string `json:"trustedTypes,omitempty"`
+}
+
+// ProtocolLimits limits that are enforced by a handler.
+type ProtocolLimits struct {
+	// MaxUploadSize the maximum size of an upload request in bytes.
+	MaxUploadSize int64 `json:"maxUploadSize"`
+	// MaxMessageBuffer the number of messages that can be queued for a
+	// socket before it is considered too slow.
+	MaxMessageBuffer int `json:"maxMessageBuffer"`
+	// ReadLimit the maximum size of an incoming websocket message in bytes.
+	ReadLimit int64 `json:"readLimit"`
+	// UploadChunkSize the size of resumable upload chunks in bytes, zero
+	// if uploads are not chunked.
+	UploadChunkSize int64 `json:"uploadChunkSize,omitempty"`
+	// Heartbeat how often in milliseconds the client should send a
+	// heartbeat, zero if it shouldn't.
+	Heartbeat int64 `json:"heartbeat,omitempty"`
+}
+
+// WithProtocolPath serve a protocol descriptor at the given path, the
+// client will fetch this before connecting.
+func WithProtocolPath(path string) EngineConfig {
+	return func(e Engine) error {
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			httpEngine.protocolPath = path
+		}
+		return nil
+	}
+}
+
+// WithEndpoint tell clients to dial the websocket, and send uploads, on the
+// given path rather than the current page. Use it when the handler is mounted
+// on a different path to the pages it serves. The client learns the endpoint
+// from the protocol descriptor, so WithProtocolPath must also be used.
+func WithEndpoint(path string) EngineConfig {
+	return func(e Engine) error {
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			httpEngine.endpoint = path
+		}
+		return nil
+	}
+}
+
+// WithReadLimit set the maximum size in bytes of a message read from a
+// websocket, connections which send a larger message are closed.
+func WithReadLimit(limit int64) EngineConfig {
+	return func(e Engine) error {
+		if limit <= 0 {
+			return fmt.Errorf("read limit must be positive, got %d", limit)
+		}
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			httpEngine.readLimit = limit
+		}
+		return nil
+	}
+}
+
+// maxMessageSize the websocket read limit of the handler.
+func (h *HttpEngine) maxMessageSize() int64 {
+	if h.readLimit == 0 {
+		return defaultReadLimit
+	}
+	return h.readLimit
+}
+
+// Protocol returns the descriptor for this handler.
+func (h *HttpEngine) Protocol() ProtocolDescriptor {
+	var endpoint string
+	if h.endpoint != "" {
+		endpoint = h.basePath + h.endpoint
+	}
+	return ProtocolDescriptor{
+		Version:  ProtocolVersion,
+		Versions: []int{ProtocolVersion, LatestProtocolVersion},
+		Endpoint: endpoint,
+		Features: ProtocolFeatures{
+			Uploads:      true,
+			Compression:  h.compression(),
+			Binary:       h.binary,
+			TrustedTypes: h.trustedTypes,
+		},
+		Limits: ProtocolLimits{
+			MaxUploadSize:    h.MaxUploadSize,
+			UploadChunkSize:  h.UploadChunkSize,
+			MaxMessageBuffer: maxMessageBufferSize,
+			ReadLimit:        h.maxMessageSize(),
+			Heartbeat:        h.heartbeat.Milliseconds(),
+		},
+	}
+}
+
+// serveProtocol writes the protocol descriptor.
+func (h *HttpEngine) serveProtocol(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := json.NewEncoder(w).Encode(h.Protocol()); err != nil {
+		h.Error()(httpContext(w, r), err)
+	}
+}
+
+// connectEvent builds the event sent when a connection is made, negotiating
+// how the client and server will talk over it.
+func (h *HttpEngine) connectEvent(c socketConn, compression bool, mark string) Event {
+	data := ConnectData{
+		Version:      ProtocolVersion,
+		Encoding:     EncodingJSON,
+		Compression:  compression,
+		Heartbeat:    h.heartbeat.Milliseconds(),
+		Batch:        true,
+		TrustedTypes: h.trustedTypes,
+		TrustedMark:  mark,
+		ReadLimit:    h.maxMessageSize(),
+	}
+	if v, ok := c.(versionedConn); ok {
+		data.Version = v.protocolVersion()
+	}
+	if isBinary(c) {
+		data.Encoding = EncodingCBOR
+	}
+	d, err := json.Marshal(data)
+	if err != nil {
+		return Event{T: EventConnect}
+	}
+	return Event{T: EventConnect, Data: d}
+}
+
+// WithHeartbeat has clients send a heartbeat at the interval, connections
+// which miss two in a row are closed. This finds connections which have
+// silently gone away, for example behind a proxy, sooner.
+func WithHeartbeat(interval time.Duration) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.heartbeat = interval
+		case *HttpEngine:
+			v.heartbeat = interval
+		}
+		return nil
+	}
+}
+
+// Websocket subprotocols clients ask for to speak a newer protocol version or
+// to use binary encoding. Clients which ask for none speak the base version
+// with JSON.
+const (
+	subprotocolV2       = "live.v2"
+	subprotocolV2Binary = "live.v2.cbor"
+)
+
+// subprotocols the websocket subprotocols the server accepts, in order of
+// preference.
+func subprotocols(binary bool) []string {
+	if binary {
+		return []string{subprotocolV2Binary, subprotocolV2, binarySubprotocol}
+	}
+	return []string{subprotocolV2}
+}
+
+// parseSubprotocol gets the protocol version and encoding of a negotiated
+// subprotocol.
+func parseSubprotocol(subprotocol string) (version int, binary bool) {
+	switch subprotocol {
+	case subprotocolV2Binary:
+		return 2, true
+	case subprotocolV2:
+		return 2, false
+	case binarySubprotocol:
+		return ProtocolVersion, true
+	}
+	return ProtocolVersion, false
+}
+
+// versionedConn is implemented by connections which have negotiated a
+// protocol version.
+type versionedConn interface {
+	protocolVersion() int
+}
+
+// protocolVersioner is implemented by sockets which know the protocol version
+// of their client.
+type protocolVersioner interface {
+	protocolVersion() int
+	setProtocolVersion(version int)
+}
+
+// socketProtocolVersion the protocol version a sockets client speaks.
+func socketProtocolVersion(s Socket) int {
+	if v, ok := s.(protocolVersioner); ok {
+		return v.protocolVersion()
+	}
+	return ProtocolVersion
+}
@@ -0,0 +1,137 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+func TestConnectEvent(t *testing.T) {
+	e := NewHttpHandler(NewTestStore("test"), NewHandler(), WithHeartbeat(15*time.Second))
+	m := e.connectEvent(newTestConn(), true, "")
+	if m.T != EventConnect {
+		t.Fatalf("expected connect event, got %s", m.T)
+	}
+	var data ConnectData
+	if err := json.Unmarshal(m.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	expected := ConnectData{Version: ProtocolVersion, Encoding: EncodingJSON, Compression: true, Heartbeat: 15000, Batch: true, ReadLimit: defaultReadLimit}
+	if data != expected {
+		t.Errorf("expected %+v, got %+v", expected, data)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	e := NewBaseEngine(testRenderHandler())
+	WithHeartbeat(20 * time.Millisecond)(e)
+	c := newTestConn()
+	sock := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(sock)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- e.serveSocket(ctx, e, sock, c, httptest.NewRequest("GET", "/", nil))
+	}()
+
+	// Heartbeats keep the connection open and are not acknowledged.
+	for i := 0; i < 4; i++ {
+		c.in <- Event{T: EventHeartbeat, ID: i + 1}
+		time.Sleep(15 * time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("connection closed while sending heartbeats: %v", err)
+	case m := <-c.out:
+		if m.T == EventAck {
+			t.Fatalf("heartbeat was acknowledged")
+		}
+	default:
+	}
+
+	// Silence closes it.
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("connection was not closed after missed heartbeats")
+	}
+}
+
+func TestProtocolDescriptor(t *testing.T) {
+	e := NewHttpHandler(NewTestStore("test"), testRenderHandler(), WithProtocolPath("/protocol"))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protocol", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected json, got %q", ct)
+	}
+	var d ProtocolDescriptor
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Endpoint != "" {
+		t.Errorf("expected the current page to be dialed, got %q", d.Endpoint)
+	}
+	if d.Limits.ReadLimit != defaultReadLimit {
+		t.Errorf("expected the default read limit, got %d", d.Limits.ReadLimit)
+	}
+
+	e = NewHttpHandler(NewTestStore("test"), testRenderHandler(), WithBasePath("/app"), WithProtocolPath("/protocol"), WithEndpoint("/live"), WithReadLimit(1024))
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/app/protocol", nil))
+	d = ProtocolDescriptor{}
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Endpoint != "/app/live" {
+		t.Errorf("expected the configured endpoint, got %q", d.Endpoint)
+	}
+	if d.Limits.ReadLimit != 1024 {
+		t.Errorf("expected the configured read limit, got %d", d.Limits.ReadLimit)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	if err := WithReadLimit(0)(&HttpEngine{}); err == nil {
+		t.Error("expected a zero read limit to be refused")
+	}
+
+	e := NewHttpHandler(NewTestStore("test"), testRenderHandler(), WithReadLimit(64))
+	if limit := dialConnectData(t, e, "Go").ReadLimit; limit != 64 {
+		t.Errorf("expected the read limit to be sent at connect, got %d", limit)
+	}
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+	ctx := context.Background()
+	c, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(websocket.StatusNormalClosure, "")
+	if _, _, err := c.Read(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A message over the limit closes the connection.
+	if err := c.Write(ctx, websocket.MessageText, []byte(`{"t":"big","d":"`+strings.Repeat("a", 128)+`"}`)); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, _, err := c.Read(ctx); err != nil {
+			if websocket.CloseStatus(err) != websocket.StatusMessageTooBig {
+				t.Errorf("expected the message to be too big, got %v", err)
+			}
+			return
+		}
+	}
+}
@@ -44,7 +44,7 @@ func (p *PubSub) Publish(ctx context.Context, topic string, msg Event) error {
 
 // Subscribe adds a handler to a PubSub topic.
 func (p *PubSub) Subscribe(topic string, h Engine) {
-	p.handlers[topic] = append(p.handlers[topic], h)
+	p.subscribe(topic, h)
 
 	// This adjusts the handlers broadcast function to publish onto the
 	// given topic.
@@ -55,6 +55,11 @@ func (p *PubSub) Subscribe(topic string, h Engine) {
 	})
 }
 
+// subscribe adds a handler to a topic without changing how it broadcasts.
+func (p *PubSub) subscribe(topic string, h Engine) {
+	p.handlers[topic] = append(p.handlers[topic], h)
+}
+
 // Recieve a message from the transport.
 func (p *PubSub) Recieve(topic string, msg Event) {
 	ctx := context.Background()
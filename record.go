@@ -0,0 +1,161 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RecordedEvent an event handled by a socket, and the assigns it left behind.
+type RecordedEvent struct {
+	Time  time.Time `json:"time"`
+	Event Event     `json:"event"`
+	// Assigns the socket's assigns after the event was handled, encoded as
+	// json. Empty if they could not be encoded.
+	Assigns json.RawMessage `json:"assigns,omitempty"`
+	// Err the error the handler returned.
+	Err string `json:"error,omitempty"`
+}
+
+// Recording the events handled by a socket, in order. It is json encodable so
+// that it can be saved from a running app and replayed in a test.
+type Recording struct {
+	Socket SocketID        `json:"socket"`
+	Events []RecordedEvent `json:"events"`
+}
+
+// Recorder captures the events handled by sockets.
+type Recorder interface {
+	Record(id SocketID, e RecordedEvent)
+}
+
+// MemoryRecorder a Recorder which keeps recordings in memory. Recordings are
+// kept after their socket closes, so that they can be looked at after a
+// crash, until MaxSockets newer ones push them out.
+type MemoryRecorder struct {
+	mu         sync.Mutex
+	recordings map[SocketID]*Recording
+	// order the sockets recorded, oldest first.
+	order []SocketID
+	// Max the number of events kept for each socket, older events are
+	// dropped. Defaults to 1000.
+	Max int
+	// MaxSockets the number of sockets recordings are kept for, the oldest
+	// recording is dropped to make room. Defaults to 100.
+	MaxSockets int
+}
+
+// NewMemoryRecorder creates a new MemoryRecorder.
+func NewMemoryRecorder() *MemoryRecorder {
+	return &MemoryRecorder{recordings: map[SocketID]*Recording{}, Max: 1000, MaxSockets: 100}
+}
+
+// Record an event handled by a socket.
+func (m *MemoryRecorder) Record(id SocketID, e RecordedEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.recordings[id]
+	if !ok {
+		r = &Recording{Socket: id}
+		m.recordings[id] = r
+		m.order = append(m.order, id)
+		if m.MaxSockets > 0 && len(m.order) > m.MaxSockets {
+			delete(m.recordings, m.order[0])
+			m.order = m.order[1:]
+		}
+	}
+	r.Events = append(r.Events, e)
+	if m.Max > 0 && len(r.Events) > m.Max {
+		r.Events = r.Events[len(r.Events)-m.Max:]
+	}
+}
+
+// Recording returns the recording of a socket.
+func (m *MemoryRecorder) Recording(id SocketID) (Recording, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.recordings[id]
+	if !ok {
+		return Recording{}, false
+	}
+	events := make([]RecordedEvent, len(r.Events))
+	copy(events, r.Events)
+	return Recording{Socket: id, Events: events}, true
+}
+
+// Delete the recording of a socket.
+func (m *MemoryRecorder) Delete(id SocketID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.recordings[id]; !ok {
+		return
+	}
+	delete(m.recordings, id)
+	m.order = slices.DeleteFunc(m.order, func(o SocketID) bool { return o == id })
+}
+
+// WithRecorder records the events handled by every connected socket, with
+// a snapshot of its assigns after each. Use it while debugging, and Replay
+// the recording in a test.
+func WithRecorder(r Recorder) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.recorder = r
+		case *HttpEngine:
+			v.recorder = r
+		}
+		return nil
+	}
+}
+
+// record passes a handled event to the recorder.
+func (e *BaseEngine) record(sock Socket, m Event, err error) {
+	if e.recorder == nil {
+		return
+	}
+	rec := RecordedEvent{Time: time.Now(), Event: m}
+	if assigns, aerr := json.Marshal(sock.Assigns()); aerr == nil {
+		rec.Assigns = assigns
+	} else {
+		slog.Debug("could not record assigns", "error", aerr, "socket", sock.ID())
+	}
+	if err != nil {
+		rec.Err = err.Error()
+	}
+	e.recorder.Record(sock.ID(), rec)
+}
+
+// Replay feeds the events of a recording back through the engine's handler
+// for a socket, stopping at the first event which fails. Compare the socket's
+// assigns with those recorded to check it ended up in the same state.
+//
+//	e := live.NewBaseEngine(h)
+//	s := live.NewBaseSocket(live.NewSession(), e, true)
+//	if err := live.Replay(ctx, e, s, recording); err != nil {
+//		t.Fatal(err)
+//	}
+func Replay(ctx context.Context, e Engine, s Socket, r Recording) error {
+	data, err := e.Mount()(ctx, s)
+	if err != nil {
+		return fmt.Errorf("replay mount error: %w", err)
+	}
+	s.Assign(data)
+	for idx, rec := range r.Events {
+		m := rec.Event
+		switch m.T {
+		case EventParams:
+			err = e.CallParams(ctx, s, m)
+		default:
+			err = e.CallEvent(ctx, m.T, s, m)
+		}
+		if err != nil {
+			return fmt.Errorf("replay event %d %s: %w", idx, m.T, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type recordState struct {
+	Count int
+}
+
+func recordHandler() *BaseHandler {
+	h := testRenderHandler()
+	h.HandleMount(func(ctx context.Context, s Socket) (any, error) {
+		return &recordState{}, nil
+	})
+	h.HandleEvent("add", func(ctx context.Context, s Socket, p Params) (any, error) {
+		state := s.Assigns().(*recordState)
+		state.Count += p.Int("n")
+		return state, nil
+	})
+	return h
+}
+
+func TestRecordReplay(t *testing.T) {
+	recorder := NewMemoryRecorder()
+	e := NewBaseEngine(recordHandler())
+	WithRecorder(recorder)(e)
+	c := serveTestSocket(t, e)
+
+	c.in <- Event{T: "add", ID: 1, Data: json.RawMessage(`{"n":2}`)}
+	c.expectAck(t, 1)
+	c.in <- Event{T: "add", ID: 2, Data: json.RawMessage(`{"n":3}`)}
+	c.expectAck(t, 2)
+
+	var recording Recording
+	for id := range recorder.recordings {
+		recording, _ = recorder.Recording(id)
+	}
+	if len(recording.Events) != 2 || string(recording.Events[1].Assigns) != `{"Count":5}` {
+		t.Fatalf("unexpected recording %+v", recording)
+	}
+
+	// Recordings are saved as json and replayed in tests.
+	d, err := json.Marshal(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded Recording
+	if err := json.Unmarshal(d, &loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	replay := NewBaseEngine(recordHandler())
+	s := NewBaseSocket(NewSession(), replay, true)
+	if err := Replay(context.Background(), replay, s, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if s.Assigns().(*recordState).Count != 5 {
+		t.Fatalf("expected replay to reach the recorded state, got %+v", s.Assigns())
+	}
+}
+
+func TestMemoryRecorderMaxSockets(t *testing.T) {
+	recorder := NewMemoryRecorder()
+	recorder.MaxSockets = 2
+	recorder.Record("a", RecordedEvent{})
+	recorder.Record("b", RecordedEvent{})
+	recorder.Record("a", RecordedEvent{})
+	recorder.Record("c", RecordedEvent{})
+	if _, ok := recorder.Recording("a"); ok {
+		t.Error("expected the oldest recording to be dropped")
+	}
+	for _, id := range []SocketID{"b", "c"} {
+		if _, ok := recorder.Recording(id); !ok {
+			t.Errorf("expected recording %s to be kept", id)
+		}
+	}
+
+	recorder.Delete("b")
+	recorder.Record("d", RecordedEvent{})
+	if _, ok := recorder.Recording("c"); !ok {
+		t.Error("expected a deleted recording to make room")
+	}
+}
@@ -0,0 +1,51 @@
+//go:build redis
+
+package live
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ SocketRegistry = &RedisRegistry{}
+
+// RedisRegistry a SocketRegistry shared between nodes through redis.
+type RedisRegistry struct {
+	client redis.UniversalClient
+	// Prefix of the keys sockets are stored under, defaults to "live:socket:".
+	Prefix string
+	// TTL how long a socket is kept without being unregistered, in case its
+	// node goes away. Defaults to a day.
+	TTL time.Duration
+}
+
+// NewRedisRegistry creates a new RedisRegistry.
+func NewRedisRegistry(client redis.UniversalClient) *RedisRegistry {
+	return &RedisRegistry{
+		client: client,
+		Prefix: "live:socket:",
+		TTL:    24 * time.Hour,
+	}
+}
+
+// Register records that a socket is connected to a node.
+func (r *RedisRegistry) Register(ctx context.Context, id SocketID, node string) error {
+	return r.client.Set(ctx, r.Prefix+string(id), node, r.TTL).Err()
+}
+
+// Unregister removes a socket from the registry.
+func (r *RedisRegistry) Unregister(ctx context.Context, id SocketID) error {
+	return r.client.Del(ctx, r.Prefix+string(id)).Err()
+}
+
+// Lookup returns the node a socket is connected to.
+func (r *RedisRegistry) Lookup(ctx context.Context, id SocketID) (string, error) {
+	node, err := r.client.Get(ctx, r.Prefix+string(id)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNoSocket
+	}
+	return node, err
+}
@@ -0,0 +1,151 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// eventForward the event published to forward a self event to a socket on
+// another node.
+const eventForward = "_live_forward"
+
+// SocketRegistry records which node each connected socket lives on, so that
+// events for a socket can be forwarded to the node it is connected to when
+// running more than one instance of an application.
+type SocketRegistry interface {
+	// Register records that a socket is connected to a node.
+	Register(ctx context.Context, id SocketID, node string) error
+	// Unregister removes a socket from the registry.
+	Unregister(ctx context.Context, id SocketID) error
+	// Lookup returns the node a socket is connected to, or ErrNoSocket.
+	Lookup(ctx context.Context, id SocketID) (string, error)
+}
+
+// LocalRegistry a SocketRegistry for a single instance, the default.
+type LocalRegistry struct {
+	mu    sync.Mutex
+	nodes map[SocketID]string
+}
+
+// NewLocalRegistry creates a new LocalRegistry.
+func NewLocalRegistry() *LocalRegistry {
+	return &LocalRegistry{nodes: map[SocketID]string{}}
+}
+
+// Register records that a socket is connected to a node.
+func (l *LocalRegistry) Register(ctx context.Context, id SocketID, node string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nodes[id] = node
+	return nil
+}
+
+// Unregister removes a socket from the registry.
+func (l *LocalRegistry) Unregister(ctx context.Context, id SocketID) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.nodes, id)
+	return nil
+}
+
+// Lookup returns the node a socket is connected to.
+func (l *LocalRegistry) Lookup(ctx context.Context, id SocketID) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	node, ok := l.nodes[id]
+	if !ok {
+		return "", ErrNoSocket
+	}
+	return node, nil
+}
+
+// forwardedEvent a self event on its way to a socket on another node.
+type forwardedEvent struct {
+	Socket SocketID `json:"socket"`
+	Event  Event    `json:"event"`
+}
+
+// WithSocketRegistry shares where sockets are connected between the nodes of
+// a cluster, so that SelfSocket can reach sockets on any of them. Events are
+// forwarded to the node a socket is on with the pubsub, node names this
+// instance and must be unique.
+func WithSocketRegistry(registry SocketRegistry, pubsub *PubSub, node string) EngineConfig {
+	return func(e Engine) error {
+		var base *BaseEngine
+		switch v := e.(type) {
+		case *BaseEngine:
+			base = v
+		case *HttpEngine:
+			base = v.BaseEngine
+		default:
+			return nil
+		}
+		base.registry = registry
+		base.node = node
+		base.forward = func(ctx context.Context, node string, msg Event) error {
+			return pubsub.Publish(ctx, forwardTopic(node), msg)
+		}
+		pubsub.subscribe(forwardTopic(node), e)
+		return nil
+	}
+}
+
+// forwardTopic the pubsub topic of events forwarded to a node.
+func forwardTopic(node string) string {
+	return "live:forward:" + node
+}
+
+// SelfSocket sends a self event to a socket by its ID, on whichever node of
+// the cluster it is connected to. Data forwarded to another node is encoded
+// as JSON, so the handler there gets it back as maps, slices and primitives
+// rather than the type it was sent as.
+func (e *BaseEngine) SelfSocket(ctx context.Context, id SocketID, event string, data interface{}) error {
+	msg := Event{T: event, SelfData: data}
+	if sock, err := e.GetSocketByID(id); err == nil {
+		e.self(ctx, sock, msg)
+		return nil
+	}
+	node, err := e.registry.Lookup(ctx, id)
+	if err != nil {
+		return fmt.Errorf("could not find socket %s: %w", id, err)
+	}
+	if node == e.node || e.forward == nil {
+		return ErrNoSocket
+	}
+	d, err := json.Marshal(forwardedEvent{Socket: id, Event: msg})
+	if err != nil {
+		return fmt.Errorf("could not encode forwarded event: %w", err)
+	}
+	return e.forward(ctx, node, Event{T: eventForward, Data: d})
+}
+
+// receiveForward handles an event forwarded from another node.
+func (e *BaseEngine) receiveForward(ctx context.Context, msg Event) {
+	var f forwardedEvent
+	if err := json.Unmarshal(msg.Data, &f); err != nil {
+		slog.ErrorContext(ctx, "could not decode forwarded event", "error", err)
+		return
+	}
+	sock, err := e.GetSocketByID(f.Socket)
+	if err != nil {
+		return
+	}
+	e.self(ctx, sock, f.Event)
+}
+
+// register records a socket in the registry.
+func (e *BaseEngine) register(id SocketID) {
+	if err := e.registry.Register(context.Background(), id, e.node); err != nil {
+		slog.Error("socket registry error", "error", err, "socket", id)
+	}
+}
+
+// unregister removes a socket from the registry.
+func (e *BaseEngine) unregister(id SocketID) {
+	if err := e.registry.Unregister(context.Background(), id); err != nil {
+		slog.Error("socket registry error", "error", err, "socket", id)
+	}
+}
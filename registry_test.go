@@ -0,0 +1,62 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSelfSocketForwards(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pubsub := NewPubSub(ctx, NewLocalTransport())
+	registry := NewLocalRegistry()
+
+	handled := make(chan interface{}, 1)
+	h := testRenderHandler()
+	h.HandleSelf("ping", func(ctx context.Context, s Socket, data interface{}) (any, error) {
+		handled <- data
+		return nil, nil
+	})
+	a := NewBaseEngine(h)
+	b := NewBaseEngine(h)
+	WithSocketRegistry(registry, pubsub, "a")(a)
+	WithSocketRegistry(registry, pubsub, "b")(b)
+
+	sock := NewBaseSocket(NewSession(), b, true)
+	b.AddSocket(sock)
+	if node, err := registry.Lookup(ctx, sock.ID()); err != nil || node != "b" {
+		t.Fatalf("expected socket to be registered on b, got %s %v", node, err)
+	}
+
+	if err := a.SelfSocket(ctx, sock.ID(), "ping", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-handled:
+		if data != "hello" {
+			t.Fatalf("expected hello, got %v", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the event to be forwarded")
+	}
+
+	// Forwarded data has been through JSON.
+	if err := a.SelfSocket(ctx, sock.ID(), "ping", struct{ N int }{N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-handled:
+		if m, ok := data.(map[string]interface{}); !ok || m["N"] != float64(1) {
+			t.Fatalf("expected the data decoded from JSON, got %#v", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the event to be forwarded")
+	}
+
+	b.DeleteSocket(sock)
+	if err := a.SelfSocket(ctx, sock.ID(), "ping", "hello"); !errors.Is(err, ErrNoSocket) {
+		t.Fatalf("expected no socket, got %v", err)
+	}
+}
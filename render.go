@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
+	"path"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/net/html"
 )
@@ -15,14 +19,75 @@ type RenderContext struct {
 	Socket  Socket
 	Uploads UploadContext
 	Assigns interface{}
+	// Locale the sockets locale, for use with FormatFuncs.
+	Locale string
+	// BasePath the path prefix the handler is mounted under.
+	BasePath string
+	// Flashes flash messages to show the user.
+	Flashes []Flash
+}
+
+// patchConfigurer is implemented by sockets which can configure the next
+// patch they send.
+type patchConfigurer interface {
+	takePatchOptions() []EventConfig
 }
 
 // RenderSocket takes the engine and current socket and renders it to html.
 func RenderSocket(ctx context.Context, e Engine, s Socket) (*html.Node, error) {
+	if l, ok := e.(renderLimiter); ok {
+		release, err := l.acquireRender(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("render queue error: %w", err)
+		}
+		defer release()
+	}
+	if r, ok := e.(statsRecorder); ok {
+		start := time.Now()
+		defer func() {
+			r.recordRender(time.Since(start))
+		}()
+	}
+
+	render, err := renderTree(ctx, e, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.LatestRender() != nil {
+		version := socketProtocolVersion(s)
+		patches, err := diffRenders(e, s.LatestRender(), render, version)
+		if err != nil {
+			return nil, fmt.Errorf("diff error: %w", err)
+		}
+		if len(patches) != 0 {
+			var options []EventConfig
+			if p, ok := s.(patchConfigurer); ok {
+				options = p.takePatchOptions()
+			}
+			s.Send(EventPatch, encodePatches(patches, version), options...)
+		}
+		if r, ok := e.(replicator); ok {
+			r.replicate(s, patches, render)
+		}
+	} else {
+		anchorTree(render, newAnchorGenerator())
+	}
+
+	return render, nil
+}
+
+// renderTree renders the socket and shapes the html ready for diffing.
+func renderTree(ctx context.Context, e Engine, s Socket) (*html.Node, error) {
 	rc := &RenderContext{
 		Socket:  s,
 		Uploads: s.Uploads(),
 		Assigns: s.Assigns(),
+		Locale:  s.Locale(),
+		Flashes: s.Flashes(),
+	}
+	if b, ok := e.(basePather); ok {
+		rc.BasePath = b.BasePath()
 	}
 
 	output, err := e.Render()(ctx, rc)
@@ -33,21 +98,44 @@ func RenderSocket(ctx context.Context, e Engine, s Socket) (*html.Node, error) {
 	if err != nil {
 		return nil, fmt.Errorf("html parse error: %w", err)
 	}
+	if m, ok := e.(minifier); ok && m.minifyEnabled() {
+		minifyTree(render)
+	}
 	shapeTree(render)
+	if h, ok := s.(headSetter); ok {
+		title, meta := h.head()
+		setHead(render, title, meta)
+	}
+	if b, ok := e.(bodyAttributer); ok {
+		setBodyAttrs(render, b.bodyAttributes())
+	}
+	if f, ok := e.(formFallbacker); ok && f.formFallbackEnabled() {
+		setFormFallback(render, csrfToken(s.Session()))
+	}
+	return render, nil
+}
 
-	if s.LatestRender() != nil {
-		patches, err := Diff(s.LatestRender(), render)
-		if err != nil {
-			return nil, fmt.Errorf("diff error: %w", err)
-		}
-		if len(patches) != 0 {
-			s.Send(EventPatch, patches)
+// bodyAttributer is implemented by engines which annotate the rendered body.
+type bodyAttributer interface {
+	bodyAttributes() []html.Attribute
+}
+
+// setBodyAttrs sets attributes on the body node of a render.
+func setBodyAttrs(root *html.Node, attrs []html.Attribute) {
+	if len(attrs) == 0 {
+		return
+	}
+	if root.Type == html.ElementNode && root.Data == "body" {
+		for _, a := range attrs {
+			if !hasAttr(root, a.Key) {
+				root.Attr = append(root.Attr, a)
+			}
 		}
-	} else {
-		anchorTree(render, newAnchorGenerator())
+		return
+	}
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		setBodyAttrs(c, attrs)
 	}
-
-	return render, nil
 }
 
 // WithTemplateRenderer set the handler to use an `html/template` renderer.
@@ -63,3 +151,71 @@ func WithTemplateRenderer(t *template.Template) HandlerConfig {
 		return nil
 	}
 }
+
+// layoutTemplate the name of the template WithTemplateFS renders if it has
+// been defined.
+const layoutTemplate = "layout"
+
+// TemplateFuncs the functions available in templates parsed by
+// WithTemplateFS. As well as FormatFuncs there is
+//
+//	{{ Event .Assigns.Child "open" }}  the event scoped for a component
+func TemplateFuncs() template.FuncMap {
+	funcs := FormatFuncs()
+	funcs["Event"] = func(c Child, event string) string {
+		return c.Event(event)
+	}
+	return funcs
+}
+
+// WithTemplateFS set the handler to render templates parsed from the files
+// in fsys matching the patterns, with TemplateFuncs available. Layouts and
+// partials can be spread across the files and used with `{{ template }}`. The
+// template named "layout" is rendered if one is defined, otherwise the first
+// file parsed. In dev mode the files are parsed again when they change.
+//
+//	live.WithTemplateFS(os.DirFS("templates"), "layout.html", "partials/*.html", "index.html")
+func WithTemplateFS(fsys fs.FS, patterns ...string) HandlerConfig {
+	return func(h Handler) error {
+		if len(patterns) == 0 {
+			return fmt.Errorf("no template patterns given")
+		}
+		parse := func() (*template.Template, error) {
+			t, err := template.New("").Funcs(TemplateFuncs()).ParseFS(fsys, patterns...)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse templates: %w", err)
+			}
+			if l := t.Lookup(layoutTemplate); l != nil {
+				return l, nil
+			}
+			files, err := fs.Glob(fsys, patterns[0])
+			if err != nil || len(files) == 0 {
+				return nil, fmt.Errorf("could not find template for %s: %w", patterns[0], err)
+			}
+			return t.Lookup(path.Base(files[0])), nil
+		}
+		t, err := parse()
+		if err != nil {
+			return err
+		}
+		var current atomic.Pointer[template.Template]
+		current.Store(t)
+
+		h.HandleRender(func(ctx context.Context, rc *RenderContext) (io.Reader, error) {
+			var buf bytes.Buffer
+			if err := current.Load().Execute(&buf, rc); err != nil {
+				return nil, err
+			}
+			return &buf, nil
+		})
+		h.HandleReload(func() error {
+			t, err := parse()
+			if err != nil {
+				return err
+			}
+			current.Store(t)
+			return nil
+		})
+		return nil
+	}
+}
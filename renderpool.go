@@ -0,0 +1,107 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// RenderStats describes the renders of an engine limited with
+// WithRenderConcurrency.
+type RenderStats struct {
+	// Limit the number of renders which can run at once.
+	Limit int
+	// Active the number of renders running.
+	Active int64
+	// Queued the number of renders waiting to run.
+	Queued int64
+	// Rendered the number of renders which have finished.
+	Rendered uint64
+	// Waited the total time renders have spent queued.
+	Waited time.Duration
+}
+
+// renderPool bounds the number of renders running at once.
+type renderPool struct {
+	sem      chan struct{}
+	active   atomic.Int64
+	queued   atomic.Int64
+	rendered atomic.Uint64
+	waited   atomic.Int64
+}
+
+func newRenderPool(limit int) *renderPool {
+	return &renderPool{sem: make(chan struct{}, limit)}
+}
+
+// acquire waits for a render to be allowed to run, returning the func to call
+// once it has finished.
+func (p *renderPool) acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+	p.queued.Add(1)
+	select {
+	case p.sem <- struct{}{}:
+	case <-ctx.Done():
+		p.queued.Add(-1)
+		return nil, ctx.Err()
+	}
+	p.queued.Add(-1)
+	p.active.Add(1)
+	p.waited.Add(int64(time.Since(start)))
+	return func() {
+		p.active.Add(-1)
+		p.rendered.Add(1)
+		<-p.sem
+	}, nil
+}
+
+func (p *renderPool) stats() RenderStats {
+	return RenderStats{
+		Limit:    cap(p.sem),
+		Active:   p.active.Load(),
+		Queued:   p.queued.Load(),
+		Rendered: p.rendered.Load(),
+		Waited:   time.Duration(p.waited.Load()),
+	}
+}
+
+// WithRenderConcurrency limits the number of sockets the engine renders at
+// once, so a burst of reconnects doesn't render every socket at the same
+// time. Renders over the limit wait their turn. Use RenderStats to see how
+// renders are queueing.
+func WithRenderConcurrency(limit int) EngineConfig {
+	return func(e Engine) error {
+		if limit < 1 {
+			return fmt.Errorf("render concurrency must be at least 1, got %d", limit)
+		}
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.renders = newRenderPool(limit)
+		case *HttpEngine:
+			v.renders = newRenderPool(limit)
+		}
+		return nil
+	}
+}
+
+// RenderStats returns the render stats of the engine, these are only kept
+// when it is configured with WithRenderConcurrency.
+func (e *BaseEngine) RenderStats() RenderStats {
+	if e.renders == nil {
+		return RenderStats{}
+	}
+	return e.renders.stats()
+}
+
+// renderLimiter is implemented by engines which limit concurrent renders.
+type renderLimiter interface {
+	acquireRender(ctx context.Context) (func(), error)
+}
+
+func (e *BaseEngine) acquireRender(ctx context.Context) (func(), error) {
+	if e.renders == nil {
+		return func() {}, nil
+	}
+	return e.renders.acquire(ctx)
+}
@@ -0,0 +1,53 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRenderConcurrency(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+	if err := WithRenderConcurrency(1)(e); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := e.acquireRender(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	acquired := make(chan func())
+	go func() {
+		r, _ := e.acquireRender(context.Background())
+		acquired <- r
+	}()
+	for e.RenderStats().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if s := e.RenderStats(); s.Active != 1 || s.Limit != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	release()
+	(<-acquired)()
+	if s := e.RenderStats(); s.Rendered != 2 || s.Active != 0 || s.Queued != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func TestRenderConcurrencyCancelled(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+	WithRenderConcurrency(1)(e)
+	release, _ := e.acquireRender(context.Background())
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := e.acquireRender(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected queued render to time out, got %v", err)
+	}
+	if s := e.RenderStats(); s.Queued != 0 {
+		t.Errorf("expected nothing queued, got %d", s.Queued)
+	}
+}
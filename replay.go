@@ -0,0 +1,77 @@
+package live
+
+import (
+	"sync"
+	"time"
+)
+
+// pageParam the query param the client identifies its page load with when
+// connecting, so that events it sends again after a reconnect are spotted.
+const pageParam = "live-page"
+
+const (
+	// replayTTL how long the events handled for a page load are remembered
+	// after its last event.
+	replayTTL = 5 * time.Minute
+	// maxReplayEvents the number of handled events remembered per page load.
+	maxReplayEvents = 256
+)
+
+// replayPage the events handled for a page load, oldest first.
+type replayPage struct {
+	ids     map[int]struct{}
+	order   []int
+	expires time.Time
+}
+
+// replayCache remembers the IDs of events handled for each page load. The
+// client queues events made before it connects, and sends again any which
+// were not acknowledged when a connection drops, this drops the ones which
+// had already been handled.
+type replayCache struct {
+	mu    sync.Mutex
+	pages map[string]*replayPage
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{pages: map[string]*replayPage{}}
+}
+
+// handled reports whether the event has already been handled for the page
+// load, remembering it if not.
+func (c *replayCache) handled(page string, id int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	p, ok := c.pages[page]
+	if !ok || now.After(p.expires) {
+		for k, p := range c.pages {
+			if now.After(p.expires) {
+				delete(c.pages, k)
+			}
+		}
+		p = &replayPage{ids: map[int]struct{}{}}
+		c.pages[page] = p
+	}
+	p.expires = now.Add(replayTTL)
+	if _, ok := p.ids[id]; ok {
+		return true
+	}
+	p.ids[id] = struct{}{}
+	p.order = append(p.order, id)
+	if len(p.order) > maxReplayEvents {
+		delete(p.ids, p.order[0])
+		p.order = p.order[1:]
+	}
+	return false
+}
+
+// replayed reports whether an event from the page load has already been
+// handled. Events without an ID, or from clients which don't identify their
+// page load, are always handled.
+func (e *BaseEngine) replayed(page string, m Event) bool {
+	if page == "" || m.ID == 0 {
+		return false
+	}
+	return e.replays.handled(page, m.ID)
+}
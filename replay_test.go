@@ -0,0 +1,54 @@
+package live
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReplayedEvents(t *testing.T) {
+	h := testRenderHandler()
+	release := make(chan struct{})
+	h.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		if s.Connected() {
+			<-release
+		}
+		return 10, nil
+	})
+	var calls atomic.Int32
+	var seen atomic.Int32
+	h.HandleEvent("inc", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		calls.Add(1)
+		n := s.Assigns().(int) + 1
+		seen.Store(int32(n))
+		return n, nil
+	})
+	e := NewBaseEngine(h)
+
+	// Events sent before the socket is mounted wait for it.
+	c := serveTestSocket(t, e, withPage("page-1"))
+	c.in <- Event{T: "inc", ID: 1}
+	close(release)
+	c.expectAck(t, 1)
+	if seen.Load() != 11 {
+		t.Errorf("expected event to be handled after mount, got %d", seen.Load())
+	}
+
+	// Sent again after reconnecting, it is acknowledged but not handled.
+	c = serveTestSocket(t, e, withPage("page-1"))
+	c.in <- Event{T: "inc", ID: 1}
+	c.expectAck(t, 1)
+	c.in <- Event{T: "inc", ID: 2}
+	c.expectAck(t, 2)
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 calls, got %d", calls.Load())
+	}
+
+	// Another page load has its own events.
+	c = serveTestSocket(t, e, withPage("page-2"))
+	c.in <- Event{T: "inc", ID: 1}
+	c.expectAck(t, 1)
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 calls, got %d", calls.Load())
+	}
+}
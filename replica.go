@@ -0,0 +1,206 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// ReplicaTopicFunc decides which replica topic a connected socket belongs to.
+// Returning an empty string opts the socket out of replication.
+type ReplicaTopicFunc func(ctx context.Context, s Socket) string
+
+// replicaGroup is a set of sockets sharing the state of a single writer.
+type replicaGroup struct {
+	// mu serialises fan out to mirrors with mirrors joining.
+	mu      sync.Mutex
+	writer  Socket
+	mirrors []Socket
+}
+
+// WithReadReplicas enables the read replica render mode. The first socket to
+// connect to a topic becomes the writer and owns the state, every other socket
+// on that topic becomes a read only mirror which receives the writers patches
+// rather than rendering itself. If the writer disconnects the oldest mirror is
+// promoted in its place.
+func WithReadReplicas(topic ReplicaTopicFunc) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.replicaTopic = topic
+		case *HttpEngine:
+			v.replicaTopic = topic
+		}
+		return nil
+	}
+}
+
+// ReplicaWriter returns the socket which currently owns the state for a topic.
+// Server jobs can use this to send self events to the writer.
+func (e *BaseEngine) ReplicaWriter(topic string) (Socket, error) {
+	e.replicasMu.Lock()
+	defer e.replicasMu.Unlock()
+	g, ok := e.replicas[topic]
+	if !ok || g.writer == nil {
+		return nil, ErrNoSocket
+	}
+	return g.writer, nil
+}
+
+// joinReplica adds a connected socket to its replica topic. If the socket
+// becomes a mirror it is brought in line with the writers current state.
+func (e *BaseEngine) joinReplica(ctx context.Context, sock Socket) error {
+	if e.replicaTopic == nil {
+		return nil
+	}
+	topic := e.replicaTopic(ctx, sock)
+	if topic == "" {
+		return nil
+	}
+
+	e.replicasMu.Lock()
+	g, ok := e.replicas[topic]
+	if !ok {
+		g = &replicaGroup{}
+		e.replicas[topic] = g
+	}
+	e.replicaOf[sock.ID()] = topic
+	if g.writer == nil {
+		g.writer = sock
+		e.replicasMu.Unlock()
+		return nil
+	}
+	e.replicasMu.Unlock()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// The writer may have gone away while we were waiting, in which case
+	// this socket takes over.
+	e.replicasMu.Lock()
+	if g.writer == nil {
+		g.writer = sock
+		e.replicas[topic] = g
+		e.replicasMu.Unlock()
+		return nil
+	}
+	writer := g.writer
+	g.mirrors = append(g.mirrors, sock)
+	e.replicasMu.Unlock()
+
+	// Patch the mirrors client to match the writers latest render, from
+	// here on it receives the writers patches.
+	sock.Assign(writer.Assigns())
+	base := writer.LatestRender()
+	if base == nil || sock.LatestRender() == nil {
+		return nil
+	}
+	version := socketProtocolVersion(sock)
+	patches, err := diffRenders(e, sock.LatestRender(), base, version)
+	if err != nil {
+		return fmt.Errorf("replica sync diff error: %w", err)
+	}
+	if len(patches) != 0 {
+		sock.Send(EventPatch, encodePatches(patches, version))
+	}
+	sock.UpdateRender(base)
+	return nil
+}
+
+// leaveReplica removes a socket from its replica topic, promoting a mirror
+// if the socket was the writer.
+func (e *BaseEngine) leaveReplica(sock Socket) {
+	e.replicasMu.Lock()
+	defer e.replicasMu.Unlock()
+
+	topic, ok := e.replicaOf[sock.ID()]
+	if !ok {
+		return
+	}
+	delete(e.replicaOf, sock.ID())
+	g := e.replicas[topic]
+
+	if g.writer != nil && g.writer.ID() == sock.ID() {
+		g.writer = nil
+		if len(g.mirrors) > 0 {
+			g.writer = g.mirrors[0]
+			g.mirrors = g.mirrors[1:]
+		}
+	} else {
+		for idx, m := range g.mirrors {
+			if m.ID() == sock.ID() {
+				g.mirrors = append(g.mirrors[:idx], g.mirrors[idx+1:]...)
+				break
+			}
+		}
+	}
+
+	if g.writer == nil {
+		delete(e.replicas, topic)
+	}
+}
+
+// isMirror returns true if the socket is a read only mirror.
+func (e *BaseEngine) isMirror(sock Socket) bool {
+	e.replicasMu.Lock()
+	defer e.replicasMu.Unlock()
+	topic, ok := e.replicaOf[sock.ID()]
+	if !ok {
+		return false
+	}
+	g := e.replicas[topic]
+	return g.writer == nil || g.writer.ID() != sock.ID()
+}
+
+// replicate fans a writers render out to its mirrors.
+func (e *BaseEngine) replicate(sock Socket, patches []Patch, render *html.Node) {
+	e.replicasMu.Lock()
+	topic, ok := e.replicaOf[sock.ID()]
+	if !ok {
+		e.replicasMu.Unlock()
+		return
+	}
+	g := e.replicas[topic]
+	if g.writer == nil || g.writer.ID() != sock.ID() {
+		e.replicasMu.Unlock()
+		return
+	}
+	e.replicasMu.Unlock()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e.replicasMu.Lock()
+	mirrors := make([]Socket, len(g.mirrors))
+	copy(mirrors, g.mirrors)
+	e.replicasMu.Unlock()
+
+	data := sock.Assigns()
+	version := socketProtocolVersion(sock)
+	for _, m := range mirrors {
+		m.Assign(data)
+		if len(patches) != 0 {
+			// Mirrors speaking another protocol version need their own
+			// patches.
+			mirrorPatches, mirrorVersion := patches, socketProtocolVersion(m)
+			if mirrorVersion != version {
+				var err error
+				mirrorPatches, err = diffRenders(e, m.LatestRender(), render, mirrorVersion)
+				if err != nil {
+					slog.Error("replica diff error", "error", err, "socket", m.ID())
+					continue
+				}
+			}
+			m.Send(EventPatch, encodePatches(mirrorPatches, mirrorVersion))
+		}
+		m.UpdateRender(render)
+	}
+}
+
+// replicator is implemented by engines which support read replicas.
+type replicator interface {
+	replicate(sock Socket, patches []Patch, render *html.Node)
+}
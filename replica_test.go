@@ -0,0 +1,48 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReplicaPromotion(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+	WithReadReplicas(func(ctx context.Context, s Socket) string {
+		return "scores"
+	})(e)
+
+	ctx := context.Background()
+	writer := NewBaseSocket(NewSession(), e, true)
+	mirror := NewBaseSocket(NewSession(), e, true)
+	for _, s := range []*BaseSocket{writer, mirror} {
+		e.AddSocket(s)
+		if err := e.joinReplica(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if e.isMirror(writer) {
+		t.Fatal("expected first socket to be the writer")
+	}
+	if !e.isMirror(mirror) {
+		t.Fatal("expected second socket to be a mirror")
+	}
+	if err := e.CallEvent(ctx, "inc", mirror, Event{T: "inc"}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+
+	e.DeleteSocket(writer)
+	w, err := e.ReplicaWriter("scores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.ID() != mirror.ID() {
+		t.Errorf("expected mirror to be promoted, got %s", w.ID())
+	}
+
+	e.DeleteSocket(mirror)
+	if _, err := e.ReplicaWriter("scores"); !errors.Is(err, ErrNoSocket) {
+		t.Errorf("expected topic to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package live
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// RequestIDHeader the header a request ID is adopted from, and returned in.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey the context key for the request ID.
+const requestIDKey contextKey = "context_request_id"
+
+// liveRequestID body attribute carrying the initial render's request ID, the
+// client connects with it so the websocket connection carries it on.
+const liveRequestID = "live-request-id"
+
+// requestIDParam the query param the client connects with the initial
+// render's request ID in.
+const requestIDParam = "live-request-id"
+
+// maxRequestIDLength the longest request ID adopted from a client.
+const maxRequestIDLength = 128
+
+// contextWithRequestID embed a request ID within the context.
+func contextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID pulls the request ID out of a context. This is the ID of the
+// initial render, and is shared by every event handled on the socket.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// requestID adopt the request ID from the request headers, or generate one.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); validRequestID(id) {
+		return id
+	}
+	return NewID()
+}
+
+// socketRequestID the request ID carried over from the initial render, or
+// one for this connection if the client didn't connect with one.
+func socketRequestID(r *http.Request) string {
+	if id := r.URL.Query().Get(requestIDParam); validRequestID(id) {
+		return id
+	}
+	return requestID(r)
+}
+
+// validRequestID returns true if a request ID from a client can be adopted,
+// it must be short and only use characters which can't forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("-_.:+/=", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package live
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDHeader(t *testing.T) {
+	var rendered string
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		rendered = RequestID(ctx)
+		return strings.NewReader(`<html><body></body></html>`), nil
+	})
+	e := NewHttpHandler(NewTestStore("test"), h)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if got := w.Header().Get(RequestIDHeader); got != "abc" {
+		t.Errorf("expected adopted id abc got %q", got)
+	}
+	if rendered != "abc" {
+		t.Errorf("expected abc in render context got %q", rendered)
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Header().Get(RequestIDHeader) == "" {
+		t.Error("expected a generated request id")
+	}
+}
+
+func TestSocketRequestID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?"+requestIDParam+"=render", nil)
+	r.Header.Set(RequestIDHeader, "upgrade")
+	if got := socketRequestID(r); got != "render" {
+		t.Errorf("expected the initial render id got %q", got)
+	}
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "upgrade")
+	if got := socketRequestID(r); got != "upgrade" {
+		t.Errorf("expected the upgrade id got %q", got)
+	}
+}
+
+func TestRequestIDRendered(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body></body></html>`), nil
+	})
+	e := NewHttpHandler(NewTestStore("test"), h)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), liveRequestID+`="abc"`) {
+		t.Errorf("expected the request id on the body, got %s", w.Body.String())
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	for id, valid := range map[string]bool{
+		"abc":                              true,
+		"4bf92f3577b34da6a3ce929d0e0e4736": true,
+		"00-4bf92f35-00f067aa0ba902b7-01":  true,
+		"a1b2/c3+d4==":                     true,
+		"":                                 false,
+		strings.Repeat("a", maxRequestIDLength+1): false,
+		"abc\nlevel=ERROR":                        false,
+		"abc def":                                 false,
+		`abc"><script>`:                           false,
+	} {
+		if got := validRequestID(id); got != valid {
+			t.Errorf("%q: expected %v got %v", id, valid, got)
+		}
+	}
+
+	// Invalid IDs aren't adopted.
+	r := httptest.NewRequest(http.MethodGet, "/?"+requestIDParam+"=a%0Ab", nil)
+	r.Header.Set(RequestIDHeader, "c d")
+	if got := socketRequestID(r); got == "a\nb" || got == "c d" || got == "" {
+		t.Errorf("expected a generated id got %q", got)
+	}
+}
@@ -0,0 +1,260 @@
+package live
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxScheduledEvents the maximum number of client events a page can have
+// waiting for delivery.
+const maxScheduledEvents = 32
+
+// maxScheduleDelay how far ahead a client can ask for an event to be
+// delivered.
+const maxScheduleDelay = 24 * time.Hour
+
+// scheduleHold how long events which came due while their page was
+// disconnected wait for it to reconnect.
+const scheduleHold = replayTTL
+
+// pageSchedule the events a page load has waiting for delivery. They outlive
+// its connection, so that a page which reconnects, say after its tab slept,
+// still gets them.
+type pageSchedule struct {
+	timers map[uint64]*scheduledEvent
+	// due events which came due while the page was disconnected.
+	due []Event
+	// deliver handles events on the page's current connection, nil while
+	// it is disconnected.
+	deliver func(Event)
+	// conn identifies the connection deliver belongs to.
+	conn uint64
+	// persist if the page can reconnect, connections without a page load
+	// ID drop their events when they close.
+	persist bool
+	// expire forgets the page if it doesn't reconnect.
+	expire *time.Timer
+}
+
+// scheduledEvent an event waiting for its time.
+type scheduledEvent struct {
+	event Event
+	timer *time.Timer
+}
+
+// eventScheduler holds client events which should be delivered at a later
+// time, by page load.
+type eventScheduler struct {
+	mu    sync.Mutex
+	max   int
+	next  uint64
+	pages map[string]*pageSchedule
+}
+
+func newEventScheduler(max int) *eventScheduler {
+	return &eventScheduler{
+		max:   max,
+		pages: map[string]*pageSchedule{},
+	}
+}
+
+// page returns the schedule of a page, mu must be held.
+func (s *eventScheduler) page(key string, persist bool) *pageSchedule {
+	p, ok := s.pages[key]
+	if !ok {
+		p = &pageSchedule{timers: map[uint64]*scheduledEvent{}, persist: persist}
+		s.pages[key] = p
+	}
+	return p
+}
+
+// schedule delivers an event to the page at the given time.
+func (s *eventScheduler) schedule(key string, persist bool, at time.Time, m Event) error {
+	if time.Until(at) > maxScheduleDelay {
+		return ErrScheduledTooFar
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.page(key, persist)
+	if len(p.timers)+len(p.due) >= s.max {
+		return ErrTooManyScheduled
+	}
+	s.next++
+	id := s.next
+	p.timers[id] = &scheduledEvent{
+		event: m,
+		timer: time.AfterFunc(time.Until(at), func() {
+			s.fire(key, id)
+		}),
+	}
+	return nil
+}
+
+// fire delivers a scheduled event, or holds it until the page reconnects.
+func (s *eventScheduler) fire(key string, id uint64) {
+	s.mu.Lock()
+	p, ok := s.pages[key]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	se, ok := p.timers[id]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	delete(p.timers, id)
+	m := se.event
+	deliver := p.deliver
+	if deliver == nil {
+		p.due = append(p.due, m)
+		s.expire(key, p)
+	}
+	s.mu.Unlock()
+	if deliver != nil {
+		deliver(m)
+	}
+}
+
+// expire forgets a disconnected page once nothing is left to come due, and
+// its held events have waited long enough, mu must be held.
+func (s *eventScheduler) expire(key string, p *pageSchedule) {
+	if p.deliver != nil || len(p.timers) > 0 {
+		return
+	}
+	if len(p.due) == 0 {
+		delete(s.pages, key)
+		return
+	}
+	if p.expire != nil {
+		p.expire.Stop()
+	}
+	p.expire = time.AfterFunc(scheduleHold, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pages[key] == p && p.deliver == nil {
+			delete(s.pages, key)
+		}
+	})
+}
+
+// attach delivers the page's events to a connection from now on, along with
+// any which came due while it was away. The returned func detaches it.
+func (s *eventScheduler) attach(key string, persist bool, deliver func(Event)) (detach func()) {
+	s.mu.Lock()
+	s.next++
+	conn := s.next
+	p := s.page(key, persist)
+	if p.expire != nil {
+		p.expire.Stop()
+		p.expire = nil
+	}
+	p.deliver = deliver
+	p.conn = conn
+	due := p.due
+	p.due = nil
+	s.mu.Unlock()
+
+	if len(due) > 0 {
+		go func() {
+			for _, m := range due {
+				deliver(m)
+			}
+		}()
+	}
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pages[key] != p || p.conn != conn || p.deliver == nil {
+			return
+		}
+		p.deliver = nil
+		if !p.persist {
+			for _, se := range p.timers {
+				se.timer.Stop()
+			}
+			delete(s.pages, key)
+			return
+		}
+		s.expire(key, p)
+	}
+}
+
+// deliverAt returns when an event should be handled, and if it should be
+// scheduled at all.
+func (e Event) deliverAt() (time.Time, bool) {
+	if e.At == 0 {
+		return time.Time{}, false
+	}
+	at := time.UnixMilli(e.At)
+	if !at.After(time.Now()) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// SendEvery sends a self event to this socket every interval, until stop is
+// called or the socket is closed. The event data is the time of the tick.
+// It does nothing on sockets which aren't connected, so can be called from
+// mount.
+func (s *BaseSocket) SendEvery(event string, d time.Duration) (stop func()) {
+	if !s.connected || d <= 0 {
+		return func() {}
+	}
+	return sendEvery(s.lifetime(), s.Self, event, d)
+}
+
+// SelfAfter sends a self event to this socket once the delay has passed,
+// unless cancel is called or the socket is closed first. Use it for things
+// like dismissing a toast or retrying later. Values are kept from the
+// context, but not its cancellation, as the context of a handler ends once it
+// returns. It does nothing on sockets which aren't connected.
+func (s *BaseSocket) SelfAfter(ctx context.Context, event string, data interface{}, d time.Duration) (cancel func()) {
+	if !s.connected {
+		return func() {}
+	}
+	return selfAfter(ctx, s.lifetime(), s.Self, event, data, d)
+}
+
+// selfFunc sends a self event, such as Socket.Self.
+type selfFunc func(ctx context.Context, event string, data interface{}, options ...EventConfig) error
+
+// sendEvery sends a self event with self every interval until stop is called
+// or the lifetime ends.
+func sendEvery(lifetime context.Context, self selfFunc, event string, d time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(lifetime)
+	go func() {
+		t := time.NewTicker(d)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case tick := <-t.C:
+				self(ctx, event, tick)
+			}
+		}
+	}()
+	return cancel
+}
+
+// selfAfter sends a self event with self once the delay has passed, unless
+// cancel is called or the lifetime ends first.
+func selfAfter(ctx context.Context, lifetime context.Context, self selfFunc, event string, data interface{}, d time.Duration) (cancel func()) {
+	ctx = context.WithoutCancel(ctx)
+	t := time.AfterFunc(d, func() {
+		if lifetime.Err() != nil {
+			return
+		}
+		self(ctx, event, data)
+	})
+	stop := context.AfterFunc(lifetime, func() {
+		t.Stop()
+	})
+	return func() {
+		t.Stop()
+		stop()
+	}
+}
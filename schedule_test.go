@@ -0,0 +1,158 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduledEvent(t *testing.T) {
+	reminded := make(chan struct{}, 1)
+	h := testRenderHandler()
+	h.HandleEvent("remind", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		reminded <- struct{}{}
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	c := serveTestSocket(t, e)
+
+	c.in <- Event{T: "remind", ID: 1, At: time.Now().Add(50 * time.Millisecond).UnixMilli()}
+	c.expectAck(t, 1)
+	select {
+	case <-reminded:
+		t.Fatal("event was handled before it was due")
+	default:
+	}
+	select {
+	case <-reminded:
+	case <-time.After(time.Second):
+		t.Fatal("scheduled event was not handled")
+	}
+}
+
+func TestScheduledEventTooFar(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleEvent("remind", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	c := serveTestSocket(t, e)
+
+	c.in <- Event{T: "remind", ID: 1, At: time.Now().Add(2 * maxScheduleDelay).UnixMilli()}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case m := <-c.out:
+			if m.T == EventError {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected an error for an event scheduled too far ahead")
+		}
+	}
+}
+
+func TestScheduledEventReconnect(t *testing.T) {
+	reminded := make(chan SocketID, 1)
+	h := testRenderHandler()
+	h.HandleEvent("remind", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		reminded <- s.ID()
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	session := NewSession()
+
+	// The tab sleeps, closing the connection, before the event is due.
+	first := serveTestSocket(t, e, withSession(session), withPage("page-1"))
+	first.in <- Event{T: "remind", ID: 1, At: time.Now().Add(30 * time.Millisecond).UnixMilli()}
+	first.expectAck(t, 1)
+	first.stop()
+	time.Sleep(60 * time.Millisecond)
+	select {
+	case <-reminded:
+		t.Fatal("event was handled without a connection")
+	default:
+	}
+
+	// It is delivered once the page reconnects.
+	serveTestSocket(t, e, withSession(session), withPage("page-1"))
+	select {
+	case <-reminded:
+	case <-time.After(time.Second):
+		t.Fatal("scheduled event was not handled after reconnecting")
+	}
+}
+
+func TestScheduledEventDoesNotBlockReads(t *testing.T) {
+	answered := make(chan string, 1)
+	h := testRenderHandler()
+	h.HandleEvent("ask", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		r, err := s.(*BaseSocket).Call(ctx, "question", nil)
+		if err != nil {
+			return nil, err
+		}
+		answered <- string(r)
+		return nil, nil
+	})
+	h.HandleEvent("remind", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	c := serveTestSocket(t, e)
+
+	// While the handler waits on the client, scheduling an event must not
+	// stop its reply being read.
+	c.in <- Event{T: "ask", ID: 1}
+	var call Event
+	for call.T != "question" {
+		select {
+		case call = <-c.out:
+		case <-time.After(time.Second):
+			t.Fatal("no call from the handler")
+		}
+	}
+	c.in <- Event{T: "remind", ID: 2, At: time.Now().Add(time.Hour).UnixMilli()}
+	c.in <- Event{T: EventReply, ID: call.ID, Data: json.RawMessage(`{"r":"yes"}`)}
+	select {
+	case r := <-answered:
+		if r != `"yes"` {
+			t.Errorf("expected the client's reply, got %s", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reply was not read while the handler was running")
+	}
+}
+
+func TestEventSchedulerLimits(t *testing.T) {
+	s := newEventScheduler(1)
+	at := time.Now().Add(time.Hour)
+	if err := s.schedule("page", true, at, Event{T: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.schedule("page", true, at, Event{T: "b"}); !errors.Is(err, ErrTooManyScheduled) {
+		t.Errorf("expected ErrTooManyScheduled, got %v", err)
+	}
+	if err := s.schedule("other", true, time.Now().Add(2*maxScheduleDelay), Event{T: "a"}); !errors.Is(err, ErrScheduledTooFar) {
+		t.Errorf("expected ErrScheduledTooFar, got %v", err)
+	}
+}
+
+func TestEventSchedulerDropsWithoutPage(t *testing.T) {
+	s := newEventScheduler(maxScheduledEvents)
+	delivered := make(chan Event, 1)
+	detach := s.attach("socket:1", false, func(m Event) { delivered <- m })
+	if err := s.schedule("socket:1", false, time.Now().Add(20*time.Millisecond), Event{T: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	detach()
+	if _, ok := s.pages["socket:1"]; ok {
+		t.Error("expected the schedule to be forgotten")
+	}
+	select {
+	case <-delivered:
+		t.Error("expected the event to be dropped")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
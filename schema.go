@@ -0,0 +1,154 @@
+package live
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrorCodeInvalidPayload the code of errors sent to the client when an
+// event's params don't match its schema.
+const ErrorCodeInvalidPayload = "invalid_payload"
+
+// EventValidator checks the params of an event before its handler is called.
+// Return an error to reject the event.
+type EventValidator func(p Params) error
+
+// Schema builds an EventValidator from a struct. Params are matched to fields
+// by their json name, and must be convertible to the field's type the way the
+// Params helpers convert them, so numeric fields accept numeric strings from
+// forms. Fields tagged `live:"required"` must be present and not empty.
+//
+//	type CreateRoom struct {
+//		Name string `json:"name" live:"required"`
+//		Size int    `json:"size"`
+//	}
+//
+//	h.HandleEventSchema("create", live.Schema[CreateRoom]())
+func Schema[T any]() EventValidator {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("live: schema of non struct type %s", t))
+	}
+	return func(p Params) error {
+		return validateStruct(t, p, "")
+	}
+}
+
+// validateStruct checks the params against the fields of a struct type.
+func validateStruct(t reflect.Type, p map[string]interface{}, prefix string) error {
+	var errs []error
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		v, ok := p[name]
+		if !ok || v == nil || v == "" {
+			if f.Tag.Get("live") == "required" {
+				errs = append(errs, fmt.Errorf("%s%s is required", prefix, name))
+			}
+			continue
+		}
+		if err := validateValue(f.Type, v, prefix+name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateValue checks that a param value can be converted to a type.
+func validateValue(t reflect.Type, v interface{}, name string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%s must be a string", name)
+		}
+	case reflect.Bool:
+		switch b := v.(type) {
+		case bool:
+		case string:
+			if b != "on" && b != "off" {
+				if _, err := strconv.ParseBool(b); err != nil {
+					return fmt.Errorf("%s must be a boolean", name)
+				}
+			}
+		default:
+			return fmt.Errorf("%s must be a boolean", name)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch n := v.(type) {
+		case float64:
+			if n != float64(int64(n)) {
+				return fmt.Errorf("%s must be an integer", name)
+			}
+		case string:
+			if _, err := strconv.ParseInt(n, 10, 64); err != nil {
+				return fmt.Errorf("%s must be an integer", name)
+			}
+		default:
+			return fmt.Errorf("%s must be an integer", name)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := v.(type) {
+		case float64:
+		case string:
+			if _, err := strconv.ParseFloat(n, 64); err != nil {
+				return fmt.Errorf("%s must be a number", name)
+			}
+		default:
+			return fmt.Errorf("%s must be a number", name)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be a list", name)
+		}
+		var errs []error
+		for idx, item := range items {
+			if err := validateValue(t.Elem(), item, fmt.Sprintf("%s[%d]", name, idx)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	case reflect.Map:
+		if _, ok := v.(map[string]interface{}); !ok {
+			return fmt.Errorf("%s must be an object", name)
+		}
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object", name)
+		}
+		return validateStruct(t, m, name+".")
+	}
+	return nil
+}
+
+// validateEvent runs the validator registered for an event, if there is one.
+func validateEvent(h Handler, t string, p Params) error {
+	validator, ok := h.getEventSchema(t)
+	if !ok {
+		return nil
+	}
+	if err := validator(p); err != nil {
+		return NewError(ErrorCodeInvalidPayload, fmt.Errorf("%w for %s: %w", ErrInvalidPayload, t, err))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type createRoom struct {
+	Name    string   `json:"name" live:"required"`
+	Size    int      `json:"size"`
+	Private bool     `json:"private"`
+	Tags    []string `json:"tags"`
+}
+
+func TestSchema(t *testing.T) {
+	validate := Schema[createRoom]()
+
+	valid := []Params{
+		{"name": "lobby"},
+		{"name": "lobby", "size": "10", "private": "on", "tags": []interface{}{"a"}},
+		{"name": "lobby", "size": float64(10), "private": true, "other": "ignored"},
+	}
+	for _, p := range valid {
+		if err := validate(p); err != nil {
+			t.Errorf("expected %v to be valid, got %s", p, err)
+		}
+	}
+
+	invalid := []Params{
+		{},
+		{"name": ""},
+		{"name": float64(1)},
+		{"name": "lobby", "size": "ten"},
+		{"name": "lobby", "size": 1.5},
+		{"name": "lobby", "private": "maybe"},
+		{"name": "lobby", "tags": []interface{}{float64(1)}},
+	}
+	for _, p := range invalid {
+		if err := validate(p); err == nil {
+			t.Errorf("expected %v to be invalid", p)
+		}
+	}
+}
+
+func TestCallEventSchema(t *testing.T) {
+	called := false
+	h := NewHandler()
+	h.HandleEvent("create", func(ctx context.Context, s Socket, p Params) (any, error) {
+		called = true
+		return nil, nil
+	})
+	h.HandleEventSchema("create", Schema[createRoom]())
+	e := NewBaseEngine(h)
+	sock := NewBaseSocket(NewSession(), e, true)
+
+	err := e.CallEvent(context.Background(), "create", sock, Event{T: "create", Data: json.RawMessage(`{"size":"big"}`)})
+	if !errors.Is(err, ErrInvalidPayload) || ErrorCode(err) != ErrorCodeInvalidPayload {
+		t.Fatalf("expected invalid payload error, got %v", err)
+	}
+	if called {
+		t.Fatal("expected the handler not to be called")
+	}
+
+	err = e.CallEvent(context.Background(), "create", sock, Event{T: "create", Data: json.RawMessage(`{"name":"lobby"}`)})
+	if err != nil || !called {
+		t.Fatalf("expected the handler to be called, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package live
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// systemdListenFdsStart the first file descriptor passed by systemd.
+const systemdListenFdsStart = 3
+
+// Serve serves this handler on a listener, for example a unix socket
+//
+//	l, err := net.Listen("unix", "/run/app/live.sock")
+//	...
+//	h.Serve(l)
+func (h *HttpEngine) Serve(l net.Listener) error {
+	return newServer(h).Serve(l)
+}
+
+// ServeSystemd serves this handler on the first listener passed by systemd
+// socket activation.
+func (h *HttpEngine) ServeSystemd() error {
+	listeners, err := SystemdListeners()
+	if err != nil {
+		return err
+	}
+	for _, l := range listeners[1:] {
+		l.Close()
+	}
+	return h.Serve(listeners[0])
+}
+
+// SystemdListeners returns the listeners passed to this process by systemd
+// socket activation. The activation environment is cleared so that child
+// processes don't inherit it.
+func SystemdListeners() ([]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, ErrNoSystemdListeners
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, ErrNoSystemdListeners
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(systemdListenFdsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(systemdListenFdsStart+i), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("systemd listener %s: %w", name, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// newServer an http server with timeouts which protect against slow clients
+// without cutting off long lived sockets. Read and write timeouts are left
+// unset as their deadlines persist on hijacked websocket connections.
+func newServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+// ListenAndServeAutoTLS serves the handler over HTTPS on port 443 using
+// certificates from Let's Encrypt for the given domains. Port 80 answers ACME
+// challenges and redirects everything else to HTTPS. Certificates are cached
+// in the user cache directory.
+func ListenAndServeAutoTLS(handler http.Handler, domains ...string) error {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return fmt.Errorf("could not find certificate cache: %w", err)
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(filepath.Join(cacheDir, "live-autocert")),
+	}
+
+	redirect := newServer(m.HTTPHandler(nil))
+	redirect.Addr = ":http"
+	errs := make(chan error, 2)
+	go func() {
+		errs <- redirect.ListenAndServe()
+	}()
+
+	srv := newServer(handler)
+	srv.Addr = ":https"
+	srv.TLSConfig = &tls.Config{
+		GetCertificate: m.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1", "acme-tls/1"},
+	}
+	go func() {
+		errs <- srv.ListenAndServeTLS("", "")
+	}()
+
+	err = <-errs
+	redirect.Close()
+	srv.Close()
+	return err
+}
@@ -0,0 +1,61 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeUnix(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body>unix</body></html>`), nil
+	})
+	e := NewHttpHandler(NewTestStore("test"), h)
+
+	path := filepath.Join(t.TempDir(), "live.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix sockets unavailable:", err)
+	}
+	defer l.Close()
+	go e.Serve(l)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		},
+	}}
+	res, err := client.Get("http://live/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if !strings.Contains(string(body), "unix") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestSystemdListenersNotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+	if _, err := SystemdListeners(); !errors.Is(err, ErrNoSystemdListeners) {
+		t.Errorf("expected ErrNoSystemdListeners got %v", err)
+	}
+}
+
+func TestNewServerTimeouts(t *testing.T) {
+	srv := newServer(http.NotFoundHandler())
+	if srv.ReadTimeout != 0 || srv.WriteTimeout != 0 {
+		t.Error("read and write timeouts would cut off websockets")
+	}
+	if srv.ReadHeaderTimeout == 0 {
+		t.Error("expected a read header timeout")
+	}
+}
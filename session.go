@@ -28,6 +28,22 @@ func SessionID(session Session) string {
 	return ID
 }
 
+// Regenerate gives the session a new ID keeping its values, do this when a
+// user logs in to prevent session fixation. Use RegenerateSession so that the
+// session store and connected sockets follow.
+func (s Session) Regenerate() {
+	s[sessionID] = NewID()
+}
+
+// Reset removes all the values from the session and gives it a new ID, do
+// this when a user logs out.
+func (s Session) Reset() {
+	for k := range s {
+		delete(s, k)
+	}
+	s[sessionID] = NewID()
+}
+
 // NewID returns a new ID.
 func NewID() string {
 	return xid.New().String()
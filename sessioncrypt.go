@@ -0,0 +1,246 @@
+package live
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+)
+
+// sessionSealed the key the encrypted values of a session are stored under.
+const sessionSealed string = "_lenc"
+
+const (
+	// sealVersion the version of the sealed format.
+	sealVersion byte = 1
+	// keyIDSize the size of the ID identifying the key a value was sealed with.
+	keyIDSize = 4
+	// dataKeySize the size of the key each value is encrypted with.
+	dataKeySize = 32
+)
+
+// Keyring the keys session values are encrypted with. The first key encrypts,
+// every key decrypts, so keys can be rotated by putting a new key first and
+// keeping the old ones until the sessions they encrypted have expired.
+// Sessions are encrypted with the new key the next time they are saved.
+type Keyring struct {
+	keys []keyringKey
+}
+
+// keyringKey a key and the ID it is identified by in sealed values.
+type keyringKey struct {
+	id   [keyIDSize]byte
+	aead cipher.AEAD
+}
+
+// NewKeyring creates a keyring from AES keys, which must be 16, 24 or 32
+// bytes long. The first key is used to encrypt.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring needs at least one key")
+	}
+	k := &Keyring{}
+	for idx, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("keyring key %d: %w", idx, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("keyring key %d: %w", idx, err)
+		}
+		sum := sha256.Sum256(key)
+		kk := keyringKey{aead: aead}
+		copy(kk.id[:], sum[:keyIDSize])
+		k.keys = append(k.keys, kk)
+	}
+	return k, nil
+}
+
+// Seal encrypts data with envelope encryption. The data is encrypted with a
+// new random key, which is itself encrypted with the first key of the ring.
+func (k *Keyring) Seal(data []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("could not generate data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	kek := k.keys[0]
+
+	out := append([]byte{sealVersion}, kek.id[:]...)
+	header := out[:1+keyIDSize]
+	out, err = seal(kek.aead, out, dataKey, header)
+	if err != nil {
+		return nil, err
+	}
+	return seal(dataAEAD, out, data, header)
+}
+
+// Open decrypts data sealed with any key of the ring.
+func (k *Keyring) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < 1+keyIDSize || sealed[0] != sealVersion {
+		return nil, fmt.Errorf("unknown sealed format: %w", ErrSessionDecrypt)
+	}
+	header, rest := sealed[:1+keyIDSize], sealed[1+keyIDSize:]
+	for _, kek := range k.keys {
+		if !bytes.Equal(kek.id[:], header[1:]) {
+			continue
+		}
+		dataKey, rest, err := open(kek.aead, rest, dataKeySize, header)
+		if err != nil {
+			return nil, err
+		}
+		dataAEAD, err := newAEAD(dataKey)
+		if err != nil {
+			return nil, err
+		}
+		data, _, err := open(dataAEAD, rest, len(rest)-dataAEAD.NonceSize()-dataAEAD.Overhead(), header)
+		return data, err
+	}
+	return nil, fmt.Errorf("sealed with a key not in the keyring: %w", ErrSessionDecrypt)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal appends a nonce and the encrypted plaintext to out.
+func seal(aead cipher.AEAD, out, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("could not generate nonce: %w", err)
+	}
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, additional), nil
+}
+
+// open decrypts a nonce and a plaintext of the given size from the front of
+// data, returning the rest.
+func open(aead cipher.AEAD, data []byte, size int, additional []byte) ([]byte, []byte, error) {
+	end := aead.NonceSize() + size + aead.Overhead()
+	if size < 0 || len(data) < end {
+		return nil, nil, fmt.Errorf("sealed value too short: %w", ErrSessionDecrypt)
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():end]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrSessionDecrypt, err)
+	}
+	return plaintext, data[end:], nil
+}
+
+// sealSession encrypts the values of a session, leaving only its ID readable.
+func sealSession(k *Keyring, s Session) (Session, error) {
+	values := Session{}
+	for key, v := range s {
+		if key == sessionID {
+			continue
+		}
+		values[key] = v
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, fmt.Errorf("could not encode session values: %w", err)
+	}
+	sealed, err := k.Seal(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return Session{sessionID: s[sessionID], sessionSealed: sealed}, nil
+}
+
+// openSession decrypts the values of a session. Sessions saved before their
+// values were encrypted are returned as they are.
+func openSession(k *Keyring, s Session) (Session, error) {
+	sealed, ok := s[sessionSealed].([]byte)
+	if !ok {
+		return s, nil
+	}
+	data, err := k.Open(sealed)
+	if err != nil {
+		return nil, err
+	}
+	values := Session{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil {
+		return nil, fmt.Errorf("could not decode session values: %w", err)
+	}
+	values[sessionID] = s[sessionID]
+	return values, nil
+}
+
+// WithEncryptedValues encrypts the values of the session with the keyring
+// before they are put in the cookie. The cookie is still signed and, given
+// block keys, encrypted as a whole by the key pairs the store was created
+// with, which can be rotated in the same way.
+func WithEncryptedValues(keys *Keyring) CookieStoreConfig {
+	return func(c *CookieStore) error {
+		c.keys = keys
+		return nil
+	}
+}
+
+// EncryptedStore wraps a session store, encrypting the values of sessions
+// before they reach it and decrypting them as they come back.
+type EncryptedStore struct {
+	Store HttpSessionStore
+	keys  *Keyring
+}
+
+var _ HttpSessionStore = &EncryptedStore{}
+var _ SessionRegenerator = &EncryptedStore{}
+
+// NewEncryptedStore encrypts the values of the sessions kept in a store.
+func NewEncryptedStore(store HttpSessionStore, keys *Keyring) *EncryptedStore {
+	return &EncryptedStore{Store: store, keys: keys}
+}
+
+// Get a session, decrypting its values.
+func (e *EncryptedStore) Get(r *http.Request) (Session, error) {
+	s, err := e.Store.Get(r)
+	if err != nil {
+		return s, err
+	}
+	open, err := openSession(e.keys, s)
+	if err != nil {
+		return NewSession(), err
+	}
+	return open, nil
+}
+
+// Save a session, encrypting its values.
+func (e *EncryptedStore) Save(w http.ResponseWriter, r *http.Request, session Session) error {
+	sealed, err := sealSession(e.keys, session)
+	if err != nil {
+		return err
+	}
+	return e.Store.Save(w, r, sealed)
+}
+
+// Regenerate a session, encrypting its values.
+func (e *EncryptedStore) Regenerate(w http.ResponseWriter, r *http.Request, old string, session Session) error {
+	s, ok := e.Store.(SessionRegenerator)
+	if !ok {
+		return e.Save(w, r, session)
+	}
+	sealed, err := sealSession(e.keys, session)
+	if err != nil {
+		return err
+	}
+	return s.Regenerate(w, r, old, sealed)
+}
+
+// Clear a session.
+func (e *EncryptedStore) Clear(w http.ResponseWriter, r *http.Request) error {
+	return e.Store.Clear(w, r)
+}
@@ -0,0 +1,109 @@
+package live
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey := bytes.Repeat([]byte("o"), 32)
+	newKey := bytes.Repeat([]byte("n"), 16)
+	old, err := NewKeyring(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := NewKeyring(newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := old.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Error("sealed value contains the plaintext")
+	}
+	data, err := rotated.Open(sealed)
+	if err != nil || string(data) != "secret" {
+		t.Fatalf("expected rotated keyring to open old value, got %q %v", data, err)
+	}
+
+	sealed, err = rotated.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Open(sealed); !errors.Is(err, ErrSessionDecrypt) {
+		t.Errorf("expected old keyring not to open new value, got %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := rotated.Open(sealed); !errors.Is(err, ErrSessionDecrypt) {
+		t.Errorf("expected tampered value to fail, got %v", err)
+	}
+	if _, err := NewKeyring([]byte("short")); err == nil {
+		t.Error("expected invalid key length to fail")
+	}
+}
+
+func TestCookieStoreEncryptedValues(t *testing.T) {
+	oldKey := bytes.Repeat([]byte("o"), 32)
+	newKey := bytes.Repeat([]byte("n"), 32)
+	old, _ := NewKeyring(oldKey)
+	store := NewCookieStoreWithConfig("test", [][]byte{[]byte("secret")}, WithEncryptedValues(old))
+
+	session := NewSession()
+	session["user"] = "ada"
+	w := httptest.NewRecorder()
+	if err := store.Save(w, httptest.NewRequest("GET", "/", nil), session); err != nil {
+		t.Fatal(err)
+	}
+	if session["user"] != "ada" {
+		t.Error("saving changed the session")
+	}
+
+	// Rotating the keys keeps existing sessions readable.
+	rotated, _ := NewKeyring(newKey, oldKey)
+	store = NewCookieStoreWithConfig("test", [][]byte{[]byte("secret")}, WithEncryptedValues(rotated))
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	got, err := store.Get(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["user"] != "ada" || SessionID(got) != SessionID(session) {
+		t.Errorf("unexpected session %v", got)
+	}
+
+	// Without the key the values can't be read.
+	other, _ := NewKeyring(newKey)
+	store = NewCookieStoreWithConfig("test", [][]byte{[]byte("secret")}, WithEncryptedValues(other))
+	if _, err := store.Get(r); !errors.Is(err, ErrSessionDecrypt) {
+		t.Errorf("expected decrypt error, got %v", err)
+	}
+}
+
+func TestEncryptedStore(t *testing.T) {
+	keys, _ := NewKeyring(bytes.Repeat([]byte("k"), 32))
+	inner := NewTestStore("test")
+	store := NewEncryptedStore(inner, keys)
+
+	session := NewSession()
+	session["user"] = "ada"
+	if err := store.Save(nil, nil, session); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := inner.s["user"]; ok {
+		t.Error("values reached the store unencrypted")
+	}
+	got, err := store.Get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["user"] != "ada" {
+		t.Errorf("unexpected session %v", got)
+	}
+}
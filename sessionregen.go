@@ -0,0 +1,135 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// sessionPreviousID the key of the ID a session was last saved under, while
+// a regenerated session waits to be saved.
+const sessionPreviousID string = "_lsprev"
+
+// SessionRegenerator is implemented by session stores which need to act when
+// a session is given a new ID, for example to delete the record stored under
+// the old ID. Stores without it have the session saved as usual.
+type SessionRegenerator interface {
+	Regenerate(w http.ResponseWriter, r *http.Request, old string, session Session) error
+}
+
+// sessionCoordinator is implemented by engines which bring the other sockets
+// of a session in line when it is regenerated.
+type sessionCoordinator interface {
+	sessionRegenerated(ctx context.Context, source Socket, old string, session Session, reset bool)
+}
+
+// RegenerateSession gives the session of a socket a new ID and saves it, so
+// that a session can be rotated on login or logout from an event handler. If
+// reset is true the values of the session are removed as well. Other sockets
+// sharing the session are moved to the new one, and are reloaded when it is
+// reset.
+func RegenerateSession(ctx context.Context, s Socket, reset bool) error {
+	session := s.Session()
+	old := SessionID(session)
+	// The store still has the session under the ID it was last saved with.
+	stored, ok := session[sessionPreviousID].(string)
+	if !ok {
+		stored = old
+	}
+	regenerateSession(session, reset)
+	session[sessionPreviousID] = stored
+
+	if c, ok := socketEngine(s).(sessionCoordinator); ok {
+		c.sessionRegenerated(ctx, s, old, session, reset)
+	}
+	return s.SaveSession(ctx)
+}
+
+// RegenerateSession gives the session of a request a new ID and saves it, for
+// rotating a session on login or logout outside of live. If reset is true the
+// values of the session are removed as well. Sockets connected with the
+// session are moved to the new one, and are reloaded when it is reset.
+func (h *HttpEngine) RegenerateSession(w http.ResponseWriter, r *http.Request, reset bool) (Session, error) {
+	session, err := h.sessionStore.Get(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not get session: %w", err)
+	}
+	old := SessionID(session)
+	regenerateSession(session, reset)
+	if err := h.storeRegenerated(w, r, old, session); err != nil {
+		return nil, err
+	}
+	h.sessionRegenerated(r.Context(), nil, old, session, reset)
+	return session, nil
+}
+
+// storeRegenerated saves a session which has been given a new ID.
+func (h *HttpEngine) storeRegenerated(w http.ResponseWriter, r *http.Request, old string, session Session) error {
+	if s, ok := h.sessionStore.(SessionRegenerator); ok {
+		if err := s.Regenerate(w, r, old, session); err != nil {
+			return fmt.Errorf("could not regenerate session: %w", err)
+		}
+		return nil
+	}
+	if err := h.sessionStore.Save(w, r, session); err != nil {
+		return fmt.Errorf("could not save session: %w", err)
+	}
+	return nil
+}
+
+// sessionRegenerated moves the other sockets of a session to its new ID. Each
+// socket's session is changed between its own events, with Update.
+func (e *BaseEngine) sessionRegenerated(ctx context.Context, source Socket, old string, session Session, reset bool) {
+	values := make(Session, len(session))
+	for k, v := range session {
+		if k == sessionPreviousID {
+			continue
+		}
+		values[k] = v
+	}
+	for _, s := range e.Sockets() {
+		if source != nil && s.ID() == source.ID() {
+			continue
+		}
+		if SessionID(s.Session()) != old {
+			continue
+		}
+		if err := s.Update(ctx, func(assigns interface{}) interface{} {
+			other := s.Session()
+			if SessionID(other) != old {
+				return assigns
+			}
+			for k := range other {
+				delete(other, k)
+			}
+			for k, v := range values {
+				other[k] = v
+			}
+			// Anything rendered from the old session has to go.
+			if reset {
+				s.Send(EventReload, nil)
+			}
+			return assigns
+		}); err != nil {
+			slog.ErrorContext(ctx, "could not move socket to regenerated session", "error", err, "socket", s.ID())
+		}
+	}
+}
+
+// regenerateSession gives a session a new ID, removing its values if reset.
+func regenerateSession(session Session, reset bool) {
+	if reset {
+		session.Reset()
+		return
+	}
+	session.Regenerate()
+}
+
+// takePreviousSessionID removes the ID a regenerated session was last saved
+// under, returning it.
+func takePreviousSessionID(session Session) (string, bool) {
+	old, ok := session[sessionPreviousID].(string)
+	delete(session, sessionPreviousID)
+	return old, ok
+}
@@ -0,0 +1,125 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionRegenerate(t *testing.T) {
+	s := NewSession()
+	s["user"] = "jo"
+	old := SessionID(s)
+
+	s.Regenerate()
+	if SessionID(s) == old || s["user"] != "jo" {
+		t.Fatalf("expected a new ID keeping values, got %v", s)
+	}
+
+	s.Reset()
+	if SessionID(s) == "" || len(s) != 1 {
+		t.Fatalf("expected only a new ID, got %v", s)
+	}
+}
+
+func TestHttpEngineRegenerateSession(t *testing.T) {
+	store := NewTestStore("old")
+	store.s["user"] = "jo"
+	e := NewHttpHandler(store, testRenderHandler())
+
+	// The other socket's session is changed in its own loop.
+	other := NewBaseSocket(Session{sessionID: "old", "user": "jo"}, e, true)
+	e.AddSocket(other)
+	c := newTestConn()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.serveSocket(ctx, e, other, c, httptest.NewRequest("GET", "/", nil))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	// session waits for the changes queued before it, then reads the
+	// socket's session.
+	session := func() Session {
+		t.Helper()
+		var s Session
+		read := make(chan struct{})
+		if err := other.Update(ctx, func(assigns interface{}) interface{} {
+			s = maps.Clone(other.Session())
+			close(read)
+			return assigns
+		}); err != nil {
+			t.Fatal(err)
+		}
+		<-read
+		return s
+	}
+
+	regenerated, err := e.RegenerateSession(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if SessionID(regenerated) == "old" || SessionID(store.s) != SessionID(regenerated) {
+		t.Fatalf("expected the store to have the new session, got %v", store.s)
+	}
+	if s := session(); SessionID(s) != SessionID(regenerated) || s["user"] != "jo" {
+		t.Fatalf("expected the connected socket to follow the session, got %v", s)
+	}
+
+	if _, err := e.RegenerateSession(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/logout", nil), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := session()["user"]; ok {
+		t.Fatal("expected the connected socket's session to be reset")
+	}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case m := <-c.out:
+			if m.T == EventReload {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected the connected socket to reload")
+		}
+	}
+}
+
+func TestRegenerateSessionOnSocket(t *testing.T) {
+	store := NewTestStore("old")
+	e := NewHttpHandler(store, NewHandler())
+	sock := NewHttpSocket(Session{sessionID: "old"}, e, true)
+	e.AddSocket(sock)
+
+	if err := RegenerateSession(context.Background(), sock, false); err != nil {
+		t.Fatal(err)
+	}
+	m := <-sock.Messages()
+	if m.T != EventSessionSave {
+		t.Fatalf("expected a session save, got %v", m)
+	}
+	var token string
+	json.Unmarshal(m.Data, &token)
+
+	// The client still has the old session when it makes the save request.
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(sessionSaveHeader, token)
+	e.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected the session to be saved, got %d", w.Code)
+	}
+	if SessionID(store.s) == "old" || SessionID(store.s) != SessionID(sock.Session()) {
+		t.Fatalf("expected the store to have the new session, got %v", store.s)
+	}
+	if _, ok := store.s[sessionPreviousID]; ok {
+		t.Fatal("expected the previous ID not to be stored")
+	}
+}
@@ -0,0 +1,152 @@
+package live
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// EventSessionSave sent to ask the client to make a request so that the
+// sockets session can be saved.
+const EventSessionSave = "session"
+
+// sessionSaveHeader carries the save token on the clients request.
+const sessionSaveHeader = "Live-Session"
+
+// sessionSaveTimeout how long the client has to make the save request.
+const sessionSaveTimeout = 30 * time.Second
+
+// SocketSessionStore is implemented by session stores which can save a
+// session without an HTTP response, for example stores backed by a database.
+// Sessions changed over the websocket are saved directly with these stores
+// rather than with a round trip through the client.
+type SocketSessionStore interface {
+	SaveOnSocket(ctx context.Context, s Socket, session Session) error
+}
+
+// sessionSaver is implemented by engines which can persist a sockets session.
+type sessionSaver interface {
+	saveSession(ctx context.Context, sock Socket) error
+}
+
+// pendingSessionSaves tracks save requests that the client has yet to make.
+type pendingSessionSaves struct {
+	mu      sync.Mutex
+	pending map[string]pendingSessionSave
+}
+
+type pendingSessionSave struct {
+	socket SocketID
+	// session a copy of the sockets session when the save was asked for,
+	// the socket carries on changing its own as it handles events.
+	session Session
+	expires time.Time
+}
+
+// add creates a one time token for saving a copy of a sockets session.
+func (p *pendingSessionSaves) add(sock Socket, session Session) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate session token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending == nil {
+		p.pending = map[string]pendingSessionSave{}
+	}
+	now := time.Now()
+	for t, s := range p.pending {
+		if now.After(s.expires) {
+			delete(p.pending, t)
+		}
+	}
+	p.pending[token] = pendingSessionSave{socket: sock.ID(), session: session, expires: now.Add(sessionSaveTimeout)}
+	return token, nil
+}
+
+// take consumes a token, returning the save it was issued for.
+func (p *pendingSessionSaves) take(token string) (pendingSessionSave, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.pending[token]
+	if !ok {
+		return pendingSessionSave{}, false
+	}
+	delete(p.pending, token)
+	if time.Now().After(s.expires) {
+		return pendingSessionSave{}, false
+	}
+	return s, true
+}
+
+// saveSession persists a sockets session, either directly if the store supports
+// it or by asking the client to make a request which the session can be saved on.
+// It is called from the sockets handlers, so the session is copied here rather
+// than read while the socket handles other events.
+func (h *HttpEngine) saveSession(ctx context.Context, sock Socket) error {
+	if s, ok := h.sessionStore.(SocketSessionStore); ok {
+		takePreviousSessionID(sock.Session())
+		return s.SaveOnSocket(ctx, sock, sock.Session())
+	}
+	token, err := h.sessionSaves.add(sock, maps.Clone(sock.Session()))
+	if err != nil {
+		return err
+	}
+	return sock.Send(EventSessionSave, token)
+}
+
+// postSession handles the clients request to save its sockets session.
+func (h *HttpEngine) postSession(ctx context.Context, w http.ResponseWriter, r *http.Request, token string) {
+	save, ok := h.sessionSaves.take(token)
+	if !ok {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	sock, err := h.GetSocketByID(save.socket)
+	if err != nil {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	// The request must come from the same session as the socket, or the
+	// one it had before it was regenerated.
+	session := save.session
+	current, err := h.sessionStore.Get(r)
+	previous, _ := session[sessionPreviousID].(string)
+	if err != nil || (SessionID(current) != SessionID(session) && (previous == "" || SessionID(current) != previous)) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if old, ok := takePreviousSessionID(session); ok {
+		if err := h.storeRegenerated(w, r, old, session); err != nil {
+			h.Error()(ctx, err)
+			return
+		}
+		// The socket's own session is only touched between its events.
+		if err := sock.Update(ctx, func(assigns interface{}) interface{} {
+			live := sock.Session()
+			if id, _ := live[sessionPreviousID].(string); id == old {
+				delete(live, sessionPreviousID)
+			}
+			return assigns
+		}); err != nil {
+			slog.ErrorContext(ctx, "could not update socket session", "error", err, "socket", sock.ID())
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := h.sessionStore.Save(w, r, session); err != nil {
+		h.Error()(ctx, fmt.Errorf("could not save session: %w", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
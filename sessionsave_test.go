@@ -0,0 +1,100 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// askSessionSave has a socket ask for its session to be saved, returning the
+// token the client is sent.
+func askSessionSave(t *testing.T, sock *HttpSocket) string {
+	t.Helper()
+	if err := sock.SaveSession(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m := <-sock.Messages()
+	if m.T != EventSessionSave {
+		t.Fatalf("expected a session save, got %v", m)
+	}
+	var token string
+	if err := json.Unmarshal(m.Data, &token); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// postSessionSave makes the clients save request.
+func postSessionSave(e *HttpEngine, token string) int {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(sessionSaveHeader, token)
+	e.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestSessionSave(t *testing.T) {
+	store := NewTestStore("abc")
+	e := NewHttpHandler(store, NewHandler())
+	sock := NewHttpSocket(Session{sessionID: "abc"}, e, true)
+	e.AddSocket(sock)
+
+	sock.Session()["user"] = "jo"
+	token := askSessionSave(t, sock)
+
+	// Changes made after the save was asked for aren't part of it.
+	sock.Session()["user"] = "sam"
+	if code := postSessionSave(e, token); code != http.StatusNoContent {
+		t.Fatalf("expected the session to be saved, got %d", code)
+	}
+	if store.s["user"] != "jo" {
+		t.Errorf("expected the session as it was when the save was asked for, got %v", store.s)
+	}
+
+	// Tokens can only be used once.
+	if code := postSessionSave(e, token); code != http.StatusForbidden {
+		t.Errorf("expected a used token to be refused, got %d", code)
+	}
+}
+
+func TestSessionSaveTokens(t *testing.T) {
+	store := NewTestStore("abc")
+	e := NewHttpHandler(store, NewHandler())
+	sock := NewHttpSocket(Session{sessionID: "abc"}, e, true)
+	e.AddSocket(sock)
+
+	if code := postSessionSave(e, "unknown"); code != http.StatusForbidden {
+		t.Errorf("expected an unknown token to be refused, got %d", code)
+	}
+
+	token := askSessionSave(t, sock)
+	e.sessionSaves.mu.Lock()
+	s := e.sessionSaves.pending[token]
+	s.expires = time.Now().Add(-time.Second)
+	e.sessionSaves.pending[token] = s
+	e.sessionSaves.mu.Unlock()
+	if code := postSessionSave(e, token); code != http.StatusForbidden {
+		t.Errorf("expected an expired token to be refused, got %d", code)
+	}
+
+	// The request has to come from the socket's session.
+	token = askSessionSave(t, sock)
+	store.s = Session{sessionID: "other"}
+	if code := postSessionSave(e, token); code != http.StatusForbidden {
+		t.Errorf("expected another session to be refused, got %d", code)
+	}
+	if SessionID(store.s) != "other" {
+		t.Errorf("expected the other session to be left alone, got %v", store.s)
+	}
+
+	// The socket has to still be connected.
+	store.s = Session{sessionID: "abc"}
+	token = askSessionSave(t, sock)
+	e.DeleteSocket(sock)
+	if code := postSessionSave(e, token); code != http.StatusGone {
+		t.Errorf("expected a save for a closed socket to be gone, got %d", code)
+	}
+}
@@ -0,0 +1,46 @@
+package live
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithSessionSync links the sockets which share a session, so that a user's
+// tabs can be kept in step with Socket.BroadcastSession rather than a
+// broadcast to every socket.
+func WithSessionSync() EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.sessionSync = true
+		case *HttpEngine:
+			v.sessionSync = true
+		}
+		return nil
+	}
+}
+
+// sessionBroadcaster is implemented by engines which can send events to the
+// sockets of a session.
+type sessionBroadcaster interface {
+	broadcastSession(ctx context.Context, source Socket, msg Event) error
+}
+
+// broadcastSession sends a self event to the other connected sockets sharing
+// the session of the source socket.
+func (e *BaseEngine) broadcastSession(ctx context.Context, source Socket, msg Event) error {
+	if !e.sessionSync {
+		return fmt.Errorf("session sync is not enabled, see WithSessionSync: %w", ErrNotImplemented)
+	}
+	id := SessionID(source.Session())
+	if id == "" {
+		return nil
+	}
+	for _, s := range e.Sockets() {
+		if s.ID() == source.ID() || !s.Connected() || SessionID(s.Session()) != id {
+			continue
+		}
+		e.self(ctx, s, msg)
+	}
+	return nil
+}
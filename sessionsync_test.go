@@ -0,0 +1,37 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBroadcastSession(t *testing.T) {
+	handled := map[SocketID]interface{}{}
+	h := testRenderHandler()
+	h.HandleSelf("cart", func(ctx context.Context, s Socket, data interface{}) (any, error) {
+		handled[s.ID()] = data
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+
+	session := NewSession()
+	a := NewBaseSocket(session, e, true)
+	b := NewBaseSocket(Session{sessionID: SessionID(session)}, e, true)
+	other := NewBaseSocket(NewSession(), e, true)
+	for _, s := range []Socket{a, b, other} {
+		e.AddSocket(s)
+	}
+
+	if err := a.BroadcastSession(context.Background(), "cart", 3); !errors.Is(err, ErrNotImplemented) {
+		t.Fatalf("expected session sync to be off by default, got %v", err)
+	}
+
+	WithSessionSync()(e)
+	if err := a.BroadcastSession(context.Background(), "cart", 3); err != nil {
+		t.Fatal(err)
+	}
+	if len(handled) != 1 || handled[b.ID()] != 3 {
+		t.Fatalf("expected only the other tab to handle the event, got %v", handled)
+	}
+}
@@ -0,0 +1,84 @@
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// SessionCodec encodes and decodes typed session values.
+type SessionCodec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte, v interface{}) error
+}
+
+// JSONSessionCodec the default session codec.
+type JSONSessionCodec struct{}
+
+// Encode a value to json.
+func (JSONSessionCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode a value from json.
+func (JSONSessionCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+var (
+	sessionCodecsMu sync.RWMutex
+	sessionCodecs   = map[reflect.Type]SessionCodec{}
+)
+
+// RegisterSessionCodec use a specific codec for session values of type T.
+// Values without a registered codec are encoded as json.
+func RegisterSessionCodec[T any](codec SessionCodec) {
+	sessionCodecsMu.Lock()
+	defer sessionCodecsMu.Unlock()
+	sessionCodecs[reflect.TypeFor[T]()] = codec
+}
+
+func sessionCodec[T any]() SessionCodec {
+	sessionCodecsMu.RLock()
+	defer sessionCodecsMu.RUnlock()
+	if c, ok := sessionCodecs[reflect.TypeFor[T]()]; ok {
+		return c
+	}
+	return JSONSessionCodec{}
+}
+
+// SessionSet stores a typed value in the session. The value is encoded
+// so that it does not need to be registered with gob.
+func SessionSet[T any](s Session, key string, v T) error {
+	data, err := sessionCodec[T]().Encode(v)
+	if err != nil {
+		return fmt.Errorf("could not encode session value %q: %w", key, err)
+	}
+	s[key] = data
+	return nil
+}
+
+// SessionGet gets a typed value from the session. Returns ErrNoSessionValue
+// if the key has not been set, and ErrSessionValueType if it was set other
+// than with SessionSet.
+func SessionGet[T any](s Session, key string) (T, error) {
+	var out T
+	raw, ok := s[key]
+	if !ok {
+		return out, ErrNoSessionValue
+	}
+	data, ok := raw.([]byte)
+	if !ok {
+		return out, fmt.Errorf("%w: session value %q expected %s, got %T", ErrSessionValueType, key, reflect.TypeFor[T](), raw)
+	}
+	if err := sessionCodec[T]().Decode(data, &out); err != nil {
+		return out, fmt.Errorf("could not decode session value %q: %w", key, err)
+	}
+	return out, nil
+}
+
+// SessionDelete removes a value from the session.
+func SessionDelete(s Session, key string) {
+	delete(s, key)
+}
@@ -0,0 +1,52 @@
+package live
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type sessionUser struct {
+	Name  string
+	Admin bool
+}
+
+func TestSessionValues(t *testing.T) {
+	s := NewSession()
+	if err := SessionSet(s, "user", sessionUser{Name: "test", Admin: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The session must survive gob encoding without registering sessionUser.
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		t.Fatal(err)
+	}
+	decoded := Session{}
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := SessionGet[sessionUser](decoded, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "test" || !u.Admin {
+		t.Errorf("unexpected session value %+v", u)
+	}
+
+	if _, err := SessionGet[sessionUser](decoded, "missing"); !errors.Is(err, ErrNoSessionValue) {
+		t.Errorf("expected ErrNoSessionValue, got %v", err)
+	}
+
+	decoded["plain"] = "set directly"
+	_, err = SessionGet[sessionUser](decoded, "plain")
+	if !errors.Is(err, ErrSessionValueType) {
+		t.Errorf("expected ErrSessionValueType, got %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"plain"`) || !strings.Contains(msg, "live.sessionUser") || !strings.Contains(msg, "string") {
+		t.Errorf("expected the key and types in the error, got %q", msg)
+	}
+}
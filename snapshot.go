@@ -0,0 +1,99 @@
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// assignsType a registered type of assigns.
+type assignsType struct {
+	name   string
+	encode func(v interface{}) ([]byte, error)
+	decode func(data []byte) (interface{}, error)
+}
+
+var (
+	assignsTypesMu sync.RWMutex
+	assignsByType  = map[reflect.Type]assignsType{}
+	assignsByName  = map[string]assignsType{}
+)
+
+// RegisterAssigns registers a type of assigns under a name, so that sockets
+// holding it can be snapshotted and restored. Values are encoded with the
+// codec, or as json if it is nil. The name is stored in the snapshot, so it
+// must stay the same between the instances taking and restoring snapshots.
+//
+//	live.RegisterAssigns[*Cart]("cart", nil)
+func RegisterAssigns[T any](name string, codec SessionCodec) {
+	if codec == nil {
+		codec = JSONSessionCodec{}
+	}
+	t := assignsType{
+		name: name,
+		encode: func(v interface{}) ([]byte, error) {
+			return codec.Encode(v)
+		},
+		decode: func(data []byte) (interface{}, error) {
+			var out T
+			if err := codec.Decode(data, &out); err != nil {
+				return nil, err
+			}
+			return out, nil
+		},
+	}
+	assignsTypesMu.Lock()
+	defer assignsTypesMu.Unlock()
+	assignsByType[reflect.TypeFor[T]()] = t
+	assignsByName[name] = t
+}
+
+// snapshot the encoded form of a socket's assigns.
+type snapshot struct {
+	Type    string `json:"type,omitempty"`
+	Assigns []byte `json:"assigns,omitempty"`
+}
+
+// snapshotAssigns encodes assigns with their registered codec.
+func snapshotAssigns(assigns interface{}) ([]byte, error) {
+	if assigns == nil {
+		return json.Marshal(snapshot{})
+	}
+	assignsTypesMu.RLock()
+	t, ok := assignsByType[reflect.TypeOf(assigns)]
+	assignsTypesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("assigns of type %T are not registered, see RegisterAssigns: %w", assigns, ErrNotImplemented)
+	}
+	data, err := t.encode(assigns)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode assigns: %w", err)
+	}
+	return json.Marshal(snapshot{Type: t.name, Assigns: data})
+}
+
+// RestoreSocket sets the assigns of a socket from a snapshot taken with
+// Socket.Snapshot, on this instance or another.
+func RestoreSocket(s Socket, data []byte) error {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("could not decode snapshot: %w", err)
+	}
+	if snap.Type == "" {
+		s.Assign(nil)
+		return nil
+	}
+	assignsTypesMu.RLock()
+	t, ok := assignsByName[snap.Type]
+	assignsTypesMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("assigns type %q is not registered, see RegisterAssigns: %w", snap.Type, ErrNotImplemented)
+	}
+	assigns, err := t.decode(snap.Assigns)
+	if err != nil {
+		return fmt.Errorf("could not decode assigns: %w", err)
+	}
+	s.Assign(assigns)
+	return nil
+}
@@ -0,0 +1,40 @@
+package live
+
+import (
+	"errors"
+	"testing"
+)
+
+type snapshotCart struct {
+	Items []string
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	RegisterAssigns[*snapshotCart]("test-cart", nil)
+
+	e := NewBaseEngine(NewHandler())
+	s := NewBaseSocket(NewSession(), e, true)
+	s.Assign(&snapshotCart{Items: []string{"apple", "pear"}})
+
+	data, err := s.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewBaseSocket(NewSession(), e, true)
+	if err := RestoreSocket(restored, data); err != nil {
+		t.Fatal(err)
+	}
+	cart, ok := restored.Assigns().(*snapshotCart)
+	if !ok || len(cart.Items) != 2 || cart.Items[1] != "pear" {
+		t.Fatalf("expected the cart to be restored, got %#v", restored.Assigns())
+	}
+}
+
+func TestSnapshotUnregistered(t *testing.T) {
+	s := NewBaseSocket(NewSession(), NewBaseEngine(NewHandler()), true)
+	s.Assign(map[string]int{"count": 1})
+	if _, err := s.Snapshot(); !errors.Is(err, ErrNotImplemented) {
+		t.Fatalf("expected unregistered assigns to fail, got %v", err)
+	}
+}
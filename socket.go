@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/url"
 	"sync"
+	"time"
 
+	"github.com/jfyne/live/js"
 	"golang.org/x/net/html"
 )
 
@@ -37,32 +41,105 @@ type Child interface {
 	Event(event string) string
 }
 
+// ChildUnmounter is implemented by children which need to know when their
+// socket closes, for example to stop subscriptions.
+type ChildUnmounter interface {
+	// UnmountChild called when the socket the child is attached to closes.
+	UnmountChild(ctx context.Context) error
+}
+
+// ChildPreviewer is implemented by children whose state can be swapped for a
+// copy while an event is previewed, so that the preview doesn't change it.
+type ChildPreviewer interface {
+	// PreviewState replaces the child's state with the result of copy, and
+	// returns a func which puts the original back.
+	PreviewState(copy func(state any) any) (restore func())
+}
+
 // Socket describes a connected user, and the state that they
 // are in.
 type Socket interface {
 	// ID return an ID for this socket.
 	ID() SocketID
+	// SetID give this socket a known ID, so that it can be found again using
+	// the engines GetSocketByID. Returns an error if the ID is already in use.
+	SetID(id string) error
 	// Assigns returns the data currently assigned to this
 	// socket.
 	Assigns() interface{}
 	// Assign set data to this socket. This will happen automatically
 	// if you return data from an `EventHander`.
 	Assign(data interface{})
+	// Snapshot encodes the assigns of this socket, to be restored with
+	// RestoreSocket. The type of the assigns must be registered with
+	// RegisterAssigns.
+	Snapshot() ([]byte, error)
+	// Update changes the assigns of this socket with fn in turn with its
+	// events, then renders it. Use it to change the assigns from another
+	// goroutine.
+	Update(ctx context.Context, fn func(assigns interface{}) interface{}) error
 	// Connected returns true if this socket is connected via the websocket.
 	Connected() bool
+	// Locale returns the locale of this socket.
+	Locale() string
+	// SetLocale sets the locale of this socket.
+	SetLocale(locale string)
 	// Self send an event to this socket itself. Will be handled in the
 	// handlers HandleSelf function.
-	Self(ctx context.Context, event string, data interface{}) error
+	// Options such as WithTarget and WithNoAck control how it is routed and
+	// delivered.
+	Self(ctx context.Context, event string, data interface{}, options ...EventConfig) error
+	// SendEvery sends a self event to this socket every interval, until
+	// stop is called or the socket is closed. The event data is the time
+	// of the tick. It does nothing on sockets which aren't connected.
+	SendEvery(event string, d time.Duration) (stop func())
+	// SelfAfter sends a self event to this socket once the delay has
+	// passed, unless cancel is called or the socket is closed first. It does
+	// nothing on sockets which aren't connected.
+	SelfAfter(ctx context.Context, event string, data interface{}, d time.Duration) (cancel func())
 	// Broadcast send an event to all sockets on this same engine.
 	Broadcast(event string, data interface{}) error
-	// Send an event to this socket's client, to be handled there.
+	// BroadcastSession send a self event to the other sockets sharing this
+	// socket's session, for example the user's other tabs.
+	BroadcastSession(ctx context.Context, event string, data interface{}) error
+	// Send an event to this socket's client, to be handled there. Options
+	// such as WithTarget, WithPriority and WithNoAck control how it is
+	// routed and delivered.
 	Send(event string, data interface{}, options ...EventConfig) error
 	// PatchURL sends an event to the client to update the
-	// query params in the URL.
-	PatchURL(values url.Values)
+	// query params in the URL. WithViewTransition can be used
+	// to animate the patch following the navigation.
+	PatchURL(values url.Values, options ...EventConfig)
+	// PatchParams updates the query params in the browsers URL without
+	// triggering the params handlers.
+	PatchParams(params Params, options ...EventConfig) error
+	// NextPatch configures the next patch sent to the client,
+	// for example WithViewTransition.
+	NextPatch(options ...EventConfig)
 	// Redirect sends a redirect event to the client. This will trigger the browser to
 	// redirect to a URL.
 	Redirect(u *url.URL)
+	// Call sends an event to the client and waits for its reply.
+	Call(ctx context.Context, event string, data interface{}) (json.RawMessage, error)
+	// Reply sets the reply to the client event being handled with the
+	// context, it is sent to the client with the events acknowledgement.
+	Reply(ctx context.Context, data interface{}) error
+	// Exec sends js commands for the client to run.
+	Exec(commands js.Commands) error
+	// SetTitle sets the document title.
+	SetTitle(title string)
+	// SetMeta sets the content of a named meta tag.
+	SetMeta(name, content string)
+	// SendFile sends a file to the client which the browser
+	// downloads, for example an export from an event handler.
+	SendFile(name string, r io.Reader, options ...DownloadConfig) error
+	// Flash adds a flash message, such as "Saved!", to be shown to the user.
+	// It is carried across a redirect.
+	Flash(kind, message string)
+	// Flashes returns the flash messages to show.
+	Flashes() []Flash
+	// ClearFlash removes the flash messages.
+	ClearFlash()
 	// AllowUploads indicates that his socket should allow uploads.
 	AllowUploads(config *UploadConfig)
 	// UploadConfigs return the list of configures uploads for this socket.
@@ -81,6 +158,9 @@ type Socket interface {
 	UpdateRender(render *html.Node)
 	// Session returns the sockets session.
 	Session() Session
+	// SaveSession persists any changes made to the session while the socket
+	// is connected.
+	SaveSession(ctx context.Context) error
 	// Messages returns the channel of events on this socket.
 	Messages() chan Event
 
@@ -88,6 +168,8 @@ type Socket interface {
 	AttachChild(child Child)
 	// GetChildren returns the children of this Socket.
 	GetChildren() []Child
+	// DetachChild removes a child from the socket.
+	DetachChild(child Child)
 
 	// Lock the data mutex.
 	Lock()
@@ -99,43 +181,124 @@ type Socket interface {
 type BaseSocket struct {
 	session Session
 	id      SocketID
+	// idMu guards id, renameMu makes one change to it at a time.
+	idMu     sync.Mutex
+	renameMu sync.Mutex
 
 	engine        Engine
 	connected     bool
 	currentRender *html.Node
 	msgs          chan Event
-	closeSlow     func()
+	// priorityMsgs events sent ahead of those in msgs.
+	priorityMsgs chan Event
+	closeSlow    func()
+	// updateCh changes to the assigns waiting to be made.
+	updateCh chan func()
 
 	uploadConfigs []*UploadConfig
 	uploads       UploadContext
 
+	locale  string
+	flashes []Flash
+	// params the socket was last given.
+	params Params
+	// protocol the protocol version the client speaks.
+	protocol int
+	// trustedMark sent with patches for the client's Trusted Types policy.
+	trustedMark string
+
+	patchOptions []EventConfig
+
+	title string
+	meta  map[string]string
+
+	// calls waiting for the client to reply.
+	calls pendingCalls
+
 	data   interface{}
 	dataMu sync.RWMutex
 	selfMu sync.RWMutex
 
+	// ctx is cancelled when the socket is deleted from its engine.
+	ctx    context.Context
+	cancel context.CancelFunc
+
 	// Child components.
 	children []Child
 }
 
 // NewBaseSocket creates a new default socket.
 func NewBaseSocket(s Session, e Engine, connected bool) *BaseSocket {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &BaseSocket{
 		session:       s,
 		engine:        e,
 		connected:     connected,
 		uploadConfigs: []*UploadConfig{},
 		msgs:          make(chan Event, maxMessageBufferSize),
+		priorityMsgs:  make(chan Event, maxMessageBufferSize),
+		updateCh:      make(chan func(), maxMessageBufferSize),
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 }
 
+// socketEngine returns the engine this socket belongs to.
+func (s *BaseSocket) socketEngine() Engine {
+	return s.engine
+}
+
+// lifetime returns a context which is cancelled when the socket is deleted.
+func (s *BaseSocket) lifetime() context.Context {
+	return s.ctx
+}
+
+// shutdown cancels the sockets lifetime.
+func (s *BaseSocket) shutdown() {
+	s.cancel()
+}
+
+// prioritySender is implemented by sockets which can send events ahead of
+// others.
+type prioritySender interface {
+	priorityMessages() chan Event
+}
+
+// lifetimer is implemented by sockets which can tie work to their lifetime.
+type lifetimer interface {
+	lifetime() context.Context
+	shutdown()
+}
+
 // ID generates a unique ID for this socket.
 func (s *BaseSocket) ID() SocketID {
+	s.idMu.Lock()
+	defer s.idMu.Unlock()
 	if s.id == "" {
 		s.id = SocketID(NewID())
 	}
 	return s.id
 }
 
+// SetID sets a known ID on this socket.
+func (s *BaseSocket) SetID(id string) error {
+	s.renameMu.Lock()
+	defer s.renameMu.Unlock()
+	old := s.ID()
+	if old == SocketID(id) {
+		return nil
+	}
+	if s.engine != nil {
+		if err := s.engine.renameSocket(old, SocketID(id)); err != nil {
+			return err
+		}
+	}
+	s.idMu.Lock()
+	s.id = SocketID(id)
+	s.idMu.Unlock()
+	return nil
+}
+
 // Assigns returns the data currently assigned to this
 // socket.
 func (s *BaseSocket) Assigns() interface{} {
@@ -144,6 +307,61 @@ func (s *BaseSocket) Assigns() interface{} {
 	return s.data
 }
 
+// Snapshot encodes the assigns of this socket, to be restored with
+// RestoreSocket.
+func (s *BaseSocket) Snapshot() ([]byte, error) {
+	return snapshotAssigns(s.Assigns())
+}
+
+// Update changes the assigns of this socket with fn. When the socket is
+// connected the change is queued to be made between its events and followed
+// by a render, so it is safe to call from any goroutine. It returns once the
+// change is queued.
+func (s *BaseSocket) Update(ctx context.Context, fn func(assigns interface{}) interface{}) error {
+	update := func() {
+		s.Assign(fn(s.Assigns()))
+	}
+	if !s.connected {
+		update()
+		return nil
+	}
+	select {
+	case s.updateCh <- update:
+		return nil
+	case <-s.ctx.Done():
+		return ErrNoSocket
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// updates returns the changes to the assigns waiting to be made.
+func (s *BaseSocket) updates() <-chan func() {
+	return s.updateCh
+}
+
+// updater is implemented by sockets which queue changes to their assigns.
+type updater interface {
+	updates() <-chan func()
+}
+
+// Update changes the typed assigns of a socket, see Socket.Update.
+//
+//	go func() {
+//		for price := range prices {
+//			live.Update(ctx, s, func(state *Ticker) *Ticker {
+//				state.Price = price
+//				return state
+//			})
+//		}
+//	}()
+func Update[T any](ctx context.Context, s Socket, fn func(state T) T) error {
+	return s.Update(ctx, func(assigns interface{}) interface{} {
+		state, _ := assigns.(T)
+		return fn(state)
+	})
+}
+
 // Assign sets data to this socket. This will happen automatically
 // if you return data from an `EventHander`.
 func (s *BaseSocket) Assign(data interface{}) {
@@ -157,13 +375,72 @@ func (s *BaseSocket) Connected() bool {
 	return s.connected
 }
 
+// Locale returns the locale of this socket, defaulting to English.
+func (s *BaseSocket) Locale() string {
+	s.dataMu.RLock()
+	defer s.dataMu.RUnlock()
+	if s.locale == "" {
+		return "en"
+	}
+	return s.locale
+}
+
+// SetLocale sets the locale of this socket.
+func (s *BaseSocket) SetLocale(locale string) {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	s.locale = locale
+}
+
+func (s *BaseSocket) protocolVersion() int {
+	s.dataMu.RLock()
+	defer s.dataMu.RUnlock()
+	if s.protocol == 0 {
+		return ProtocolVersion
+	}
+	return s.protocol
+}
+
+func (s *BaseSocket) setProtocolVersion(version int) {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	s.protocol = version
+}
+
+func (s *BaseSocket) setTrustedMark(mark string) {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	s.trustedMark = mark
+}
+
+// swapParams sets the sockets current params, returning the previous ones.
+func (s *BaseSocket) swapParams(p Params) Params {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	prev := s.params
+	s.params = p
+	return prev
+}
+
 // Self sends an event to this socket itself. Will be handled in the
 // handlers HandleSelf function.
-func (s *BaseSocket) Self(ctx context.Context, event string, data interface{}) error {
+func (s *BaseSocket) Self(ctx context.Context, event string, data interface{}, options ...EventConfig) error {
 	defer panicCatcher()
 	//s.selfMu.Lock()
 	//defer s.selfMu.Unlock()
 	msg := Event{T: event, SelfData: data}
+	for _, o := range options {
+		if err := o(&msg); err != nil {
+			return fmt.Errorf("could not configure event: %w", err)
+		}
+	}
+	if msg.noAck {
+		go func() {
+			defer panicCatcher()
+			s.engine.self(context.WithoutCancel(ctx), s, msg)
+		}()
+		return nil
+	}
 	s.engine.self(ctx, s, msg)
 	return nil
 }
@@ -173,6 +450,16 @@ func (s *BaseSocket) Broadcast(event string, data interface{}) error {
 	return s.engine.Broadcast(event, data)
 }
 
+// BroadcastSession sends a self event to the other sockets sharing this
+// socket's session. The engine must be configured WithSessionSync.
+func (s *BaseSocket) BroadcastSession(ctx context.Context, event string, data interface{}) error {
+	b, ok := s.engine.(sessionBroadcaster)
+	if !ok {
+		return fmt.Errorf("engine cannot broadcast to sessions: %w", ErrNotImplemented)
+	}
+	return b.broadcastSession(ctx, s, Event{T: event, SelfData: data})
+}
+
 // Send an event to this socket's client, to be handled there.
 func (s *BaseSocket) Send(event string, data interface{}, options ...EventConfig) error {
 	payload, err := json.Marshal(data)
@@ -185,23 +472,102 @@ func (s *BaseSocket) Send(event string, data interface{}, options ...EventConfig
 			return fmt.Errorf("could not configure event: %w", err)
 		}
 	}
+	if event == EventPatch || event == EventPreview {
+		s.dataMu.RLock()
+		msg.Mark = s.trustedMark
+		s.dataMu.RUnlock()
+	}
+	msgs := s.msgs
+	if msg.priority {
+		msgs = s.priorityMsgs
+	}
 	select {
-	case s.msgs <- msg:
+	case msgs <- msg:
 	default:
+		if msg.noAck {
+			slog.Debug("dropped event for slow client", "event", msg.T, "socket", s.ID())
+			return nil
+		}
 		go s.closeSlow()
 	}
 	return nil
 }
 
+// Reply sets the reply to the client event being handled with the context,
+// it is sent to the client with the events acknowledgement. Calling it again
+// replaces the reply. Replies are kept per event, so events handled
+// concurrently each get their own.
+func (s *BaseSocket) Reply(ctx context.Context, data interface{}) error {
+	slot := replyFromContext(ctx)
+	if slot == nil {
+		return ErrNoEvent
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("could not encode reply: %w", err)
+	}
+	slot.set(payload)
+	return nil
+}
+
+// Exec sends js commands for the client to run.
+func (s *BaseSocket) Exec(commands js.Commands) error {
+	if len(commands) == 0 {
+		return nil
+	}
+	return s.Send(EventExec, commands)
+}
+
 // PatchURL sends an event to the client to update the
 // query params in the URL.
-func (s *BaseSocket) PatchURL(values url.Values) {
-	s.Send(EventParams, values.Encode())
+func (s *BaseSocket) PatchURL(values url.Values, options ...EventConfig) {
+	s.Send(EventParams, values.Encode(), options...)
+}
+
+// PatchParams updates the query params in the browsers URL to keep it in sync
+// with state changed by an event, without a navigation. The params are merged
+// with the current ones, a key set to an empty string is removed. Unlike
+// PatchURL the params handlers are not called, and no history entry is added.
+func (s *BaseSocket) PatchParams(params Params, options ...EventConfig) error {
+	s.dataMu.Lock()
+	merged := Params{}
+	for k, v := range s.params {
+		merged[k] = v
+	}
+	for k, v := range params {
+		if v == "" || v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	s.params = merged
+	s.dataMu.Unlock()
+	return s.Send(EventURL, merged.values().Encode(), options...)
+}
+
+// NextPatch configures the next patch sent to the client.
+func (s *BaseSocket) NextPatch(options ...EventConfig) {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	s.patchOptions = append(s.patchOptions, options...)
+}
+
+// takePatchOptions returns the options for the next patch and clears them.
+func (s *BaseSocket) takePatchOptions() []EventConfig {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	options := s.patchOptions
+	s.patchOptions = nil
+	return options
 }
 
 // Redirect sends a redirect event to the client. This will trigger the browser to
 // redirect to a URL.
 func (s *BaseSocket) Redirect(u *url.URL) {
+	if persistFlashes(s) && s.connected {
+		s.SaveSession(context.Background())
+	}
 	s.Send(EventRedirect, u.String())
 }
 
@@ -277,11 +643,26 @@ func (s *BaseSocket) Session() Session {
 	return s.session
 }
 
+// SaveSession persists the session of this socket.
+func (s *BaseSocket) SaveSession(ctx context.Context) error {
+	saver, ok := s.engine.(sessionSaver)
+	if !ok {
+		return fmt.Errorf("engine cannot save sessions: %w", ErrNotImplemented)
+	}
+	return saver.saveSession(ctx, s)
+}
+
 // Messages returns a channel of event messages sent and received by this socket.
 func (s *BaseSocket) Messages() chan Event {
 	return s.msgs
 }
 
+// priorityMessages returns the channel of events to send to the client ahead
+// of Messages.
+func (s *BaseSocket) priorityMessages() chan Event {
+	return s.priorityMsgs
+}
+
 // AttachChild attaches a child to this socket.
 func (s *BaseSocket) AttachChild(child Child) {
 	s.children = append(s.children, child)
@@ -292,6 +673,16 @@ func (s *BaseSocket) GetChildren() []Child {
 	return s.children
 }
 
+// DetachChild removes a child from this socket.
+func (s *BaseSocket) DetachChild(child Child) {
+	for idx, c := range s.children {
+		if c == child {
+			s.children = append(s.children[:idx], s.children[idx+1:]...)
+			return
+		}
+	}
+}
+
 // Lock the data mutex.
 func (s *BaseSocket) Lock() { s.dataMu.Lock() }
 
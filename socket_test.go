@@ -0,0 +1,429 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSocketSetID(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+
+	a := NewBaseSocket(NewSession(), e, true)
+	b := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(a)
+	e.AddSocket(b)
+
+	if err := a.SetID("user-1"); err != nil {
+		t.Fatal(err)
+	}
+	s, err := e.GetSocketByID("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != a {
+		t.Error("expected to find the renamed socket")
+	}
+	if err := b.SetID("user-1"); !errors.Is(err, ErrSocketIDTaken) {
+		t.Errorf("expected ErrSocketIDTaken, got %v", err)
+	}
+	if len(e.Sockets()) != 2 {
+		t.Errorf("expected 2 sockets, got %d", len(e.Sockets()))
+	}
+
+	e.DeleteSocket(a)
+	if _, err := e.GetSocketByID("user-1"); !errors.Is(err, ErrNoSocket) {
+		t.Errorf("expected ErrNoSocket, got %v", err)
+	}
+}
+
+func TestSocketSetIDConcurrent(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+	sock := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(sock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sock.SetID(fmt.Sprintf("user-%d", i))
+		}()
+		go func() {
+			defer wg.Done()
+			sock.ID()
+		}()
+	}
+	wg.Wait()
+
+	s, err := e.GetSocketByID(sock.ID())
+	if err != nil || s != sock {
+		t.Fatalf("expected to find the socket under its final ID, got %v", err)
+	}
+	if len(e.Sockets()) != 1 {
+		t.Errorf("expected 1 socket, got %d", len(e.Sockets()))
+	}
+}
+
+func TestSocketNextPatch(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+	s := NewBaseSocket(NewSession(), e, true)
+
+	s.NextPatch(WithViewTransition("reorder"))
+	options := s.takePatchOptions()
+	if err := s.Send(EventPatch, []Patch{}, options...); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-s.Messages()
+	if msg.Transition != "reorder" {
+		t.Errorf("expected reorder transition, got %q", msg.Transition)
+	}
+	if len(s.takePatchOptions()) != 0 {
+		t.Error("expected options to apply to one patch only")
+	}
+}
+
+func TestSocketSendEvery(t *testing.T) {
+	ticks := make(chan time.Time, 8)
+	h := NewHandler()
+	h.HandleSelf("tick", func(ctx context.Context, s Socket, data interface{}) (interface{}, error) {
+		ticks <- data.(time.Time)
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	s := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(s)
+
+	s.SendEvery("tick", time.Millisecond)
+	select {
+	case <-ticks:
+	case <-time.After(time.Second):
+		t.Fatal("expected a tick")
+	}
+
+	// Ticks stop when the socket is deleted.
+	e.DeleteSocket(s)
+	time.Sleep(5 * time.Millisecond)
+	for len(ticks) > 0 {
+		<-ticks
+	}
+	time.Sleep(5 * time.Millisecond)
+	if len(ticks) != 0 {
+		t.Error("expected ticks to stop when the socket is deleted")
+	}
+
+	// Unconnected sockets don't tick.
+	stop := NewBaseSocket(NewSession(), e, false).SendEvery("tick", time.Millisecond)
+	stop()
+}
+
+func TestSocketSelfAfter(t *testing.T) {
+	got := make(chan interface{}, 8)
+	h := NewHandler()
+	h.HandleSelf("dismiss", func(ctx context.Context, s Socket, data interface{}) (interface{}, error) {
+		got <- data
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	s := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.SelfAfter(ctx, "dismiss", "toast", 5*time.Millisecond)
+	// The event is still sent after the handler's context has ended.
+	cancel()
+	select {
+	case data := <-got:
+		if data != "toast" {
+			t.Errorf("expected toast, got %v", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the delayed event")
+	}
+
+	// Cancelled events aren't sent.
+	stop := s.SelfAfter(context.Background(), "dismiss", "cancelled", 5*time.Millisecond)
+	stop()
+
+	// Nor are those for deleted sockets.
+	s.SelfAfter(context.Background(), "dismiss", "deleted", 5*time.Millisecond)
+	e.DeleteSocket(s)
+
+	time.Sleep(20 * time.Millisecond)
+	if len(got) != 0 {
+		t.Errorf("expected no more events, got %v", <-got)
+	}
+}
+
+// unmountChild a child which records being unmounted.
+type unmountChild struct {
+	unmounted bool
+}
+
+func (c *unmountChild) ID() string { return "child" }
+func (c *unmountChild) CallEvent(ctx context.Context, t string, sock Socket, msg Params) error {
+	return ErrNoEventHandler
+}
+func (c *unmountChild) CallSelf(ctx context.Context, t string, sock Socket, msg Event) error {
+	return ErrNoEventHandler
+}
+func (c *unmountChild) GetState() any             { return nil }
+func (c *unmountChild) Event(event string) string { return event }
+func (c *unmountChild) UnmountChild(ctx context.Context) error {
+	c.unmounted = true
+	return nil
+}
+
+func TestCloseSocketUnmountsChildren(t *testing.T) {
+	e := NewBaseEngine(NewHandler())
+	s := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(s)
+
+	attached, detached := &unmountChild{}, &unmountChild{}
+	s.AttachChild(attached)
+	s.AttachChild(detached)
+	s.DetachChild(detached)
+	if len(s.GetChildren()) != 1 {
+		t.Fatalf("expected 1 child got %d", len(s.GetChildren()))
+	}
+
+	e.CloseSocket(context.Background(), s, CloseNormal)
+	if !attached.unmounted {
+		t.Error("expected attached child to be unmounted")
+	}
+	if detached.unmounted {
+		t.Error("expected detached child to be left alone")
+	}
+}
+
+func TestCloseSocketDisconnects(t *testing.T) {
+	h := NewHandler()
+	var disconnected Socket
+	h.HandleDisconnect(func(ctx context.Context, s Socket) error {
+		disconnected = s
+		return nil
+	})
+	e := NewBaseEngine(h)
+	s := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(s)
+
+	e.CloseSocket(context.Background(), s, CloseNormal)
+	if disconnected != s {
+		t.Error("expected disconnect to be called with the socket")
+	}
+}
+
+func TestSocketReply(t *testing.T) {
+	s := NewBaseSocket(NewSession(), NewBaseEngine(NewHandler()), true)
+	if err := s.Reply(context.Background(), "abc"); !errors.Is(err, ErrNoEvent) {
+		t.Fatalf("expected no event error, got %v", err)
+	}
+	ctx, slot := contextWithReply(context.Background())
+	if slot.get() != nil {
+		t.Fatal("expected no reply")
+	}
+	if err := s.Reply(ctx, map[string]string{"id": "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(slot.get()); got != `{"id":"abc"}` {
+		t.Errorf("unexpected reply %s", got)
+	}
+}
+
+func TestSocketCall(t *testing.T) {
+	s := NewBaseSocket(NewSession(), NewBaseEngine(NewHandler()), true)
+
+	type result struct {
+		data json.RawMessage
+		err  error
+	}
+	call := func(reply string) result {
+		done := make(chan result, 1)
+		go func() {
+			data, err := s.Call(context.Background(), "read-storage", "theme")
+			done <- result{data, err}
+		}()
+		msg := <-s.Messages()
+		if msg.T != "read-storage" || !msg.Call || msg.ID == 0 {
+			t.Fatalf("unexpected call event %+v", msg)
+		}
+		s.resolveCall(Event{T: EventReply, ID: msg.ID, Data: json.RawMessage(reply)})
+		return <-done
+	}
+
+	r := call(`{"r":"dark"}`)
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if string(r.data) != `"dark"` {
+		t.Errorf("unexpected reply %s", r.data)
+	}
+
+	r = call(`{"e":"no handler"}`)
+	if !errors.Is(r.err, ErrCallFailed) {
+		t.Errorf("expected call to fail, got %v", r.err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Call(ctx, "read-storage", "theme"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+
+	if _, err := NewBaseSocket(NewSession(), NewBaseEngine(NewHandler()), false).Call(ctx, "read-storage", nil); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("expected not connected, got %v", err)
+	}
+}
+
+func TestSocketPatchParams(t *testing.T) {
+	s := NewBaseSocket(NewSession(), NewBaseEngine(NewHandler()), true)
+	s.swapParams(Params{"page": "2", "filter": "open"})
+
+	if err := s.PatchParams(Params{"sort": "name", "filter": ""}); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-s.Messages()
+	if msg.T != EventURL {
+		t.Fatalf("expected url event, got %s", msg.T)
+	}
+	var q string
+	if err := json.Unmarshal(msg.Data, &q); err != nil {
+		t.Fatal(err)
+	}
+	if q != "page=2&sort=name" {
+		t.Errorf("unexpected query %s", q)
+	}
+	if prev := s.swapParams(nil); prev.String("sort") != "name" {
+		t.Errorf("expected the socket params to be updated, got %v", prev)
+	}
+}
+
+func TestSocketUpdate(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		return 0, nil
+	})
+	h.HandleEvent("inc", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return s.Assigns().(int) + 1, nil
+	})
+	e := NewBaseEngine(h)
+	c := newTestConn()
+	sock := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(sock)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.serveSocket(ctx, e, sock, c, httptest.NewRequest("GET", "/", nil))
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	c.in <- Event{T: "inc", ID: 1}
+	c.expectAck(t, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Update(ctx, sock, func(n int) int { return n + 1 }); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	for i := 2; i < 12; i++ {
+		c.in <- Event{T: "inc", ID: i}
+	}
+	wg.Wait()
+
+	deadline := time.After(time.Second)
+	for sock.Assigns().(int) != 31 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected every update to be made, got %v", sock.Assigns())
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// selfChild a child which records the self events it handles.
+type selfChild struct {
+	id  string
+	got []string
+}
+
+func (c *selfChild) ID() string { return c.id }
+func (c *selfChild) CallEvent(ctx context.Context, t string, sock Socket, msg Params) error {
+	return ErrNoEventHandler
+}
+func (c *selfChild) CallSelf(ctx context.Context, t string, sock Socket, msg Event) error {
+	c.got = append(c.got, t)
+	return nil
+}
+func (c *selfChild) GetState() any             { return nil }
+func (c *selfChild) Event(event string) string { return event }
+
+func TestSocketSelfTarget(t *testing.T) {
+	h := testRenderHandler()
+	var root []string
+	h.HandleSelf("refresh", func(ctx context.Context, s Socket, data interface{}) (interface{}, error) {
+		root = append(root, "refresh")
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	s := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(s)
+	a, b := &selfChild{id: "a"}, &selfChild{id: "b"}
+	s.AttachChild(a)
+	s.AttachChild(b)
+
+	s.Self(context.Background(), "refresh", nil, WithTarget("b"))
+	if len(a.got) != 0 || len(b.got) != 1 || len(root) != 0 {
+		t.Errorf("expected only b to handle the event, got a %v b %v root %v", a.got, b.got, root)
+	}
+	s.Self(context.Background(), "refresh", nil)
+	if len(a.got) != 1 || len(b.got) != 2 || len(root) != 1 {
+		t.Errorf("expected everyone to handle the event, got a %v b %v root %v", a.got, b.got, root)
+	}
+}
+
+func TestSocketSendOptions(t *testing.T) {
+	e := NewBaseEngine(testRenderHandler())
+	s := NewBaseSocket(NewSession(), e, true)
+	closed := make(chan struct{}, 1)
+	s.closeSlow = func() { closed <- struct{}{} }
+
+	for i := 0; i < maxMessageBufferSize; i++ {
+		s.Send("patch", i)
+	}
+	if err := s.Send("urgent", nil, WithPriority(), WithTarget("toast")); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-s.priorityMessages()
+	if msg.T != "urgent" || msg.Target != "toast" {
+		t.Errorf("unexpected priority message %+v", msg)
+	}
+
+	// The buffer is full, events which don't need delivering are dropped.
+	if err := s.Send("progress", nil, WithNoAck()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-closed:
+		t.Fatal("client was disconnected for a dropped event")
+	case <-time.After(20 * time.Millisecond):
+	}
+	s.Send("patch", nil)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("slow client was not disconnected")
+	}
+}
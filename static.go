@@ -0,0 +1,57 @@
+package live
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// WithStaticMode renders pages entirely on the server. The rendered page
+// doesn't tell the client to connect, and websocket upgrades are refused, so
+// the page behaves like any other server rendered one while using the same
+// mount, params and render code.
+func WithStaticMode() EngineConfig {
+	return WithStaticModeFunc(func(r *http.Request) bool {
+		return true
+	})
+}
+
+// WithStaticModeFunc renders the requests the func matches in static mode,
+// see WithStaticMode. Use it to serve crawlers or print views, or to roll
+// live out behind a feature flag.
+//
+//	live.WithStaticModeFunc(func(r *http.Request) bool {
+//		return r.URL.Query().Has("print")
+//	})
+func WithStaticModeFunc(fn func(r *http.Request) bool) EngineConfig {
+	return func(e Engine) error {
+		if httpEngine, ok := e.(*HttpEngine); ok {
+			httpEngine.staticMode = fn
+		}
+		return nil
+	}
+}
+
+// isStatic checks if a request should be served in static mode.
+func (h *HttpEngine) isStatic(r *http.Request) bool {
+	return h.staticMode != nil && h.staticMode(r)
+}
+
+// stripLive removes the attribute which tells the client to connect, and
+// the anchors patches are applied with, from a render.
+func stripLive(root *html.Node) {
+	if root.Type == html.ElementNode {
+		attrs := root.Attr[:0]
+		for _, a := range root.Attr {
+			if a.Key == LiveRendered || strings.HasPrefix(a.Key, liveAnchorPrefix) {
+				continue
+			}
+			attrs = append(attrs, a)
+		}
+		root.Attr = attrs
+	}
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		stripLive(c)
+	}
+}
@@ -0,0 +1,46 @@
+package live
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStaticMode(t *testing.T) {
+	h := NewHandler()
+	h.HandleMount(func(ctx context.Context, s Socket) (interface{}, error) {
+		return "mounted", nil
+	})
+	h.HandleRender(func(ctx context.Context, rc *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body><p>` + rc.Assigns.(string) + `</p></body></html>`), nil
+	})
+	e := NewHttpHandler(NewTestStore("test"), h, WithStaticModeFunc(func(r *http.Request) bool {
+		return r.URL.Query().Has("print")
+	}))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(w.Body.String(), LiveRendered) {
+		t.Errorf("expected live page, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?print", nil))
+	if strings.Contains(w.Body.String(), LiveRendered) {
+		t.Errorf("expected static page, got %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "<p>mounted</p>") {
+		t.Errorf("expected rendered page, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/?print", nil)
+	r.Header.Set("Upgrade", "websocket")
+	e.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected websocket to be refused, got %d", w.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package live
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes what an engine has been doing.
+type Stats struct {
+	// Sockets the number of connected sockets.
+	Sockets int
+	// Events the number of client events handled.
+	Events uint64
+	// EventErrors the number of client events whose handler failed.
+	EventErrors uint64
+	// EventCounts the number of times each client event has been handled.
+	EventCounts map[string]uint64
+	// Renders the number of socket renders.
+	Renders uint64
+	// AverageRender the mean time a render takes, not including time spent
+	// queued by WithRenderConcurrency.
+	AverageRender time.Duration
+	// Render the stats of renders limited with WithRenderConcurrency.
+	Render RenderStats
+	// Errors the most recent event errors, oldest first.
+	Errors []StatsError
+}
+
+// StatsError an event error kept in the stats.
+type StatsError struct {
+	Time    time.Time
+	Event   string
+	Message string
+}
+
+// maxStatsErrors the number of recent errors kept in the stats.
+const maxStatsErrors = 20
+
+// engineStats counts events and renders for an engine.
+type engineStats struct {
+	events      atomic.Uint64
+	eventErrors atomic.Uint64
+	renders     atomic.Uint64
+	renderTime  atomic.Int64
+
+	mu     sync.Mutex
+	counts map[string]uint64
+	errors []StatsError
+}
+
+func newEngineStats() *engineStats {
+	return &engineStats{counts: map[string]uint64{}}
+}
+
+func (s *engineStats) event(t string, err error) {
+	s.events.Add(1)
+	if err != nil {
+		s.eventErrors.Add(1)
+	}
+	// Only events with handlers are counted by name, clients can send any.
+	if errors.Is(err, ErrNoEventHandler) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[t]++
+	if err != nil {
+		s.errors = append(s.errors, StatsError{Time: time.Now(), Event: t, Message: err.Error()})
+		if len(s.errors) > maxStatsErrors {
+			s.errors = s.errors[len(s.errors)-maxStatsErrors:]
+		}
+	}
+}
+
+func (s *engineStats) render(d time.Duration) {
+	s.renders.Add(1)
+	s.renderTime.Add(int64(d))
+}
+
+// Stats returns the stats of the engine.
+func (e *BaseEngine) Stats() Stats {
+	e.stats.mu.Lock()
+	counts := make(map[string]uint64, len(e.stats.counts))
+	for t, n := range e.stats.counts {
+		counts[t] = n
+	}
+	errs := make([]StatsError, len(e.stats.errors))
+	copy(errs, e.stats.errors)
+	e.stats.mu.Unlock()
+
+	stats := Stats{
+		Sockets:     len(e.Sockets()),
+		Events:      e.stats.events.Load(),
+		EventErrors: e.stats.eventErrors.Load(),
+		EventCounts: counts,
+		Renders:     e.stats.renders.Load(),
+		Render:      e.RenderStats(),
+		Errors:      errs,
+	}
+	if stats.Renders > 0 {
+		stats.AverageRender = time.Duration(e.stats.renderTime.Load() / int64(stats.Renders))
+	}
+	return stats
+}
+
+// PublishExpvar publishes the stats of the engine with expvar under the name,
+// so they are served by the expvar handler at /debug/vars. Like
+// expvar.Publish it panics if the name is already in use.
+func (e *BaseEngine) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return e.Stats()
+	}))
+}
+
+// statsRecorder is implemented by engines which keep stats.
+type statsRecorder interface {
+	recordRender(d time.Duration)
+}
+
+func (e *BaseEngine) recordRender(d time.Duration) {
+	e.stats.render(d)
+}
@@ -0,0 +1,47 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleEvent("ok", func(ctx context.Context, s Socket, p Params) (any, error) {
+		return nil, nil
+	})
+	h.HandleEvent("fail", func(ctx context.Context, s Socket, p Params) (any, error) {
+		return nil, errors.New("failed")
+	})
+	e := NewBaseEngine(h)
+	sock := NewBaseSocket(NewSession(), e, true)
+	e.AddSocket(sock)
+
+	ctx := context.Background()
+	e.CallEvent(ctx, "ok", sock, Event{T: "ok"})
+	e.CallEvent(ctx, "ok", sock, Event{T: "ok"})
+	e.CallEvent(ctx, "fail", sock, Event{T: "fail"})
+	e.CallEvent(ctx, "unknown", sock, Event{T: "unknown"})
+	if _, err := RenderSocket(ctx, e, sock); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := e.Stats()
+	if stats.Sockets != 1 || stats.Events != 4 || stats.EventErrors != 2 || stats.Renders != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if stats.EventCounts["ok"] != 2 || stats.EventCounts["fail"] != 1 || len(stats.EventCounts) != 2 {
+		t.Fatalf("unexpected event counts %v", stats.EventCounts)
+	}
+
+	if len(stats.Errors) != 1 || stats.Errors[0].Event != "fail" || stats.Errors[0].Message != "failed" {
+		t.Fatalf("expected the failed event in the errors, got %v", stats.Errors)
+	}
+
+	e.PublishExpvar("live_test_stats")
+	if v := expvar.Get("live_test_stats"); v == nil || v.String() == "" {
+		t.Fatal("expected stats to be published")
+	}
+}
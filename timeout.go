@@ -0,0 +1,94 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrorCodeTimeout the code of the error sent when an event handler times out.
+const ErrorCodeTimeout = "timeout"
+
+// WithEventTimeout limits how long event handlers may run. Given no events it
+// applies to all of them, otherwise only to those named, overriding the
+// default for them. When a handler runs out of time its context is cancelled
+// and the client is sent an error, so that a hung handler doesn't hold up the
+// rest of the socket's events. Whatever the handler returns afterwards is
+// discarded.
+//
+//	live.WithEventTimeout(5*time.Second)
+//	live.WithEventTimeout(time.Minute, "export")
+func WithEventTimeout(d time.Duration, events ...string) EngineConfig {
+	return func(e Engine) error {
+		var base *BaseEngine
+		switch v := e.(type) {
+		case *BaseEngine:
+			base = v
+		case *HttpEngine:
+			base = v.BaseEngine
+		}
+		if base == nil {
+			return nil
+		}
+		if len(events) == 0 {
+			base.eventTimeout = d
+			return nil
+		}
+		if base.eventTimeouts == nil {
+			base.eventTimeouts = map[string]time.Duration{}
+		}
+		for _, event := range events {
+			base.eventTimeouts[event] = d
+		}
+		return nil
+	}
+}
+
+// timeoutFor the time a handler for an event may run, zero if there is no
+// limit.
+func (e *BaseEngine) timeoutFor(t string) time.Duration {
+	if d, ok := e.eventTimeouts[t]; ok {
+		return d
+	}
+	return e.eventTimeout
+}
+
+// eventResult what an event handler returned.
+type eventResult struct {
+	data interface{}
+	err  error
+}
+
+// runEvent runs an event handler, giving up on it if it runs for longer than
+// the event's timeout.
+func (e *BaseEngine) runEvent(ctx context.Context, t string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	d := e.timeoutFor(t)
+	if d <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	done := make(chan eventResult, 1)
+	go func() {
+		// The handler may outlive the event, so its panics can't reach the
+		// connection's recover.
+		defer func() {
+			if r := recover(); r != nil {
+				done <- eventResult{err: fmt.Errorf("%w: handling event %s: %v", ErrEventPanic, t, r)}
+			}
+		}()
+		data, err := fn(ctx)
+		done <- eventResult{data: data, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.data, res.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, NewError(ErrorCodeTimeout, fmt.Errorf("%w: %s took longer than %s", ErrEventTimeout, t, d))
+		}
+		return nil, ctx.Err()
+	}
+}
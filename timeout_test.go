@@ -0,0 +1,74 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventTimeout(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleEvent("hang", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		select {}
+	})
+	h.HandleEvent("slow", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		time.Sleep(30 * time.Millisecond)
+		return 1, nil
+	})
+	e := NewBaseEngine(h)
+	WithEventTimeout(10 * time.Millisecond)(e)
+	WithEventTimeout(time.Second, "slow")(e)
+	sock := NewBaseSocket(NewSession(), e, true)
+
+	err := e.CallEvent(context.Background(), "hang", sock, Event{T: "hang"})
+	if !errors.Is(err, ErrEventTimeout) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if ErrorCode(err) != ErrorCodeTimeout {
+		t.Errorf("expected timeout code, got %s", ErrorCode(err))
+	}
+
+	if err := e.CallEvent(context.Background(), "slow", sock, Event{T: "slow"}); err != nil {
+		t.Fatal(err)
+	}
+	if sock.Assigns() != 1 {
+		t.Errorf("expected assigns from slow event, got %v", sock.Assigns())
+	}
+}
+
+func TestEventTimeoutUnblocksSocket(t *testing.T) {
+	h := testRenderHandler()
+	h.HandleEvent("hang", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	h.HandleEvent("next", func(ctx context.Context, s Socket, p Params) (interface{}, error) {
+		return nil, nil
+	})
+	e := NewBaseEngine(h)
+	WithEventTimeout(10*time.Millisecond, "hang")(e)
+	c := serveTestSocket(t, e)
+
+	c.in <- Event{T: "hang", ID: 1}
+	c.in <- Event{T: "next", ID: 2}
+
+	timeout := time.After(time.Second)
+	var sawError bool
+	for {
+		select {
+		case m := <-c.out:
+			switch {
+			case m.T == EventError:
+				sawError = true
+			case m.T == EventAck && m.ID == 2:
+				if !sawError {
+					t.Error("expected an error event for the timed out event")
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("socket was held up by the hung event")
+		}
+	}
+}
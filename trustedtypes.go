@@ -0,0 +1,56 @@
+package live
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// WithTrustedTypes make the client apply patches through a Trusted Types
+// policy with the given name, the name must be allowed by the pages
+// `trusted-types` content security policy directive. The client is told the
+// name when it connects, along with a mark unique to the connection. Patches
+// the server sends carry the mark, and the policy refuses HTML from any patch
+// which doesn't.
+func WithTrustedTypes(policy string) EngineConfig {
+	return func(e Engine) error {
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.trustedTypes = policy
+		case *HttpEngine:
+			v.trustedTypes = policy
+		}
+		return nil
+	}
+}
+
+// trustedMark returns a new mark for a connection, empty if the engine
+// doesn't use a Trusted Types policy.
+func (e *BaseEngine) trustedMark() string {
+	if e.trustedTypes == "" {
+		return ""
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("live: could not generate trusted types mark: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+type trustedMarkContextKey struct{}
+
+// contextWithTrustedMark sets the mark the connection's patches are sent with.
+func contextWithTrustedMark(ctx context.Context, mark string) context.Context {
+	return context.WithValue(ctx, trustedMarkContextKey{}, mark)
+}
+
+// trustedMarkFromContext gets the mark the connection's patches are sent with.
+func trustedMarkFromContext(ctx context.Context) string {
+	mark, _ := ctx.Value(trustedMarkContextKey{}).(string)
+	return mark
+}
+
+// trustedMarker is implemented by sockets which mark the patches they send.
+type trustedMarker interface {
+	setTrustedMark(mark string)
+}
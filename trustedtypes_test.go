@@ -0,0 +1,60 @@
+package live
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTrustedTypesConnect(t *testing.T) {
+	e := NewHttpHandler(NewTestStore("test"), testRenderHandler(), WithTrustedTypes("live"))
+	if data := dialConnectData(t, e, "Go"); data.TrustedTypes != "live" {
+		t.Errorf("expected the policy to be negotiated at connect, got %q", data.TrustedTypes)
+	}
+	first, second := dialConnectData(t, e, "Go").TrustedMark, dialConnectData(t, e, "Go").TrustedMark
+	if first == "" || first == second {
+		t.Errorf("expected a mark unique to each connection, got %q and %q", first, second)
+	}
+	if policy := e.Protocol().Features.TrustedTypes; policy != "live" {
+		t.Errorf("expected the policy in the protocol descriptor, got %q", policy)
+	}
+
+	e = NewHttpHandler(NewTestStore("test"), testRenderHandler())
+	if data := dialConnectData(t, e, "Go"); data.TrustedTypes != "" || data.TrustedMark != "" {
+		t.Errorf("expected no policy or mark, got %q and %q", data.TrustedTypes, data.TrustedMark)
+	}
+}
+
+func TestTrustedTypesMark(t *testing.T) {
+	e := NewBaseEngine(testRenderHandler())
+	sock := NewBaseSocket(NewSession(), e, true)
+	sock.setTrustedMark("abc")
+	for _, event := range []string{EventPatch, EventPreview, EventError} {
+		if err := sock.Send(event, nil); err != nil {
+			t.Fatal(err)
+		}
+		msg := <-sock.msgs
+		d, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		marked := strings.Contains(string(d), `"m":"abc"`)
+		if expected := event != EventError; marked != expected {
+			t.Errorf("%s: expected marked %t, got %s", event, expected, d)
+		}
+	}
+}
+
+func TestTrustedTypesPatches(t *testing.T) {
+	// Patches don't name the policy, the client uses the one from connect.
+	patches := []Patch{{Anchor: "_l0", Action: Replace, HTML: "<p>hi</p>"}}
+	for _, version := range []int{ProtocolVersion, LatestProtocolVersion} {
+		d, err := json.Marshal(encodePatches(patches, version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(d), "Policy") || strings.Contains(string(d), `"y"`) {
+			t.Errorf("version %d: expected no policy in %s", version, d)
+		}
+	}
+}
@@ -48,6 +48,9 @@ type UploadConfig struct {
 
 // Upload describes an upload from the client.
 type Upload struct {
+	// ID identifies the upload, render it in a `live-upload-cancel`
+	// attribute to let the user cancel the upload.
+	ID           string
 	Name         string
 	Size         int64
 	Type         string
@@ -59,6 +62,11 @@ type Upload struct {
 	bytesRead        int64  `json:"-"`
 }
 
+// Offset the number of bytes of the upload which have been received.
+func (u Upload) Offset() int64 {
+	return u.bytesRead
+}
+
 // File gets an open file reader.
 func (u Upload) File() (*os.File, error) {
 	return os.Open(u.internalLocation)
@@ -92,8 +100,6 @@ func (u *UploadProgress) Write(p []byte) (n int, err error) {
 	n, err = len(p), nil
 	u.Upload.bytesRead += int64(n)
 	u.Upload.Progress = float32(u.Upload.bytesRead) / float32(u.Upload.Size)
-	u.Socket.Lock()
-	defer u.Socket.Unlock()
 	render, err := RenderSocket(context.Background(), u.Engine, u.Socket)
 	if err != nil {
 		slog.Error("error in upload progress", "error", err, "socket", u.Socket.ID())
@@ -106,6 +112,8 @@ func (u *UploadProgress) Write(p []byte) (n int, err error) {
 // ValidateUploads checks proposed uploads for errors, should be called
 // in a validation check function.
 func ValidateUploads(s Socket, p Params) {
+	// Keep track of uploads in progress so they can be resumed.
+	previous := s.Uploads()
 	s.ClearUploads()
 
 	input, ok := p[upKey].(map[string]interface{})
@@ -134,6 +142,14 @@ func ValidateUploads(s Socket, p Params) {
 				Size: int64(mapInt(f, "size")),
 				Type: mapString(f, "type"),
 			}
+			u.ID = uploadID(c.Name, u.Name, u.Size)
+			for _, p := range previous[c.Name] {
+				if p.ID == u.ID && p.bytesRead > 0 {
+					u.bytesRead = p.bytesRead
+					u.Progress = p.Progress
+					u.internalLocation = p.internalLocation
+				}
+			}
 
 			// Check size.
 			if u.Size > c.MaxSize {
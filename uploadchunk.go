@@ -0,0 +1,416 @@
+package live
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// uploadIDHeader identifies the upload a chunk or cancellation is for.
+	uploadIDHeader = "Live-Upload-ID"
+	// uploadInputHeader the name of the input the upload is from.
+	uploadInputHeader = "Live-Upload-Input"
+	// uploadFilenameHeader the url encoded name of the file being uploaded.
+	uploadFilenameHeader = "Live-Upload-Filename"
+	// uploadSizeHeader the total size of the file being uploaded.
+	uploadSizeHeader = "Live-Upload-Size"
+	// uploadOffsetHeader the offset of a chunk in the file, and in responses
+	// the number of bytes the server has received.
+	uploadOffsetHeader = "Live-Upload-Offset"
+
+	// liveUploadChunk body attribute telling the client the chunk size to
+	// upload with.
+	liveUploadChunk = "live-upload-chunk"
+)
+
+// WithUploadChunkSize uploads files in chunks of at most size bytes. An upload
+// which is interrupted, for example by a flaky mobile connection, is resumed
+// from the last chunk the server received rather than starting again.
+func WithUploadChunkSize(size int64) EngineConfig {
+	return func(e Engine) error {
+		if size < 0 {
+			return fmt.Errorf("invalid upload chunk size %d", size)
+		}
+		switch v := e.(type) {
+		case *BaseEngine:
+			v.UploadChunkSize = size
+		case *HttpEngine:
+			v.UploadChunkSize = size
+		}
+		return nil
+	}
+}
+
+// uploadID the ID of an upload, the client derives the same ID from the
+// input and file.
+func uploadID(input, filename string, size int64) string {
+	return fmt.Sprintf("%s-%s-%d", input, filename, size)
+}
+
+// findUpload finds an upload on the socket by its ID.
+func findUpload(sock Socket, id string) (string, *Upload) {
+	for config, uploads := range sock.Uploads() {
+		for _, u := range uploads {
+			if u.ID == id {
+				return config, u
+			}
+		}
+	}
+	return "", nil
+}
+
+// tempStaging the temporary directory uploads are staged in when no staging
+// location is configured, made when the engine first needs it.
+type tempStaging struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// stagingLocation returns the directory the engine stages uploads in.
+func (h *HttpEngine) stagingLocation() (string, error) {
+	if h.UploadStagingLocation != "" {
+		return h.UploadStagingLocation, nil
+	}
+	h.tempStaging.mu.Lock()
+	defer h.tempStaging.mu.Unlock()
+	if h.tempStaging.dir == "" {
+		dir, err := os.MkdirTemp("", "live-uploads")
+		if err != nil {
+			return "", err
+		}
+		h.tempStaging.dir = dir
+	}
+	return h.tempStaging.dir, nil
+}
+
+// stagingDir returns the directory a sockets uploads are staged in.
+func (h *HttpEngine) stagingDir(sock Socket) (string, error) {
+	location, err := h.stagingLocation()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(location, string(sock.ID()))
+	return dir, os.MkdirAll(dir, 0o700)
+}
+
+// dropUploads forgets the chunked uploads of a socket which has closed, and
+// removes the files it staged.
+func (h *HttpEngine) dropUploads(sock Socket) {
+	h.chunkedUploads.drop(sock)
+	location := h.UploadStagingLocation
+	if location == "" {
+		h.tempStaging.mu.Lock()
+		location = h.tempStaging.dir
+		h.tempStaging.mu.Unlock()
+	}
+	if location == "" {
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(location, string(sock.ID()))); err != nil {
+		slog.Error("could not remove staged uploads", "error", err, "socket", sock.ID())
+	}
+}
+
+// Close stops the engine, removing the temporary directory uploads were
+// staged in.
+func (h *HttpEngine) Close() error {
+	h.tempStaging.mu.Lock()
+	dir := h.tempStaging.dir
+	h.tempStaging.dir = ""
+	h.tempStaging.mu.Unlock()
+	if dir != "" {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("could not remove staged uploads: %w", err)
+		}
+	}
+	return h.BaseEngine.Close()
+}
+
+// chunkedUpload a chunked upload being received. Its chunks are written one
+// at a time, and what the handlers and renders see of it is only changed in
+// the socket's loop.
+type chunkedUpload struct {
+	mu     sync.Mutex
+	upload *Upload
+	// received the number of bytes written to path.
+	received int64
+	path     string
+}
+
+// chunkedUploads the chunked uploads being received by an engine.
+type chunkedUploads struct {
+	mu      sync.Mutex
+	uploads map[string]*chunkedUpload
+}
+
+// get returns the upload with the key, creating it with create if there
+// isn't one, and whether it was created.
+func (c *chunkedUploads) get(key string, create func() (*chunkedUpload, bool)) (cu *chunkedUpload, created bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cu, ok := c.uploads[key]; ok {
+		return cu, false, true
+	}
+	cu, ok = create()
+	if !ok {
+		return nil, false, false
+	}
+	if c.uploads == nil {
+		c.uploads = map[string]*chunkedUpload{}
+	}
+	c.uploads[key] = cu
+	return cu, true, true
+}
+
+// done forgets an upload once it is complete or cancelled.
+func (c *chunkedUploads) done(key string, cu *chunkedUpload) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.uploads[key] == cu {
+		delete(c.uploads, key)
+	}
+}
+
+// cancel forgets an upload, chunks sent for it afterwards start it again.
+func (c *chunkedUploads) cancel(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.uploads, key)
+}
+
+// drop forgets every upload of a socket.
+func (c *chunkedUploads) drop(sock Socket) {
+	prefix := chunkedUploadKey(sock, "")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.uploads {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.uploads, key)
+		}
+	}
+}
+
+// chunkedUploadKey the key of an upload on a socket.
+func chunkedUploadKey(sock Socket, id string) string {
+	return string(sock.ID()) + "/" + id
+}
+
+// updateUploads changes the uploads of a socket with fn between its events,
+// and re-renders it to show them.
+func (h *HttpEngine) updateUploads(ctx context.Context, sock Socket, fn func()) {
+	if err := sock.Update(ctx, func(assigns interface{}) interface{} {
+		fn()
+		return assigns
+	}); err != nil {
+		h.Error()(ctx, fmt.Errorf("could not update uploads: %w", err))
+	}
+}
+
+// postUploadChunk handles a chunk of a resumable upload. A chunk must start
+// at the offset the server has received up to, if it doesn't the server
+// responds with a conflict and the offset to resume from.
+func (h *HttpEngine) postUploadChunk(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	id, err := url.PathUnescape(r.Header.Get(uploadIDHeader))
+	if err != nil {
+		http.Error(w, ErrUploadMalformed.Error(), http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.sessionStore.Get(r)
+	if err != nil {
+		h.Error()(ctx, fmt.Errorf("no session found: %w", err))
+		return
+	}
+	sock, err := h.GetSocket(session)
+	if err != nil {
+		h.Error()(ctx, err)
+		return
+	}
+
+	input := r.Header.Get(uploadInputHeader)
+	var config *UploadConfig
+	for _, c := range sock.UploadConfigs() {
+		if c.Name == input {
+			config = c
+		}
+	}
+	if config == nil {
+		http.Error(w, ErrUploadNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	filename, err := url.PathUnescape(r.Header.Get(uploadFilenameHeader))
+	if err != nil {
+		http.Error(w, ErrUploadMalformed.Error(), http.StatusBadRequest)
+		return
+	}
+	size, err := strconv.ParseInt(r.Header.Get(uploadSizeHeader), 10, 64)
+	if err != nil || size < 0 {
+		http.Error(w, ErrUploadMalformed.Error(), http.StatusBadRequest)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, ErrUploadMalformed.Error(), http.StatusBadRequest)
+		return
+	}
+	if size > config.MaxSize {
+		http.Error(w, ErrUploadTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	key := chunkedUploadKey(sock, id)
+	cu, created, ok := h.chunkedUploads.get(key, func() (*chunkedUpload, bool) {
+		if offset != 0 {
+			return nil, false
+		}
+		return &chunkedUpload{upload: &Upload{ID: id, Name: filename, Size: size}}, true
+	})
+	if !ok {
+		w.Header().Set(uploadOffsetHeader, "0")
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	// The socket is updated outside the lock, so that a slow socket doesn't
+	// hold up uploads to the others.
+	if created {
+		h.updateUploads(ctx, sock, func() {
+			sock.AssignUpload(config.Name, cu.upload)
+		})
+	}
+
+	// Chunks sent in parallel are written one at a time, any which doesn't
+	// start where the last one finished is refused.
+	cu.mu.Lock()
+	defer cu.mu.Unlock()
+	u := cu.upload
+	if offset != cu.received {
+		w.Header().Set(uploadOffsetHeader, strconv.FormatInt(cu.received, 10))
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	limit := u.Size - offset
+	if h.UploadChunkSize > 0 && h.UploadChunkSize < limit {
+		limit = h.UploadChunkSize
+	}
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, limit))
+
+	if cu.path == "" {
+		// Check the actual filetype from the start of the file.
+		head, err := body.Peek(512)
+		if err != nil && err != io.EOF {
+			http.Error(w, ErrUploadMalformed.Error(), http.StatusBadRequest)
+			return
+		}
+		filetype := http.DetectContentType(head)
+		allowed := false
+		for _, a := range config.Accept {
+			if filetype == a {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			h.chunkedUploads.done(key, cu)
+			h.updateUploads(ctx, sock, func() {
+				u.Type = filetype
+				u.Errors = append(u.Errors, fmt.Errorf("%s filetype is not allowed", filename))
+			})
+			http.Error(w, ErrUploadNotAccepted.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		dir, err := h.stagingDir(sock)
+		if err != nil {
+			h.Error()(ctx, fmt.Errorf("%s upload dir creation failed: %w", sock.ID(), err))
+			return
+		}
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%d%s", time.Now().UnixNano(), filepath.Ext(filename))))
+		if err != nil {
+			h.Error()(ctx, fmt.Errorf("%s upload file creation failed: %w", filename, err))
+			return
+		}
+		f.Close()
+		cu.path = f.Name()
+		path := cu.path
+		h.updateUploads(ctx, sock, func() {
+			u.Type = filetype
+			u.internalLocation = path
+		})
+	}
+
+	f, err := os.OpenFile(cu.path, os.O_WRONLY, 0)
+	if err != nil {
+		h.Error()(ctx, fmt.Errorf("%s upload file open failed: %w", filename, err))
+		return
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		h.Error()(ctx, fmt.Errorf("%s upload seek failed: %w", filename, err))
+		return
+	}
+
+	written, copyErr := io.Copy(f, body)
+	// Only count what made it to disk, so the client resumes from there.
+	cu.received = offset + written
+	received := cu.received
+	if received == u.Size {
+		h.chunkedUploads.done(key, cu)
+	}
+	h.updateUploads(ctx, sock, func() {
+		u.bytesRead = received
+		u.Progress = float32(received) / float32(u.Size)
+	})
+
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(received, 10))
+	if copyErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteUpload handles the client cancelling an upload, the staged file is
+// removed and the upload cleared from the socket.
+func (h *HttpEngine) deleteUpload(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	id, err := url.PathUnescape(r.Header.Get(uploadIDHeader))
+	if err != nil || id == "" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	session, err := h.sessionStore.Get(r)
+	if err != nil {
+		h.Error()(ctx, fmt.Errorf("no session found: %w", err))
+		return
+	}
+	sock, err := h.GetSocket(session)
+	if err != nil {
+		h.Error()(ctx, err)
+		return
+	}
+
+	h.chunkedUploads.cancel(chunkedUploadKey(sock, id))
+
+	h.updateUploads(ctx, sock, func() {
+		config, u := findUpload(sock, id)
+		if u == nil {
+			return
+		}
+		if u.internalLocation != "" {
+			os.Remove(u.internalLocation)
+		}
+		sock.ClearUpload(config, u)
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
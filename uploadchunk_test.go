@@ -0,0 +1,192 @@
+package live
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUploadChunks(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body></body></html>`), nil
+	})
+	store := NewTestStore("test")
+	e := NewHttpHandler(store, h, WithUploadChunkSize(4))
+	e.UploadStagingLocation = t.TempDir()
+
+	sock := NewHttpSocket(store.s, e, true)
+	sock.AllowUploads(&UploadConfig{Name: "doc", MaxFiles: 1, MaxSize: 1024, Accept: []string{"text/plain; charset=utf-8"}})
+	serveTestSocket(t, e, withSocket(sock))
+
+	content := "hello world!"
+	id := uploadID("doc", "hello.txt", int64(len(content)))
+	chunk := func(offset int) *httptest.ResponseRecorder {
+		end := offset + 4
+		if end > len(content) {
+			end = len(content)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(content[offset:end]))
+		r.Header.Set(uploadIDHeader, id)
+		r.Header.Set(uploadInputHeader, "doc")
+		r.Header.Set(uploadFilenameHeader, "hello.txt")
+		r.Header.Set(uploadSizeHeader, strconv.Itoa(len(content)))
+		r.Header.Set(uploadOffsetHeader, strconv.Itoa(offset))
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := chunk(0); w.Code != http.StatusNoContent || w.Header().Get(uploadOffsetHeader) != "4" {
+		t.Fatalf("expected first chunk accepted, got %d %s", w.Code, w.Header().Get(uploadOffsetHeader))
+	}
+	// A retried chunk is told where to resume from.
+	if w := chunk(0); w.Code != http.StatusConflict || w.Header().Get(uploadOffsetHeader) != "4" {
+		t.Fatalf("expected conflict resuming at 4, got %d %s", w.Code, w.Header().Get(uploadOffsetHeader))
+	}
+	chunk(4)
+	chunk(8)
+
+	syncSocket(t, sock)
+	_, u := findUpload(sock, id)
+	if u == nil || u.Offset() != int64(len(content)) {
+		t.Fatalf("expected complete upload, got %+v", u)
+	}
+	f, err := u.File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(f)
+	f.Close()
+	if string(got) != content {
+		t.Errorf("expected %q got %q", content, got)
+	}
+
+	// Cancelling removes the upload and its staged file.
+	r := httptest.NewRequest(http.MethodDelete, "/", nil)
+	r.Header.Set(uploadIDHeader, id)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected cancel to succeed got %d", w.Code)
+	}
+	syncSocket(t, sock)
+	if _, u := findUpload(sock, id); u != nil {
+		t.Error("expected upload to be cleared")
+	}
+	if _, err := u.File(); !os.IsNotExist(err) {
+		t.Errorf("expected staged file removed, got %v", err)
+	}
+}
+
+func TestUploadChunksParallel(t *testing.T) {
+	h := NewHandler()
+	h.HandleRender(func(ctx context.Context, data *RenderContext) (io.Reader, error) {
+		return strings.NewReader(`<html><body></body></html>`), nil
+	})
+	store := NewTestStore("test")
+	e := NewHttpHandler(store, h, WithUploadChunkSize(4))
+	e.UploadStagingLocation = t.TempDir()
+
+	sock := NewHttpSocket(store.s, e, true)
+	sock.AllowUploads(&UploadConfig{Name: "doc", MaxFiles: 1, MaxSize: 1024, Accept: []string{"text/plain; charset=utf-8"}})
+	serveTestSocket(t, e, withSocket(sock))
+
+	content := "hello world!"
+	id := uploadID("doc", "hello.txt", int64(len(content)))
+	post := func(offset int) int {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(content[offset:offset+4]))
+		r.Header.Set(uploadIDHeader, id)
+		r.Header.Set(uploadInputHeader, "doc")
+		r.Header.Set(uploadFilenameHeader, "hello.txt")
+		r.Header.Set(uploadSizeHeader, strconv.Itoa(len(content)))
+		r.Header.Set(uploadOffsetHeader, strconv.Itoa(offset))
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// The same chunk sent twice at once is only written once.
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() { codes <- post(0) }()
+	}
+	accepted := 0
+	for i := 0; i < 2; i++ {
+		if <-codes == http.StatusNoContent {
+			accepted++
+		}
+	}
+	if accepted != 1 {
+		t.Fatalf("expected one chunk to be accepted, got %d", accepted)
+	}
+
+	syncSocket(t, sock)
+	if n := len(sock.Uploads()["doc"]); n != 1 {
+		t.Fatalf("expected one upload, got %d", n)
+	}
+	if _, u := findUpload(sock, id); u.Offset() != 4 {
+		t.Errorf("expected 4 bytes received, got %d", u.Offset())
+	}
+}
+
+func TestUploadChunksSocketClosed(t *testing.T) {
+	store := NewTestStore("test")
+	e := NewHttpHandler(store, testRenderHandler(), WithUploadChunkSize(4))
+	e.UploadStagingLocation = t.TempDir()
+
+	sock := NewHttpSocket(store.s, e, true)
+	sock.AllowUploads(&UploadConfig{Name: "doc", MaxFiles: 1, MaxSize: 1024, Accept: []string{"text/plain; charset=utf-8"}})
+	serveTestSocket(t, e, withSocket(sock))
+
+	content := "hello world!"
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(content[:4]))
+	r.Header.Set(uploadIDHeader, uploadID("doc", "hello.txt", int64(len(content))))
+	r.Header.Set(uploadInputHeader, "doc")
+	r.Header.Set(uploadFilenameHeader, "hello.txt")
+	r.Header.Set(uploadSizeHeader, strconv.Itoa(len(content)))
+	r.Header.Set(uploadOffsetHeader, "0")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected the chunk to be accepted, got %d", w.Code)
+	}
+	syncSocket(t, sock)
+
+	// Closing the socket forgets its uploads and removes what it staged.
+	e.DeleteSocket(sock)
+	if n := len(e.chunkedUploads.uploads); n != 0 {
+		t.Errorf("expected the uploads to be forgotten, got %d", n)
+	}
+	if _, err := os.Stat(filepath.Join(e.UploadStagingLocation, string(sock.ID()))); !os.IsNotExist(err) {
+		t.Errorf("expected the staged files to be removed, got %v", err)
+	}
+}
+
+func TestUploadTempStaging(t *testing.T) {
+	e := NewHttpHandler(NewTestStore("test"), testRenderHandler())
+	a, err := e.stagingDir(NewHttpSocket(NewSession(), e, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := e.stagingDir(NewHttpSocket(NewSession(), e, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sockets share one temporary directory, which is removed on close.
+	if filepath.Dir(a) != filepath.Dir(b) {
+		t.Errorf("expected one temporary directory, got %s and %s", a, b)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(a)); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary directory to be removed, got %v", err)
+	}
+}
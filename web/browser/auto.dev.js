@@ -0,0 +1,2052 @@
+(() => {
+    class LiveElement {
+        static hook(element) {
+            if (element.getAttribute === undefined) {
+                return null;
+            }
+            return element.getAttribute('live-hook');
+        }
+    }
+    const EventMounted = 'live:mounted';
+    const EventBeforeUpdate = 'live:beforeupdate';
+    const EventUpdated = 'live:updated';
+    const EventBeforeDestroy = 'live:beforedestroy';
+    const EventDestroyed = 'live:destroyed';
+    const EventDisconnected = 'live:disconnected';
+    const EventReconnected = 'live:reconnected';
+    const ClassConnected = 'live-connected';
+    const ClassDisconnected = 'live-disconnected';
+    const ClassError = 'live-error';
+    class LiveEvent {
+        constructor(typ, data, id, key) {
+            this.typ = typ;
+            this.data = data;
+            if (id !== undefined) {
+                this.id = id;
+            } else {
+                this.id = 0;
+            }
+            if (key !== undefined && key !== '') {
+                this.key = key;
+            }
+        }
+        static GetID() {
+            return this.sequence++;
+        }
+        serialize() {
+            return JSON.stringify(this.toObject());
+        }
+        toObject() {
+            return {
+                t: this.typ,
+                i: this.id,
+                d: this.data,
+                k: this.key,
+                a: this.at,
+                v: this.preview
+            };
+        }
+        static fromMessage(data) {
+            return this.fromObject(JSON.parse(data));
+        }
+        static fromObject(e) {
+            const ev = new LiveEvent(e.t, e.d, e.i);
+            if (e.vt !== undefined) {
+                ev.transition = e.vt;
+            }
+            if (e.c === true) {
+                ev.call = true;
+            }
+            if (e.tg !== undefined) {
+                ev.target = e.tg;
+            }
+            if (e.m !== undefined) {
+                ev.mark = e.m;
+            }
+            return ev;
+        }
+    }
+    LiveEvent.sequence = 1;
+    class EventDispatch {
+        constructor() {
+        }
+        static init(hooks, dom) {
+            this.hooks = hooks;
+            this.dom = dom;
+            this.eventHandlers = {};
+        }
+        static handleEvent(ev) {
+            if (!(ev.typ in this.eventHandlers)) {
+                if (ev.call === true) {
+                    this.reply(ev, Promise.reject(`no handler for ${ ev.typ }`));
+                }
+                return;
+            }
+            const handlers = this.eventHandlers[ev.typ].filter(h => {
+                return ev.target === undefined || h.el.id === ev.target;
+            });
+            const results = handlers.map(h => {
+                return h.cb(ev.data);
+            });
+            if (ev.call === true) {
+                const result = results.find(r => r !== undefined);
+                this.reply(ev, Promise.resolve(result));
+            }
+        }
+        static reply(ev, result) {
+            result.then(r => {
+                Socket.send(new LiveEvent('reply', { r: r }, ev.id));
+            }).catch(err => {
+                Socket.send(new LiveEvent('reply', { e: `${ err }` }, ev.id));
+            });
+        }
+        static mounted(element) {
+            const event = new CustomEvent(EventMounted, {});
+            const h = this.getElementHooks(element);
+            if (h === null) {
+                return;
+            }
+            this.callHook(event, element, h.mounted);
+        }
+        static beforeUpdate(fromEl, toEl) {
+            const event = new CustomEvent(EventBeforeUpdate, {});
+            const h = this.getElementHooks(fromEl);
+            if (h !== null) {
+                this.callHook(event, fromEl, h.beforeUpdate);
+            }
+            if (this.dom !== undefined && this.dom.onBeforeElUpdated !== undefined) {
+                this.dom.onBeforeElUpdated(fromEl, toEl);
+            }
+        }
+        static updated(element) {
+            const event = new CustomEvent(EventUpdated, {});
+            const h = this.getElementHooks(element);
+            if (h === null) {
+                return;
+            }
+            this.callHook(event, element, h.updated);
+        }
+        static beforeDestroy(element) {
+            const event = new CustomEvent(EventBeforeDestroy, {});
+            const h = this.getElementHooks(element);
+            if (h === null) {
+                return;
+            }
+            this.callHook(event, element, h.beforeDestroy);
+        }
+        static destroyed(element) {
+            const event = new CustomEvent(EventDestroyed, {});
+            const h = this.getElementHooks(element);
+            if (h === null) {
+                return;
+            }
+            this.callHook(event, element, h.destroyed);
+        }
+        static disconnected() {
+            const event = new CustomEvent(EventDisconnected, {});
+            document.querySelectorAll(`[live-hook]`).forEach(element => {
+                const h = this.getElementHooks(element);
+                if (h === null) {
+                    return;
+                }
+                this.callHook(event, element, h.disconnected);
+            });
+            document.body.classList.add(ClassDisconnected);
+            document.body.classList.remove(ClassConnected);
+        }
+        static reconnected() {
+            const event = new CustomEvent(EventReconnected, {});
+            document.querySelectorAll(`[live-hook]`).forEach(element => {
+                const h = this.getElementHooks(element);
+                if (h === null) {
+                    return;
+                }
+                this.callHook(event, element, h.reconnected);
+            });
+            document.body.classList.remove(ClassDisconnected);
+            document.body.classList.add(ClassConnected);
+        }
+        static error() {
+            document.body.classList.add(ClassError);
+        }
+        static getElementHooks(element) {
+            const val = LiveElement.hook(element);
+            if (val === null) {
+                return val;
+            }
+            return this.hooks[val];
+        }
+        static callHook(event, el, f) {
+            if (f === undefined) {
+                return;
+            }
+            const pushEvent = e => {
+                if (!(e instanceof LiveEvent)) {
+                    e = new LiveEvent(e.t, e.d);
+                }
+                return Socket.sendAndAwait(e);
+            };
+            const handleEvent = (e, cb) => {
+                if (!(e in this.eventHandlers)) {
+                    this.eventHandlers[e] = [];
+                }
+                this.eventHandlers[e].push({
+                    el: el,
+                    cb: cb
+                });
+            };
+            f.bind({
+                el,
+                pushEvent,
+                handleEvent
+            })();
+            el.dispatchEvent(event);
+        }
+    }
+    class Forms {
+        static dehydrate() {
+            const forms = document.querySelectorAll('form');
+            forms.forEach(f => {
+                if (f.id === '') {
+                    console.error('form does not have an ID. DOM updates may be affected', f);
+                    return;
+                }
+                if (f.hasAttribute('live-no-state')) {
+                    return;
+                }
+                this.formState[f.id] = [];
+                new FormData(f).forEach((value, name) => {
+                    const i = {
+                        name: name,
+                        value: value,
+                        focus: f.querySelector(`[name="${ name }"]`) == document.activeElement
+                    };
+                    this.formState[f.id].push(i);
+                });
+            });
+        }
+        static hydrate() {
+            Object.keys(this.formState).map(formID => {
+                const form = document.querySelector(`#${ formID }`);
+                if (form === null) {
+                    delete this.formState[formID];
+                    return;
+                }
+                if (form.hasAttribute('live-no-state')) {
+                    return;
+                }
+                const state = this.formState[formID];
+                state.map(i => {
+                    const input = form.querySelector(`[name="${ i.name }"]`);
+                    if (input === null) {
+                        return;
+                    }
+                    switch (input.type) {
+                    case 'file':
+                        break;
+                    case 'checkbox':
+                        if (i.value === 'on') {
+                            input.checked = true;
+                        }
+                        break;
+                    default:
+                        input.value = i.value;
+                        if (i.focus === true) {
+                            input.focus();
+                        }
+                        break;
+                    }
+                });
+            });
+        }
+        static serialize(form) {
+            if (form.hasAttribute('live-serialize')) {
+                const fn = form.getAttribute('live-serialize');
+                if (fn === null) {
+                    throw new Error('live-serialize attribute is empty');
+                }
+                const f = window[fn];
+                if (typeof f !== 'function') {
+                    throw new Error('live-serialize attribute is not a function');
+                }
+                return f(form);
+            }
+            const values = {};
+            const formData = new FormData(form);
+            formData.forEach((value, key) => {
+                if (key.startsWith('_live_')) {
+                    return;
+                }
+                switch (true) {
+                case value instanceof File:
+                    const file = value;
+                    const fi = {
+                        name: file.name,
+                        type: file.type,
+                        size: file.size,
+                        lastModified: file.lastModified
+                    };
+                    if (!Reflect.has(values, this.upKey)) {
+                        values[this.upKey] = {};
+                    }
+                    if (!Reflect.has(values[this.upKey], key)) {
+                        values[this.upKey][key] = [];
+                    }
+                    values[this.upKey][key].push(fi);
+                    break;
+                default:
+                    if (!Reflect.has(values, key)) {
+                        values[key] = value;
+                        return;
+                    }
+                    if (!Array.isArray(values[key])) {
+                        values[key] = [values[key]];
+                    }
+                    values[key].push(value);
+                }
+            });
+            return values;
+        }
+        static hasFiles(form) {
+            const formData = new FormData(form);
+            let hasFiles = false;
+            formData.forEach(value => {
+                if (value instanceof File) {
+                    hasFiles = true;
+                }
+            });
+            return hasFiles;
+        }
+    }
+    Forms.upKey = 'uploads';
+    Forms.formState = {};
+    class TrustedTypes {
+        static init(name, mark) {
+            this.mark = mark !== undefined && mark !== '' ? mark : null;
+            if (this.policy !== null) {
+                return;
+            }
+            const tt = window.trustedTypes;
+            if (tt === undefined || tt === null) {
+                return;
+            }
+            this.policy = tt.createPolicy(name, {
+                createHTML: html => {
+                    if (!TrustedTypes.applying) {
+                        throw new TypeError('live: refusing html outside of a marked patch');
+                    }
+                    return html;
+                }
+            });
+        }
+        static apply(e, fn) {
+            if (this.policy === null) {
+                fn();
+                return;
+            }
+            if (this.mark === null || e.mark !== this.mark) {
+                console.error('live: refusing patches without the connection mark');
+                return;
+            }
+            this.applying = true;
+            try {
+                fn();
+            } finally {
+                this.applying = false;
+            }
+        }
+        static html(html) {
+            if (this.policy === null) {
+                return html;
+            }
+            return this.policy.createHTML(html);
+        }
+    }
+    TrustedTypes.policy = null;
+    TrustedTypes.mark = null;
+    TrustedTypes.applying = false;
+    class Patch {
+        static handle(event) {
+            TrustedTypes.apply(event, () => {
+                Forms.dehydrate();
+                const patches = Array.isArray(event.data) ? event.data : Patch.expand(event.data);
+                patches.map(Patch.applyPatch);
+                Forms.hydrate();
+            });
+        }
+        static expand(c) {
+            return c.o.map(op => {
+                const e = {
+                    Anchor: c.s[op[0]],
+                    Action: op[1],
+                    HTML: ''
+                };
+                switch (e.Action) {
+                case 4:
+                    e.Attrs = [];
+                    for (let i = 2; i + 1 < op.length; i += 2) {
+                        e.Attrs.push([
+                            c.s[op[i]],
+                            c.s[op[i + 1]]
+                        ]);
+                    }
+                    break;
+                case 5:
+                    e.Text = c.s[op[2]];
+                    break;
+                default:
+                    e.HTML = c.s[op[2]];
+                }
+                return e;
+            });
+        }
+        static applyPatch(e) {
+            const target = document.querySelector(`*[${ e.Anchor }]`);
+            if (target === null) {
+                return;
+            }
+            if (e.Action === 4) {
+                Patch.setAttrs(target, e.Attrs || []);
+                return;
+            }
+            if (e.Action === 5) {
+                Patch.setText(target, e.Text || '');
+                return;
+            }
+            const newElement = Patch.html2Node(e.HTML);
+            switch (e.Action) {
+            case 0:
+                return;
+            case 1:
+                if (e.HTML === '') {
+                    EventDispatch.beforeDestroy(target);
+                } else {
+                    EventDispatch.beforeUpdate(target, newElement);
+                }
+                target.outerHTML = TrustedTypes.html(e.HTML);
+                if (e.HTML === '') {
+                    EventDispatch.destroyed(target);
+                } else {
+                    EventDispatch.updated(target);
+                }
+                break;
+            case 2:
+                EventDispatch.beforeUpdate(target, newElement);
+                target.append(newElement);
+                EventDispatch.updated(target);
+                break;
+            case 3:
+                EventDispatch.beforeUpdate(target, newElement);
+                target.prepend(newElement);
+                EventDispatch.updated(target);
+                break;
+            }
+        }
+        static setAttrs(target, attrs) {
+            const updated = target.cloneNode(false);
+            const keep = new Set(attrs.map(a => a[0]));
+            for (const name of target.getAttributeNames()) {
+                if (!keep.has(name)) {
+                    updated.removeAttribute(name);
+                }
+            }
+            attrs.forEach(([k, v]) => updated.setAttribute(k, v));
+            EventDispatch.beforeUpdate(target, updated);
+            for (const name of target.getAttributeNames()) {
+                if (!keep.has(name)) {
+                    target.removeAttribute(name);
+                }
+            }
+            attrs.forEach(([k, v]) => target.setAttribute(k, v));
+            EventDispatch.updated(target);
+        }
+        static setText(target, text) {
+            const updated = target.cloneNode(false);
+            updated.textContent = text;
+            EventDispatch.beforeUpdate(target, updated);
+            target.textContent = text;
+            EventDispatch.updated(target);
+        }
+        static html2Node(html) {
+            const template = document.createElement('template');
+            html = html.trim();
+            template.innerHTML = TrustedTypes.html(html);
+            if (template.content.firstChild === null) {
+                return document.createTextNode(html);
+            }
+            return template.content.firstChild;
+        }
+    }
+    function GetParams(element) {
+        const output = {};
+        const urlParams = new URLSearchParams(window.location.search);
+        urlParams.forEach((value, key) => {
+            output[key] = value;
+        });
+        if (element === undefined) {
+            return output;
+        }
+        if (!element.hasAttributes()) {
+            return output;
+        }
+        const attrs = element.attributes;
+        for (let i = 0; i < attrs.length; i++) {
+            if (!attrs[i].name.startsWith('live-value-')) {
+                continue;
+            }
+            output[attrs[i].name.split('live-value-')[1]] = attrs[i].value;
+        }
+        return output;
+    }
+    function GetURLParams(path) {
+        const url = new URL(path, location.origin);
+        const urlParams = new URLSearchParams(url.search);
+        const output = {};
+        urlParams.forEach((value, key) => {
+            output[key] = value;
+        });
+        return output;
+    }
+    function ReplaceURLParams(path) {
+        window.history.replaceState(window.history.state, '', path);
+    }
+    function UpdateURLParams(path, element) {
+        window.history.pushState({}, '', path);
+        if (element === undefined) {
+            Socket.send(new LiveEvent('params', { ...GetURLParams(path) }));
+        } else {
+            const params = GetParams(element);
+            Socket.sendAndTrack(new LiveEvent('params', {
+                ...params,
+                ...GetURLParams(path)
+            }, LiveEvent.GetID()), element);
+        }
+    }
+    class Preview {
+        static handle(e) {
+            this.revert();
+            if (e.data === undefined || e.data === null) {
+                return;
+            }
+            Patch.handle(this.patchEvent(e.data.patches || [], e.mark));
+            this.revertPatches = e.data.revert || [];
+            this.revertMark = e.mark;
+        }
+        static revert() {
+            if (this.revertPatches === null) {
+                return;
+            }
+            const patches = this.revertPatches;
+            this.revertPatches = null;
+            Patch.handle(this.patchEvent(patches, this.revertMark));
+        }
+        static patchEvent(patches, mark) {
+            const e = new LiveEvent('patch', patches);
+            if (mark !== undefined) {
+                e.mark = mark;
+            }
+            return e;
+        }
+    }
+    Preview.revertPatches = null;
+    const ProtocolVersion = 1;
+    const LatestProtocolVersion = 2;
+    const V2Subprotocol = 'live.v2';
+    class Protocol {
+        static async negotiate(path) {
+            const res = await fetch(path, {
+                headers: { Accept: 'application/json' },
+                credentials: 'include'
+            });
+            if (!res.ok) {
+                throw new Error(`protocol descriptor request failed: ${ res.status }`);
+            }
+            const d = await res.json();
+            const versions = d.versions || [d.version];
+            if (versions.indexOf(ProtocolVersion) === -1) {
+                throw new Error(`protocol mismatch: server ${ d.version }, client ${ ProtocolVersion }`);
+            }
+            this.descriptor = d;
+            return d;
+        }
+        static get() {
+            return this.descriptor;
+        }
+        static basePath() {
+            return document.body.getAttribute('live-base-path') || '';
+        }
+        static url(path) {
+            const base = this.basePath();
+            if (base === '' || !path.startsWith('/') || path.startsWith('//') || path === base || path.startsWith(`${ base }/`)) {
+                return path;
+            }
+            return `${ base }${ path }`;
+        }
+        static endpoint() {
+            if (this.descriptor === null || this.descriptor.endpoint === '') {
+                return location.pathname;
+            }
+            return this.descriptor.endpoint;
+        }
+    }
+    Protocol.descriptor = null;
+    const maxRetries = 5;
+    class Uploads {
+        static chunkSize() {
+            const size = document.body.getAttribute('live-upload-chunk');
+            if (size === null || isNaN(parseInt(size))) {
+                return 0;
+            }
+            return parseInt(size);
+        }
+        static id(input, file) {
+            return `${ input }-${ file.name }-${ file.size }`;
+        }
+        static send(form) {
+            if (this.chunkSize() === 0) {
+                return this.sendForm(form);
+            }
+            const uploads = [];
+            new FormData(form).forEach((value, name) => {
+                if (value instanceof File) {
+                    uploads.push(this.sendFile(name, value));
+                }
+            });
+            return Promise.all(uploads).then(() => {
+            });
+        }
+        static cancel(id) {
+            this.cancelled[id] = true;
+            if (id in this.requests) {
+                this.requests[id].abort();
+                delete this.requests[id];
+            }
+            fetch(`${ Protocol.endpoint() }${ location.search }`, {
+                method: 'DELETE',
+                headers: { 'Live-Upload-ID': encodeURIComponent(id) },
+                credentials: 'include'
+            }).catch(err => {
+                console.error('could not cancel upload', err);
+            });
+        }
+        static sendForm(form) {
+            return new Promise(resolve => {
+                const request = new XMLHttpRequest();
+                const ids = [];
+                new FormData(form).forEach((value, name) => {
+                    if (value instanceof File) {
+                        const id = this.id(name, value);
+                        ids.push(id);
+                        this.requests[id] = request;
+                    }
+                });
+                const done = () => {
+                    ids.forEach(id => delete this.requests[id]);
+                    resolve();
+                };
+                request.open('POST', '');
+                request.addEventListener('load', done);
+                request.addEventListener('abort', done);
+                request.addEventListener('error', done);
+                request.send(new FormData(form));
+            });
+        }
+        static async sendFile(input, file) {
+            const id = this.id(input, file);
+            delete this.cancelled[id];
+            const size = this.chunkSize();
+            let offset = 0;
+            let retries = 0;
+            while (offset < file.size || offset === 0 && file.size === 0) {
+                if (this.cancelled[id] === true) {
+                    return;
+                }
+                const end = Math.min(offset + size, file.size);
+                const res = await this.sendChunk(id, input, file, offset, end);
+                if (res.offset !== null) {
+                    if (res.offset > offset) {
+                        retries = 0;
+                    }
+                    offset = res.offset;
+                }
+                if (res.ok) {
+                    if (file.size === 0) {
+                        return;
+                    }
+                    continue;
+                }
+                if (res.status !== 0 && res.status !== 409 && res.status < 500) {
+                    return;
+                }
+                retries++;
+                if (retries > maxRetries) {
+                    console.error('upload failed', file.name);
+                    return;
+                }
+                await new Promise(r => setTimeout(r, 250 * 2 ** retries));
+            }
+        }
+        static sendChunk(id, input, file, start, end) {
+            return new Promise(resolve => {
+                const request = new XMLHttpRequest();
+                this.requests[id] = request;
+                request.open('POST', `${ Protocol.endpoint() }${ location.search }`);
+                request.withCredentials = true;
+                request.setRequestHeader('Content-Type', 'application/octet-stream');
+                request.setRequestHeader('Live-Upload-ID', encodeURIComponent(id));
+                request.setRequestHeader('Live-Upload-Input', input);
+                request.setRequestHeader('Live-Upload-Filename', encodeURIComponent(file.name));
+                request.setRequestHeader('Live-Upload-Size', `${ file.size }`);
+                request.setRequestHeader('Live-Upload-Offset', `${ start }`);
+                const done = () => {
+                    delete this.requests[id];
+                    const offset = request.getResponseHeader('Live-Upload-Offset');
+                    resolve({
+                        ok: request.status >= 200 && request.status < 300,
+                        status: request.status,
+                        offset: offset === null ? null : parseInt(offset)
+                    });
+                };
+                request.addEventListener('load', done);
+                request.addEventListener('error', done);
+                request.addEventListener('abort', done);
+                request.send(file.slice(start, end));
+            });
+        }
+    }
+    Uploads.requests = {};
+    Uploads.cancelled = {};
+    function elementEvent(t, data, element) {
+        const key = element.getAttribute('live-idempotency-key');
+        const e = new LiveEvent(t, data, LiveEvent.GetID(), key === null ? undefined : key);
+        const delay = element.getAttribute('live-delay');
+        if (delay !== null && !isNaN(parseInt(delay))) {
+            e.at = Date.now() + parseInt(delay);
+        }
+        return e;
+    }
+    class LiveHandler {
+        constructor(event, attribute) {
+            this.limiter = new Limiter();
+            this.event = event;
+            this.attribute = attribute;
+        }
+        isWired(element) {
+            if (element.hasAttribute(`${ this.attribute }-wired`)) {
+                return true;
+            }
+            element.setAttribute(`${ this.attribute }-wired`, '');
+            return false;
+        }
+        attach() {
+            document.querySelectorAll(`*[${ this.attribute }]`).forEach(element => {
+                if (this.isWired(element) == true) {
+                    return;
+                }
+                element.addEventListener(this.event, e => {
+                    this.limiter.limit(element, e, this.handler(element, GetParams(element)));
+                });
+                element.addEventListener('ack', _ => {
+                    element.classList.remove(`${ this.attribute }-loading`);
+                });
+            });
+        }
+        windowAttach() {
+            document.querySelectorAll(`*[${ this.attribute }]`).forEach(element => {
+                if (this.isWired(element) === true) {
+                    return;
+                }
+                window.addEventListener(this.event, this.handler(element, GetParams(element)));
+                window.addEventListener('ack', _ => {
+                    element.classList.remove(`${ this.attribute }-loading`);
+                });
+            });
+        }
+        handler(element, params) {
+            return _ => {
+                const t = element?.getAttribute(this.attribute);
+                if (t === null) {
+                    return;
+                }
+                element.classList.add(`${ this.attribute }-loading`);
+                Socket.sendAndTrack(elementEvent(t, params, element), element);
+            };
+        }
+    }
+    class KeyHandler extends LiveHandler {
+        handler(element, params) {
+            return ev => {
+                const ke = ev;
+                const t = element?.getAttribute(this.attribute);
+                if (t === null) {
+                    return;
+                }
+                const filter = element.getAttribute('live-key');
+                if (filter !== null) {
+                    if (ke.key !== filter) {
+                        return;
+                    }
+                }
+                element.classList.add(`${ this.attribute }-loading`);
+                const keyData = {
+                    key: ke.key,
+                    altKey: ke.altKey,
+                    ctrlKey: ke.ctrlKey,
+                    shiftKey: ke.shiftKey,
+                    metaKey: ke.metaKey
+                };
+                Socket.sendAndTrack(elementEvent(t, {
+                    ...params,
+                    ...keyData
+                }, element), element);
+            };
+        }
+    }
+    class Limiter {
+        constructor() {
+            this.debounceAttr = 'live-debounce';
+            this.throttleAttr = 'live-throttle';
+        }
+        hasDebounce(element) {
+            return element.hasAttribute(this.debounceAttr);
+        }
+        hasThrottle(element) {
+            return element.hasAttribute(this.throttleAttr);
+        }
+        debounce(element, e, fn) {
+            clearTimeout(this.debounceEvent);
+            const debounce = element.getAttribute(this.debounceAttr);
+            if (!this.hasDebounce(element) || debounce === null) {
+                fn(e);
+                return;
+            }
+            if (debounce === 'blur') {
+                this.debounceEvent = fn;
+                element.addEventListener('blur', () => {
+                    this.debounceEvent();
+                });
+                return;
+            }
+            this.debounceEvent = setTimeout(() => {
+                fn(e);
+            }, parseInt(debounce));
+        }
+        throttle(element, e, fn) {
+            const throttle = element.getAttribute(this.throttleAttr);
+            if (!this.hasThrottle(element) || throttle === null) {
+                fn(e);
+                return;
+            }
+            if (this.throttleEvent) {
+                this.throttleFunc = () => {
+                    fn(e);
+                };
+            } else {
+                fn(e);
+                this.throttleEvent = setTimeout(() => {
+                    if (this.throttleFunc) {
+                        this.throttleFunc();
+                        this.throttleFunc = null;
+                        this.throttleEvent = null;
+                    }
+                }, parseInt(throttle));
+            }
+        }
+        limit(element, e, fn) {
+            this.debounce(element, e, ev => {
+                this.throttle(element, ev, fn);
+            });
+        }
+    }
+    class Click extends LiveHandler {
+        constructor() {
+            super('click', 'live-click');
+        }
+    }
+    class Contextmenu extends LiveHandler {
+        constructor() {
+            super('contextmenu', 'live-contextmenu');
+        }
+    }
+    class Mousedown extends LiveHandler {
+        constructor() {
+            super('mousedown', 'live-mousedown');
+        }
+    }
+    class Mouseup extends LiveHandler {
+        constructor() {
+            super('mouseup', 'live-mouseup');
+        }
+    }
+    class Focus extends LiveHandler {
+        constructor() {
+            super('focus', 'live-focus');
+        }
+    }
+    class Blur extends LiveHandler {
+        constructor() {
+            super('blur', 'live-blur');
+        }
+    }
+    class WindowFocus extends LiveHandler {
+        constructor() {
+            super('focus', 'live-window-focus');
+        }
+        attach() {
+            this.windowAttach();
+        }
+    }
+    class WindowBlur extends LiveHandler {
+        constructor() {
+            super('blur', 'live-window-blur');
+        }
+        attach() {
+            this.windowAttach();
+        }
+    }
+    class Keydown extends KeyHandler {
+        constructor() {
+            super('keydown', 'live-keydown');
+        }
+    }
+    class Keyup extends KeyHandler {
+        constructor() {
+            super('keyup', 'live-keyup');
+        }
+    }
+    class WindowKeydown extends KeyHandler {
+        constructor() {
+            super('keydown', 'live-window-keydown');
+        }
+        attach() {
+            this.windowAttach();
+        }
+    }
+    class WindowKeyup extends KeyHandler {
+        constructor() {
+            super('keyup', 'live-window-keyup');
+        }
+        attach() {
+            this.windowAttach();
+        }
+    }
+    class Change {
+        constructor() {
+            this.attribute = 'live-change';
+            this.limiter = new Limiter();
+        }
+        isWired(element) {
+            if (element.hasAttribute(`${ this.attribute }-wired`)) {
+                return true;
+            }
+            element.setAttribute(`${ this.attribute }-wired`, '');
+            return false;
+        }
+        attach() {
+            let forms = [];
+            document.querySelectorAll(`form[${ this.attribute }]`).forEach(element => {
+                element.addEventListener('ack', _ => {
+                    element.classList.remove(`${ this.attribute }-loading`);
+                });
+                forms.push(element);
+                element.querySelectorAll(`input,select,textarea`).forEach(childElement => {
+                    this.addEvent(element, childElement);
+                });
+            });
+            forms.forEach(element => {
+                document.querySelectorAll(`[form=${ element.getAttribute('id') }]`).forEach(childElement => {
+                    this.addEvent(element, childElement);
+                });
+            });
+        }
+        addEvent(element, childElement) {
+            if (this.isWired(childElement)) {
+                return;
+            }
+            childElement.addEventListener('input', e => {
+                this.limiter.limit(childElement, e, () => {
+                    this.handler(element);
+                });
+            });
+        }
+        handler(element) {
+            const t = element?.getAttribute(this.attribute);
+            if (t === null) {
+                return;
+            }
+            const values = Forms.serialize(element);
+            element.classList.add(`${ this.attribute }-loading`);
+            Socket.sendAndTrack(elementEvent(t, values, element), element);
+        }
+    }
+    class Submit extends LiveHandler {
+        constructor() {
+            super('submit', 'live-submit');
+        }
+        handler(element, params) {
+            return e => {
+                if (e.preventDefault)
+                    e.preventDefault();
+                const hasFiles = Forms.hasFiles(element);
+                if (hasFiles === true) {
+                    Uploads.send(element).then(() => {
+                        this.sendEvent(element, params);
+                    });
+                } else {
+                    this.sendEvent(element, params);
+                }
+                return false;
+            };
+        }
+        sendEvent(element, params) {
+            const t = element?.getAttribute(this.attribute);
+            if (t === null) {
+                return;
+            }
+            var vals = { ...params };
+            const data = Forms.serialize(element);
+            Object.keys(data).map(k => {
+                vals[k] = data[k];
+            });
+            element.classList.add(`${ this.attribute }-loading`);
+            Socket.sendAndTrack(elementEvent(t, vals, element), element);
+        }
+    }
+    class PreviewHandler extends LiveHandler {
+        constructor() {
+            super('mouseenter', 'live-preview');
+        }
+        attach() {
+            document.querySelectorAll(`*[${ this.attribute }]`).forEach(element => {
+                if (this.isWired(element) == true) {
+                    return;
+                }
+                const show = this.handler(element, GetParams(element));
+                element.addEventListener('mouseenter', show);
+                element.addEventListener('focus', show);
+                element.addEventListener('mouseleave', () => Preview.revert());
+                element.addEventListener('blur', () => Preview.revert());
+            });
+        }
+        handler(element, params) {
+            return _ => {
+                const t = element?.getAttribute(this.attribute);
+                if (t === null) {
+                    return;
+                }
+                const e = new LiveEvent(t, params, LiveEvent.GetID());
+                e.preview = true;
+                Socket.send(e);
+            };
+        }
+    }
+    class UploadCancel extends LiveHandler {
+        constructor() {
+            super('click', 'live-upload-cancel');
+        }
+        handler(element, _) {
+            return e => {
+                if (e.preventDefault)
+                    e.preventDefault();
+                const id = element.getAttribute(this.attribute);
+                if (id === null || id === '') {
+                    return;
+                }
+                Uploads.cancel(id);
+            };
+        }
+    }
+    class Hook extends LiveHandler {
+        constructor() {
+            super('', 'live-hook');
+        }
+        attach() {
+            document.querySelectorAll(`[${ this.attribute }]`).forEach(element => {
+                if (this.isWired(element) == true) {
+                    return;
+                }
+                EventDispatch.mounted(element);
+            });
+        }
+    }
+    class Patch2 extends LiveHandler {
+        constructor() {
+            super('click', 'live-patch');
+        }
+        handler(element, _) {
+            return e => {
+                if (e.preventDefault)
+                    e.preventDefault();
+                const path = element.getAttribute('href');
+                if (path === null) {
+                    return;
+                }
+                UpdateURLParams(path, element);
+                return false;
+            };
+        }
+    }
+    class Events {
+        static init() {
+            this.clicks = new Click();
+            this.contextmenu = new Contextmenu();
+            this.mousedown = new Mousedown();
+            this.mouseup = new Mouseup();
+            this.focus = new Focus();
+            this.blur = new Blur();
+            this.windowFocus = new WindowFocus();
+            this.windowBlur = new WindowBlur();
+            this.keydown = new Keydown();
+            this.keyup = new Keyup();
+            this.windowKeydown = new WindowKeydown();
+            this.windowKeyup = new WindowKeyup();
+            this.change = new Change();
+            this.submit = new Submit();
+            this.hook = new Hook();
+            this.preview = new PreviewHandler();
+            this.uploadCancel = new UploadCancel();
+            this.patch = new Patch2();
+            this.handleBrowserNav();
+        }
+        static rewire() {
+            this.clicks.attach();
+            this.contextmenu.attach();
+            this.mousedown.attach();
+            this.mouseup.attach();
+            this.focus.attach();
+            this.blur.attach();
+            this.windowFocus.attach();
+            this.windowBlur.attach();
+            this.keydown.attach();
+            this.keyup.attach();
+            this.windowKeyup.attach();
+            this.windowKeydown.attach();
+            this.change.attach();
+            this.submit.attach();
+            this.hook.attach();
+            this.preview.attach();
+            this.uploadCancel.attach();
+            this.patch.attach();
+        }
+        static handleBrowserNav() {
+            window.onpopstate = function (_) {
+                Socket.send(new LiveEvent('params', GetURLParams(document.location.search), LiveEvent.GetID()));
+            };
+        }
+    }
+    const units = [
+        [
+            'year',
+            365 * 24 * 60 * 60
+        ],
+        [
+            'month',
+            30 * 24 * 60 * 60
+        ],
+        [
+            'day',
+            24 * 60 * 60
+        ],
+        [
+            'hour',
+            60 * 60
+        ],
+        [
+            'minute',
+            60
+        ]
+    ];
+    class RelativeTime {
+        static init() {
+            if (this.timer !== null) {
+                return;
+            }
+            this.timer = setInterval(() => {
+                RelativeTime.refresh();
+            }, this.interval);
+        }
+        static refresh() {
+            document.querySelectorAll('time[live-relative]').forEach(element => {
+                const datetime = element.getAttribute('datetime');
+                if (datetime === null) {
+                    return;
+                }
+                const t = Date.parse(datetime);
+                if (isNaN(t)) {
+                    return;
+                }
+                const locale = element.getAttribute('live-relative') || document.documentElement.lang || undefined;
+                const text = RelativeTime.format(t, locale);
+                if (element.textContent !== text) {
+                    element.textContent = text;
+                }
+            });
+        }
+        static format(t, locale) {
+            const diff = Math.round((t - Date.now()) / 1000);
+            const rtf = new Intl.RelativeTimeFormat(locale, { numeric: 'auto' });
+            for (const [unit, seconds] of units) {
+                if (Math.abs(diff) >= seconds) {
+                    return rtf.format(Math.trunc(diff / seconds), unit);
+                }
+            }
+            return rtf.format(0, 'second');
+        }
+    }
+    RelativeTime.timer = null;
+    RelativeTime.interval = 15000;
+    class Loading {
+        static handle(data) {
+            if (data === undefined || typeof data.e !== 'string') {
+                return;
+            }
+            const target = typeof data.tg === 'string' ? data.tg : '';
+            const key = target === '' ? data.e : `${ target }:${ data.e }`;
+            Loading.count(Loading.events, key, data.l === true);
+            if (target !== '') {
+                Loading.count(Loading.targets, target, data.l === true);
+            }
+            Loading.refresh();
+            document.dispatchEvent(new CustomEvent('live:loading', {
+                detail: {
+                    event: data.e,
+                    target: target,
+                    loading: data.l
+                }
+            }));
+        }
+        static reset() {
+            this.events = {};
+            this.targets = {};
+            Loading.refresh();
+        }
+        static refresh() {
+            document.querySelectorAll('[live-loading],[live-loading-target]').forEach(element => {
+                Loading.mark(element, Loading.loading(element));
+            });
+        }
+        static loading(element) {
+            const target = element.getAttribute('live-loading-target');
+            const event = element.getAttribute('live-loading');
+            if (target !== null && target !== '') {
+                if (event !== null && event !== '') {
+                    return (this.events[`${ target }:${ event }`] || 0) > 0;
+                }
+                return (this.targets[target] || 0) > 0;
+            }
+            if (event !== null && event !== '') {
+                return (this.events[event] || 0) > 0;
+            }
+            return false;
+        }
+        static mark(element, loading) {
+            if (loading) {
+                element.classList.add('live-loading');
+            } else {
+                element.classList.remove('live-loading');
+            }
+            if (!element.hasAttribute('live-loading-disable')) {
+                return;
+            }
+            if (loading && !element.hasAttribute('disabled')) {
+                element.setAttribute('disabled', '');
+                element.setAttribute('live-loading-disabled', '');
+            } else if (!loading && element.hasAttribute('live-loading-disabled')) {
+                element.removeAttribute('disabled');
+                element.removeAttribute('live-loading-disabled');
+            }
+        }
+        static count(counts, key, loading) {
+            const n = (counts[key] || 0) + (loading ? 1 : -1);
+            if (n > 0) {
+                counts[key] = n;
+            } else {
+                delete counts[key];
+            }
+        }
+    }
+    Loading.events = {};
+    Loading.targets = {};
+    class WebTransportConn extends EventTarget {
+        constructor(url) {
+            super();
+            this.encoder = new TextEncoder();
+            this.seq = 0;
+            this.transport = new window.WebTransport(url);
+            this.open().catch(err => {
+                console.error('webtransport error', err);
+                this.close(1006, `${ err }`);
+            });
+        }
+        static supported() {
+            return 'WebTransport' in window && document.body.hasAttribute('live-webtransport');
+        }
+        send(data) {
+            if (this.writer === undefined) {
+                return;
+            }
+            this.writer.write(this.encoder.encode(`${ data }\n`));
+        }
+        async open() {
+            await this.transport.ready;
+            const stream = await this.transport.createBidirectionalStream();
+            this.writer = stream.writable.getWriter();
+            this.dispatchEvent(new Event('open'));
+            this.transport.closed.then(info => this.close(1000, info.reason)).catch(err => this.close(1006, `${ err }`));
+            this.readDatagrams();
+            await this.readStream(stream.readable);
+        }
+        async readStream(readable) {
+            const reader = readable.pipeThrough(new TextDecoderStream()).getReader();
+            let buffered = '';
+            while (true) {
+                const {value, done} = await reader.read();
+                if (done) {
+                    return;
+                }
+                buffered += value;
+                let idx = buffered.indexOf('\n');
+                while (idx !== -1) {
+                    this.message(buffered.slice(0, idx));
+                    buffered = buffered.slice(idx + 1);
+                    idx = buffered.indexOf('\n');
+                }
+            }
+        }
+        async readDatagrams() {
+            const decoder = new TextDecoder();
+            const reader = this.transport.datagrams.readable.getReader();
+            while (true) {
+                const {value, done} = await reader.read();
+                if (done) {
+                    return;
+                }
+                this.message(decoder.decode(value), true);
+            }
+        }
+        message(data, datagram = false) {
+            const seq = WebTransportConn.sequence(data);
+            if (seq !== null) {
+                if (datagram && seq <= this.seq) {
+                    return;
+                }
+                this.seq = Math.max(this.seq, seq);
+            }
+            this.dispatchEvent(new MessageEvent('message', { data: data }));
+        }
+        static sequence(data) {
+            const m = /^\{"q":(\d+),/.exec(data);
+            return m === null ? null : parseInt(m[1], 10);
+        }
+        close(code, reason) {
+            const ev = new Event('close');
+            ev.code = code;
+            ev.reason = reason;
+            this.dispatchEvent(ev);
+        }
+    }
+    class ViewTransition {
+        static queue(name) {
+            this.pending = name;
+        }
+        static run(name, update) {
+            if ((name === undefined || name === '') && this.pending !== null) {
+                name = this.pending;
+            }
+            this.pending = null;
+            const doc = document;
+            if (name === undefined || name === '' || typeof doc.startViewTransition !== 'function') {
+                update();
+                return;
+            }
+            const root = document.documentElement;
+            root.setAttribute('live-transition', name);
+            const transition = doc.startViewTransition(update);
+            transition.finished.finally(() => {
+                root.removeAttribute('live-transition');
+            });
+        }
+    }
+    ViewTransition.pending = null;
+    class Exec {
+        static run(commands) {
+            if (!Array.isArray(commands)) {
+                return;
+            }
+            commands.forEach(c => {
+                try {
+                    this.command(c);
+                } catch (err) {
+                    console.error('could not run command', c, err);
+                }
+            });
+        }
+        static command(c) {
+            const elements = document.querySelectorAll(c.to);
+            switch (c.op) {
+            case 'focus':
+                if (elements.length > 0) {
+                    elements[0].focus();
+                }
+                return;
+            }
+            elements.forEach(el => {
+                switch (c.op) {
+                case 'blur':
+                    el.blur();
+                    break;
+                case 'add_class':
+                    el.classList.add(c.name || '');
+                    break;
+                case 'remove_class':
+                    el.classList.remove(c.name || '');
+                    break;
+                case 'toggle_class':
+                    el.classList.toggle(c.name || '');
+                    break;
+                case 'set_attr':
+                    el.setAttribute(c.name || '', c.value || '');
+                    break;
+                case 'remove_attr':
+                    el.removeAttribute(c.name || '');
+                    break;
+                case 'show':
+                    el.removeAttribute('hidden');
+                    break;
+                case 'hide':
+                    el.setAttribute('hidden', '');
+                    break;
+                case 'dispatch':
+                    el.dispatchEvent(new CustomEvent(c.name || '', {
+                        bubbles: true,
+                        detail: c.detail
+                    }));
+                    break;
+                default:
+                    console.warn('unknown command', c.op);
+                }
+            });
+        }
+    }
+    const BinarySubprotocol = 'live.cbor';
+    const BinaryV2Subprotocol = 'live.v2.cbor';
+    class CBOR {
+        static encode(v) {
+            const out = [];
+            this.write(out, v);
+            return new Uint8Array(out);
+        }
+        static decode(data) {
+            const view = new DataView(data);
+            const [v, offset] = this.read(view, 0);
+            if (offset !== view.byteLength) {
+                throw new Error('cbor: trailing data');
+            }
+            return v;
+        }
+        static head(out, major, n) {
+            major <<= 5;
+            if (n < 24) {
+                out.push(major | n);
+            } else if (n <= 255) {
+                out.push(major | 24, n);
+            } else if (n <= 65535) {
+                out.push(major | 25, n >> 8, n & 255);
+            } else if (n <= 4294967295) {
+                out.push(major | 26, n >>> 24 & 255, n >> 16 & 255, n >> 8 & 255, n & 255);
+            } else {
+                const hi = Math.floor(n / 4294967296);
+                out.push(major | 27);
+                for (const word of [
+                        hi,
+                        n >>> 0
+                    ]) {
+                    out.push(word >>> 24 & 255, word >> 16 & 255, word >> 8 & 255, word & 255);
+                }
+            }
+        }
+        static write(out, v) {
+            if (v === null || v === undefined) {
+                out.push(246);
+            } else if (v === false) {
+                out.push(244);
+            } else if (v === true) {
+                out.push(245);
+            } else if (typeof v === 'number') {
+                if (Number.isSafeInteger(v)) {
+                    if (v >= 0) {
+                        this.head(out, 0, v);
+                    } else {
+                        this.head(out, 1, -1 - v);
+                    }
+                } else {
+                    const b = new DataView(new ArrayBuffer(8));
+                    b.setFloat64(0, v);
+                    out.push(251);
+                    for (let i = 0; i < 8; i++) {
+                        out.push(b.getUint8(i));
+                    }
+                }
+            } else if (typeof v === 'string') {
+                const b = new TextEncoder().encode(v);
+                this.head(out, 3, b.length);
+                b.forEach(byte => out.push(byte));
+            } else if (Array.isArray(v)) {
+                this.head(out, 4, v.length);
+                v.forEach(item => this.write(out, item));
+            } else if (typeof v === 'object') {
+                const keys = Object.keys(v).filter(k => v[k] !== undefined);
+                this.head(out, 5, keys.length);
+                keys.forEach(k => {
+                    this.write(out, k);
+                    this.write(out, v[k]);
+                });
+            } else {
+                throw new Error(`cbor: can't encode ${ typeof v }`);
+            }
+        }
+        static read(view, offset) {
+            const initial = view.getUint8(offset++);
+            const major = initial >> 5;
+            const info = initial & 31;
+            if (major === 7) {
+                switch (info) {
+                case 20:
+                    return [
+                        false,
+                        offset
+                    ];
+                case 21:
+                    return [
+                        true,
+                        offset
+                    ];
+                case 22:
+                case 23:
+                    return [
+                        null,
+                        offset
+                    ];
+                case 25:
+                    return [
+                        this.half(view.getUint16(offset)),
+                        offset + 2
+                    ];
+                case 26:
+                    return [
+                        view.getFloat32(offset),
+                        offset + 4
+                    ];
+                case 27:
+                    return [
+                        view.getFloat64(offset),
+                        offset + 8
+                    ];
+                }
+                throw new Error(`cbor: unsupported simple value ${ info }`);
+            }
+            let n = info;
+            if (info === 24) {
+                n = view.getUint8(offset);
+                offset += 1;
+            } else if (info === 25) {
+                n = view.getUint16(offset);
+                offset += 2;
+            } else if (info === 26) {
+                n = view.getUint32(offset);
+                offset += 4;
+            } else if (info === 27) {
+                n = view.getUint32(offset) * 4294967296 + view.getUint32(offset + 4);
+                offset += 8;
+            } else if (info > 27) {
+                throw new Error(`cbor: unsupported length ${ info }`);
+            }
+            switch (major) {
+            case 0:
+                return [
+                    n,
+                    offset
+                ];
+            case 1:
+                return [
+                    -1 - n,
+                    offset
+                ];
+            case 2:
+            case 3: {
+                    const b = new Uint8Array(view.buffer, view.byteOffset + offset, n);
+                    return [
+                        new TextDecoder().decode(b),
+                        offset + n
+                    ];
+                }
+            case 4: {
+                    const out = [];
+                    for (let i = 0; i < n; i++) {
+                        const [v, next] = this.read(view, offset);
+                        out.push(v);
+                        offset = next;
+                    }
+                    return [
+                        out,
+                        offset
+                    ];
+                }
+            case 5: {
+                    const out = {};
+                    for (let i = 0; i < n; i++) {
+                        const [k, next] = this.read(view, offset);
+                        const [v, after] = this.read(view, next);
+                        out[k] = v;
+                        offset = after;
+                    }
+                    return [
+                        out,
+                        offset
+                    ];
+                }
+            }
+            throw new Error(`cbor: unsupported type ${ major }`);
+        }
+        static half(h) {
+            const exp = h >> 10 & 31;
+            const mant = h & 1023;
+            let f;
+            if (exp === 0) {
+                f = mant * Math.pow(2, -24);
+            } else if (exp === 31) {
+                f = mant === 0 ? Infinity : NaN;
+            } else {
+                f = (mant + 1024) * Math.pow(2, exp - 25);
+            }
+            return h & 32768 ? -f : f;
+        }
+    }
+    class Socket {
+        constructor() {
+        }
+        static dial() {
+            console.debug('Socket.dial called');
+            if (WebTransportConn.supported()) {
+                this.conn = new WebTransportConn(`https://${ location.host }${ Protocol.endpoint() }${ this.query() }`);
+            } else {
+                const ws = new WebSocket(`${ location.protocol === 'https:' ? 'wss' : 'ws' }://${ location.host }${ Protocol.endpoint() }${ this.query() }${ location.hash }`, document.body.hasAttribute('live-binary') ? [
+                    BinaryV2Subprotocol,
+                    V2Subprotocol,
+                    BinarySubprotocol
+                ] : [V2Subprotocol]);
+                ws.binaryType = 'arraybuffer';
+                this.conn = ws;
+            }
+            this.conn.addEventListener('close', ev => {
+                this.ready = false;
+                this.stopHeartbeat();
+                this.requeue();
+                this.rejectReplies();
+                Loading.reset();
+                console.warn(`WebSocket Disconnected code: ${ ev.code }, reason: ${ ev.reason }`);
+                if (this.idle) {
+                    this.idle = false;
+                    this.redialOnActivity();
+                    return;
+                }
+                if (ev.code !== 1001) {
+                    if (this.disconnectNotified === false) {
+                        EventDispatch.disconnected();
+                        this.disconnectNotified = true;
+                    }
+                    setTimeout(() => {
+                        Socket.dial();
+                    }, 1000);
+                }
+            });
+            this.conn.addEventListener('open', _ => {
+                this.binary = this.conn instanceof WebSocket && (this.conn.protocol === BinarySubprotocol || this.conn.protocol === BinaryV2Subprotocol);
+                EventDispatch.reconnected();
+                this.disconnectNotified = false;
+                this.ready = true;
+            });
+            this.conn.addEventListener('message', ev => {
+                let e;
+                if (typeof ev.data === 'string') {
+                    e = LiveEvent.fromMessage(ev.data);
+                } else if (this.binary && ev.data instanceof ArrayBuffer) {
+                    e = LiveEvent.fromObject(CBOR.decode(ev.data));
+                } else {
+                    console.error('unexpected message type', typeof ev.data);
+                    return;
+                }
+                if (e.typ === 'chunk') {
+                    const whole = this.reassemble(e.data);
+                    if (whole === null) {
+                        return;
+                    }
+                    e = whole;
+                }
+                switch (e.typ) {
+                case 'connect':
+                    if (e.data !== undefined) {
+                        this.connected(e.data);
+                    }
+                    this.flush();
+                    EventDispatch.handleEvent(e);
+                    break;
+                case 'patch':
+                    ViewTransition.run(e.transition, () => {
+                        Preview.revert();
+                        Patch.handle(e);
+                        Events.rewire();
+                        RelativeTime.refresh();
+                        Loading.refresh();
+                    });
+                    break;
+                case 'preview':
+                    Preview.handle(e);
+                    Events.rewire();
+                    break;
+                case 'params':
+                    if (e.transition !== undefined) {
+                        ViewTransition.queue(e.transition);
+                    }
+                    UpdateURLParams(`${ window.location.pathname }?${ e.data }`);
+                    break;
+                case 'reload':
+                    window.location.reload();
+                    break;
+                case 'idle':
+                    this.idle = true;
+                    break;
+                case 'loading':
+                    Loading.handle(e.data);
+                    break;
+                case 'url':
+                    ReplaceURLParams(`${ window.location.pathname }?${ e.data }`);
+                    break;
+                case 'redirect':
+                    this.sessionSaved.then(() => {
+                        window.location.assign(Protocol.url(e.data));
+                    });
+                    break;
+                case 'ack':
+                    this.ack(e);
+                    break;
+                case 'session':
+                    this.saveSession(e.data);
+                    break;
+                case 'title':
+                    document.title = e.data;
+                    break;
+                case 'meta':
+                    this.setMeta(e.data.name, e.data.content);
+                    break;
+                case 'exec':
+                    Exec.run(e.data);
+                    break;
+                case 'download':
+                    this.download(e.data);
+                    break;
+                case 'err':
+                    if (e.data !== undefined && e.data.err !== undefined) {
+                        const err = e.data.err;
+                        console.error(`live error ${ err.code } (request ${ e.data.request_id }): ${ err.message }`);
+                        if (err.stack !== undefined) {
+                            console.error(err.stack.join('\n'));
+                        }
+                    }
+                    EventDispatch.error();
+                default:
+                    EventDispatch.handleEvent(e);
+                }
+            });
+        }
+        static sendAndTrack(e, element) {
+            if (this.ready === false) {
+                this.queue(e, element);
+                return;
+            }
+            this.trackedEvents[e.id] = {
+                ev: e,
+                el: element
+            };
+            this.write(e);
+        }
+        static sendAndAwait(e) {
+            if (this.ready === false) {
+                console.warn('connection not ready for send of event', e);
+                return Promise.reject(new Error('connection not ready'));
+            }
+            if (e.id === 0) {
+                e.id = LiveEvent.GetID();
+            }
+            return new Promise((resolve, reject) => {
+                this.pendingReplies[e.id] = {
+                    resolve: resolve,
+                    reject: reject
+                };
+                this.write(e);
+            });
+        }
+        static rejectReplies() {
+            const pending = this.pendingReplies;
+            this.pendingReplies = {};
+            for (const id in pending) {
+                pending[id].reject(new Error('connection closed'));
+            }
+        }
+        static connected(data) {
+            if (data.v !== undefined && (data.v < ProtocolVersion || data.v > LatestProtocolVersion)) {
+                console.error(`protocol mismatch: server ${ data.v }`);
+            }
+            if (data.enc !== undefined) {
+                this.binary = data.enc === 'cbor';
+            }
+            if (data.tt !== undefined) {
+                TrustedTypes.init(data.tt, data.tm);
+            }
+            this.batch = data.b === true;
+            this.readLimit = data.rl !== undefined ? data.rl : 0;
+            this.stopHeartbeat();
+            if (data.hb !== undefined && data.hb > 0) {
+                this.heartbeat = window.setInterval(() => {
+                    Socket.send(new LiveEvent('hb', {}));
+                }, data.hb);
+            }
+        }
+        static queue(e, el) {
+            if (this.queued.length >= this.maxQueued) {
+                console.warn('connection not ready, dropping event', e);
+                return;
+            }
+            this.queued.push({
+                ev: e,
+                el: el
+            });
+        }
+        static requeue() {
+            const unacked = [];
+            for (const id in this.trackedEvents) {
+                unacked.push(this.trackedEvents[id]);
+            }
+            this.trackedEvents = {};
+            unacked.sort((a, b) => a.ev.id - b.ev.id);
+            this.queued = unacked.concat(this.queued).slice(0, this.maxQueued);
+        }
+        static query() {
+            const params = new URLSearchParams(location.search);
+            params.set('live-page', this.page);
+            const requestID = document.body.getAttribute('live-request-id');
+            if (requestID !== null) {
+                params.set('live-request-id', requestID);
+            }
+            return `?${ params.toString() }`;
+        }
+        static flush() {
+            const queued = this.queued;
+            this.queued = [];
+            if (queued.length === 0) {
+                return;
+            }
+            for (const q of queued) {
+                if (q.el !== undefined) {
+                    this.trackedEvents[q.ev.id] = {
+                        ev: q.ev,
+                        el: q.el
+                    };
+                }
+            }
+            if (!this.batch) {
+                for (const q of queued) {
+                    this.write(q.ev);
+                }
+                return;
+            }
+            const overhead = 9;
+            let events = [];
+            let size = overhead;
+            for (const q of queued) {
+                const e = q.ev.toObject();
+                const n = this.encodedSize(e) + 1;
+                if (events.length > 0 && this.readLimit > 0 && size + n > this.readLimit) {
+                    this.sendBatch(events);
+                    events = [];
+                    size = overhead;
+                }
+                events.push(e);
+                size += n;
+            }
+            this.sendBatch(events);
+        }
+        static sendBatch(events) {
+            if (this.binary) {
+                this.conn.send(CBOR.encode(events));
+                return;
+            }
+            this.conn.send(JSON.stringify(events));
+        }
+        static encodedSize(m) {
+            if (this.binary) {
+                return CBOR.encode(m).length;
+            }
+            return new TextEncoder().encode(JSON.stringify(m)).length;
+        }
+        static reassemble(c) {
+            if (c.n === 0) {
+                this.chunks = {
+                    id: c.id,
+                    of: c.of,
+                    parts: []
+                };
+            }
+            const chunks = this.chunks;
+            if (chunks === null || chunks.id !== c.id || chunks.parts.length !== c.n) {
+                console.error('could not reassemble chunked event', c.id);
+                this.chunks = null;
+                window.location.reload();
+                return null;
+            }
+            chunks.parts.push(atob(c.d));
+            if (chunks.parts.length < chunks.of) {
+                return null;
+            }
+            this.chunks = null;
+            const joined = chunks.parts.join('');
+            const bytes = Uint8Array.from(joined, ch => ch.charCodeAt(0));
+            return LiveEvent.fromMessage(new TextDecoder().decode(bytes));
+        }
+        static redialOnActivity() {
+            const events = [
+                'pointerdown',
+                'keydown',
+                'focus',
+                'visibilitychange'
+            ];
+            const redial = () => {
+                if (document.visibilityState === 'hidden') {
+                    return;
+                }
+                for (const e of events) {
+                    window.removeEventListener(e, redial, true);
+                }
+                Socket.dial();
+            };
+            for (const e of events) {
+                window.addEventListener(e, redial, true);
+            }
+        }
+        static stopHeartbeat() {
+            if (this.heartbeat !== null) {
+                window.clearInterval(this.heartbeat);
+                this.heartbeat = null;
+            }
+        }
+        static write(e) {
+            if (this.binary) {
+                this.conn.send(CBOR.encode(e.toObject()));
+                return;
+            }
+            this.conn.send(e.serialize());
+        }
+        static send(e) {
+            if (this.ready === false) {
+                if (e.typ !== 'hb') {
+                    this.queue(e);
+                }
+                return;
+            }
+            this.write(e);
+        }
+        static saveSession(token) {
+            this.sessionSaved = fetch(`${ Protocol.endpoint() }${ location.search }`, {
+                method: 'POST',
+                headers: { 'Live-Session': token },
+                credentials: 'include'
+            }).then(() => {
+            }).catch(err => {
+                console.error('could not save session', err);
+            });
+        }
+        static setMeta(name, content) {
+            let meta = Array.from(document.head.querySelectorAll('meta[name]')).find(m => m.name === name);
+            if (meta === undefined) {
+                meta = document.createElement('meta');
+                meta.name = name;
+                document.head.appendChild(meta);
+            }
+            meta.content = content;
+        }
+        static download(data) {
+            const params = new URLSearchParams(location.search);
+            params.set('live-download', data.token);
+            const a = document.createElement('a');
+            a.href = `${ Protocol.endpoint() }?${ params.toString() }`;
+            a.download = data.name;
+            a.style.display = 'none';
+            document.body.appendChild(a);
+            a.click();
+            a.remove();
+        }
+        static ack(e) {
+            if (e.id in this.pendingReplies) {
+                this.pendingReplies[e.id].resolve(e.data);
+                delete this.pendingReplies[e.id];
+            }
+            if (!(e.id in this.trackedEvents)) {
+                return;
+            }
+            this.trackedEvents[e.id].el.dispatchEvent(new Event('ack'));
+            delete this.trackedEvents[e.id];
+        }
+    }
+    Socket.binary = false;
+    Socket.ready = false;
+    Socket.disconnectNotified = false;
+    Socket.sessionSaved = Promise.resolve();
+    Socket.heartbeat = null;
+    Socket.idle = false;
+    Socket.batch = false;
+    Socket.readLimit = 0;
+    Socket.queued = [];
+    Socket.maxQueued = 100;
+    Socket.chunks = null;
+    Socket.page = Math.random().toString(36).slice(2) + Date.now().toString(36);
+    Socket.trackedEvents = {};
+    Socket.pendingReplies = {};
+    class Live {
+        constructor(hooks, dom) {
+            this.hooks = hooks;
+            this.dom = dom;
+        }
+        init() {
+            if (document.querySelector(`[live-rendered]`) === null) {
+                return;
+            }
+            EventDispatch.init(this.hooks, this.dom);
+            const protocol = document.body.getAttribute('live-protocol');
+            if (protocol === null) {
+                Socket.dial();
+            } else {
+                Protocol.negotiate(protocol).then(() => Socket.dial()).catch(err => {
+                    console.error('live protocol negotiation failed', err);
+                    EventDispatch.error();
+                });
+            }
+            Events.init();
+            Events.rewire();
+            RelativeTime.refresh();
+            RelativeTime.init();
+        }
+        send(typ, data, id) {
+            const e = new LiveEvent(typ, data, id);
+            Socket.send(e);
+        }
+    }
+    window.LiveEvent = LiveEvent;
+    document.addEventListener('DOMContentLoaded', _ => {
+        if (window.Live !== undefined) {
+            console.error('window.Live already defined');
+        }
+        const hooks = window.Hooks || {};
+        window.Live = new Live(hooks);
+        window.Live.init();
+    });
+})();
@@ -0,0 +1,198 @@
+/**
+ * The websocket subprotocol of connections which encode
+ * events with CBOR.
+ */
+export const BinarySubprotocol = "live.cbor";
+
+/**
+ * The websocket subprotocol of connections which encode
+ * events with CBOR and speak protocol version 2.
+ */
+export const BinaryV2Subprotocol = "live.v2.cbor";
+
+/**
+ * A minimal CBOR codec for the values events are made of.
+ */
+export class CBOR {
+    /**
+     * Encode a value.
+     */
+    static encode(v: any): Uint8Array {
+        const out: number[] = [];
+        this.write(out, v);
+        return new Uint8Array(out);
+    }
+
+    /**
+     * Decode a value.
+     */
+    static decode(data: ArrayBuffer): any {
+        const view = new DataView(data);
+        const [v, offset] = this.read(view, 0);
+        if (offset !== view.byteLength) {
+            throw new Error("cbor: trailing data");
+        }
+        return v;
+    }
+
+    private static head(out: number[], major: number, n: number) {
+        major <<= 5;
+        if (n < 24) {
+            out.push(major | n);
+        } else if (n <= 0xff) {
+            out.push(major | 24, n);
+        } else if (n <= 0xffff) {
+            out.push(major | 25, n >> 8, n & 0xff);
+        } else if (n <= 0xffffffff) {
+            out.push(
+                major | 26,
+                (n >>> 24) & 0xff,
+                (n >> 16) & 0xff,
+                (n >> 8) & 0xff,
+                n & 0xff
+            );
+        } else {
+            const hi = Math.floor(n / 0x100000000);
+            out.push(major | 27);
+            for (const word of [hi, n >>> 0]) {
+                out.push(
+                    (word >>> 24) & 0xff,
+                    (word >> 16) & 0xff,
+                    (word >> 8) & 0xff,
+                    word & 0xff
+                );
+            }
+        }
+    }
+
+    private static write(out: number[], v: any) {
+        if (v === null || v === undefined) {
+            out.push(0xf6);
+        } else if (v === false) {
+            out.push(0xf4);
+        } else if (v === true) {
+            out.push(0xf5);
+        } else if (typeof v === "number") {
+            if (Number.isSafeInteger(v)) {
+                if (v >= 0) {
+                    this.head(out, 0, v);
+                } else {
+                    this.head(out, 1, -1 - v);
+                }
+            } else {
+                const b = new DataView(new ArrayBuffer(8));
+                b.setFloat64(0, v);
+                out.push(0xfb);
+                for (let i = 0; i < 8; i++) {
+                    out.push(b.getUint8(i));
+                }
+            }
+        } else if (typeof v === "string") {
+            const b = new TextEncoder().encode(v);
+            this.head(out, 3, b.length);
+            b.forEach((byte) => out.push(byte));
+        } else if (Array.isArray(v)) {
+            this.head(out, 4, v.length);
+            v.forEach((item) => this.write(out, item));
+        } else if (typeof v === "object") {
+            const keys = Object.keys(v).filter((k) => v[k] !== undefined);
+            this.head(out, 5, keys.length);
+            keys.forEach((k) => {
+                this.write(out, k);
+                this.write(out, v[k]);
+            });
+        } else {
+            throw new Error(`cbor: can't encode ${typeof v}`);
+        }
+    }
+
+    private static read(view: DataView, offset: number): [any, number] {
+        const initial = view.getUint8(offset++);
+        const major = initial >> 5;
+        const info = initial & 0x1f;
+
+        if (major === 7) {
+            switch (info) {
+                case 20:
+                    return [false, offset];
+                case 21:
+                    return [true, offset];
+                case 22:
+                case 23:
+                    return [null, offset];
+                case 25:
+                    return [this.half(view.getUint16(offset)), offset + 2];
+                case 26:
+                    return [view.getFloat32(offset), offset + 4];
+                case 27:
+                    return [view.getFloat64(offset), offset + 8];
+            }
+            throw new Error(`cbor: unsupported simple value ${info}`);
+        }
+
+        let n = info;
+        if (info === 24) {
+            n = view.getUint8(offset);
+            offset += 1;
+        } else if (info === 25) {
+            n = view.getUint16(offset);
+            offset += 2;
+        } else if (info === 26) {
+            n = view.getUint32(offset);
+            offset += 4;
+        } else if (info === 27) {
+            n =
+                view.getUint32(offset) * 0x100000000 +
+                view.getUint32(offset + 4);
+            offset += 8;
+        } else if (info > 27) {
+            throw new Error(`cbor: unsupported length ${info}`);
+        }
+
+        switch (major) {
+            case 0:
+                return [n, offset];
+            case 1:
+                return [-1 - n, offset];
+            case 2:
+            case 3: {
+                const b = new Uint8Array(view.buffer, view.byteOffset + offset, n);
+                return [new TextDecoder().decode(b), offset + n];
+            }
+            case 4: {
+                const out: any[] = [];
+                for (let i = 0; i < n; i++) {
+                    const [v, next] = this.read(view, offset);
+                    out.push(v);
+                    offset = next;
+                }
+                return [out, offset];
+            }
+            case 5: {
+                const out: { [k: string]: any } = {};
+                for (let i = 0; i < n; i++) {
+                    const [k, next] = this.read(view, offset);
+                    const [v, after] = this.read(view, next);
+                    out[k] = v;
+                    offset = after;
+                }
+                return [out, offset];
+            }
+        }
+        throw new Error(`cbor: unsupported type ${major}`);
+    }
+
+    private static half(h: number): number {
+        const exp = (h >> 10) & 0x1f;
+        const mant = h & 0x3ff;
+        let f: number;
+        if (exp === 0) {
+            f = mant * Math.pow(2, -24);
+        } else if (exp === 31) {
+            f = mant === 0 ? Infinity : NaN;
+        } else {
+            f = (mant + 1024) * Math.pow(2, exp - 25);
+        }
+        return h & 0x8000 ? -f : f;
+    }
+}
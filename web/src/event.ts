@@ -22,9 +22,16 @@ export class LiveEvent {
     public typ: string;
     public id: number;
     public data: any;
+    public key?: string;
+    public at?: number;
+    public preview?: boolean;
+    public transition?: string;
+    public call?: boolean;
+    public target?: string;
+    public mark?: string;
     private static sequence: number = 1;
 
-    constructor(typ: string, data: any, id?: number) {
+    constructor(typ: string, data: any, id?: number, key?: string) {
         this.typ = typ;
         this.data = data;
         if (id !== undefined) {
@@ -32,6 +39,9 @@ export class LiveEvent {
         } else {
             this.id = 0;
         }
+        if (key !== undefined && key !== "") {
+            this.key = key;
+        }
     }
 
     /**
@@ -45,19 +55,48 @@ export class LiveEvent {
      * Convert the event onto our wire format
      */
     public serialize(): string {
-        return JSON.stringify({
+        return JSON.stringify(this.toObject());
+    }
+
+    /**
+     * The event in its wire format, before encoding.
+     */
+    public toObject(): any {
+        return {
             t: this.typ,
             i: this.id,
             d: this.data,
-        });
+            k: this.key,
+            a: this.at,
+            v: this.preview,
+        };
     }
 
     /**
      * From an incoming message create a live event.
      */
     public static fromMessage(data: any): LiveEvent {
-        const e = JSON.parse(data);
-        return new LiveEvent(e.t, e.d, e.i);
+        return this.fromObject(JSON.parse(data));
+    }
+
+    /**
+     * From a decoded message create a live event.
+     */
+    public static fromObject(e: any): LiveEvent {
+        const ev = new LiveEvent(e.t, e.d, e.i);
+        if (e.vt !== undefined) {
+            ev.transition = e.vt;
+        }
+        if (e.c === true) {
+            ev.call = true;
+        }
+        if (e.tg !== undefined) {
+            ev.target = e.tg;
+        }
+        if (e.m !== undefined) {
+            ev.mark = e.m;
+        }
+        return ev;
     }
 }
 
@@ -69,7 +108,9 @@ export class LiveEvent {
 export class EventDispatch {
     private static hooks: Hooks;
     private static dom?: DOM;
-    private static eventHandlers: { [e: string]: ((d: any) => void)[] };
+    private static eventHandlers: {
+        [e: string]: { el: Element; cb: (d: any) => any }[];
+    };
 
     constructor() {}
 
@@ -87,11 +128,36 @@ export class EventDispatch {
      */
     static handleEvent(ev: LiveEvent) {
         if (!(ev.typ in this.eventHandlers)) {
+            if (ev.call === true) {
+                this.reply(ev, Promise.reject(`no handler for ${ev.typ}`));
+            }
             return;
         }
-        this.eventHandlers[ev.typ].map((h) => {
-            h(ev.data);
+        // Targeted events only go to the hooks of that element.
+        const handlers = this.eventHandlers[ev.typ].filter((h) => {
+            return ev.target === undefined || h.el.id === ev.target;
+        });
+        const results = handlers.map((h) => {
+            return h.cb(ev.data);
         });
+        if (ev.call === true) {
+            // The first handler to return something replies.
+            const result = results.find((r) => r !== undefined);
+            this.reply(ev, Promise.resolve(result));
+        }
+    }
+
+    /**
+     * Reply to a call from the server.
+     */
+    private static reply(ev: LiveEvent, result: Promise<any>) {
+        result
+            .then((r) => {
+                Socket.send(new LiveEvent("reply", { r: r }, ev.id));
+            })
+            .catch((err) => {
+                Socket.send(new LiveEvent("reply", { e: `${err}` }, ev.id));
+            });
     }
 
     /**
@@ -216,14 +282,19 @@ export class EventDispatch {
         if (f === undefined) {
             return;
         }
-        const pushEvent = (e: LiveEvent) => {
-            Socket.send(e);
+        const pushEvent = (
+            e: LiveEvent | { t: string; d: any }
+        ): Promise<any> => {
+            if (!(e instanceof LiveEvent)) {
+                e = new LiveEvent(e.t, e.d);
+            }
+            return Socket.sendAndAwait(e);
         };
-        const handleEvent = (e: string, cb: (d: any) => void) => {
+        const handleEvent = (e: string, cb: (d: any) => any) => {
             if (!(e in this.eventHandlers)) {
                 this.eventHandlers[e] = [];
             }
-            this.eventHandlers[e].push(cb);
+            this.eventHandlers[e].push({ el: el, cb: cb });
         };
         f.bind({ el, pushEvent, handleEvent })();
         el.dispatchEvent(event);
@@ -2,6 +2,28 @@ import { Socket } from "./socket";
 import { Forms } from "./forms";
 import { UpdateURLParams, GetParams, GetURLParams, Params } from "./params";
 import { EventDispatch, LiveEvent } from "./event";
+import { Preview } from "./preview";
+import { Uploads } from "./upload";
+
+/**
+ * Create a tracked event for an element. Picks up the idempotency
+ * key from `live-idempotency-key` and a delivery delay in milliseconds
+ * from `live-delay`.
+ */
+function elementEvent(t: string, data: any, element: Element): LiveEvent {
+    const key = element.getAttribute("live-idempotency-key");
+    const e = new LiveEvent(
+        t,
+        data,
+        LiveEvent.GetID(),
+        key === null ? undefined : key
+    );
+    const delay = element.getAttribute("live-delay");
+    if (delay !== null && !isNaN(parseInt(delay))) {
+        e.at = Date.now() + parseInt(delay);
+    }
+    return e;
+}
 
 /**
  * Standard event handler class. Clicks, focus and blur.
@@ -64,7 +86,7 @@ class LiveHandler {
             }
             element.classList.add(`${this.attribute}-loading`);
             Socket.sendAndTrack(
-                new LiveEvent(t, params, LiveEvent.GetID()),
+                elementEvent(t, params, element),
                 element
             );
         };
@@ -97,7 +119,7 @@ export class KeyHandler extends LiveHandler {
                 metaKey: ke.metaKey,
             };
             Socket.sendAndTrack(
-                new LiveEvent(t, { ...params, ...keyData }, LiveEvent.GetID()),
+                elementEvent(t, { ...params, ...keyData }, element),
                 element
             );
         };
@@ -350,7 +372,7 @@ class Change {
         const values: { [key: string]: any } = Forms.serialize(element);
         element.classList.add(`${this.attribute}-loading`);
         Socket.sendAndTrack(
-            new LiveEvent(t, values, LiveEvent.GetID()),
+            elementEvent(t, values, element),
             element
         );
     }
@@ -370,13 +392,9 @@ class Submit extends LiveHandler {
 
             const hasFiles = Forms.hasFiles(element as HTMLFormElement);
             if (hasFiles === true) {
-                const request = new XMLHttpRequest();
-                request.open("POST", "");
-                request.addEventListener('load', () => {
+                Uploads.send(element as HTMLFormElement).then(() => {
                     this.sendEvent(element, params);
                 });
-
-                request.send(new FormData(element as HTMLFormElement));
             } else {
                 this.sendEvent(element, params);
             }
@@ -400,12 +418,73 @@ class Submit extends LiveHandler {
         });
         element.classList.add(`${this.attribute}-loading`);
         Socket.sendAndTrack(
-            new LiveEvent(t, vals, LiveEvent.GetID()),
+            elementEvent(t, vals, element),
             element
         );
     }
 }
 
+/**
+ * live-preview event handler. Sends the event as a preview while
+ * the element is hovered or focused, reverting when it is left.
+ */
+class PreviewHandler extends LiveHandler {
+    constructor() {
+        super("mouseenter", "live-preview");
+    }
+
+    public attach() {
+        document
+            .querySelectorAll(`*[${this.attribute}]`)
+            .forEach((element: Element) => {
+                if (this.isWired(element) == true) {
+                    return;
+                }
+                const show = this.handler(
+                    element as HTMLElement,
+                    GetParams(element as HTMLElement)
+                );
+                element.addEventListener("mouseenter", show);
+                element.addEventListener("focus", show);
+                element.addEventListener("mouseleave", () => Preview.revert());
+                element.addEventListener("blur", () => Preview.revert());
+            });
+    }
+
+    protected handler(element: HTMLElement, params: Params): EventListener {
+        return (_: Event) => {
+            const t = element?.getAttribute(this.attribute);
+            if (t === null) {
+                return;
+            }
+            const e = new LiveEvent(t, params, LiveEvent.GetID());
+            e.preview = true;
+            Socket.send(e);
+        };
+    }
+}
+
+/**
+ * live-upload-cancel handler, cancels the upload with the ID
+ * in the attribute.
+ */
+class UploadCancel extends LiveHandler {
+    constructor() {
+        super("click", "live-upload-cancel");
+    }
+
+    protected handler(element: HTMLElement, _: Params): EventListener {
+        return (e: Event) => {
+            if (e.preventDefault) e.preventDefault();
+            const id = element.getAttribute(this.attribute);
+            if (id === null || id === "") {
+                return;
+            }
+            Uploads.cancel(id);
+        };
+    }
+}
+
 /**
  * live-hook event handler.
  */
@@ -466,6 +545,8 @@ export class Events {
     private static change: Change;
     private static submit: Submit;
     private static hook: Hook;
+    private static preview: PreviewHandler;
+    private static uploadCancel: UploadCancel;
     private static patch: Patch;
 
     /**
@@ -487,6 +568,8 @@ export class Events {
         this.change = new Change();
         this.submit = new Submit();
         this.hook = new Hook();
+        this.preview = new PreviewHandler();
+        this.uploadCancel = new UploadCancel();
         this.patch = new Patch();
 
         this.handleBrowserNav();
@@ -511,6 +594,8 @@ export class Events {
         this.change.attach();
         this.submit.attach();
         this.hook.attach();
+        this.preview.attach();
+        this.uploadCancel.attach();
         this.patch.attach();
     }
 
@@ -0,0 +1,77 @@
+/**
+ * A js command sent by the server.
+ */
+interface Command {
+    op: string;
+    to: string;
+    name?: string;
+    value?: string;
+    detail?: any;
+}
+
+/**
+ * Exec runs js commands sent by the server.
+ */
+export class Exec {
+    static run(commands: Command[]) {
+        if (!Array.isArray(commands)) {
+            return;
+        }
+        commands.forEach((c) => {
+            try {
+                this.command(c);
+            } catch (err) {
+                console.error("could not run command", c, err);
+            }
+        });
+    }
+
+    private static command(c: Command) {
+        const elements = document.querySelectorAll<HTMLElement>(c.to);
+        switch (c.op) {
+            case "focus":
+                if (elements.length > 0) {
+                    elements[0].focus();
+                }
+                return;
+        }
+        elements.forEach((el) => {
+            switch (c.op) {
+                case "blur":
+                    el.blur();
+                    break;
+                case "add_class":
+                    el.classList.add(c.name || "");
+                    break;
+                case "remove_class":
+                    el.classList.remove(c.name || "");
+                    break;
+                case "toggle_class":
+                    el.classList.toggle(c.name || "");
+                    break;
+                case "set_attr":
+                    el.setAttribute(c.name || "", c.value || "");
+                    break;
+                case "remove_attr":
+                    el.removeAttribute(c.name || "");
+                    break;
+                case "show":
+                    el.removeAttribute("hidden");
+                    break;
+                case "hide":
+                    el.setAttribute("hidden", "");
+                    break;
+                case "dispatch":
+                    el.dispatchEvent(
+                        new CustomEvent(c.name || "", {
+                            bubbles: true,
+                            detail: c.detail,
+                        })
+                    );
+                    break;
+                default:
+                    console.warn("unknown command", c.op);
+            }
+        });
+    }
+}
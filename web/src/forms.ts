@@ -122,6 +122,10 @@ export class Forms {
         const values: { [key: string]: any } = {};
         const formData = new FormData(form);
         formData.forEach((value, key) => {
+            // Fields for submitting without javascript.
+            if (key.startsWith("_live_")) {
+                return;
+            }
             switch (true) {
                 case value instanceof File:
                     const file = value as File;
@@ -2,6 +2,8 @@ import { Socket } from "./socket";
 import { Events } from "./events";
 import { EventDispatch, LiveEvent } from "./event";
 import { Hooks, DOM } from "./interop";
+import { Protocol } from "./protocol";
+import { RelativeTime } from "./relative";
 
 export class Live {
     constructor(private hooks: Hooks, private dom?: DOM) {}
@@ -14,14 +16,29 @@ export class Live {
         // Initialise the event dispatch.
         EventDispatch.init(this.hooks, this.dom);
 
-        // Dial the server.
-        Socket.dial();
+        // Dial the server, negotiating the protocol first if the
+        // server has told us where to find it.
+        const protocol = document.body.getAttribute("live-protocol");
+        if (protocol === null) {
+            Socket.dial();
+        } else {
+            Protocol.negotiate(protocol)
+                .then(() => Socket.dial())
+                .catch((err) => {
+                    console.error("live protocol negotiation failed", err);
+                    EventDispatch.error();
+                });
+        }
 
         // Initialise our live bindings.
         Events.init();
 
         // Rewire all the events.
         Events.rewire();
+
+        // Keep relative times current.
+        RelativeTime.refresh();
+        RelativeTime.init();
     }
 
     public send(typ: string, data: any, id?: number) {
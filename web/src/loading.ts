@@ -0,0 +1,104 @@
+/**
+ * Tracks the events the server is handling from its loading
+ * events, and marks the elements waiting on them.
+ *
+ * `live-loading="save"` is loading while the save event is
+ * handled, `live-loading-target="cart"` while any event for
+ * the cart component is. Loading elements get the
+ * `live-loading` class, and are disabled if they have a
+ * `live-loading-disable` attribute.
+ */
+export class Loading {
+    private static events: { [name: string]: number } = {};
+    private static targets: { [id: string]: number } = {};
+
+    /**
+     * Handle a loading event from the server.
+     */
+    static handle(data: any) {
+        if (data === undefined || typeof data.e !== "string") {
+            return;
+        }
+        const target = typeof data.tg === "string" ? data.tg : "";
+        const key = target === "" ? data.e : `${target}:${data.e}`;
+        Loading.count(Loading.events, key, data.l === true);
+        if (target !== "") {
+            Loading.count(Loading.targets, target, data.l === true);
+        }
+        Loading.refresh();
+        document.dispatchEvent(
+            new CustomEvent("live:loading", {
+                detail: { event: data.e, target: target, loading: data.l },
+            })
+        );
+    }
+
+    /**
+     * Forget everything that was loading, the connection has
+     * closed so no more stop events will come.
+     */
+    static reset() {
+        this.events = {};
+        this.targets = {};
+        Loading.refresh();
+    }
+
+    /**
+     * Mark the elements waiting on events, called after
+     * patches as the server render won't have the class.
+     */
+    static refresh() {
+        document
+            .querySelectorAll("[live-loading],[live-loading-target]")
+            .forEach((element: Element) => {
+                Loading.mark(element as HTMLElement, Loading.loading(element));
+            });
+    }
+
+    private static loading(element: Element): boolean {
+        const target = element.getAttribute("live-loading-target");
+        const event = element.getAttribute("live-loading");
+        if (target !== null && target !== "") {
+            if (event !== null && event !== "") {
+                return (this.events[`${target}:${event}`] || 0) > 0;
+            }
+            return (this.targets[target] || 0) > 0;
+        }
+        if (event !== null && event !== "") {
+            return (this.events[event] || 0) > 0;
+        }
+        return false;
+    }
+
+    private static mark(element: HTMLElement, loading: boolean) {
+        if (loading) {
+            element.classList.add("live-loading");
+        } else {
+            element.classList.remove("live-loading");
+        }
+        if (!element.hasAttribute("live-loading-disable")) {
+            return;
+        }
+        // Only re-enable elements which we disabled.
+        if (loading && !element.hasAttribute("disabled")) {
+            element.setAttribute("disabled", "");
+            element.setAttribute("live-loading-disabled", "");
+        } else if (!loading && element.hasAttribute("live-loading-disabled")) {
+            element.removeAttribute("disabled");
+            element.removeAttribute("live-loading-disabled");
+        }
+    }
+
+    private static count(
+        counts: { [key: string]: number },
+        key: string,
+        loading: boolean
+    ) {
+        const n = (counts[key] || 0) + (loading ? 1 : -1);
+        if (n > 0) {
+            counts[key] = n;
+        } else {
+            delete counts[key];
+        }
+    }
+}
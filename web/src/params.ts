@@ -53,6 +53,14 @@ export function GetURLParams(path: string): Params {
     return output;
 }
 
+/**
+ * ReplaceURLParams update the URL using the replace state api, without
+ * notifying the backend.
+ */
+export function ReplaceURLParams(path: string) {
+    window.history.replaceState(window.history.state, "", path);
+}
+
 /**
  * UpdateURLParams update the URL using the push state api, then
  * notify the backend.
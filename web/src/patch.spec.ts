@@ -62,3 +62,50 @@ test("head update", () => {
 
     expect(document.head.innerHTML).toEqual(`<title _l0="">2</title>`);
 });
+
+test("compact patches", () => {
+    document.head.innerHTML = "";
+    document.body.innerHTML = `<div _l0="">Hello</div><div _l1="">World</div>`;
+    const p = new LiveEvent("patch", {
+        s: ["_l0", `<div _l0="">Goodbye</div>`, "_l1", ""],
+        o: [
+            [0, 1, 1],
+            [2, 1, 3],
+        ],
+    });
+    Patch.handle(p);
+
+    expect(document.body.innerHTML).toEqual(`<div _l0="">Goodbye</div>`);
+});
+
+test("set attrs", () => {
+    document.body.innerHTML = `<div _l0="" class="a" hidden=""><input _l01="" value="kept"></div>`;
+    const input = document.querySelector("input") as HTMLInputElement;
+    input.value = "typed";
+    const p = new LiveEvent("patch", {
+        s: ["_l0", "class", "b", "title", "hi", ""],
+        o: [[0, 4, 1, 2, 3, 4, 0, 5]],
+    });
+    Patch.handle(p);
+
+    const div = document.querySelector("div") as HTMLDivElement;
+    expect(div.getAttribute("class")).toEqual("b");
+    expect(div.getAttribute("title")).toEqual("hi");
+    expect(div.hasAttribute("hidden")).toBe(false);
+    // The children are left alone.
+    expect(document.querySelector("input")).toBe(input);
+    expect(input.value).toEqual("typed");
+});
+
+test("set text", () => {
+    document.body.innerHTML = `<table><tbody><tr _l0=""><td _l01="">1</td></tr></tbody></table>`;
+    const p = new LiveEvent("patch", {
+        s: ["_l01", "<b>2</b>"],
+        o: [[0, 5, 1]],
+    });
+    Patch.handle(p);
+
+    // The text is set as text, not parsed as HTML.
+    expect((document.querySelector("td") as HTMLElement).textContent).toEqual("<b>2</b>");
+    expect(document.querySelector("b")).toBeNull();
+});
@@ -1,10 +1,21 @@
 import { LiveEvent, EventDispatch } from "./event";
 import { Forms } from "./forms";
+import { TrustedTypes } from "./trusted";
 
 interface PatchEvent {
     Anchor: string;
     Action: number;
     HTML: string;
+    Attrs?: [string, string][];
+    Text?: string;
+}
+
+/**
+ * Patches in the compact format of protocol version 2.
+ */
+interface CompactPatches {
+    s: string[];
+    o: number[][];
 }
 
 /**
@@ -12,12 +23,43 @@ interface PatchEvent {
  */
 export class Patch {
     static handle(event: LiveEvent) {
-        Forms.dehydrate();
+        TrustedTypes.apply(event, () => {
+            Forms.dehydrate();
 
-        const patches = event.data;
-        patches.map(Patch.applyPatch);
+            const patches: PatchEvent[] = Array.isArray(event.data)
+                ? event.data
+                : Patch.expand(event.data);
+            patches.map(Patch.applyPatch);
 
-        Forms.hydrate();
+            Forms.hydrate();
+        });
+    }
+
+    /**
+     * Expand compact patches into patch events.
+     */
+    private static expand(c: CompactPatches): PatchEvent[] {
+        return c.o.map((op) => {
+            const e: PatchEvent = {
+                Anchor: c.s[op[0]],
+                Action: op[1],
+                HTML: "",
+            };
+            switch (e.Action) {
+                case 4: // SETATTRS
+                    e.Attrs = [];
+                    for (let i = 2; i + 1 < op.length; i += 2) {
+                        e.Attrs.push([c.s[op[i]], c.s[op[i + 1]]]);
+                    }
+                    break;
+                case 5: // SETTEXT
+                    e.Text = c.s[op[2]];
+                    break;
+                default:
+                    e.HTML = c.s[op[2]];
+            }
+            return e;
+        });
     }
 
     private static applyPatch(e: PatchEvent) {
@@ -25,6 +67,14 @@ export class Patch {
         if (target === null) {
             return;
         }
+        if (e.Action === 4) {
+            Patch.setAttrs(target, e.Attrs || []);
+            return;
+        }
+        if (e.Action === 5) {
+            Patch.setText(target, e.Text || "");
+            return;
+        }
 
         const newElement = Patch.html2Node(e.HTML);
         switch (e.Action) {
@@ -36,7 +86,7 @@ export class Patch {
                 } else {
                     EventDispatch.beforeUpdate(target, newElement as Element);
                 }
-                target.outerHTML = e.HTML;
+                target.outerHTML = TrustedTypes.html(e.HTML);
                 if (e.HTML === "") {
                     EventDispatch.destroyed(target);
                 } else {
@@ -56,10 +106,46 @@ export class Patch {
         }
     }
 
+    /**
+     * Set the attributes of an element, removing any which
+     * are no longer present.
+     */
+    private static setAttrs(target: Element, attrs: [string, string][]) {
+        const updated = target.cloneNode(false) as Element;
+        const keep = new Set(attrs.map((a) => a[0]));
+        for (const name of target.getAttributeNames()) {
+            if (!keep.has(name)) {
+                updated.removeAttribute(name);
+            }
+        }
+        attrs.forEach(([k, v]) => updated.setAttribute(k, v));
+
+        EventDispatch.beforeUpdate(target, updated);
+        for (const name of target.getAttributeNames()) {
+            if (!keep.has(name)) {
+                target.removeAttribute(name);
+            }
+        }
+        attrs.forEach(([k, v]) => target.setAttribute(k, v));
+        EventDispatch.updated(target);
+    }
+
+    /**
+     * Set the text of an element whose only child is text.
+     */
+    private static setText(target: Element, text: string) {
+        const updated = target.cloneNode(false) as Element;
+        updated.textContent = text;
+
+        EventDispatch.beforeUpdate(target, updated);
+        target.textContent = text;
+        EventDispatch.updated(target);
+    }
+
     private static html2Node(html: string): Node {
         const template = document.createElement("template");
         html = html.trim();
-        template.innerHTML = html;
+        template.innerHTML = TrustedTypes.html(html);
         if (template.content.firstChild === null) {
             return document.createTextNode(html);
         }
@@ -0,0 +1,48 @@
+import { LiveEvent } from "./event";
+import { Patch } from "./patch";
+
+/**
+ * Handles previews of events. The server sends the patches
+ * to show the result of an event, and those to revert it,
+ * without committing the change.
+ */
+export class Preview {
+    private static revertPatches: any[] | null = null;
+    private static revertMark?: string;
+
+    /**
+     * Show a preview, reverting any preview already showing.
+     */
+    static handle(e: LiveEvent) {
+        this.revert();
+        if (e.data === undefined || e.data === null) {
+            return;
+        }
+        Patch.handle(this.patchEvent(e.data.patches || [], e.mark));
+        this.revertPatches = e.data.revert || [];
+        this.revertMark = e.mark;
+    }
+
+    /**
+     * Revert the preview that is showing, if there is one.
+     */
+    static revert() {
+        if (this.revertPatches === null) {
+            return;
+        }
+        const patches = this.revertPatches;
+        this.revertPatches = null;
+        Patch.handle(this.patchEvent(patches, this.revertMark));
+    }
+
+    /**
+     * A patch event carrying the mark of the preview it came from.
+     */
+    private static patchEvent(patches: any[], mark?: string): LiveEvent {
+        const e = new LiveEvent("patch", patches);
+        if (mark !== undefined) {
+            e.mark = mark;
+        }
+        return e;
+    }
+}
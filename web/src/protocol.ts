@@ -0,0 +1,112 @@
+/**
+ * The protocol version this client speaks.
+ */
+export const ProtocolVersion = 1;
+
+/**
+ * The newest protocol version this client speaks.
+ */
+export const LatestProtocolVersion = 2;
+
+/**
+ * The websocket subprotocol of connections speaking
+ * protocol version 2, which sends compact patches.
+ */
+export const V2Subprotocol = "live.v2";
+
+/**
+ * Describes how to talk to the server, served by the
+ * handler at the path given in the `live-protocol`
+ * body attribute.
+ */
+export interface ProtocolDescriptor {
+    version: number;
+    versions?: number[];
+    endpoint: string;
+    features: {
+        uploads: boolean;
+        compression: boolean;
+        binary: boolean;
+        trustedTypes?: string;
+    };
+    limits: {
+        maxUploadSize: number;
+        maxMessageBuffer: number;
+        readLimit: number;
+        heartbeat?: number;
+    };
+}
+
+/**
+ * Protocol negotiation with the server.
+ */
+export class Protocol {
+    private static descriptor: ProtocolDescriptor | null = null;
+
+    /**
+     * Fetch the protocol descriptor and check that we are
+     * able to talk to the server.
+     */
+    static async negotiate(path: string): Promise<ProtocolDescriptor> {
+        const res = await fetch(path, {
+            headers: { Accept: "application/json" },
+            credentials: "include",
+        });
+        if (!res.ok) {
+            throw new Error(`protocol descriptor request failed: ${res.status}`);
+        }
+        const d = (await res.json()) as ProtocolDescriptor;
+        const versions = d.versions || [d.version];
+        if (versions.indexOf(ProtocolVersion) === -1) {
+            throw new Error(
+                `protocol mismatch: server ${d.version}, client ${ProtocolVersion}`
+            );
+        }
+        this.descriptor = d;
+        return d;
+    }
+
+    /**
+     * The negotiated descriptor, null if there has been no
+     * negotiation.
+     */
+    static get(): ProtocolDescriptor | null {
+        return this.descriptor;
+    }
+
+    /**
+     * The path prefix the handler is mounted under, from the
+     * `live-base-path` body attribute.
+     */
+    static basePath(): string {
+        return document.body.getAttribute("live-base-path") || "";
+    }
+
+    /**
+     * Resolve an absolute path from the server against the
+     * base path.
+     */
+    static url(path: string): string {
+        const base = this.basePath();
+        if (
+            base === "" ||
+            !path.startsWith("/") ||
+            path.startsWith("//") ||
+            path === base ||
+            path.startsWith(`${base}/`)
+        ) {
+            return path;
+        }
+        return `${base}${path}`;
+    }
+
+    /**
+     * The path the websocket should be dialed on.
+     */
+    static endpoint(): string {
+        if (this.descriptor === null || this.descriptor.endpoint === "") {
+            return location.pathname;
+        }
+        return this.descriptor.endpoint;
+    }
+}
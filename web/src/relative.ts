@@ -0,0 +1,71 @@
+const units: [string, number][] = [
+    ["year", 365 * 24 * 60 * 60],
+    ["month", 30 * 24 * 60 * 60],
+    ["day", 24 * 60 * 60],
+    ["hour", 60 * 60],
+    ["minute", 60],
+];
+
+/**
+ * Keeps `time[live-relative]` elements rendered by the server
+ * up to date without asking the server to re-render.
+ */
+export class RelativeTime {
+    private static timer: any = null;
+    private static interval = 15000;
+
+    /**
+     * Start refreshing relative times.
+     */
+    static init() {
+        if (this.timer !== null) {
+            return;
+        }
+        this.timer = setInterval(() => {
+            RelativeTime.refresh();
+        }, this.interval);
+    }
+
+    /**
+     * Update the text of any relative time that has changed.
+     */
+    static refresh() {
+        document
+            .querySelectorAll("time[live-relative]")
+            .forEach((element: Element) => {
+                const datetime = element.getAttribute("datetime");
+                if (datetime === null) {
+                    return;
+                }
+                const t = Date.parse(datetime);
+                if (isNaN(t)) {
+                    return;
+                }
+                const locale =
+                    element.getAttribute("live-relative") ||
+                    document.documentElement.lang ||
+                    undefined;
+                const text = RelativeTime.format(t, locale);
+                if (element.textContent !== text) {
+                    element.textContent = text;
+                }
+            });
+    }
+
+    /**
+     * Format a time relative to now.
+     */
+    static format(t: number, locale?: string): string {
+        const diff = Math.round((t - Date.now()) / 1000);
+        // RelativeTimeFormat is newer than our target lib.
+        const rtf = new (Intl as any).RelativeTimeFormat(locale, {
+            numeric: "auto",
+        });
+        for (const [unit, seconds] of units) {
+            if (Math.abs(diff) >= seconds) {
+                return rtf.format(Math.trunc(diff / seconds), unit);
+            }
+        }
+        return rtf.format(0, "second");
+    }
+}
@@ -1,37 +1,94 @@
 import { EventDispatch, LiveEvent } from "./event";
 import { Patch } from "./patch";
 import { Events } from "./events";
-import { UpdateURLParams } from "./params";
+import { ReplaceURLParams, UpdateURLParams } from "./params";
+import {
+    LatestProtocolVersion,
+    Protocol,
+    ProtocolVersion,
+    V2Subprotocol,
+} from "./protocol";
+import { TrustedTypes } from "./trusted";
+import { RelativeTime } from "./relative";
+import { Loading } from "./loading";
+import { WebTransportConn } from "./webtransport";
+import { Preview } from "./preview";
+import { ViewTransition } from "./transition";
+import { Exec } from "./exec";
+import { BinarySubprotocol, BinaryV2Subprotocol, CBOR } from "./cbor";
 
 /**
  * Represents the websocket connection to
  * the backend server.
  */
 export class Socket {
-    private static conn: WebSocket;
+    private static conn: EventTarget & {
+        send(data: string | Uint8Array): void;
+    };
+    private static binary: boolean = false;
     private static ready: boolean = false;
     private static disconnectNotified: boolean = false;
+    private static sessionSaved: Promise<void> = Promise.resolve();
+    private static heartbeat: number | null = null;
+    private static idle: boolean = false;
+    private static batch: boolean = false;
+    private static readLimit: number = 0;
+    private static queued: { ev: LiveEvent; el?: HTMLElement }[] = [];
+    private static readonly maxQueued = 100;
+    private static chunks: { id: number; of: number; parts: string[] } | null =
+        null;
+
+    // Identifies this page load to the server, so that events sent
+    // again after a reconnect are only handled once.
+    private static readonly page: string =
+        Math.random().toString(36).slice(2) + Date.now().toString(36);
 
     private static trackedEvents: {
         [id: number]: { ev: LiveEvent; el: HTMLElement };
-    };
+    } = {};
+    private static pendingReplies: {
+        [id: number]: {
+            resolve: (reply: any) => void;
+            reject: (err: Error) => void;
+        };
+    } = {};
 
     constructor() {}
 
     static dial() {
-        this.trackedEvents = {};
-
         console.debug("Socket.dial called");
-        this.conn = new WebSocket(
-            `${location.protocol === "https:" ? "wss" : "ws"}://${
-                location.host
-            }${location.pathname}${location.search}${location.hash}`
-        );
-        this.conn.addEventListener("close", (ev) => {
+        if (WebTransportConn.supported()) {
+            this.conn = new WebTransportConn(
+                `https://${location.host}${Protocol.endpoint()}${this.query()}`
+            );
+        } else {
+            const ws = new WebSocket(
+                `${location.protocol === "https:" ? "wss" : "ws"}://${
+                    location.host
+                }${Protocol.endpoint()}${this.query()}${location.hash}`,
+                document.body.hasAttribute("live-binary")
+                    ? [BinaryV2Subprotocol, V2Subprotocol, BinarySubprotocol]
+                    : [V2Subprotocol]
+            );
+            ws.binaryType = "arraybuffer";
+            this.conn = ws;
+        }
+        this.conn.addEventListener("close", (ev: any) => {
             this.ready = false;
+            this.stopHeartbeat();
+            this.requeue();
+            this.rejectReplies();
+            Loading.reset();
             console.warn(
                 `WebSocket Disconnected code: ${ev.code}, reason: ${ev.reason}`
             );
+            if (this.idle) {
+                // The server dropped us for being idle, wait for the
+                // user to come back before reconnecting.
+                this.idle = false;
+                this.redialOnActivity();
+                return;
+            }
             if (ev.code !== 1001) {
                 if (this.disconnectNotified === false) {
                     EventDispatch.disconnected();
@@ -44,31 +101,104 @@ export class Socket {
         });
         // Ping on open.
         this.conn.addEventListener("open", (_) => {
+            this.binary =
+                this.conn instanceof WebSocket &&
+                (this.conn.protocol === BinarySubprotocol ||
+                    this.conn.protocol === BinaryV2Subprotocol);
             EventDispatch.reconnected();
             this.disconnectNotified = false;
             this.ready = true;
         });
-        this.conn.addEventListener("message", (ev) => {
-            if (typeof ev.data !== "string") {
+        this.conn.addEventListener("message", (ev: any) => {
+            let e: LiveEvent;
+            if (typeof ev.data === "string") {
+                e = LiveEvent.fromMessage(ev.data);
+            } else if (this.binary && ev.data instanceof ArrayBuffer) {
+                e = LiveEvent.fromObject(CBOR.decode(ev.data));
+            } else {
                 console.error("unexpected message type", typeof ev.data);
                 return;
             }
-            const e = LiveEvent.fromMessage(ev.data);
+            if (e.typ === "chunk") {
+                const whole = this.reassemble(e.data);
+                if (whole === null) {
+                    return;
+                }
+                e = whole;
+            }
             switch (e.typ) {
+                case "connect":
+                    if (e.data !== undefined) {
+                        this.connected(e.data);
+                    }
+                    this.flush();
+                    EventDispatch.handleEvent(e);
+                    break;
                 case "patch":
-                    Patch.handle(e);
+                    ViewTransition.run(e.transition, () => {
+                        Preview.revert();
+                        Patch.handle(e);
+                        Events.rewire();
+                        RelativeTime.refresh();
+                        Loading.refresh();
+                    });
+                    break;
+                case "preview":
+                    Preview.handle(e);
                     Events.rewire();
                     break;
                 case "params":
+                    if (e.transition !== undefined) {
+                        ViewTransition.queue(e.transition);
+                    }
                     UpdateURLParams(`${window.location.pathname}?${e.data}`);
                     break;
+                case "reload":
+                    window.location.reload();
+                    break;
+                case "idle":
+                    this.idle = true;
+                    break;
+                case "loading":
+                    Loading.handle(e.data);
+                    break;
+                case "url":
+                    ReplaceURLParams(`${window.location.pathname}?${e.data}`);
+                    break;
                 case "redirect":
-                    window.location.assign(e.data);
+                    // Wait for any session save so flashes survive the redirect.
+                    this.sessionSaved.then(() => {
+                        window.location.assign(Protocol.url(e.data));
+                    });
                     break;
                 case "ack":
                     this.ack(e);
                     break;
+                case "session":
+                    this.saveSession(e.data);
+                    break;
+                case "title":
+                    document.title = e.data;
+                    break;
+                case "meta":
+                    this.setMeta(e.data.name, e.data.content);
+                    break;
+                case "exec":
+                    Exec.run(e.data);
+                    break;
+                case "download":
+                    this.download(e.data);
+                    break;
                 case "err":
+                    if (e.data !== undefined && e.data.err !== undefined) {
+                        const err = e.data.err;
+                        console.error(
+                            `live error ${err.code} (request ${e.data.request_id}): ${err.message}`
+                        );
+                        if (err.stack !== undefined) {
+                            console.error(err.stack.join("\n"));
+                        }
+                    }
                     EventDispatch.error();
                 // Fallthrough here.
                 default:
@@ -83,29 +213,321 @@ export class Socket {
      */
     static sendAndTrack(e: LiveEvent, element: HTMLElement) {
         if (this.ready === false) {
-            console.warn("connection not ready for send of event", e);
+            this.queue(e, element);
             return;
         }
         this.trackedEvents[e.id] = {
             ev: e,
             el: element,
         };
-        this.conn.send(e.serialize());
+        this.write(e);
     }
 
-    static send(e: LiveEvent) {
+    /**
+     * Send an event and resolve with the reply the server
+     * sends with its ack.
+     */
+    static sendAndAwait(e: LiveEvent): Promise<any> {
         if (this.ready === false) {
             console.warn("connection not ready for send of event", e);
+            return Promise.reject(new Error("connection not ready"));
+        }
+        if (e.id === 0) {
+            e.id = LiveEvent.GetID();
+        }
+        return new Promise((resolve, reject) => {
+            this.pendingReplies[e.id] = { resolve: resolve, reject: reject };
+            this.write(e);
+        });
+    }
+
+    /**
+     * Reject the replies still waited on when the connection
+     * closes, the server won't send them on a new one.
+     */
+    private static rejectReplies() {
+        const pending = this.pendingReplies;
+        this.pendingReplies = {};
+        for (const id in pending) {
+            pending[id].reject(new Error("connection closed"));
+        }
+    }
+
+    /**
+     * Apply what the server negotiated for the connection.
+     */
+    private static connected(data: any) {
+        if (
+            data.v !== undefined &&
+            (data.v < ProtocolVersion || data.v > LatestProtocolVersion)
+        ) {
+            console.error(`protocol mismatch: server ${data.v}`);
+        }
+        if (data.enc !== undefined) {
+            this.binary = data.enc === "cbor";
+        }
+        if (data.tt !== undefined) {
+            TrustedTypes.init(data.tt, data.tm);
+        }
+        this.batch = data.b === true;
+        this.readLimit = data.rl !== undefined ? data.rl : 0;
+        this.stopHeartbeat();
+        if (data.hb !== undefined && data.hb > 0) {
+            this.heartbeat = window.setInterval(() => {
+                Socket.send(new LiveEvent("hb", {}));
+            }, data.hb);
+        }
+    }
+
+    /**
+     * Hold an event sent while disconnected, to be sent
+     * once the connection is made.
+     */
+    private static queue(e: LiveEvent, el?: HTMLElement) {
+        if (this.queued.length >= this.maxQueued) {
+            console.warn("connection not ready, dropping event", e);
+            return;
+        }
+        this.queued.push({ ev: e, el: el });
+    }
+
+    /**
+     * Queue the events which were sent but not acknowledged
+     * before the connection closed, to be sent again ahead of
+     * any queued since. The server drops those it had handled.
+     */
+    private static requeue() {
+        const unacked: { ev: LiveEvent; el?: HTMLElement }[] = [];
+        for (const id in this.trackedEvents) {
+            unacked.push(this.trackedEvents[id]);
+        }
+        this.trackedEvents = {};
+        unacked.sort((a, b) => a.ev.id - b.ev.id);
+        this.queued = unacked.concat(this.queued).slice(0, this.maxQueued);
+    }
+
+    /**
+     * The query to connect with, identifying this page load and
+     * the request it was rendered by.
+     */
+    private static query(): string {
+        const params = new URLSearchParams(location.search);
+        params.set("live-page", this.page);
+        const requestID = document.body.getAttribute("live-request-id");
+        if (requestID !== null) {
+            params.set("live-request-id", requestID);
+        }
+        return `?${params.toString()}`;
+    }
+
+    /**
+     * Send the events queued while disconnected, in as few
+     * messages as the server's read limit allows if it accepts
+     * batches.
+     */
+    private static flush() {
+        const queued = this.queued;
+        this.queued = [];
+        if (queued.length === 0) {
+            return;
+        }
+        for (const q of queued) {
+            if (q.el !== undefined) {
+                this.trackedEvents[q.ev.id] = { ev: q.ev, el: q.el };
+            }
+        }
+        if (!this.batch) {
+            for (const q of queued) {
+                this.write(q.ev);
+            }
+            return;
+        }
+        // Room for the array around the events, and a separator each.
+        const overhead = 9;
+        let events: any[] = [];
+        let size = overhead;
+        for (const q of queued) {
+            const e = q.ev.toObject();
+            const n = this.encodedSize(e) + 1;
+            if (
+                events.length > 0 &&
+                this.readLimit > 0 &&
+                size + n > this.readLimit
+            ) {
+                this.sendBatch(events);
+                events = [];
+                size = overhead;
+            }
+            events.push(e);
+            size += n;
+        }
+        this.sendBatch(events);
+    }
+
+    /**
+     * Send events in a single batch message.
+     */
+    private static sendBatch(events: any[]) {
+        if (this.binary) {
+            this.conn.send(CBOR.encode(events));
+            return;
+        }
+        this.conn.send(JSON.stringify(events));
+    }
+
+    /**
+     * The size in bytes of a message in the connection's encoding.
+     */
+    private static encodedSize(m: any): number {
+        if (this.binary) {
+            return CBOR.encode(m).length;
+        }
+        return new TextEncoder().encode(JSON.stringify(m)).length;
+    }
+
+    /**
+     * Collect the chunks of an event too large to send in one
+     * message, returning the event once every chunk has arrived.
+     * The page is reloaded if a chunk goes missing.
+     */
+    private static reassemble(c: {
+        id: number;
+        n: number;
+        of: number;
+        d: string;
+    }): LiveEvent | null {
+        if (c.n === 0) {
+            this.chunks = { id: c.id, of: c.of, parts: [] };
+        }
+        const chunks = this.chunks;
+        if (
+            chunks === null ||
+            chunks.id !== c.id ||
+            chunks.parts.length !== c.n
+        ) {
+            console.error("could not reassemble chunked event", c.id);
+            this.chunks = null;
+            window.location.reload();
+            return null;
+        }
+        chunks.parts.push(atob(c.d));
+        if (chunks.parts.length < chunks.of) {
+            return null;
+        }
+        this.chunks = null;
+        const joined = chunks.parts.join("");
+        const bytes = Uint8Array.from(joined, (ch) => ch.charCodeAt(0));
+        return LiveEvent.fromMessage(new TextDecoder().decode(bytes));
+    }
+
+    /**
+     * Reconnect the next time the user interacts with,
+     * or returns to, the page.
+     */
+    private static redialOnActivity() {
+        const events = [
+            "pointerdown",
+            "keydown",
+            "focus",
+            "visibilitychange",
+        ];
+        const redial = () => {
+            if (document.visibilityState === "hidden") {
+                return;
+            }
+            for (const e of events) {
+                window.removeEventListener(e, redial, true);
+            }
+            Socket.dial();
+        };
+        for (const e of events) {
+            window.addEventListener(e, redial, true);
+        }
+    }
+
+    private static stopHeartbeat() {
+        if (this.heartbeat !== null) {
+            window.clearInterval(this.heartbeat);
+            this.heartbeat = null;
+        }
+    }
+
+    /**
+     * Encode an event onto the connection.
+     */
+    private static write(e: LiveEvent) {
+        if (this.binary) {
+            this.conn.send(CBOR.encode(e.toObject()));
             return;
         }
         this.conn.send(e.serialize());
     }
 
+    static send(e: LiveEvent) {
+        if (this.ready === false) {
+            if (e.typ !== "hb") {
+                this.queue(e);
+            }
+            return;
+        }
+        this.write(e);
+    }
+
+    /**
+     * Make a request so that the server can save the
+     * session for this socket.
+     */
+    static saveSession(token: string) {
+        this.sessionSaved = fetch(`${Protocol.endpoint()}${location.search}`, {
+            method: "POST",
+            headers: { "Live-Session": token },
+            credentials: "include",
+        })
+            .then(() => {})
+            .catch((err) => {
+                console.error("could not save session", err);
+            });
+    }
+
+    /**
+     * Set the content of a named meta tag, adding it if needed.
+     */
+    static setMeta(name: string, content: string) {
+        let meta = Array.from(
+            document.head.querySelectorAll<HTMLMetaElement>("meta[name]")
+        ).find((m) => m.name === name);
+        if (meta === undefined) {
+            meta = document.createElement("meta");
+            meta.name = name;
+            document.head.appendChild(meta);
+        }
+        meta.content = content;
+    }
+
+    /**
+     * Download a file the server has sent.
+     */
+    static download(data: { token: string; name: string }) {
+        const params = new URLSearchParams(location.search);
+        params.set("live-download", data.token);
+        const a = document.createElement("a");
+        a.href = `${Protocol.endpoint()}?${params.toString()}`;
+        a.download = data.name;
+        a.style.display = "none";
+        document.body.appendChild(a);
+        a.click();
+        a.remove();
+    }
+
     /**
      * Called when a ack event comes in. Complete the loop
      * with any outstanding tracked events.
      */
     static ack(e: LiveEvent) {
+        if (e.id in this.pendingReplies) {
+            this.pendingReplies[e.id].resolve(e.data);
+            delete this.pendingReplies[e.id];
+        }
         if (!(e.id in this.trackedEvents)) {
             return;
         }
@@ -0,0 +1,42 @@
+/**
+ * ViewTransition applies changes from the server in
+ * a view transition when the server asks for one.
+ */
+export class ViewTransition {
+    private static pending: string | null = null;
+
+    /**
+     * Apply the transition to the next patch, used when
+     * the server patches the URL with a transition.
+     */
+    static queue(name: string) {
+        this.pending = name;
+    }
+
+    /**
+     * Run an update in the named transition. The queued
+     * transition is used if there isn't a name.
+     */
+    static run(name: string | undefined, update: () => void) {
+        if ((name === undefined || name === "") && this.pending !== null) {
+            name = this.pending;
+        }
+        this.pending = null;
+
+        const doc = document as any;
+        if (
+            name === undefined ||
+            name === "" ||
+            typeof doc.startViewTransition !== "function"
+        ) {
+            update();
+            return;
+        }
+        const root = document.documentElement;
+        root.setAttribute("live-transition", name);
+        const transition = doc.startViewTransition(update);
+        transition.finished.finally(() => {
+            root.removeAttribute("live-transition");
+        });
+    }
+}
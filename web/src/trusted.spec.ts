@@ -0,0 +1,56 @@
+import { Patch } from "./patch";
+import { Preview } from "./preview";
+import { LiveEvent } from "./event";
+import { TrustedTypes } from "./trusted";
+
+// jsdom has no Trusted Types, stand in a factory which runs the policy.
+if ((window as any).trustedTypes === undefined) {
+    (window as any).trustedTypes = {
+        createPolicy: (_: string, rules: any) => rules,
+    };
+}
+TrustedTypes.init("live-test", "mark");
+
+function replace(html: string, mark?: string): LiveEvent {
+    const e = new LiveEvent("patch", [{ Anchor: "_l0", Action: 1, HTML: html }]);
+    if (mark !== undefined) {
+        e.mark = mark;
+    }
+    return e;
+}
+
+test("refuses unmarked patches", () => {
+    document.body.innerHTML = `<div _l0="">Hello</div>`;
+    Patch.handle(replace(`<div _l0="">Unmarked</div>`));
+    Patch.handle(replace(`<div _l0="">Wrong</div>`, "other"));
+    expect(document.body.innerHTML).toEqual(`<div _l0="">Hello</div>`);
+
+    Patch.handle(replace(`<div _l0="">World</div>`, "mark"));
+    expect(document.body.innerHTML).toEqual(`<div _l0="">World</div>`);
+});
+
+test("refuses html outside of a patch", () => {
+    expect(() => TrustedTypes.html("<p>hi</p>")).toThrow();
+});
+
+test("marks previews", () => {
+    document.body.innerHTML = `<div _l0="">Hello</div>`;
+    const e = new LiveEvent("preview", {
+        patches: [{ Anchor: "_l0", Action: 1, HTML: `<div _l0="">Preview</div>` }],
+        revert: [{ Anchor: "_l0", Action: 1, HTML: `<div _l0="">Hello</div>` }],
+    });
+    e.mark = "mark";
+    Preview.handle(e);
+    expect(document.body.innerHTML).toEqual(`<div _l0="">Preview</div>`);
+    Preview.revert();
+    expect(document.body.innerHTML).toEqual(`<div _l0="">Hello</div>`);
+});
+
+test("new connections change the mark", () => {
+    TrustedTypes.init("live-test", "next");
+    document.body.innerHTML = `<div _l0="">Hello</div>`;
+    Patch.handle(replace(`<div _l0="">Old</div>`, "mark"));
+    expect(document.body.innerHTML).toEqual(`<div _l0="">Hello</div>`);
+    Patch.handle(replace(`<div _l0="">New</div>`, "next"));
+    expect(document.body.innerHTML).toEqual(`<div _l0="">New</div>`);
+});
@@ -0,0 +1,72 @@
+import { LiveEvent } from "./event";
+
+/**
+ * Applies patch HTML through a Trusted Types policy when the
+ * server has asked for one at connect. The server marks the
+ * patches it sends, the policy only creates HTML while a
+ * patch with the connection's mark is being applied.
+ */
+export class TrustedTypes {
+    private static policy: any = null;
+    private static mark: string | null = null;
+    private static applying: boolean = false;
+
+    /**
+     * Create the named policy, this only happens once as
+     * policy names are usually not allowed to be reused. The
+     * mark changes with every connection.
+     */
+    static init(name: string, mark?: string) {
+        this.mark = mark !== undefined && mark !== "" ? mark : null;
+        if (this.policy !== null) {
+            return;
+        }
+        const tt = (window as any).trustedTypes;
+        if (tt === undefined || tt === null) {
+            return;
+        }
+        this.policy = tt.createPolicy(name, {
+            createHTML: (html: string) => {
+                if (!TrustedTypes.applying) {
+                    throw new TypeError(
+                        "live: refusing html outside of a marked patch"
+                    );
+                }
+                return html;
+            },
+        });
+    }
+
+    /**
+     * Run fn to apply the patches in an event, refusing the
+     * event if there is a policy and it doesn't carry the
+     * connection's mark.
+     */
+    static apply(e: LiveEvent, fn: () => void) {
+        if (this.policy === null) {
+            fn();
+            return;
+        }
+        if (this.mark === null || e.mark !== this.mark) {
+            console.error("live: refusing patches without the connection mark");
+            return;
+        }
+        this.applying = true;
+        try {
+            fn();
+        } finally {
+            this.applying = false;
+        }
+    }
+
+    /**
+     * Convert patch HTML so that it can be given to a DOM sink,
+     * through the policy if the server asked for one.
+     */
+    static html(html: string): string {
+        if (this.policy === null) {
+            return html;
+        }
+        return this.policy.createHTML(html);
+    }
+}
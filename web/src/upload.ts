@@ -0,0 +1,171 @@
+import { Protocol } from "./protocol";
+
+/**
+ * The number of times a chunk is retried before the upload fails.
+ */
+const maxRetries = 5;
+
+/**
+ * Uploads sends files to the server, in resumable chunks when the
+ * server asks for them, and lets them be cancelled.
+ */
+export class Uploads {
+    private static requests: { [id: string]: XMLHttpRequest } = {};
+    private static cancelled: { [id: string]: boolean } = {};
+
+    /**
+     * The chunk size the server wants, 0 if uploads aren't chunked.
+     */
+    static chunkSize(): number {
+        const size = document.body.getAttribute("live-upload-chunk");
+        if (size === null || isNaN(parseInt(size))) {
+            return 0;
+        }
+        return parseInt(size);
+    }
+
+    /**
+     * The ID of an upload, matching the ID the server gives it.
+     */
+    static id(input: string, file: File): string {
+        return `${input}-${file.name}-${file.size}`;
+    }
+
+    /**
+     * Upload the files in a form.
+     */
+    static send(form: HTMLFormElement): Promise<void> {
+        if (this.chunkSize() === 0) {
+            return this.sendForm(form);
+        }
+        const uploads: Promise<void>[] = [];
+        new FormData(form).forEach((value, name) => {
+            if (value instanceof File) {
+                uploads.push(this.sendFile(name, value));
+            }
+        });
+        return Promise.all(uploads).then(() => {});
+    }
+
+    /**
+     * Cancel an upload by its ID.
+     */
+    static cancel(id: string) {
+        this.cancelled[id] = true;
+        if (id in this.requests) {
+            this.requests[id].abort();
+            delete this.requests[id];
+        }
+        fetch(`${Protocol.endpoint()}${location.search}`, {
+            method: "DELETE",
+            headers: { "Live-Upload-ID": encodeURIComponent(id) },
+            credentials: "include",
+        }).catch((err) => {
+            console.error("could not cancel upload", err);
+        });
+    }
+
+    /**
+     * Upload a form in a single request.
+     */
+    private static sendForm(form: HTMLFormElement): Promise<void> {
+        return new Promise((resolve) => {
+            const request = new XMLHttpRequest();
+            const ids: string[] = [];
+            new FormData(form).forEach((value, name) => {
+                if (value instanceof File) {
+                    const id = this.id(name, value);
+                    ids.push(id);
+                    this.requests[id] = request;
+                }
+            });
+            const done = () => {
+                ids.forEach((id) => delete this.requests[id]);
+                resolve();
+            };
+            request.open("POST", "");
+            request.addEventListener("load", done);
+            request.addEventListener("abort", done);
+            request.addEventListener("error", done);
+            request.send(new FormData(form));
+        });
+    }
+
+    /**
+     * Upload a file in chunks, resuming from the offset the
+     * server has when a chunk fails.
+     */
+    private static async sendFile(input: string, file: File) {
+        const id = this.id(input, file);
+        delete this.cancelled[id];
+        const size = this.chunkSize();
+        let offset = 0;
+        let retries = 0;
+        while (offset < file.size || (offset === 0 && file.size === 0)) {
+            if (this.cancelled[id] === true) {
+                return;
+            }
+            const end = Math.min(offset + size, file.size);
+            const res = await this.sendChunk(id, input, file, offset, end);
+            if (res.offset !== null) {
+                if (res.offset > offset) {
+                    retries = 0;
+                }
+                offset = res.offset;
+            }
+            if (res.ok) {
+                if (file.size === 0) {
+                    return;
+                }
+                continue;
+            }
+            if (res.status !== 0 && res.status !== 409 && res.status < 500) {
+                // The server won't accept the file.
+                return;
+            }
+            retries++;
+            if (retries > maxRetries) {
+                console.error("upload failed", file.name);
+                return;
+            }
+            await new Promise((r) => setTimeout(r, 250 * 2 ** retries));
+        }
+    }
+
+    private static sendChunk(
+        id: string,
+        input: string,
+        file: File,
+        start: number,
+        end: number
+    ): Promise<{ ok: boolean; status: number; offset: number | null }> {
+        return new Promise((resolve) => {
+            const request = new XMLHttpRequest();
+            this.requests[id] = request;
+            request.open("POST", `${Protocol.endpoint()}${location.search}`);
+            request.withCredentials = true;
+            request.setRequestHeader("Content-Type", "application/octet-stream");
+            request.setRequestHeader("Live-Upload-ID", encodeURIComponent(id));
+            request.setRequestHeader("Live-Upload-Input", input);
+            request.setRequestHeader(
+                "Live-Upload-Filename",
+                encodeURIComponent(file.name)
+            );
+            request.setRequestHeader("Live-Upload-Size", `${file.size}`);
+            request.setRequestHeader("Live-Upload-Offset", `${start}`);
+            const done = () => {
+                delete this.requests[id];
+                const offset = request.getResponseHeader("Live-Upload-Offset");
+                resolve({
+                    ok: request.status >= 200 && request.status < 300,
+                    status: request.status,
+                    offset: offset === null ? null : parseInt(offset),
+                });
+            };
+            request.addEventListener("load", done);
+            request.addEventListener("error", done);
+            request.addEventListener("abort", done);
+            request.send(file.slice(start, end));
+        });
+    }
+}
@@ -0,0 +1,110 @@
+/**
+ * Experimental WebTransport connection which behaves like the
+ * parts of a WebSocket that the live socket uses. Events are newline
+ * delimited JSON on a single bidirectional stream, the server may also
+ * send patches as datagrams.
+ */
+export class WebTransportConn extends EventTarget {
+    private transport: any;
+    private writer: any;
+    private encoder = new TextEncoder();
+    // The number of the newest patch received, datagrams older
+    // than it are stale.
+    private seq = 0;
+
+    constructor(url: string) {
+        super();
+        this.transport = new (window as any).WebTransport(url);
+        this.open().catch((err) => {
+            console.error("webtransport error", err);
+            this.close(1006, `${err}`);
+        });
+    }
+
+    /**
+     * Is WebTransport available, and has the server advertised it.
+     */
+    static supported(): boolean {
+        return (
+            "WebTransport" in window &&
+            document.body.hasAttribute("live-webtransport")
+        );
+    }
+
+    send(data: string) {
+        if (this.writer === undefined) {
+            return;
+        }
+        this.writer.write(this.encoder.encode(`${data}\n`));
+    }
+
+    private async open() {
+        await this.transport.ready;
+        const stream = await this.transport.createBidirectionalStream();
+        this.writer = stream.writable.getWriter();
+        this.dispatchEvent(new Event("open"));
+
+        this.transport.closed
+            .then((info: any) => this.close(1000, info.reason))
+            .catch((err: any) => this.close(1006, `${err}`));
+
+        this.readDatagrams();
+        await this.readStream(stream.readable);
+    }
+
+    private async readStream(readable: any) {
+        const reader = readable.pipeThrough(new TextDecoderStream()).getReader();
+        let buffered = "";
+        while (true) {
+            const { value, done } = await reader.read();
+            if (done) {
+                return;
+            }
+            buffered += value;
+            let idx = buffered.indexOf("\n");
+            while (idx !== -1) {
+                this.message(buffered.slice(0, idx));
+                buffered = buffered.slice(idx + 1);
+                idx = buffered.indexOf("\n");
+            }
+        }
+    }
+
+    private async readDatagrams() {
+        const decoder = new TextDecoder();
+        const reader = this.transport.datagrams.readable.getReader();
+        while (true) {
+            const { value, done } = await reader.read();
+            if (done) {
+                return;
+            }
+            this.message(decoder.decode(value), true);
+        }
+    }
+
+    private message(data: string, datagram = false) {
+        const seq = WebTransportConn.sequence(data);
+        if (seq !== null) {
+            if (datagram && seq <= this.seq) {
+                return;
+            }
+            this.seq = Math.max(this.seq, seq);
+        }
+        this.dispatchEvent(new MessageEvent("message", { data: data }));
+    }
+
+    /**
+     * The number the server gave a patch, null if it has none.
+     */
+    private static sequence(data: string): number | null {
+        const m = /^\{"q":(\d+),/.exec(data);
+        return m === null ? null : parseInt(m[1], 10);
+    }
+
+    private close(code: number, reason: string) {
+        const ev: any = new Event("close");
+        ev.code = code;
+        ev.reason = reason;
+        this.dispatchEvent(ev);
+    }
+}
@@ -0,0 +1,224 @@
+//go:build webtransport
+
+package live
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/quic-go/webtransport-go"
+)
+
+// maxDatagramSize the largest patch which will be sent as a datagram.
+const maxDatagramSize = 1200
+
+var _ Engine = &WebTransportEngine{}
+var _ Socket = &WebTransportSocket{}
+
+// WebTransportEngine serves live over WebTransport. This is experimental and
+// only built with the `webtransport` build tag. Pages, uploads and session saves
+// are served by the net/http engine, WebTransport sessions carry the events.
+//
+// Clients fall back to websockets when their browser doesn't support
+// WebTransport, so also serve the handler over HTTP/1.1 or HTTP/2.
+type WebTransportEngine struct {
+	// DatagramPatches send patches which only set attributes, and fit in a
+	// datagram, unreliably. A lost patch leaves the attributes stale until
+	// they next change, so use this for frequently refreshed values such as
+	// progress bars. Patches are numbered so the client drops those which
+	// arrive after a newer one. Other patches always go on the stream, as
+	// losing them would leave the page out of step with the server.
+	DatagramPatches bool
+
+	server *webtransport.Server
+	*HttpEngine
+}
+
+// NewWebTransportHandler returns the WebTransport handler for live. It should
+// be set as the handler of the servers HTTP/3 server.
+func NewWebTransportHandler(server *webtransport.Server, store HttpSessionStore, handler Handler, configs ...EngineConfig) *WebTransportEngine {
+	e := &WebTransportEngine{
+		server:     server,
+		HttpEngine: NewHttpHandler(store, handler, configs...),
+	}
+	e.HttpEngine.webTransport = true
+	return e
+}
+
+// ServeHTTP serves this handler.
+func (h *WebTransportEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		h.HttpEngine.ServeHTTP(w, r)
+		return
+	}
+
+	session, err := h.sessionStore.Get(r)
+	if err != nil {
+		h.Error()(httpContext(w, r), err)
+		return
+	}
+
+	sess, err := h.server.Upgrade(w, r)
+	if err != nil {
+		slog.Warn("webtransport upgrade failed", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer sess.CloseWithError(0, "")
+
+	ctx := contextWithRequest(sess.Context(), r)
+
+	// The client opens a single stream for events.
+	stream, err := sess.AcceptStream(ctx)
+	if err != nil {
+		slog.DebugContext(ctx, fmt.Sprintf("webtransport stream error: %s", err))
+		return
+	}
+	conn := &webTransportConn{
+		sess:   sess,
+		stream: stream,
+		reader: bufio.NewReader(stream),
+	}
+	if h.DatagramPatches {
+		conn.datagrams = sess
+	}
+	mark := h.trustedMark()
+	ctx = contextWithTrustedMark(ctx, mark)
+	writeTimeout(ctx, time.Second*5, conn, h.connectEvent(conn, false, mark))
+
+	if err := h._serveWT(ctx, r, session, conn); err != nil && !errors.Is(err, context.Canceled) {
+		slog.DebugContext(ctx, fmt.Sprintf("webtransport closed: %s", err))
+	}
+}
+
+// _serveWT implement the logic for a WebTransport session.
+func (h *WebTransportEngine) _serveWT(ctx context.Context, r *http.Request, session Session, c *webTransportConn) (err error) {
+	// Get the sessions socket and register it with the server.
+	sock := NewWebTransportSocket(session, h, true)
+	sock.SetLocale(h.negotiateLocale(r, session))
+	sock.assignWT(c.sess)
+	h.AddSocket(sock)
+	defer func() {
+		h.CloseSocket(ctx, sock, webTransportCloseReason(err))
+	}()
+
+	return h.serveSocket(ctx, h, sock, c, r)
+}
+
+// WebTransportSocket a socket served over WebTransport.
+type WebTransportSocket struct {
+	*BaseSocket
+}
+
+// NewWebTransportSocket creates a new WebTransport socket.
+func NewWebTransportSocket(s Session, e Engine, connected bool) *WebTransportSocket {
+	return &WebTransportSocket{
+		BaseSocket: NewBaseSocket(s, e, connected),
+	}
+}
+
+// assignWT connect a WebTransport session to a socket.
+func (s *WebTransportSocket) assignWT(sess *webtransport.Session) {
+	s.closeSlow = func() {
+		sess.CloseWithError(1, "socket too slow to keep up with messages")
+	}
+}
+
+// webTransportConn newline delimited events on a WebTransport stream, with
+// patches optionally sent as datagrams.
+type webTransportConn struct {
+	sess   *webtransport.Session
+	stream webtransport.Stream
+	reader *bufio.Reader
+	// datagrams sends patches as datagrams, nil if they all go on the
+	// stream.
+	datagrams datagramSender
+	// seq the number of the last patch sent, written by the socket's loop.
+	seq uint64
+}
+
+// datagramSender sends unreliable datagrams.
+type datagramSender interface {
+	SendDatagram(b []byte) error
+}
+
+// patchPrefix the start of an encoded patch event.
+var patchPrefix = []byte(`{"t":"` + EventPatch + `"`)
+
+func (c *webTransportConn) read(ctx context.Context) (bool, []byte, error) {
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return false, nil, err
+	}
+	return true, bytes.TrimSuffix(line, []byte{'\n'}), nil
+}
+
+func (c *webTransportConn) write(ctx context.Context, data []byte) error {
+	if c.datagrams != nil && bytes.HasPrefix(data, patchPrefix) {
+		c.seq++
+		data = sequencePatch(data, c.seq)
+		if len(data) <= maxDatagramSize && attrsOnly(data) {
+			if err := c.datagrams.SendDatagram(data); err == nil {
+				return nil
+			}
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		c.stream.SetWriteDeadline(deadline)
+	}
+	_, err := c.stream.Write(append(data, '\n'))
+	return err
+}
+
+// protocolVersion WebTransport is only spoken by the bundled client, which
+// speaks the latest version. Compact patches are needed to set attributes.
+func (c *webTransportConn) protocolVersion() int {
+	return LatestProtocolVersion
+}
+
+// sequencePatch numbers an encoded patch event, the client uses the number
+// to drop datagrams which arrive after a newer patch.
+func sequencePatch(data []byte, seq uint64) []byte {
+	out := make([]byte, 0, len(data)+24)
+	out = append(out, `{"q":`...)
+	out = strconv.AppendUint(out, seq, 10)
+	out = append(out, ',')
+	return append(out, data[1:]...)
+}
+
+// attrsOnly returns true if an encoded patch event only sets the attributes
+// of elements. Losing it leaves them stale until they next change, where
+// losing any other patch would leave the page out of step with the server.
+func attrsOnly(data []byte) bool {
+	var m struct {
+		Data compactPatches `json:"d"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil || len(m.Data.Ops) == 0 {
+		return false
+	}
+	for _, op := range m.Data.Ops {
+		if len(op) < 2 || PatchAction(op[1]) != SetAttrs {
+			return false
+		}
+	}
+	return true
+}
+
+// webTransportCloseReason works out why a WebTransport session closed from
+// the error it closed with.
+func webTransportCloseReason(err error) CloseReason {
+	var sessErr *webtransport.SessionError
+	if errors.Is(err, io.EOF) || (errors.As(err, &sessErr) && sessErr.ErrorCode == 0) {
+		return CloseNormal
+	}
+	return closeReason(err)
+}
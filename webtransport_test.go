@@ -0,0 +1,97 @@
+//go:build webtransport
+
+package live
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/webtransport-go"
+)
+
+// testStream records what is written to a WebTransport stream.
+type testStream struct {
+	webtransport.Stream
+	written [][]byte
+}
+
+func (s *testStream) Write(b []byte) (int, error) {
+	s.written = append(s.written, bytes.TrimSuffix(b, []byte{'\n'}))
+	return len(b), nil
+}
+
+func (s *testStream) SetWriteDeadline(time.Time) error { return nil }
+
+// testDatagrams records the datagrams sent.
+type testDatagrams struct {
+	sent [][]byte
+}
+
+func (d *testDatagrams) SendDatagram(b []byte) error {
+	d.sent = append(d.sent, b)
+	return nil
+}
+
+// encodedPatch encodes patches as the socket's loop would for protocol
+// version 2.
+func encodedPatch(t *testing.T, patches ...Patch) []byte {
+	t.Helper()
+	d, err := json.Marshal(encodePatches(patches, LatestProtocolVersion))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(Event{T: EventPatch, Data: d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestWebTransportDatagramPatches(t *testing.T) {
+	stream, datagrams := &testStream{}, &testDatagrams{}
+	c := &webTransportConn{stream: stream, datagrams: datagrams}
+	ctx := context.Background()
+
+	attrs := encodedPatch(t, Patch{Anchor: "_l01", Action: SetAttrs, Attrs: [][2]string{{"value", "50"}}})
+	replace := encodedPatch(t, Patch{Anchor: "_l01", Action: Replace, HTML: "<p>done</p>"})
+	large := encodedPatch(t, Patch{Anchor: "_l01", Action: SetAttrs, Attrs: [][2]string{{"title", strings.Repeat("a", maxDatagramSize)}}})
+	for _, data := range [][]byte{attrs, replace, large, []byte(`{"t":"ack","i":1}`)} {
+		if err := c.write(ctx, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the small patch which sets attributes is sent as a datagram.
+	if len(datagrams.sent) != 1 || !bytes.HasPrefix(datagrams.sent[0], []byte(`{"q":1,"t":"patch"`)) {
+		t.Fatalf("expected the attribute patch as a datagram, got %q", datagrams.sent)
+	}
+	if len(stream.written) != 3 {
+		t.Fatalf("expected three messages on the stream, got %q", stream.written)
+	}
+	for i, prefix := range []string{`{"q":2,"t":"patch"`, `{"q":3,"t":"patch"`, `{"t":"ack"`} {
+		if !bytes.HasPrefix(stream.written[i], []byte(prefix)) {
+			t.Errorf("expected %s, got %s", prefix, stream.written[i])
+		}
+	}
+	for _, data := range append(datagrams.sent, stream.written...) {
+		if !json.Valid(data) {
+			t.Errorf("expected valid json, got %s", data)
+		}
+	}
+}
+
+func TestWebTransportStreamOnly(t *testing.T) {
+	stream := &testStream{}
+	c := &webTransportConn{stream: stream}
+	attrs := encodedPatch(t, Patch{Anchor: "_l01", Action: SetAttrs, Attrs: [][2]string{{"value", "50"}}})
+	if err := c.write(context.Background(), attrs); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.written) != 1 || !bytes.Equal(stream.written[0], attrs) {
+		t.Errorf("expected the patch unchanged on the stream, got %q", stream.written)
+	}
+}